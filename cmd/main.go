@@ -2,6 +2,7 @@ package main
 
 import (
 	"archive/zip"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -22,6 +23,7 @@ import (
 	"github.com/sharath018/temple-management-backend/internal/event"
 	"github.com/sharath018/temple-management-backend/internal/eventrsvp"
 	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/seva"
 	"github.com/sharath018/temple-management-backend/middleware"
 	"github.com/sharath018/temple-management-backend/routes"
 	"github.com/sharath018/temple-management-backend/utils"
@@ -57,6 +59,12 @@ func main() {
 	cfg := config.Load()
 	db := database.Connect(cfg)
 
+	// 🧮 Maintenance command: server repair-slot-counters [entity_id]
+	if len(os.Args) > 1 && os.Args[1] == "repair-slot-counters" {
+		repairSlotCounters(db, os.Args[2:])
+		return
+	}
+
 	// Init Redis
 	if err := utils.InitRedis(); err != nil {
 		log.Fatalf("❌ Redis init failed: %v", err)
@@ -687,3 +695,25 @@ func migrateIsActiveColumn(db *gorm.DB) error {
 	log.Println("✅ IsActive column added successfully")
 	return nil
 }
+
+// repairSlotCounters recomputes the booked and remaining slot counters of
+// every seva, or of one temple's sevas, from seva_bookings
+func repairSlotCounters(db *gorm.DB, args []string) {
+	var entityID uint
+	if len(args) > 0 {
+		id, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil {
+			log.Fatalf("❌ Invalid entity_id %q: %v", args[0], err)
+		}
+		entityID = uint(id)
+	}
+
+	auditSvc := auditlog.NewService(auditlog.NewRepository(db))
+	sevaService := seva.NewService(seva.NewRepository(db), auditSvc)
+
+	repairs, err := sevaService.RepairSlotCounters(context.Background(), entityID)
+	if err != nil {
+		log.Fatalf("❌ Slot counter repair failed: %v", err)
+	}
+	log.Printf("✅ Slot counter repair done: %d seva(s) corrected", len(repairs))
+}
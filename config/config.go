@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -45,6 +46,44 @@ type Config struct {
 
 	// ✅ Frontend URL for CORS
 	FrontendURL string // Frontend URL for CORS origins
+
+	// ✅ Recurring donations
+	RecurringSchedulerMinutes int    // How often due recurring donations are raised (default 60)
+	SubscriptionGateway       string // "razorpay" (default) or "fake" for local testing
+
+	// ✅ Payment reconciliation
+	ReconcileIntervalMinutes int // How often stale pending payments are checked with the gateway (default 15)
+	ReconcileMinAgeMinutes   int // Only orders older than this are checked (default 30)
+	ReconcileAbandonHours    int // Orders still unpaid after this long are marked failed (default 24)
+	WebhookRetryMinutes      int // How often failed payment webhooks are retried (default 5)
+
+	// ✅ Payment providers
+	PaymentProvider       string // "fake" forces the local fake provider for every tenant
+	RazorpayWebhookSecret string // Platform-wide Razorpay webhook secret
+	CashfreeEnv           string // "production" or "sandbox" (default)
+
+	// ✅ Foreign-currency donations
+	FXRates    string // Fallback rupee rates, e.g. "USD=83.10,EUR=90.25"
+	FXRatesURL string // Optional INR-based rate feed, e.g. https://open.er-api.com/v6/latest/INR
+
+	// ✅ Annual donor statements
+	DonorStatementHours int // How often unsent statements for the last financial year are emailed (default 24)
+
+	// ✅ Pledges
+	PledgeReminderHours int // How often overdue pledge instalments are checked for reminders (default 24)
+
+	// ✅ UPI payment links
+	PaymentLinkBaseURL string // Guest donation page short links open, e.g. https://temple.example.org/pay (default FRONTEND_URL + "/pay")
+
+	// ✅ Seva schedules
+	SevaScheduleHours int // How often open-ended seva schedules are generated ahead (default 24)
+
+	// ✅ Seva waitlist
+	SevaWaitlistMinutes      int // How often expired waitlist offers are passed on (default 5)
+	SevaWaitlistOfferMinutes int // How long a waitlist offer holds its place for the devotee (default 120)
+
+	// ✅ Seva tickets
+	SevaTicketSecret string // Key the QR tickets of approved bookings are signed with; tickets are disabled without it
 }
 
 // Load reads environment variables and returns a Config object
@@ -56,6 +95,56 @@ func Load() *Config {
 	accessTTL, _ := strconv.Atoi(os.Getenv("JWT_ACCESS_TTL_HOURS"))
 	refreshTTL, _ := strconv.Atoi(os.Getenv("JWT_REFRESH_TTL_HOURS"))
 	redisDB, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+	recurringMinutes, _ := strconv.Atoi(os.Getenv("RECURRING_SCHEDULER_MINUTES"))
+	if recurringMinutes <= 0 {
+		recurringMinutes = 60
+	}
+	reconcileMinutes, _ := strconv.Atoi(os.Getenv("RECONCILE_INTERVAL_MINUTES"))
+	if reconcileMinutes <= 0 {
+		reconcileMinutes = 15
+	}
+	reconcileMinAge, _ := strconv.Atoi(os.Getenv("RECONCILE_MIN_AGE_MINUTES"))
+	if reconcileMinAge <= 0 {
+		reconcileMinAge = 30
+	}
+	reconcileAbandon, _ := strconv.Atoi(os.Getenv("RECONCILE_ABANDON_HOURS"))
+	if reconcileAbandon <= 0 {
+		reconcileAbandon = 24
+	}
+	webhookRetryMinutes, _ := strconv.Atoi(os.Getenv("WEBHOOK_RETRY_MINUTES"))
+	if webhookRetryMinutes <= 0 {
+		webhookRetryMinutes = 5
+	}
+	donorStatementHours, _ := strconv.Atoi(os.Getenv("DONOR_STATEMENT_HOURS"))
+	if donorStatementHours <= 0 {
+		donorStatementHours = 24
+	}
+	pledgeReminderHours, _ := strconv.Atoi(os.Getenv("PLEDGE_REMINDER_HOURS"))
+	if pledgeReminderHours <= 0 {
+		pledgeReminderHours = 24
+	}
+	sevaScheduleHours, _ := strconv.Atoi(os.Getenv("SEVA_SCHEDULE_HOURS"))
+	if sevaScheduleHours <= 0 {
+		sevaScheduleHours = 24
+	}
+	sevaWaitlistMinutes, _ := strconv.Atoi(os.Getenv("SEVA_WAITLIST_MINUTES"))
+	if sevaWaitlistMinutes <= 0 {
+		sevaWaitlistMinutes = 5
+	}
+	sevaWaitlistOfferMinutes, _ := strconv.Atoi(os.Getenv("SEVA_WAITLIST_OFFER_MINUTES"))
+	if sevaWaitlistOfferMinutes <= 0 {
+		sevaWaitlistOfferMinutes = 120
+	}
+	// Tickets are printed on paper and live for the whole seva day, so they
+	// get a key of their own that rotates independently of the auth tokens
+	sevaTicketSecret := os.Getenv("SEVA_TICKET_SECRET")
+	if sevaTicketSecret == "" {
+		log.Println("⚠️ SEVA_TICKET_SECRET is not set: seva QR tickets and counter check-in are DISABLED")
+	}
+	paymentLinkBaseURL := os.Getenv("PAYMENT_LINK_BASE_URL")
+	if paymentLinkBaseURL == "" {
+		paymentLinkBaseURL = strings.TrimRight(os.Getenv("FRONTEND_URL"), "/") + "/pay"
+	}
 
 	return &Config{
 		Port: os.Getenv("PORT"),
@@ -86,5 +175,33 @@ func Load() *Config {
 		FCMProjectID:       os.Getenv("FCM_PROJECT_ID"),
 
 		FrontendURL: os.Getenv("FRONTEND_URL"),
+
+		RecurringSchedulerMinutes: recurringMinutes,
+		SubscriptionGateway:       os.Getenv("SUBSCRIPTION_GATEWAY"),
+
+		ReconcileIntervalMinutes: reconcileMinutes,
+		ReconcileMinAgeMinutes:   reconcileMinAge,
+		ReconcileAbandonHours:    reconcileAbandon,
+		WebhookRetryMinutes:      webhookRetryMinutes,
+
+		PaymentProvider:       os.Getenv("PAYMENT_PROVIDER"),
+		RazorpayWebhookSecret: os.Getenv("RAZORPAY_WEBHOOK_SECRET"),
+		CashfreeEnv:           os.Getenv("CASHFREE_ENV"),
+
+		FXRates:    os.Getenv("FX_RATES"),
+		FXRatesURL: os.Getenv("FX_RATES_URL"),
+
+		DonorStatementHours: donorStatementHours,
+
+		PledgeReminderHours: pledgeReminderHours,
+
+		PaymentLinkBaseURL: strings.TrimRight(paymentLinkBaseURL, "/"),
+
+		SevaScheduleHours: sevaScheduleHours,
+
+		SevaWaitlistMinutes:      sevaWaitlistMinutes,
+		SevaWaitlistOfferMinutes: sevaWaitlistOfferMinutes,
+
+		SevaTicketSecret: sevaTicketSecret,
 	}
 }
\ No newline at end of file
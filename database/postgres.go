@@ -10,10 +10,12 @@ import (
 	"gorm.io/gorm/logger"
 
 	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/internal/accounting"
 	"github.com/sharath018/temple-management-backend/internal/auth"
 	"github.com/sharath018/temple-management-backend/internal/donation"
 	"github.com/sharath018/temple-management-backend/internal/entity"
 	"github.com/sharath018/temple-management-backend/internal/event"
+	"github.com/sharath018/temple-management-backend/internal/hundi"
 	"github.com/sharath018/temple-management-backend/internal/seva"
 	"github.com/sharath018/temple-management-backend/internal/userprofile"
 	"github.com/sharath018/temple-management-backend/internal/notification"
@@ -64,9 +66,34 @@ func Connect(cfg *config.Config) *gorm.DB {
 		// Other models
 		&seva.Seva{},
 		&seva.SevaBooking{},
+		&seva.SevaSchedule{},
+		&seva.SevaSlot{},
+		&seva.CancellationPolicy{},
+		&seva.SevaWaitlistEntry{},
+		&seva.SevaBeneficiary{},
+		&seva.SevaVariant{},
+		&seva.SevaAddOn{},
+		&seva.SevaBookingItem{},
 		&entity.Entity{},
 		&event.Event{},
 		&donation.Donation{},
+		&donation.ReceiptSequence{},
+		&donation.RecurringDonation{},
+		&donation.Refund{},
+		&donation.Campaign{},
+		&donation.InKindDonation{},
+		&donation.PaymentWebhookEvent{},
+		&donation.DonorStatement{},
+		&donation.Pledge{},
+		&donation.PledgeInstalment{},
+		&donation.PaymentLink{},
+		&hundi.Hundi{},
+		&hundi.CountingSession{},
+		&hundi.CounterTally{},
+		&hundi.DenominationCount{},
+		&accounting.LedgerHead{},
+		&accounting.LedgerExport{},
+		&accounting.LedgerExportItem{},
 		&notification.NotificationTemplate{},
 		&notification.NotificationLog{},
 		&userprofile.DevoteeProfile{},
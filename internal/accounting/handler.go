@@ -0,0 +1,158 @@
+package accounting
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func getAccessContextFromContext(c *gin.Context) (middleware.AccessContext, bool) {
+	accessContextRaw, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return middleware.AccessContext{}, false
+	}
+	accessContext, ok := accessContextRaw.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid access context"})
+		return middleware.AccessContext{}, false
+	}
+	return accessContext, true
+}
+
+func getEntityIDFromRequest(c *gin.Context, accessContext middleware.AccessContext) uint {
+	if entityIDQuery := c.Query("entity_id"); entityIDQuery != "" {
+		if id, err := strconv.ParseUint(entityIDQuery, 10, 32); err == nil {
+			return uint(id)
+		}
+	}
+	if contextEntityID := accessContext.GetAccessibleEntityID(); contextEntityID != nil {
+		return *contextEntityID
+	}
+	return 0
+}
+
+// requireEntity resolves the temple of the request, answering 400 when there is none
+func requireEntity(c *gin.Context) (middleware.AccessContext, uint, bool) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return accessContext, 0, false
+	}
+	entityID := getEntityIDFromRequest(c, accessContext)
+	if entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return accessContext, 0, false
+	}
+	return accessContext, entityID, true
+}
+
+// ==============================
+// 📒 Ledger Heads
+// ==============================
+
+func (h *Handler) ListLedgerHeads(c *gin.Context) {
+	accessContext, entityID, ok := requireEntity(c)
+	if !ok {
+		return
+	}
+
+	heads, err := h.svc.ListLedgerHeads(c.Request.Context(), entityID, accessContext)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": heads, "success": true})
+}
+
+func (h *Handler) UpdateLedgerHeads(c *gin.Context) {
+	accessContext, entityID, ok := requireEntity(c)
+	if !ok {
+		return
+	}
+	var req UpdateLedgerHeadsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	heads, err := h.svc.UpdateLedgerHeads(c.Request.Context(), entityID, req, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": heads, "success": true})
+}
+
+// ==============================
+// 📤 Ledger Exports
+// ==============================
+
+// Export downloads Tally XML vouchers or a journal CSV for a date range
+func (h *Handler) Export(c *gin.Context) {
+	accessContext, entityID, ok := requireEntity(c)
+	if !ok {
+		return
+	}
+	var req ExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	content, filename, err := h.svc.Export(c.Request.Context(), entityID, req, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "text/csv"
+	if req.Format == FormatTally {
+		contentType = "application/xml"
+	}
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, contentType, content)
+}
+
+func (h *Handler) ListExports(c *gin.Context) {
+	accessContext, entityID, ok := requireEntity(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if limit <= 0 {
+		limit = 20
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	exports, total, err := h.svc.ListExports(c.Request.Context(), ExportFilter{
+		EntityID: entityID,
+		Format:   c.Query("format"),
+		Limit:    limit,
+		Offset:   (page - 1) * limit,
+	}, accessContext)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        exports,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": (int(total) + limit - 1) / limit,
+		"success":     true,
+	})
+}
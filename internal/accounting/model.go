@@ -0,0 +1,150 @@
+package accounting
+
+import (
+	"time"
+)
+
+// Export formats
+const (
+	FormatTally   = "tally"   // Tally Prime / ERP 9 XML vouchers
+	FormatJournal = "journal" // generic double-entry journal CSV
+)
+
+// Kinds of income and outflow that are exported
+const (
+	SourceDonation    = "donation"
+	SourceSevaBooking = "seva_booking"
+	SourceRefund      = "refund"
+)
+
+// Ledger head kinds. Income heads are keyed by donation type or seva type,
+// refund heads by what was refunded, and account heads by where the money sits.
+const (
+	HeadDonation = "donation" // key: donation type (general, annadanam, ...)
+	HeadSeva     = "seva"     // key: seva type (archana, abhishekam, ...)
+	HeadRefund   = "refund"   // key: donation / seva_booking
+	HeadAccount  = "account"  // key: cash / bank / gateway / fcra
+)
+
+// Account keys — where a receipt is deposited or a refund is paid from
+const (
+	AccountCash    = "cash"
+	AccountBank    = "bank"    // cheques, DDs and bank transfers at the counter
+	AccountGateway = "gateway" // online payments, until settled to the bank
+	AccountFCRA    = "fcra"    // foreign contributions
+)
+
+// DefaultKey is the fallback head of a kind when no specific key is mapped
+const DefaultKey = "*"
+
+// defaultLedgerNames are used for any kind the temple has not mapped yet
+var defaultLedgerNames = map[string]string{
+	HeadDonation: "Donations",
+	HeadSeva:     "Seva Income",
+	HeadRefund:   "Refunds",
+}
+
+var defaultAccountNames = map[string]string{
+	AccountCash:    "Cash",
+	AccountBank:    "Bank Account",
+	AccountGateway: "Payment Gateway",
+	AccountFCRA:    "FCRA Bank Account",
+}
+
+// LedgerHead maps a kind of transaction to the ledger name used in the
+// temple's books, e.g. donation/annadanam → "Annadanam Donations"
+type LedgerHead struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EntityID   uint      `gorm:"not null;uniqueIndex:idx_ledger_head" json:"entity_id"`
+	Kind       string    `gorm:"size:20;not null;uniqueIndex:idx_ledger_head" json:"kind"`
+	Key        string    `gorm:"size:100;not null;uniqueIndex:idx_ledger_head" json:"key"` // "*" for the default of the kind
+	LedgerName string    `gorm:"size:255;not null" json:"ledger_name"`
+	UpdatedBy  uint      `json:"updated_by"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// LedgerExport is one ledger file handed to the accountants
+type LedgerExport struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	EntityID     uint      `gorm:"not null;index" json:"entity_id"`
+	Format       string    `gorm:"size:20;not null" json:"format"`
+	FromDate     time.Time `json:"from_date"`
+	ToDate       time.Time `json:"to_date"` // inclusive
+	VoucherCount int       `json:"voucher_count"`
+	TotalDebit   float64   `gorm:"type:decimal(14,2)" json:"total_debit"`
+	FileName     string    `gorm:"size:255" json:"file_name"`
+	ExportedBy   uint      `gorm:"index" json:"exported_by"`
+	CreatedAt    time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// LedgerExportItem records that a transaction went out in an export, so the
+// next export in the same format only picks up what is new
+type LedgerExportItem struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ExportID   uint      `gorm:"not null;index" json:"export_id"`
+	EntityID   uint      `gorm:"not null;uniqueIndex:idx_ledger_export_item" json:"entity_id"`
+	Format     string    `gorm:"size:20;not null;uniqueIndex:idx_ledger_export_item" json:"format"`
+	SourceType string    `gorm:"size:20;not null;uniqueIndex:idx_ledger_export_item" json:"source_type"`
+	SourceID   uint      `gorm:"not null;uniqueIndex:idx_ledger_export_item" json:"source_id"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// SourceTransaction is a donation, paid seva booking or processed refund
+// as read from the database, before it is turned into a voucher
+type SourceTransaction struct {
+	SourceType string    `db:"source_type"`
+	SourceID   uint      `db:"source_id"`
+	TxnDate    time.Time `db:"txn_date"`
+	Amount     float64   `db:"amount"`
+	Category   string    `db:"category"` // donation type, seva type or refunded source
+	Account    string    `db:"account"`  // cash / bank / gateway / fcra
+	Reference  string    `db:"reference"`
+	Party      string    `db:"party"`
+	Narration  string    `db:"narration"`
+}
+
+// Voucher is a balanced double-entry voucher: Debit is debited and Credit
+// credited with Amount
+type Voucher struct {
+	SourceType  string    `json:"source_type"`
+	SourceID    uint      `json:"source_id"`
+	VoucherType string    `json:"voucher_type"` // Receipt / Payment
+	Number      string    `json:"number"`
+	Reference   string    `json:"reference"` // receipt number, gateway payment or refund id
+	Date        time.Time `json:"date"`
+	Debit       string    `json:"debit"`
+	Credit      string    `json:"credit"`
+	Amount      float64   `json:"amount"`
+	Party       string    `json:"party"`
+	Narration   string    `json:"narration"`
+}
+
+// ============================
+// Requests
+// ============================
+
+type LedgerHeadInput struct {
+	Kind       string `json:"kind" binding:"required,oneof=donation seva refund account"`
+	Key        string `json:"key" binding:"required"`
+	LedgerName string `json:"ledger_name" binding:"required"`
+}
+
+type UpdateLedgerHeadsRequest struct {
+	Heads []LedgerHeadInput `json:"heads" binding:"required,min=1,dive"`
+}
+
+type ExportRequest struct {
+	Format          string `json:"format" binding:"required,oneof=tally journal"`
+	From            string `json:"from" binding:"required"` // YYYY-MM-DD
+	To              string `json:"to" binding:"required"`   // YYYY-MM-DD, inclusive
+	IncludeExported bool   `json:"include_exported"`        // also re-export transactions already sent in this format
+	CompanyName     string `json:"company_name"`            // Tally company to import into; defaults to the temple name
+}
+
+type ExportFilter struct {
+	EntityID uint
+	Format   string
+	Limit    int
+	Offset   int
+}
@@ -0,0 +1,195 @@
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	// Ledger heads
+	ListLedgerHeads(ctx context.Context, entityID uint) ([]LedgerHead, error)
+	SaveLedgerHeads(ctx context.Context, heads []LedgerHead) error
+
+	// Transactions in [from, to), optionally leaving out those already exported in format
+	ListDonationTransactions(ctx context.Context, entityID uint, from, to time.Time, format string, includeExported bool) ([]SourceTransaction, error)
+	ListSevaTransactions(ctx context.Context, entityID uint, from, to time.Time, format string, includeExported bool) ([]SourceTransaction, error)
+	ListRefundTransactions(ctx context.Context, entityID uint, from, to time.Time, format string, includeExported bool) ([]SourceTransaction, error)
+
+	// Exports
+	GetEntityName(ctx context.Context, entityID uint) (string, error)
+	RecordExport(ctx context.Context, export *LedgerExport, vouchers []Voucher) error
+	ListExports(ctx context.Context, filter ExportFilter) ([]LedgerExport, int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// ==============================
+// Ledger heads
+// ==============================
+
+func (r *repository) ListLedgerHeads(ctx context.Context, entityID uint) ([]LedgerHead, error) {
+	var heads []LedgerHead
+	err := r.db.WithContext(ctx).
+		Where("entity_id = ?", entityID).
+		Order("kind ASC, key ASC").
+		Find(&heads).Error
+	return heads, err
+}
+
+// SaveLedgerHeads inserts the heads or renames the existing ones with the same kind and key
+func (r *repository) SaveLedgerHeads(ctx context.Context, heads []LedgerHead) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "entity_id"}, {Name: "kind"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"ledger_name", "updated_by", "updated_at"}),
+		}).
+		Create(&heads).Error
+}
+
+// ==============================
+// Transactions
+// ==============================
+
+// notExportedWhere leaves out transactions that already went out in the format
+const notExportedWhere = `NOT EXISTS (SELECT 1 FROM ledger_export_items li
+	WHERE li.entity_id = ? AND li.format = ? AND li.source_type = ? AND li.source_id = %s)`
+
+func (r *repository) excludeExported(query *gorm.DB, entityID uint, format, sourceType, idColumn string, includeExported bool) *gorm.DB {
+	if includeExported {
+		return query
+	}
+	return query.Where(fmt.Sprintf(notExportedWhere, idColumn), entityID, format, sourceType)
+}
+
+// ListDonationTransactions returns successful donations, including ones
+// refunded since — the refund is exported as its own voucher
+func (r *repository) ListDonationTransactions(ctx context.Context, entityID uint, from, to time.Time, format string, includeExported bool) ([]SourceTransaction, error) {
+	var txns []SourceTransaction
+	query := r.db.WithContext(ctx).
+		Table("donations d").
+		Select(`'donation' as source_type, d.id as source_id, COALESCE(d.donated_at, d.created_at) as txn_date,
+			d.amount, d.donation_type as category,
+			CASE WHEN COALESCE(d.is_foreign_contribution, false) THEN 'fcra'
+				WHEN UPPER(d.method) = 'CASH' THEN 'cash'
+				WHEN COALESCE(d.is_offline, false) THEN 'bank'
+				ELSE 'gateway' END as account,
+			COALESCE(NULLIF(d.receipt_number, ''), d.order_id) as reference,
+			CASE WHEN COALESCE(d.is_anonymous, false) THEN 'Anonymous'
+				ELSE COALESCE(NULLIF(d.donor_name, ''), NULLIF(u.full_name, ''), u.email, 'Anonymous') END as party,
+			CONCAT('Donation #', d.id, ' (', d.donation_type, ') via ', UPPER(TRIM(d.method)),
+				COALESCE(' ref ' || NULLIF(d.instrument_number, ''), COALESCE(' ref ' || d.payment_id, ''))) as narration`).
+		Joins("LEFT JOIN users u ON d.user_id = u.id").
+		Where("d.entity_id = ? AND d.deleted_at IS NULL AND UPPER(d.status) IN ('SUCCESS', 'REFUNDED')", entityID).
+		Where("COALESCE(d.donated_at, d.created_at) >= ? AND COALESCE(d.donated_at, d.created_at) < ?", from, to)
+	err := r.excludeExported(query, entityID, format, SourceDonation, "d.id", includeExported).
+		Order("txn_date ASC, d.id ASC").
+		Scan(&txns).Error
+	return txns, err
+}
+
+// ListSevaTransactions returns seva bookings whose payment was verified
+func (r *repository) ListSevaTransactions(ctx context.Context, entityID uint, from, to time.Time, format string, includeExported bool) ([]SourceTransaction, error) {
+	var txns []SourceTransaction
+	query := r.db.WithContext(ctx).
+		Table("seva_bookings sb").
+		Select(`'seva_booking' as source_type, sb.id as source_id, sb.payment_verified_at as txn_date,
+			sb.amount, COALESCE(NULLIF(s.seva_type, ''), '') as category, 'gateway' as account,
+			COALESCE(NULLIF(sb.razorpay_payment_id, ''), sb.razorpay_order_id, '') as reference,
+			COALESCE(NULLIF(u.full_name, ''), u.email, '') as party,
+			CONCAT('Seva booking #', sb.id, ' - ', COALESCE(s.name, '')) as narration`).
+		Joins("LEFT JOIN sevas s ON sb.seva_id = s.id").
+		Joins("LEFT JOIN users u ON sb.user_id = u.id").
+		Where("sb.entity_id = ? AND sb.payment_verified_at IS NOT NULL AND sb.amount > 0", entityID).
+		Where("sb.payment_verified_at >= ? AND sb.payment_verified_at < ?", from, to)
+	err := r.excludeExported(query, entityID, format, SourceSevaBooking, "sb.id", includeExported).
+		Order("txn_date ASC, sb.id ASC").
+		Scan(&txns).Error
+	return txns, err
+}
+
+// ListRefundTransactions returns refunds the gateway has processed
+func (r *repository) ListRefundTransactions(ctx context.Context, entityID uint, from, to time.Time, format string, includeExported bool) ([]SourceTransaction, error) {
+	var txns []SourceTransaction
+	query := r.db.WithContext(ctx).
+		Table("refunds rf").
+		Select(`'refund' as source_type, rf.id as source_id, rf.processed_at as txn_date,
+			rf.amount, rf.source_type as category,
+			CASE WHEN rf.source_type = 'donation' AND COALESCE(d.is_foreign_contribution, false) THEN 'fcra' ELSE 'gateway' END as account,
+			COALESCE(rf.gateway_refund_id, rf.gateway_payment_id) as reference,
+			COALESCE(NULLIF(u.full_name, ''), u.email, '') as party,
+			CONCAT('Refund #', rf.id, ' of ', REPLACE(rf.source_type, '_', ' '), ' #', rf.source_id, ': ', rf.reason) as narration`).
+		Joins("LEFT JOIN donations d ON rf.source_type = 'donation' AND rf.source_id = d.id").
+		Joins("LEFT JOIN users u ON rf.user_id = u.id").
+		Where("rf.entity_id = ? AND rf.status = 'PROCESSED' AND rf.processed_at IS NOT NULL", entityID).
+		Where("rf.processed_at >= ? AND rf.processed_at < ?", from, to)
+	err := r.excludeExported(query, entityID, format, SourceRefund, "rf.id", includeExported).
+		Order("txn_date ASC, rf.id ASC").
+		Scan(&txns).Error
+	return txns, err
+}
+
+// ==============================
+// Exports
+// ==============================
+
+func (r *repository) GetEntityName(ctx context.Context, entityID uint) (string, error) {
+	var name string
+	err := r.db.WithContext(ctx).
+		Table("entities").
+		Select("COALESCE(name, '')").
+		Where("id = ?", entityID).
+		Scan(&name).Error
+	return name, err
+}
+
+// RecordExport stores the export and marks its vouchers as exported. Vouchers
+// re-exported with include_exported keep their original record.
+func (r *repository) RecordExport(ctx context.Context, export *LedgerExport, vouchers []Voucher) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(export).Error; err != nil {
+			return err
+		}
+		items := make([]LedgerExportItem, 0, len(vouchers))
+		for _, v := range vouchers {
+			items = append(items, LedgerExportItem{
+				ExportID:   export.ID,
+				EntityID:   export.EntityID,
+				Format:     export.Format,
+				SourceType: v.SourceType,
+				SourceID:   v.SourceID,
+			})
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(items, 500).Error
+	})
+}
+
+func (r *repository) ListExports(ctx context.Context, filter ExportFilter) ([]LedgerExport, int64, error) {
+	var exports []LedgerExport
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&LedgerExport{}).Where("entity_id = ?", filter.EntityID)
+	if filter.Format != "" {
+		query = query.Where("format = ?", filter.Format)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit).Offset(filter.Offset)
+	}
+	err := query.Order("created_at DESC").Find(&exports).Error
+	return exports, total, err
+}
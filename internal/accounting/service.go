@@ -0,0 +1,309 @@
+package accounting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+var errNothingToExport = errors.New("no new transactions to export in this date range")
+
+type Service interface {
+	// Ledger heads
+	ListLedgerHeads(ctx context.Context, entityID uint, accessContext middleware.AccessContext) ([]LedgerHead, error)
+	UpdateLedgerHeads(ctx context.Context, entityID uint, req UpdateLedgerHeadsRequest, accessContext middleware.AccessContext, ip string) ([]LedgerHead, error)
+
+	// Exports
+	Export(ctx context.Context, entityID uint, req ExportRequest, accessContext middleware.AccessContext, ip string) ([]byte, string, error)
+	ListExports(ctx context.Context, filter ExportFilter, accessContext middleware.AccessContext) ([]LedgerExport, int64, error)
+}
+
+type service struct {
+	repo     Repository
+	auditSvc auditlog.Service
+}
+
+func NewService(repo Repository, auditSvc auditlog.Service) Service {
+	return &service{repo: repo, auditSvc: auditSvc}
+}
+
+// checkEntity verifies the caller works for the temple whose books are exported
+func checkEntity(accessContext middleware.AccessContext, entityID uint) error {
+	accessible := accessContext.GetAccessibleEntityID()
+	if accessible == nil || *accessible != entityID {
+		return errors.New("access denied to requested entity")
+	}
+	return nil
+}
+
+// ==============================
+// Ledger heads
+// ==============================
+
+// ListLedgerHeads returns the temple's mapped heads, plus the built-in
+// default of every kind the temple has not given a default of its own
+func (s *service) ListLedgerHeads(ctx context.Context, entityID uint, accessContext middleware.AccessContext) ([]LedgerHead, error) {
+	if !accessContext.CanRead() {
+		return nil, errors.New("read access denied")
+	}
+	if err := checkEntity(accessContext, entityID); err != nil {
+		return nil, err
+	}
+	heads, err := s.repo.ListLedgerHeads(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	mapped := make(map[string]bool)
+	for _, h := range heads {
+		mapped[h.Kind+"|"+h.Key] = true
+	}
+	for kind, name := range defaultLedgerNames {
+		if !mapped[kind+"|"+DefaultKey] {
+			heads = append(heads, LedgerHead{EntityID: entityID, Kind: kind, Key: DefaultKey, LedgerName: name})
+		}
+	}
+	for key, name := range defaultAccountNames {
+		if !mapped[HeadAccount+"|"+key] {
+			heads = append(heads, LedgerHead{EntityID: entityID, Kind: HeadAccount, Key: key, LedgerName: name})
+		}
+	}
+	sort.Slice(heads, func(i, j int) bool {
+		if heads[i].Kind != heads[j].Kind {
+			return heads[i].Kind < heads[j].Kind
+		}
+		return heads[i].Key < heads[j].Key
+	})
+	return heads, nil
+}
+
+func (s *service) UpdateLedgerHeads(ctx context.Context, entityID uint, req UpdateLedgerHeadsRequest, accessContext middleware.AccessContext, ip string) ([]LedgerHead, error) {
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	if err := checkEntity(accessContext, entityID); err != nil {
+		return nil, err
+	}
+
+	heads := make([]LedgerHead, 0, len(req.Heads))
+	for _, in := range req.Heads {
+		key := strings.ToLower(strings.TrimSpace(in.Key))
+		name := strings.TrimSpace(in.LedgerName)
+		if key == "" || name == "" {
+			return nil, errors.New("every ledger head needs a key and a ledger name")
+		}
+		if in.Kind == HeadAccount {
+			if _, ok := defaultAccountNames[key]; !ok {
+				return nil, fmt.Errorf("unknown account %q, expected cash, bank, gateway or fcra", in.Key)
+			}
+		}
+		heads = append(heads, LedgerHead{
+			EntityID:   entityID,
+			Kind:       in.Kind,
+			Key:        key,
+			LedgerName: name,
+			UpdatedBy:  accessContext.UserID,
+		})
+	}
+	if err := s.repo.SaveLedgerHeads(ctx, heads); err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "LEDGER_HEADS_UPDATED",
+			map[string]interface{}{"count": len(heads), "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "LEDGER_HEADS_UPDATED",
+		map[string]interface{}{"heads": req.Heads}, ip, "success")
+	return s.ListLedgerHeads(ctx, entityID, accessContext)
+}
+
+// ledgerBook resolves transaction kinds to the temple's ledger names
+type ledgerBook map[string]string
+
+func (s *service) loadLedgerBook(ctx context.Context, entityID uint) (ledgerBook, error) {
+	heads, err := s.repo.ListLedgerHeads(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	book := make(ledgerBook)
+	for _, h := range heads {
+		book[h.Kind+"|"+h.Key] = h.LedgerName
+	}
+	return book, nil
+}
+
+// name looks up the head for the key, then the temple's default of the kind,
+// then the built-in default
+func (b ledgerBook) name(kind, key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if name, ok := b[kind+"|"+key]; ok {
+		return name
+	}
+	if kind == HeadAccount {
+		if name, ok := defaultAccountNames[key]; ok {
+			return name
+		}
+		return defaultAccountNames[AccountGateway]
+	}
+	if name, ok := b[kind+"|"+DefaultKey]; ok {
+		return name
+	}
+	return defaultLedgerNames[kind]
+}
+
+// ==============================
+// Exports
+// ==============================
+
+// Export builds the vouchers for the date range and records them as exported
+// in the format, so the next export only carries new transactions
+func (s *service) Export(ctx context.Context, entityID uint, req ExportRequest, accessContext middleware.AccessContext, ip string) ([]byte, string, error) {
+	if !accessContext.CanWrite() {
+		return nil, "", errors.New("write access denied")
+	}
+	if err := checkEntity(accessContext, entityID); err != nil {
+		return nil, "", err
+	}
+	from, err := time.ParseInLocation("2006-01-02", req.From, utils.IST)
+	if err != nil {
+		return nil, "", errors.New("from must be in YYYY-MM-DD format")
+	}
+	to, err := time.ParseInLocation("2006-01-02", req.To, utils.IST)
+	if err != nil {
+		return nil, "", errors.New("to must be in YYYY-MM-DD format")
+	}
+	if to.Before(from) {
+		return nil, "", errors.New("to cannot be before from")
+	}
+	end := to.AddDate(0, 0, 1)
+
+	vouchers, err := s.buildVouchers(ctx, entityID, from, end, req.Format, req.IncludeExported)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(vouchers) == 0 {
+		return nil, "", errNothingToExport
+	}
+
+	var content []byte
+	var filename string
+	switch req.Format {
+	case FormatTally:
+		company := strings.TrimSpace(req.CompanyName)
+		if company == "" {
+			company, _ = s.repo.GetEntityName(ctx, entityID)
+		}
+		content, err = renderTallyXML(company, entityID, vouchers)
+		filename = fmt.Sprintf("tally_vouchers_%d_%s_%s.xml", entityID, req.From, req.To)
+	case FormatJournal:
+		content, err = renderJournalCSV(vouchers)
+		filename = fmt.Sprintf("journal_%d_%s_%s.csv", entityID, req.From, req.To)
+	default:
+		return nil, "", errors.New("unsupported export format")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	export := &LedgerExport{
+		EntityID:     entityID,
+		Format:       req.Format,
+		FromDate:     from,
+		ToDate:       to,
+		VoucherCount: len(vouchers),
+		FileName:     filename,
+		ExportedBy:   accessContext.UserID,
+	}
+	for _, v := range vouchers {
+		export.TotalDebit += v.Amount
+	}
+	export.TotalDebit = utils.RoundMoney(export.TotalDebit)
+	if err := s.repo.RecordExport(ctx, export, vouchers); err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "LEDGER_EXPORTED",
+			map[string]interface{}{"format": req.Format, "from": req.From, "to": req.To, "error": err.Error()}, ip, "failure")
+		return nil, "", fmt.Errorf("failed to record export: %w", err)
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "LEDGER_EXPORTED",
+		map[string]interface{}{
+			"export_id":        export.ID,
+			"format":           req.Format,
+			"from":             req.From,
+			"to":               req.To,
+			"vouchers":         export.VoucherCount,
+			"total":            export.TotalDebit,
+			"include_exported": req.IncludeExported,
+		}, ip, "success")
+	return content, filename, nil
+}
+
+// buildVouchers turns the range's donations, seva payments and refunds into
+// vouchers, in date order
+func (s *service) buildVouchers(ctx context.Context, entityID uint, from, to time.Time, format string, includeExported bool) ([]Voucher, error) {
+	book, err := s.loadLedgerBook(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	var txns []SourceTransaction
+	for _, list := range []func(context.Context, uint, time.Time, time.Time, string, bool) ([]SourceTransaction, error){
+		s.repo.ListDonationTransactions,
+		s.repo.ListSevaTransactions,
+		s.repo.ListRefundTransactions,
+	} {
+		found, err := list(ctx, entityID, from, to, format, includeExported)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, found...)
+	}
+	sort.SliceStable(txns, func(i, j int) bool { return txns[i].TxnDate.Before(txns[j].TxnDate) })
+
+	vouchers := make([]Voucher, 0, len(txns))
+	for _, t := range txns {
+		if t.Amount <= 0 {
+			continue
+		}
+		v := Voucher{
+			SourceType: t.SourceType,
+			SourceID:   t.SourceID,
+			Reference:  t.Reference,
+			Date:       t.TxnDate.In(utils.IST),
+			Amount:     utils.RoundMoney(t.Amount),
+			Party:      t.Party,
+			Narration:  t.Narration,
+		}
+		if t.Party != "" {
+			v.Narration += " - " + t.Party
+		}
+		switch t.SourceType {
+		case SourceDonation:
+			v.VoucherType, v.Number = "Receipt", t.Reference
+			v.Debit, v.Credit = book.name(HeadAccount, t.Account), book.name(HeadDonation, t.Category)
+		case SourceSevaBooking:
+			v.VoucherType, v.Number = "Receipt", fmt.Sprintf("SEVA/%d", t.SourceID)
+			v.Debit, v.Credit = book.name(HeadAccount, t.Account), book.name(HeadSeva, t.Category)
+		case SourceRefund:
+			v.VoucherType, v.Number = "Payment", fmt.Sprintf("RFD/%d", t.SourceID)
+			v.Debit, v.Credit = book.name(HeadRefund, t.Category), book.name(HeadAccount, t.Account)
+		}
+		vouchers = append(vouchers, v)
+	}
+	return vouchers, nil
+}
+
+func (s *service) ListExports(ctx context.Context, filter ExportFilter, accessContext middleware.AccessContext) ([]LedgerExport, int64, error) {
+	if !accessContext.CanRead() {
+		return nil, 0, errors.New("read access denied")
+	}
+	if err := checkEntity(accessContext, filter.EntityID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListExports(ctx, filter)
+}
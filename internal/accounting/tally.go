@@ -0,0 +1,113 @@
+package accounting
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// ==============================
+// Tally XML
+// ==============================
+
+// Tally's import envelope. Debits carry ISDEEMEDPOSITIVE=Yes and a negative
+// amount; credits carry No and a positive amount.
+type tallyEnvelope struct {
+	XMLName      xml.Name        `xml:"ENVELOPE"`
+	TallyRequest string          `xml:"HEADER>TALLYREQUEST"`
+	ImportData   tallyImportData `xml:"BODY>IMPORTDATA"`
+}
+
+type tallyImportData struct {
+	ReportName string         `xml:"REQUESTDESC>REPORTNAME"`
+	Company    string         `xml:"REQUESTDESC>STATICVARIABLES>SVCURRENTCOMPANY,omitempty"`
+	Messages   []tallyMessage `xml:"REQUESTDATA>TALLYMESSAGE"`
+}
+
+type tallyMessage struct {
+	Voucher tallyVoucher `xml:"VOUCHER"`
+}
+
+type tallyVoucher struct {
+	RemoteID        string             `xml:"REMOTEID,attr"`
+	VchType         string             `xml:"VCHTYPE,attr"`
+	Action          string             `xml:"ACTION,attr"`
+	Date            string             `xml:"DATE"`
+	VoucherTypeName string             `xml:"VOUCHERTYPENAME"`
+	VoucherNumber   string             `xml:"VOUCHERNUMBER"`
+	Reference       string             `xml:"REFERENCE,omitempty"`
+	Narration       string             `xml:"NARRATION"`
+	Entries         []tallyLedgerEntry `xml:"ALLLEDGERENTRIES.LIST"`
+}
+
+type tallyLedgerEntry struct {
+	LedgerName       string `xml:"LEDGERNAME"`
+	IsDeemedPositive string `xml:"ISDEEMEDPOSITIVE"`
+	Amount           string `xml:"AMOUNT"`
+}
+
+// renderTallyXML writes the vouchers as a Tally import file. Each voucher has
+// a stable REMOTEID, so importing it again updates it instead of duplicating it.
+func renderTallyXML(company string, entityID uint, vouchers []Voucher) ([]byte, error) {
+	envelope := tallyEnvelope{
+		TallyRequest: "Import Data",
+		ImportData: tallyImportData{
+			ReportName: "Vouchers",
+			Company:    company,
+			Messages:   make([]tallyMessage, 0, len(vouchers)),
+		},
+	}
+	for _, v := range vouchers {
+		amount := strconv.FormatFloat(v.Amount, 'f', 2, 64)
+		envelope.ImportData.Messages = append(envelope.ImportData.Messages, tallyMessage{Voucher: tallyVoucher{
+			RemoteID:        fmt.Sprintf("temple-%d-%s-%d", entityID, v.SourceType, v.SourceID),
+			VchType:         v.VoucherType,
+			Action:          "Create",
+			Date:            v.Date.Format("20060102"),
+			VoucherTypeName: v.VoucherType,
+			VoucherNumber:   v.Number,
+			Reference:       v.Reference,
+			Narration:       v.Narration,
+			Entries: []tallyLedgerEntry{
+				{LedgerName: v.Debit, IsDeemedPositive: "Yes", Amount: "-" + amount},
+				{LedgerName: v.Credit, IsDeemedPositive: "No", Amount: amount},
+			},
+		}})
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(envelope); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ==============================
+// Journal CSV
+// ==============================
+
+// renderJournalCSV writes one debit and one credit line per voucher
+func renderJournalCSV(vouchers []Voucher) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	_ = writer.Write([]string{"Date", "Voucher Type", "Voucher No", "Reference", "Ledger", "Debit", "Credit", "Narration", "Source", "Source ID"})
+	for _, v := range vouchers {
+		date := v.Date.Format("2006-01-02")
+		amount := strconv.FormatFloat(v.Amount, 'f', 2, 64)
+		sourceID := strconv.FormatUint(uint64(v.SourceID), 10)
+		_ = writer.Write([]string{date, v.VoucherType, v.Number, v.Reference, v.Debit, amount, "", v.Narration, v.SourceType, sourceID})
+		_ = writer.Write([]string{date, v.VoucherType, v.Number, v.Reference, v.Credit, "", amount, v.Narration, v.SourceType, sourceID})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -53,6 +53,10 @@ type RegisterRequest struct {
 		// 🆕 Razorpay credentials
 	RazorpayKeyID  string `form:"razorpayKeyId" json:"razorpayKeyId"`
 	RazorpaySecret string `form:"razorpaySecret" json:"razorpaySecret"`
+	// 🆕 Payment provider selection (razorpay | cashfree) + Cashfree credentials
+	PaymentProvider string `form:"paymentProvider" json:"paymentProvider"`
+	CashfreeAppID   string `form:"cashfreeAppId" json:"cashfreeAppId"`
+	CashfreeSecret  string `form:"cashfreeSecret" json:"cashfreeSecret"`
 
 	LogoURL       string `json:"logo_url"`
 	IntroVideoURL string `json:"intro_video_url"`
@@ -160,6 +164,9 @@ func (h *Handler) Register(c *gin.Context) {
     UPIID:             req.UPIID,
 	RazorpayKeyID:     req.RazorpayKeyID,
 	RazorpaySecret:    req.RazorpaySecret,
+	PaymentProvider:   req.PaymentProvider,
+	CashfreeAppID:     req.CashfreeAppID,
+	CashfreeSecret:    req.CashfreeSecret,
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -595,6 +602,20 @@ func (h *Handler) UpdateAccountDetails(c *gin.Context) {
 		UPIID             string `json:"upi_id"`
 		RazorpayKeyID     string `json:"razorpay_key_id"`
 		RazorpaySecret    string `json:"razorpay_secret"`
+		PaymentProvider   string `json:"payment_provider"`
+		CashfreeAppID     string `json:"cashfree_app_id"`
+		CashfreeSecret    string `json:"cashfree_secret"`
+
+		FCRARegistrationNumber string `json:"fcra_registration_number"`
+		FCRAAccountHolderName  string `json:"fcra_account_holder_name"`
+		FCRAAccountNumber      string `json:"fcra_account_number"`
+		FCRABankName           string `json:"fcra_bank_name"`
+		FCRABranchName         string `json:"fcra_branch_name"`
+		FCRAIFSCCode           string `json:"fcra_ifsc_code"`
+		FCRARazorpayKeyID      string `json:"fcra_razorpay_key_id"`
+		FCRARazorpaySecret     string `json:"fcra_razorpay_secret"`
+		FCRACashfreeAppID      string `json:"fcra_cashfree_app_id"`
+		FCRACashfreeSecret     string `json:"fcra_cashfree_secret"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -621,6 +642,20 @@ func (h *Handler) UpdateAccountDetails(c *gin.Context) {
 		UPIID:             req.UPIID,
 		RazorpayKeyID:     req.RazorpayKeyID,
 		RazorpaySecret:    req.RazorpaySecret,
+		PaymentProvider:   req.PaymentProvider,
+		CashfreeAppID:     req.CashfreeAppID,
+		CashfreeSecret:    req.CashfreeSecret,
+
+		FCRARegistrationNumber: req.FCRARegistrationNumber,
+		FCRAAccountHolderName:  req.FCRAAccountHolderName,
+		FCRAAccountNumber:      req.FCRAAccountNumber,
+		FCRABankName:           req.FCRABankName,
+		FCRABranchName:         req.FCRABranchName,
+		FCRAIFSCCode:           req.FCRAIFSCCode,
+		FCRARazorpayKeyID:      req.FCRARazorpayKeyID,
+		FCRARazorpaySecret:     req.FCRARazorpaySecret,
+		FCRACashfreeAppID:      req.FCRACashfreeAppID,
+		FCRACashfreeSecret:     req.FCRACashfreeSecret,
 	}
 
 	data, err := h.service.UpdateAccountDetails(user.ID, input)
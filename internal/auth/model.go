@@ -106,6 +106,22 @@ type Tenant_BankAccountDetails struct {
 	UPIID             *string `gorm:"size:100" json:"upi_id,omitempty"`
 	RazorpayKeyID     string  `gorm:"size:100" json:"-"`
 	RazorpaySecret    string  `gorm:"size:100" json:"-"`
+	PaymentProvider   string  `gorm:"size:20;default:'razorpay'" json:"payment_provider"` // razorpay | cashfree | fake
+	CashfreeAppID     string  `gorm:"size:100" json:"-"`
+	CashfreeSecret    string  `gorm:"size:100" json:"-"`
+
+	// FCRA-designated account — foreign contributions may only be received here.
+	// The gateway credentials belong to a merchant account settling into it.
+	FCRARegistrationNumber string `gorm:"size:50" json:"fcra_registration_number,omitempty"`
+	FCRAAccountHolderName  string `gorm:"size:255" json:"fcra_account_holder_name,omitempty"`
+	FCRAAccountNumber      string `gorm:"size:30" json:"fcra_account_number,omitempty"`
+	FCRABankName           string `gorm:"size:255" json:"fcra_bank_name,omitempty"`
+	FCRABranchName         string `gorm:"size:255" json:"fcra_branch_name,omitempty"`
+	FCRAIFSCCode           string `gorm:"size:11" json:"fcra_ifsc_code,omitempty"`
+	FCRARazorpayKeyID      string `gorm:"size:100" json:"-"`
+	FCRARazorpaySecret     string `gorm:"size:100" json:"-"`
+	FCRACashfreeAppID      string `gorm:"size:100" json:"-"`
+	FCRACashfreeSecret     string `gorm:"size:100" json:"-"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -83,6 +83,9 @@ type RegisterInput struct {
     UPIID             string
 	RazorpayKeyID     string
 	RazorpaySecret    string
+	PaymentProvider   string
+	CashfreeAppID     string
+	CashfreeSecret    string
 	LogoURL           string
 	IntroVideoURL     string
 }
@@ -156,6 +159,9 @@ func (s *service) RegisterAndReturnUser(in RegisterInput) (*User, error) {
 			AccountType:       in.AccountType,
 			RazorpayKeyID:     in.RazorpayKeyID,
 			RazorpaySecret:    in.RazorpaySecret,
+			PaymentProvider:   normalizePaymentProvider(in.PaymentProvider),
+			CashfreeAppID:     in.CashfreeAppID,
+			CashfreeSecret:    in.CashfreeSecret,
 		}
 
 		// Only set UPI ID if provided
@@ -263,6 +269,33 @@ func (s *service) UpdateAccountDetails(userID uint, input UpdateAccountDetailsIn
 	if input.RazorpaySecret != "" {
 		bankUpdates["razorpay_secret"] = input.RazorpaySecret
 	}
+	if input.PaymentProvider != "" {
+		bankUpdates["payment_provider"] = normalizePaymentProvider(input.PaymentProvider)
+	}
+	if input.CashfreeAppID != "" {
+		bankUpdates["cashfree_app_id"] = input.CashfreeAppID
+	}
+	if input.CashfreeSecret != "" {
+		bankUpdates["cashfree_secret"] = input.CashfreeSecret
+	}
+	// FCRA account details, used for foreign contributions
+	fcraUpdates := map[string]string{
+		"fcra_registration_number": input.FCRARegistrationNumber,
+		"fcra_account_holder_name": input.FCRAAccountHolderName,
+		"fcra_account_number":      input.FCRAAccountNumber,
+		"fcra_bank_name":           input.FCRABankName,
+		"fcra_branch_name":         input.FCRABranchName,
+		"fcra_ifsc_code":           strings.ToUpper(input.FCRAIFSCCode),
+		"fcra_razorpay_key_id":     input.FCRARazorpayKeyID,
+		"fcra_razorpay_secret":     input.FCRARazorpaySecret,
+		"fcra_cashfree_app_id":     input.FCRACashfreeAppID,
+		"fcra_cashfree_secret":     input.FCRACashfreeSecret,
+	}
+	for column, value := range fcraUpdates {
+		if value != "" {
+			bankUpdates[column] = value
+		}
+	}
 
 	if len(bankUpdates) > 0 {
 		if err := s.repo.UpdateBankDetails(userID, bankUpdates); err != nil {
@@ -294,7 +327,32 @@ type UpdateAccountDetailsInput struct {
 	UPIID             string
 	RazorpayKeyID     string
 	RazorpaySecret    string
+	PaymentProvider   string
+	CashfreeAppID     string
+	CashfreeSecret    string
+
+	FCRARegistrationNumber string
+	FCRAAccountHolderName  string
+	FCRAAccountNumber      string
+	FCRABankName           string
+	FCRABranchName         string
+	FCRAIFSCCode           string
+	FCRARazorpayKeyID      string
+	FCRARazorpaySecret     string
+	FCRACashfreeAppID      string
+	FCRACashfreeSecret     string
+}
+
+// normalizePaymentProvider keeps only the providers a tenant may choose. The
+// fake provider is never one of them; only PAYMENT_PROVIDER=fake enables it.
+func normalizePaymentProvider(provider string) string {
+	switch p := strings.ToLower(strings.TrimSpace(provider)); p {
+	case "cashfree":
+		return p
+	}
+	return "razorpay"
 }
+
 func (s *service) UpdateTenantMedia(tenantID uint, logoURL, videoURL string) error {
 	updates := map[string]interface{}{}
 
@@ -605,6 +663,20 @@ func (s *service) GetAccountDetails(userID uint) (*AccountDetailsResponse, error
 				UPIID             *string `json:"upi_id,omitempty"`
 				RazorpayKeyID     string  `json:"razorpay_key_id"`
 				RazorpaySecret    string  `json:"razorpay_secret"`
+				PaymentProvider   string  `json:"payment_provider"`
+				CashfreeAppID     string  `json:"cashfree_app_id"`
+				CashfreeSecret    string  `json:"cashfree_secret"`
+
+				FCRARegistrationNumber string `json:"fcra_registration_number"`
+				FCRAAccountHolderName  string `json:"fcra_account_holder_name"`
+				FCRAAccountNumber      string `json:"fcra_account_number"`
+				FCRABankName           string `json:"fcra_bank_name"`
+				FCRABranchName         string `json:"fcra_branch_name"`
+				FCRAIFSCCode           string `json:"fcra_ifsc_code"`
+				FCRARazorpayKeyID      string `json:"fcra_razorpay_key_id"`
+				FCRARazorpaySecret     string `json:"fcra_razorpay_secret"`
+				FCRACashfreeAppID      string `json:"fcra_cashfree_app_id"`
+				FCRACashfreeSecret     string `json:"fcra_cashfree_secret"`
 			}{
 				AccountHolderName: bank.AccountHolderName,
 				AccountNumber:     bank.AccountNumber,
@@ -615,6 +687,20 @@ func (s *service) GetAccountDetails(userID uint) (*AccountDetailsResponse, error
 				UPIID:             bank.UPIID,
 				RazorpayKeyID:     bank.RazorpayKeyID,  // ✅ was missing
 				RazorpaySecret:    bank.RazorpaySecret, // ✅ was missing
+				PaymentProvider:   bank.PaymentProvider,
+				CashfreeAppID:     bank.CashfreeAppID,
+				CashfreeSecret:    bank.CashfreeSecret,
+
+				FCRARegistrationNumber: bank.FCRARegistrationNumber,
+				FCRAAccountHolderName:  bank.FCRAAccountHolderName,
+				FCRAAccountNumber:      bank.FCRAAccountNumber,
+				FCRABankName:           bank.FCRABankName,
+				FCRABranchName:         bank.FCRABranchName,
+				FCRAIFSCCode:           bank.FCRAIFSCCode,
+				FCRARazorpayKeyID:      bank.FCRARazorpayKeyID,
+				FCRARazorpaySecret:     bank.FCRARazorpaySecret,
+				FCRACashfreeAppID:      bank.FCRACashfreeAppID,
+				FCRACashfreeSecret:     bank.FCRACashfreeSecret,
 			}
 		}
 	}
@@ -651,5 +737,19 @@ type AccountDetailsResponse struct {
 		UPIID             *string `json:"upi_id,omitempty"`
 		RazorpayKeyID     string  `json:"razorpay_key_id"` // ✅ ADD
 		RazorpaySecret    string  `json:"razorpay_secret"`  // ✅ ADD
+		PaymentProvider   string  `json:"payment_provider"`
+		CashfreeAppID     string  `json:"cashfree_app_id"`
+		CashfreeSecret    string  `json:"cashfree_secret"`
+
+		FCRARegistrationNumber string `json:"fcra_registration_number"`
+		FCRAAccountHolderName  string `json:"fcra_account_holder_name"`
+		FCRAAccountNumber      string `json:"fcra_account_number"`
+		FCRABankName           string `json:"fcra_bank_name"`
+		FCRABranchName         string `json:"fcra_branch_name"`
+		FCRAIFSCCode           string `json:"fcra_ifsc_code"`
+		FCRARazorpayKeyID      string `json:"fcra_razorpay_key_id"`
+		FCRARazorpaySecret     string `json:"fcra_razorpay_secret"`
+		FCRACashfreeAppID      string `json:"fcra_cashfree_app_id"`
+		FCRACashfreeSecret     string `json:"fcra_cashfree_secret"`
 	} `json:"bank,omitempty"`
 }
@@ -0,0 +1,229 @@
+package donation
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// campaignRecentDonors is how many donors the public progress endpoint lists
+const campaignRecentDonors = 10
+
+// ==============================
+// Fundraising Campaigns
+// ==============================
+
+func (s *service) CreateCampaign(req CampaignRequest, entityID uint, userID uint, accessContext middleware.AccessContext, ip string) (*Campaign, error) {
+	ctx := context.Background()
+
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	accessibleEntityID := accessContext.GetAccessibleEntityID()
+	if accessibleEntityID == nil || *accessibleEntityID != entityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+
+	campaign := &Campaign{EntityID: entityID, CreatedBy: userID}
+	if err := applyCampaignRequest(campaign, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateCampaign(ctx, campaign); err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &entityID, "CAMPAIGN_CREATED",
+			map[string]interface{}{"title": req.Title, "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+	campaign.Status = campaign.StatusAt(time.Now())
+
+	s.auditSvc.LogAction(ctx, &userID, &entityID, "CAMPAIGN_CREATED",
+		map[string]interface{}{
+			"campaign_id": campaign.ID,
+			"title":       campaign.Title,
+			"goal_amount": campaign.GoalAmount,
+			"start_date":  campaign.StartDate,
+			"end_date":    campaign.EndDate,
+		}, ip, "success")
+	return campaign, nil
+}
+
+func (s *service) UpdateCampaign(id uint, req CampaignRequest, userID uint, accessContext middleware.AccessContext, ip string) (*Campaign, error) {
+	ctx := context.Background()
+
+	campaign, err := s.campaignForWrite(ctx, id, accessContext)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyCampaignRequest(campaign, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateCampaign(ctx, id, map[string]interface{}{
+		"title":           campaign.Title,
+		"description":     campaign.Description,
+		"goal_amount":     campaign.GoalAmount,
+		"start_date":      campaign.StartDate,
+		"end_date":        campaign.EndDate,
+		"cover_image_url": campaign.CoverImageURL,
+		"donation_type":   campaign.DonationType,
+	}); err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &campaign.EntityID, "CAMPAIGN_UPDATED",
+			map[string]interface{}{"campaign_id": id, "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+	campaign.Status = campaign.StatusAt(time.Now())
+
+	s.auditSvc.LogAction(ctx, &userID, &campaign.EntityID, "CAMPAIGN_UPDATED",
+		map[string]interface{}{"campaign_id": id, "title": campaign.Title, "goal_amount": campaign.GoalAmount}, ip, "success")
+	return campaign, nil
+}
+
+// CloseCampaign stops a campaign from accepting donations before its end date
+func (s *service) CloseCampaign(id uint, userID uint, accessContext middleware.AccessContext, ip string) (*Campaign, error) {
+	ctx := context.Background()
+
+	campaign, err := s.campaignForWrite(ctx, id, accessContext)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.ClosedAt != nil {
+		return nil, errors.New("campaign is already closed")
+	}
+
+	now := time.Now()
+	if err := s.repo.UpdateCampaign(ctx, id, map[string]interface{}{"closed_at": now}); err != nil {
+		return nil, err
+	}
+	campaign.ClosedAt = &now
+	campaign.Status = CampaignClosed
+
+	s.auditSvc.LogAction(ctx, &userID, &campaign.EntityID, "CAMPAIGN_CLOSED",
+		map[string]interface{}{"campaign_id": id, "title": campaign.Title}, ip, "success")
+	return campaign, nil
+}
+
+// ListCampaigns returns a temple's campaigns. Devotees of the temple may list
+// them too, so they can pick one when donating.
+func (s *service) ListCampaigns(entityID uint, accessContext middleware.AccessContext) ([]Campaign, error) {
+	if accessContext.RoleName != "devotee" {
+		if !accessContext.CanRead() {
+			return nil, errors.New("read access denied")
+		}
+		accessibleEntityID := accessContext.GetAccessibleEntityID()
+		if accessibleEntityID == nil || *accessibleEntityID != entityID {
+			return nil, errors.New("access denied to requested entity")
+		}
+	}
+
+	campaigns, err := s.repo.ListCampaigns(context.Background(), entityID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range campaigns {
+		campaigns[i].Status = campaigns[i].StatusAt(now)
+	}
+	return campaigns, nil
+}
+
+// GetCampaignProgress is public: raised amount, donors and the latest gifts
+func (s *service) GetCampaignProgress(id uint) (*CampaignProgress, error) {
+	ctx := context.Background()
+
+	campaign, err := s.repo.GetCampaignByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("campaign not found")
+	}
+	campaign.Status = campaign.StatusAt(time.Now())
+
+	totals, err := s.repo.GetCampaignTotals(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := s.repo.GetCampaignRecentDonors(ctx, id, campaignRecentDonors)
+	if err != nil {
+		return nil, err
+	}
+	if recent == nil {
+		recent = []CampaignDonor{}
+	}
+
+	percentage := 0.0
+	if campaign.GoalAmount > 0 {
+		percentage = math.Round(totals.Raised/campaign.GoalAmount*10000) / 100
+	}
+
+	return &CampaignProgress{
+		Campaign:      *campaign,
+		Raised:        totals.Raised,
+		DonationCount: totals.DonationCount,
+		DonorCount:    totals.DonorCount,
+		Percentage:    percentage,
+		RecentDonors:  recent,
+	}, nil
+}
+
+// openCampaign returns the campaign a new donation is attached to, checking it
+// belongs to the temple and is accepting donations at the given time
+func (s *service) openCampaign(ctx context.Context, campaignID *uint, entityID uint, at time.Time) (*Campaign, error) {
+	if campaignID == nil || *campaignID == 0 {
+		return nil, nil
+	}
+	campaign, err := s.repo.GetCampaignByID(ctx, *campaignID)
+	if err != nil || campaign.EntityID != entityID {
+		return nil, errors.New("campaign not found")
+	}
+	if status := campaign.StatusAt(at); status != CampaignActive {
+		return nil, errors.New("campaign is " + status + " and not accepting donations")
+	}
+	return campaign, nil
+}
+
+func (s *service) campaignForWrite(ctx context.Context, id uint, accessContext middleware.AccessContext) (*Campaign, error) {
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	campaign, err := s.repo.GetCampaignByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("campaign not found")
+	}
+	accessibleEntityID := accessContext.GetAccessibleEntityID()
+	if accessibleEntityID == nil || *accessibleEntityID != campaign.EntityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+	return campaign, nil
+}
+
+// applyCampaignRequest validates req and copies it onto campaign. The end
+// date is inclusive, so the campaign runs until the end of that day (IST).
+func applyCampaignRequest(campaign *Campaign, req CampaignRequest) error {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return errors.New("title is required")
+	}
+	start, err := time.ParseInLocation("2006-01-02", req.StartDate, utils.IST)
+	if err != nil {
+		return errors.New("startDate must be in YYYY-MM-DD format")
+	}
+	end, err := time.ParseInLocation("2006-01-02", req.EndDate, utils.IST)
+	if err != nil {
+		return errors.New("endDate must be in YYYY-MM-DD format")
+	}
+	if end.Before(start) {
+		return errors.New("endDate cannot be before startDate")
+	}
+
+	campaign.Title = title
+	campaign.Description = strings.TrimSpace(req.Description)
+	campaign.GoalAmount = req.GoalAmount
+	campaign.StartDate = start
+	campaign.EndDate = end.Add(24*time.Hour - time.Second)
+	campaign.CoverImageURL = strings.TrimSpace(req.CoverImageURL)
+	campaign.DonationType = req.DonationType
+	return nil
+}
@@ -0,0 +1,43 @@
+package donation
+
+import (
+	"errors"
+	"strings"
+)
+
+// ==============================
+// Donation Dedications
+// ==============================
+
+// normalized trims the dedication and checks the name and type go together.
+// A name without a type is taken as on_behalf_of.
+func (d DonationDedication) normalized() (DonationDedication, error) {
+	if d.OnBehalfOf != nil {
+		name := strings.TrimSpace(*d.OnBehalfOf)
+		d.OnBehalfOf = nil
+		if name != "" {
+			d.OnBehalfOf = &name
+		}
+	}
+	if d.OnBehalfOf == nil {
+		if d.DedicationType != "" {
+			return d, errors.New("onBehalfOf is required when dedicationType is set")
+		}
+		return d, nil
+	}
+	if d.DedicationType == "" {
+		d.DedicationType = DedicationOnBehalfOf
+	}
+	return d, nil
+}
+
+// dedicationLabel is the receipt caption for a dedication type
+func dedicationLabel(dedicationType string) string {
+	switch dedicationType {
+	case DedicationInMemoryOf:
+		return "In Memory Of"
+	case DedicationInHonourOf:
+		return "In Honour Of"
+	}
+	return "On Behalf Of"
+}
@@ -27,9 +27,23 @@ type row struct {
 	AccountType       string `gorm:"column:account_type"`
 	RazorpayKeyID     string `gorm:"column:razorpay_key_id"`
 	RazorpaySecret    string `gorm:"column:razorpay_secret"`
+	PaymentProvider   string `gorm:"column:payment_provider"`
+	CashfreeAppID     string `gorm:"column:cashfree_app_id"`
+	CashfreeSecret    string `gorm:"column:cashfree_secret"`
+
+	FCRARegistrationNumber string `gorm:"column:fcra_registration_number"`
+	FCRAAccountHolderName  string `gorm:"column:fcra_account_holder_name"`
+	FCRAAccountNumber      string `gorm:"column:fcra_account_number"`
+	FCRABankName           string `gorm:"column:fcra_bank_name"`
+	FCRABranchName         string `gorm:"column:fcra_branch_name"`
+	FCRAIFSCCode           string `gorm:"column:fcra_ifsc_code"`
+	FCRARazorpayKeyID      string `gorm:"column:fcra_razorpay_key_id"`
+	FCRARazorpaySecret     string `gorm:"column:fcra_razorpay_secret"`
+	FCRACashfreeAppID      string `gorm:"column:fcra_cashfree_app_id"`
+	FCRACashfreeSecret     string `gorm:"column:fcra_cashfree_secret"`
 }
 
-// GetBankDetailsByEntityID returns the TENANT's bank details including Razorpay credentials.
+// GetBankDetailsByEntityID returns the TENANT's bank details including payment gateway credentials.
 //
 // Strategy (in order):
 //  1. Direct: entities.created_by → tenant_bank_account_details.user_id
@@ -49,7 +63,20 @@ func (r *entityBankRepo) GetBankDetailsByEntityID(ctx context.Context, entityID
 			COALESCE(tba.branch_name, '')         AS branch_name,
 			COALESCE(tba.account_type, '')        AS account_type,
 			COALESCE(tba.razorpay_key_id, '')     AS razorpay_key_id,
-			COALESCE(tba.razorpay_secret, '')     AS razorpay_secret
+			COALESCE(tba.razorpay_secret, '')     AS razorpay_secret,
+			COALESCE(tba.payment_provider, '')    AS payment_provider,
+			COALESCE(tba.cashfree_app_id, '')     AS cashfree_app_id,
+			COALESCE(tba.cashfree_secret, '')     AS cashfree_secret,
+			COALESCE(tba.fcra_registration_number, '') AS fcra_registration_number,
+			COALESCE(tba.fcra_account_holder_name, '') AS fcra_account_holder_name,
+			COALESCE(tba.fcra_account_number, '')      AS fcra_account_number,
+			COALESCE(tba.fcra_bank_name, '')           AS fcra_bank_name,
+			COALESCE(tba.fcra_branch_name, '')         AS fcra_branch_name,
+			COALESCE(tba.fcra_ifsc_code, '')           AS fcra_ifsc_code,
+			COALESCE(tba.fcra_razorpay_key_id, '')     AS fcra_razorpay_key_id,
+			COALESCE(tba.fcra_razorpay_secret, '')     AS fcra_razorpay_secret,
+			COALESCE(tba.fcra_cashfree_app_id, '')     AS fcra_cashfree_app_id,
+			COALESCE(tba.fcra_cashfree_secret, '')     AS fcra_cashfree_secret
 		FROM entities e
 		INNER JOIN tenant_bank_account_details tba ON tba.user_id = e.created_by
 		WHERE e.id = ?
@@ -75,7 +102,20 @@ func (r *entityBankRepo) GetBankDetailsByEntityID(ctx context.Context, entityID
 			COALESCE(tba.branch_name, '')         AS branch_name,
 			COALESCE(tba.account_type, '')        AS account_type,
 			COALESCE(tba.razorpay_key_id, '')     AS razorpay_key_id,
-			COALESCE(tba.razorpay_secret, '')     AS razorpay_secret
+			COALESCE(tba.razorpay_secret, '')     AS razorpay_secret,
+			COALESCE(tba.payment_provider, '')    AS payment_provider,
+			COALESCE(tba.cashfree_app_id, '')     AS cashfree_app_id,
+			COALESCE(tba.cashfree_secret, '')     AS cashfree_secret,
+			COALESCE(tba.fcra_registration_number, '') AS fcra_registration_number,
+			COALESCE(tba.fcra_account_holder_name, '') AS fcra_account_holder_name,
+			COALESCE(tba.fcra_account_number, '')      AS fcra_account_number,
+			COALESCE(tba.fcra_bank_name, '')           AS fcra_bank_name,
+			COALESCE(tba.fcra_branch_name, '')         AS fcra_branch_name,
+			COALESCE(tba.fcra_ifsc_code, '')           AS fcra_ifsc_code,
+			COALESCE(tba.fcra_razorpay_key_id, '')     AS fcra_razorpay_key_id,
+			COALESCE(tba.fcra_razorpay_secret, '')     AS fcra_razorpay_secret,
+			COALESCE(tba.fcra_cashfree_app_id, '')     AS fcra_cashfree_app_id,
+			COALESCE(tba.fcra_cashfree_secret, '')     AS fcra_cashfree_secret
 		FROM tenant_bank_account_details tba
 		ORDER BY tba.user_id ASC
 		LIMIT 1
@@ -103,5 +143,19 @@ func toDetails(r row) *EntityBankDetails {
 		AccountType:       r.AccountType,
 		RazorpayKeyID:     r.RazorpayKeyID,
 		RazorpaySecret:    r.RazorpaySecret,
+		PaymentProvider:   r.PaymentProvider,
+		CashfreeAppID:     r.CashfreeAppID,
+		CashfreeSecret:    r.CashfreeSecret,
+
+		FCRARegistrationNumber: r.FCRARegistrationNumber,
+		FCRAAccountHolderName:  r.FCRAAccountHolderName,
+		FCRAAccountNumber:      r.FCRAAccountNumber,
+		FCRABankName:           r.FCRABankName,
+		FCRABranchName:         r.FCRABranchName,
+		FCRAIFSCCode:           r.FCRAIFSCCode,
+		FCRARazorpayKeyID:      r.FCRARazorpayKeyID,
+		FCRARazorpaySecret:     r.FCRARazorpaySecret,
+		FCRACashfreeAppID:      r.FCRACashfreeAppID,
+		FCRACashfreeSecret:     r.FCRACashfreeSecret,
 	}
 }
\ No newline at end of file
@@ -0,0 +1,320 @@
+package donation
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// ==============================
+// Form 10BD / 10BE
+// ==============================
+
+// Values used by the Income Tax portal's Form 10BD template
+const (
+	form10BDSectionCode = "Section 80G(5)(vi)"
+
+	form10BDIDPAN     = "Permanent Account Number"
+	form10BDIDAadhaar = "Aadhaar Number"
+
+	form10BDTypeCorpus = "Corpus"
+	form10BDTypeOthers = "Others"
+
+	form10BDModeCash       = "Cash"
+	form10BDModeElectronic = "Electronic modes including account payee cheque/draft"
+	form10BDModeOthers     = "Others"
+)
+
+var errNotInForm10BD = errors.New("this donation is not reportable in Form 10BD: the donor gave neither PAN nor Aadhaar")
+
+// form10BDIdentity picks the donor's identification for Form 10BD, preferring the PAN
+func form10BDIdentity(d Form10BDDonation) (string, string) {
+	if d.DonorPAN != "" {
+		return form10BDIDPAN, d.DonorPAN
+	}
+	return form10BDIDAadhaar, d.DonorAadhaar
+}
+
+// form10BDDonationType maps a donation type to the Form 10BD category.
+// Construction gifts go to the temple corpus; everything else is "Others".
+func form10BDDonationType(donationType string) string {
+	if donationType == TypeConstruction {
+		return form10BDTypeCorpus
+	}
+	return form10BDTypeOthers
+}
+
+// form10BDMode maps a payment method to the Form 10BD mode of receipt
+func form10BDMode(method string) string {
+	switch strings.ToUpper(strings.TrimSpace(method)) {
+	case MethodCash:
+		return form10BDModeCash
+	case MethodUPI, MethodCard, MethodNetbanking, MethodWallet, MethodCheque, MethodDD, MethodBankTransfer:
+		return form10BDModeElectronic
+	}
+	return form10BDModeOthers
+}
+
+// buildForm10BDRows sums each donor's gifts per donation type and mode, in
+// the order the donors first gave. Name and address come from the latest gift.
+func buildForm10BDRows(donations []Form10BDDonation) []Form10BDRow {
+	var rows []Form10BDRow
+	index := make(map[string]int)
+	for _, d := range donations {
+		idCode, idNumber := form10BDIdentity(d)
+		row := Form10BDRow{
+			IDCode:       idCode,
+			IDNumber:     idNumber,
+			SectionCode:  form10BDSectionCode,
+			DonorName:    d.DonorName,
+			DonorAddress: d.DonorAddress,
+			DonationType: form10BDDonationType(d.DonationType),
+			Mode:         form10BDMode(d.Method),
+		}
+		key := strings.Join([]string{row.IDCode, row.IDNumber, row.DonationType, row.Mode}, "|")
+		i, ok := index[key]
+		if !ok {
+			i = len(rows)
+			index[key] = i
+			rows = append(rows, row)
+		}
+		rows[i].DonorName = d.DonorName
+		if d.DonorAddress != "" {
+			rows[i].DonorAddress = d.DonorAddress
+		}
+		rows[i].Amount = utils.RoundMoney(rows[i].Amount + d.NetAmount)
+		rows[i].DonationIDs = append(rows[i].DonationIDs, d.ID)
+	}
+	return rows
+}
+
+// ExportForm10BD writes the temple's Form 10BD for a financial year in the
+// column layout of the Income Tax portal's CSV template. It also returns how
+// many successful gifts were left out for want of a PAN or Aadhaar number.
+func (s *service) ExportForm10BD(entityID uint, financialYear string, accessContext middleware.AccessContext) ([]byte, string, int, error) {
+	if !accessContext.CanRead() {
+		return nil, "", 0, errors.New("read access denied")
+	}
+	accessibleEntityID := accessContext.GetAccessibleEntityID()
+	if accessibleEntityID == nil || *accessibleEntityID != entityID {
+		return nil, "", 0, errors.New("access denied to requested entity")
+	}
+	from, to, err := financialYearBounds(financialYear)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	ctx := context.Background()
+	donations, err := s.repo.ListForm10BDDonations(ctx, entityID, from, to)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	skipped, err := s.repo.CountForm10BDMissingIdentity(ctx, entityID, from, to)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write([]string{"Sr. No.", "Pre Acknowledgement Number", "ID Code", "Unique Identification Number",
+		"Section Code", "Unique Registration Number (URN)", "Date of Issuance of Unique Registration Number",
+		"Name of donor", "Address of donor", "Donation Type", "Mode of receipt", "Amount of donation (Indian rupees)"})
+	for i, row := range buildForm10BDRows(donations) {
+		_ = writer.Write([]string{
+			strconv.Itoa(i + 1), "", row.IDCode, row.IDNumber,
+			row.SectionCode, "", "",
+			row.DonorName, row.DonorAddress, row.DonationType, row.Mode,
+			fmt.Sprintf("%.2f", row.Amount),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, "", 0, err
+	}
+	return buf.Bytes(), fmt.Sprintf("form_10bd_%d_%s.csv", entityID, financialYear), skipped, nil
+}
+
+// GenerateForm10BE renders the Form 10BE certificate for the donor of a
+// donation, covering all their gifts to the temple in that financial year.
+// The URN and its date are allotted by the portal once Form 10BD is filed.
+func (s *service) GenerateForm10BE(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint, urn, urnDate string) ([]byte, string, error) {
+	ctx := context.Background()
+	donation, err := s.repo.GetByIDWithUser(ctx, donationID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !canViewDonation(donation, userID, accessContext, entityID) {
+		return nil, "", errors.New("unauthorized to access this donation")
+	}
+	if donation.Status != StatusSuccess {
+		return nil, "", errors.New("Form 10BE can only be generated for successful donations")
+	}
+
+	donatedAt := donation.CreatedAt
+	if donation.DonatedAt != nil {
+		donatedAt = *donation.DonatedAt
+	}
+	financialYear := financialYearOf(donatedAt)
+	from, to, _ := financialYearBounds(financialYear)
+	donations, err := s.repo.ListForm10BDDonations(ctx, donation.EntityID, from, to)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// The donor is whoever shares this gift's PAN or Aadhaar number
+	var idCode, idNumber string
+	for _, d := range donations {
+		if d.ID == donation.ID {
+			idCode, idNumber = form10BDIdentity(d)
+			break
+		}
+	}
+	if idNumber == "" {
+		return nil, "", errNotInForm10BD
+	}
+	var donorGifts []Form10BDDonation
+	for _, d := range donations {
+		if code, number := form10BDIdentity(d); code == idCode && number == idNumber {
+			donorGifts = append(donorGifts, d)
+		}
+	}
+
+	rows := buildForm10BDRows(donorGifts)
+	certificate := &Form10BECertificate{
+		FinancialYear: financialYear,
+		DonorName:     rows[len(rows)-1].DonorName,
+		DonorAddress:  rows[len(rows)-1].DonorAddress,
+		IDCode:        idCode,
+		IDNumber:      idNumber,
+		URN:           strings.TrimSpace(urn),
+		URNDate:       strings.TrimSpace(urnDate),
+		Rows:          rows,
+		EntityName:    donation.EntityName,
+		GeneratedAt:   time.Now(),
+	}
+	if idCode == form10BDIDAadhaar {
+		certificate.IDNumber = maskAadhaar(idNumber)
+	}
+	for _, row := range rows {
+		certificate.TotalAmount += row.Amount
+	}
+	certificate.TotalAmount = utils.RoundMoney(certificate.TotalAmount)
+	certificate.AmountInWords = amountInWords(certificate.TotalAmount)
+
+	var logoPath string
+	if details, err := s.repo.GetReceiptEntityDetails(ctx, donation.EntityID); err != nil {
+		log.Printf("⚠️ Could not load receipt details for entity=%d: %v", donation.EntityID, err)
+	} else {
+		certificate.EntityName = details.Name
+		certificate.EntityAddress = formatEntityAddress(details)
+		certificate.Registration80G = details.Registration80G
+		certificate.TrustPAN = details.TrustPAN
+		_, logoPath = entityLogo(donation.EntityID, details.Media)
+	}
+
+	pdfBytes, err := renderForm10BEPDF(certificate, logoPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render Form 10BE: %w", err)
+	}
+	return pdfBytes, form10BEFileName(financialYear, donation.ID), nil
+}
+
+// renderForm10BEPDF draws the certificate of donation under section 80G(5)(ix)
+// for one donor: their identity, the URN, and each donation type and mode
+// as filed in Form 10BD
+func renderForm10BEPDF(c *Form10BECertificate, logoPath string) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(c.GeneratedAt)
+	pdf.SetModificationDate(c.GeneratedAt)
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle("Form 10BE FY "+c.FinancialYear, false)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	drawEntityHeader(pdf, tr, c.EntityName, c.EntityAddress, c.TrustPAN, c.Registration80G, logoPath)
+
+	pdf.SetXY(10, 46)
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(190, 8, "FORM No. 10BE", "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	pdf.CellFormat(190, 5, "[See rule 18AB]", "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(190, 6, "Certificate of donation under clause (ix) of sub-section (5) of section 80G", "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(190, 6, "Financial Year "+c.FinancialYear, "", 1, "C", false, 0, "")
+	pdf.Ln(3)
+
+	urn, urnDate := c.URN, c.URNDate
+	if urn == "" {
+		urn = "To be allotted on filing of Form 10BD"
+	}
+	rows := [][2]string{
+		{"PAN of reporting person", c.TrustPAN},
+		{"Name of reporting person", c.EntityName},
+		{"Approval No. (80G)", c.Registration80G},
+		{"Unique Registration No.", urn},
+	}
+	if urnDate != "" {
+		rows = append(rows, [2]string{"Date of issue of URN", urnDate})
+	}
+	rows = append(rows, [][2]string{
+		{"Name of donor", c.DonorName},
+		{"Address of donor", c.DonorAddress},
+		{c.IDCode, c.IDNumber},
+	}...)
+	drawDetailRows(pdf, tr, rows)
+	pdf.Ln(5)
+
+	// ── Donations ────────────────────────────────────────────────────────
+	widths := []float64{40, 105, 45}
+	headers := []string{"Donation Type", "Mode of receipt", "Amount (Rs.)"}
+	pdf.SetFont("Arial", "B", 9)
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Arial", "", 9)
+	for _, row := range c.Rows {
+		pdf.CellFormat(widths[0], 6, row.DonationType, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, row.Mode, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, fmt.Sprintf("%.2f", row.Amount), "1", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Arial", "B", 9)
+	pdf.CellFormat(145, 7, "Total", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(45, 7, fmt.Sprintf("%.2f", c.TotalAmount), "1", 1, "R", false, 0, "")
+	pdf.Ln(3)
+	pdf.SetFont("Arial", "", 10)
+	pdf.MultiCell(190, 6, tr("Amount in words: "+c.AmountInWords), "", "L", false)
+	pdf.Ln(4)
+
+	pdf.SetFont("Arial", "", 9)
+	pdf.MultiCell(190, 5, "This is to certify that the donation(s) above were received during the financial year and have been "+
+		"reported in the statement of particulars of donations (Form No. 10BD) furnished by the reporting person.", "", "L", false)
+	pdf.Ln(10)
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(190, 6, "Authorised Signatory", "", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "I", 8)
+	pdf.CellFormat(190, 5, "This is a computer-generated certificate and does not require a physical signature.", "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// form10BEFileName is the download name of a Form 10BE certificate
+func form10BEFileName(financialYear string, donationID uint) string {
+	return fmt.Sprintf("form_10be_%s_%d.pdf", financialYear, donationID)
+}
@@ -1,19 +1,17 @@
 package donation
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/payment"
 	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
 )
 
 type Handler struct {
@@ -119,10 +117,27 @@ func (h *Handler) GetMyDonations(c *gin.Context) {
 		return
 	}
 
+	// In-kind offerings are listed and valued separately from monetary donations
+	inKind, err := h.svc.GetInKindDonationsByUserAndEntity(accessContext.UserID, entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if inKind == nil {
+		inKind = []InKindDonationWithUser{}
+	}
+	inKindValue := 0.0
+	for _, d := range inKind {
+		inKindValue += d.EstimatedValue
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"data":    donations,
-		"count":   len(donations),
-		"success": true,
+		"data":          donations,
+		"count":         len(donations),
+		"in_kind":       inKind,
+		"in_kind_count": len(inKind),
+		"in_kind_value": inKindValue,
+		"success":       true,
 	})
 }
 
@@ -185,6 +200,8 @@ func (h *Handler) GetDonationsByEntity(c *gin.Context) {
 		Method: c.Query("method"),
 		Search: c.Query("search"),
 	}
+	filters.CampaignID = uint(parseIntQuery(c, "campaign_id", 0))
+	filters.Contribution = c.Query("contribution")
 
 	// 🔒 ROLE-BASED FILTERING WITH ENTITY ISOLATION
 	switch accessContext.RoleName {
@@ -374,6 +391,18 @@ func (h *Handler) GenerateReceipt(c *gin.Context) {
 		entityID = extractedEntityID
 	}
 
+	// ?format=pdf → downloadable 80G receipt
+	if c.Query("format") == "pdf" {
+		pdfBytes, filename, err := h.svc.GenerateReceiptPDF(uint(donationID), accessContext.UserID, &accessContext, entityID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "application/pdf", pdfBytes)
+		return
+	}
+
 	receipt, err := h.svc.GenerateReceipt(uint(donationID), accessContext.UserID, &accessContext, entityID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -402,7 +431,8 @@ func (h *Handler) GetAnalytics(c *gin.Context) {
 	}
 
 	days := parseIntQuery(c, "days", 30)
-	analytics, err := h.svc.GetAnalytics(entityID, days, accessContext)
+	campaignID := uint(parseIntQuery(c, "campaign_id", 0))
+	analytics, err := h.svc.GetAnalytics(entityID, days, campaignID, accessContext)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -431,6 +461,19 @@ func (h *Handler) ExportDonations(c *gin.Context) {
 
 	format := c.DefaultQuery("format", "csv")
 
+	// ?format=10bd → Form 10BD for ?financial_year= (defaults to the last completed year)
+	if format == "10bd" {
+		fileContent, filename, skipped, err := h.svc.ExportForm10BD(entityID, statementFinancialYear(c), accessContext)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Header("X-Skipped-Donations", strconv.Itoa(skipped)) // gifts without PAN or Aadhaar
+		c.Data(http.StatusOK, "text/csv", fileContent)
+		return
+	}
+
 	// Build filters for export
 	filters := DonationFilters{
 		EntityID: entityID,
@@ -441,6 +484,8 @@ func (h *Handler) ExportDonations(c *gin.Context) {
 		Page:     1,
 		Limit:    10000, // Large limit for export
 	}
+	filters.CampaignID = uint(parseIntQuery(c, "campaign_id", 0))
+	filters.Contribution = c.Query("contribution")
 
 	// Parse date filters
 	if fromStr := c.Query("from"); fromStr != "" {
@@ -525,146 +570,1037 @@ func parseIntQuery(c *gin.Context, key string, defaultValue int) int {
 }
 
 // ==============================
-// 🔔 11. Razorpay Webhook Handler
+// 🔔 11. Payment Webhook Handler
 // ==============================
+
+// HandleWebhook keeps the original Razorpay webhook URL working
 func (h *Handler) HandleWebhook(c *gin.Context) {
-	// Step 1: Verify webhook signature
-	webhookSecret := os.Getenv("RAZORPAY_WEBHOOK_SECRET")
-	if webhookSecret == "" {
-		log.Println("⚠️ RAZORPAY_WEBHOOK_SECRET not configured")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "webhook secret not configured"})
+	h.handleProviderWebhook(c, payment.ProviderRazorpay)
+}
+
+// HandleProviderWebhook receives webhooks at /donations/webhook/:provider
+func (h *Handler) HandleProviderWebhook(c *gin.Context) {
+	h.handleProviderWebhook(c, c.Param("provider"))
+}
+
+func (h *Handler) handleProviderWebhook(c *gin.Context, provider string) {
+	// Step 1: Read request body
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
 
-	// Get signature from header
-	signature := c.GetHeader("X-Razorpay-Signature")
-	if signature == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing signature"})
+	// Step 2: Store the delivery, verify its signature and apply it once
+	rec, duplicate, err := h.svc.ReceivePaymentWebhook(provider, body, c.Request.Header)
+	if err != nil {
+		log.Printf("❌ Webhook(%s) rejected: %v", provider, err)
+		switch {
+		case errors.Is(err, payment.ErrNoWebhookSecret):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "webhook secret not configured"})
+		case errors.Is(err, payment.ErrInvalidSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+		case rec == nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	// Read request body
-	body, err := io.ReadAll(c.Request.Body)
+	// Step 3: Report the outcome — failures are answered with 500 so the gateway retries too
+	switch {
+	case duplicate:
+		c.JSON(http.StatusOK, gin.H{"message": "duplicate event ignored"})
+	case rec.Status == WebhookProcessing:
+		c.JSON(http.StatusOK, gin.H{"message": "event already being processed"})
+	case rec.Status == WebhookIgnored:
+		c.JSON(http.StatusOK, gin.H{"message": "event ignored"})
+	case rec.Status == WebhookRejected:
+		c.JSON(http.StatusBadRequest, gin.H{"error": rec.LastError})
+	case rec.Status == WebhookFailed:
+		log.Printf("❌ Webhook processing failed for order %s: %s", rec.OrderID, rec.LastError)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process webhook"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "webhook processed"})
+	}
+}
+
+// ListWebhookEvents lists stored payment webhooks for superadmins
+func (h *Handler) ListWebhookEvents(c *gin.Context) {
+	filters := WebhookEventFilters{
+		Provider:  c.Query("provider"),
+		Status:    c.Query("status"),
+		EventType: c.Query("event_type"),
+		OrderID:   c.Query("order_id"),
+		Page:      parseIntQuery(c, "page", 1),
+		Limit:     parseIntQuery(c, "limit", 20),
+	}
+	if from := c.Query("from"); from != "" {
+		if t, err := time.Parse("2006-01-02", from); err == nil {
+			filters.From = &t
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if t, err := time.Parse("2006-01-02", to); err == nil {
+			end := t.Add(24*time.Hour - time.Second)
+			filters.To = &end
+		}
+	}
+
+	events, total, err := h.svc.ListWebhookEvents(filters)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        events,
+		"total":       total,
+		"page":        filters.Page,
+		"limit":       filters.Limit,
+		"total_pages": (total + filters.Limit - 1) / filters.Limit,
+		"success":     true,
+	})
+}
+
+// ReplayWebhookEvent applies a stored payment webhook again
+func (h *Handler) ReplayWebhookEvent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+
+	rec, err := h.svc.ReplayWebhookEvent(uint(id), accessContext.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"data": rec, "success": true})
+}
+
+// ==============================
+// 🔁 12. Recurring Donations
+// ==============================
+func (h *Handler) CreateRecurringDonation(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+	var req CreateRecurringDonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.UserID = accessContext.UserID
+	req.EntityID = entityID
+	req.IPAddress = middleware.GetIPFromContext(c)
 
-	// Verify signature
-	expectedSignature := hmac.New(sha256.New, []byte(webhookSecret))
-	expectedSignature.Write(body)
-	computedSignature := hex.EncodeToString(expectedSignature.Sum(nil))
+	rd, err := h.svc.CreateRecurringDonation(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": rd, "success": true})
+}
 
-	if computedSignature != signature {
-		log.Printf("❌ Webhook signature mismatch - Expected: %s, Got: %s", computedSignature, signature)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+func (h *Handler) GetMyRecurringDonations(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
 		return
 	}
+	entityID, _ := getEntityIDFromRequest(c, accessContext)
 
-	// Step 2: Parse webhook payload
-	var payload map[string]interface{}
-	if err := json.Unmarshal(body, &payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON"})
+	list, err := h.svc.GetMyRecurringDonations(accessContext.UserID, entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"data": list, "count": len(list), "success": true})
+}
 
-	event, ok := payload["event"].(string)
+func (h *Handler) UpdateRecurringDonationStatus(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing event type"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recurring donation ID"})
+		return
+	}
+	var req UpdateRecurringStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Step 3: Handle different webhook events
-	switch event {
-	case "payment.captured":
-		h.handlePaymentCaptured(c, payload)
-	case "payment.failed":
-		h.handlePaymentFailed(c, payload)
-	default:
-		log.Printf("⚠️ Unhandled webhook event: %s", event)
-		c.JSON(http.StatusOK, gin.H{"message": "event ignored"})
+	rd, err := h.svc.UpdateRecurringDonationStatus(uint(id), accessContext.UserID, req.Status, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"data": rd, "success": true})
+}
+
+// ==============================
+// 💸 13. Refunds
+// ==============================
+func (h *Handler) RefundDonation(c *gin.Context) {
+	h.initiateRefund(c, RefundSourceDonation)
+}
+
+func (h *Handler) RefundSevaBooking(c *gin.Context) {
+	h.initiateRefund(c, RefundSourceSevaBooking)
 }
 
-// Helper: Handle successful payment webhook
-func (h *Handler) handlePaymentCaptured(c *gin.Context, payload map[string]interface{}) {
-	paymentData, ok := payload["payload"].(map[string]interface{})
+func (h *Handler) initiateRefund(c *gin.Context, sourceType string) {
+	accessContext, ok := getAccessContextFromContext(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload structure"})
 		return
 	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	var req InitiateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.SourceType = sourceType
+	req.SourceID = uint(id)
+	req.InitiatedBy = accessContext.UserID
+	req.IPAddress = middleware.GetIPFromContext(c)
+
+	refund, err := h.svc.InitiateRefund(req, accessContext)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": refund, "success": true})
+}
 
-	payment, ok := paymentData["payment"].(map[string]interface{})
+func (h *Handler) ListRefunds(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing payment data"})
 		return
 	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	filters := RefundFilters{
+		EntityID:   entityID,
+		SourceType: c.Query("source_type"),
+		Status:     c.Query("status"),
+		Page:       parseIntQuery(c, "page", 1),
+		Limit:      parseIntQuery(c, "limit", 20),
+	}
+	if sourceID := c.Query("source_id"); sourceID != "" {
+		if id, err := strconv.ParseUint(sourceID, 10, 32); err == nil {
+			filters.SourceID = uint(id)
+		}
+	}
+
+	refunds, total, err := h.svc.ListRefunds(filters, accessContext)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        refunds,
+		"total":       total,
+		"page":        filters.Page,
+		"limit":       filters.Limit,
+		"total_pages": (total + filters.Limit - 1) / filters.Limit,
+		"success":     true,
+	})
+}
 
-	entity, ok := payment["entity"].(map[string]interface{})
+// ==============================
+// 🏦 14. Offline (Counter) Donations
+// ==============================
+func (h *Handler) RecordOfflineDonation(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing payment entity"})
 		return
 	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	var req RecordOfflineDonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.EntityID = entityID
+	req.RecordedBy = accessContext.UserID
+	req.IPAddress = middleware.GetIPFromContext(c)
 
-	// Extract payment details
-	orderID, _ := entity["order_id"].(string)
-	paymentID, _ := entity["id"].(string)
-	method, _ := entity["method"].(string)
-	amountPaise, _ := entity["amount"].(float64)
+	donation, err := h.svc.RecordOfflineDonation(req, accessContext)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": donation, "success": true})
+}
 
-	if orderID == "" || paymentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing order_id or payment_id"})
+func (h *Handler) UpdateOfflineClearance(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	var req UpdateClearanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	amount := amountPaise / 100 // Convert paise to rupees
+	donation, err := h.svc.UpdateOfflineClearance(uint(id), req, accessContext.UserID, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": donation, "success": true})
+}
 
-	// Update donation via service
-	if err := h.svc.HandleRazorpayWebhook(orderID, paymentID, method, amount); err != nil {
-		log.Printf("❌ Webhook processing failed for order %s: %v", orderID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update donation"})
+// ==============================
+// 🎯 15. Fundraising Campaigns
+// ==============================
+func (h *Handler) CreateCampaign(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+	var req CampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	log.Printf("✅ Webhook processed successfully for order %s", orderID)
-	c.JSON(http.StatusOK, gin.H{"message": "webhook processed"})
+	campaign, err := h.svc.CreateCampaign(req, entityID, accessContext.UserID, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": campaign, "success": true})
 }
 
-// Helper: Handle failed payment webhook
-func (h *Handler) handlePaymentFailed(c *gin.Context, payload map[string]interface{}) {
-	paymentData, ok := payload["payload"].(map[string]interface{})
+func (h *Handler) UpdateCampaign(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload structure"})
 		return
 	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid campaign ID"})
+		return
+	}
+	var req CampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	campaign, err := h.svc.UpdateCampaign(uint(id), req, accessContext.UserID, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": campaign, "success": true})
+}
 
-	payment, ok := paymentData["payment"].(map[string]interface{})
+func (h *Handler) CloseCampaign(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing payment data"})
 		return
 	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid campaign ID"})
+		return
+	}
+
+	campaign, err := h.svc.CloseCampaign(uint(id), accessContext.UserID, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": campaign, "success": true})
+}
 
-	entity, ok := payment["entity"].(map[string]interface{})
+func (h *Handler) ListCampaigns(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing payment entity"})
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
 		return
 	}
 
-	orderID, _ := entity["order_id"].(string)
-	paymentID, _ := entity["id"].(string)
+	campaigns, err := h.svc.ListCampaigns(entityID, accessContext)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": campaigns, "count": len(campaigns), "success": true})
+}
 
-	if orderID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing order_id"})
+// GetCampaignProgress is public so temples can embed it on their website
+func (h *Handler) GetCampaignProgress(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid campaign ID"})
 		return
 	}
 
-	err := h.svc.HandleFailedPaymentWebhook(orderID, paymentID)
+	progress, err := h.svc.GetCampaignProgress(uint(id))
 	if err != nil {
-		log.Printf("❌ Webhook: Failed to process failed payment for order %s: %v", orderID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update donation"})
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": progress, "success": true})
+}
+
+// ==============================
+// 📦 16. In-Kind (Material) Donations
+// ==============================
+func (h *Handler) RecordInKindDonation(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
 		return
 	}
 
-	log.Printf("✅ Failed payment webhook processed for order %s", orderID)
-	c.JSON(http.StatusOK, gin.H{"message": "webhook processed"})
-}
\ No newline at end of file
+	var req RecordInKindDonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.EntityID = entityID
+	req.RecordedBy = accessContext.UserID
+	req.IPAddress = middleware.GetIPFromContext(c)
+
+	donation, err := h.svc.RecordInKindDonation(req, accessContext)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": donation, "success": true})
+}
+
+// ListInKindDonations searches a temple's in-kind donations, e.g. ?user_id= for one devotee
+func (h *Handler) ListInKindDonations(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	filters := InKindFilters{
+		EntityID: entityID,
+		UserID:   uint(parseIntQuery(c, "user_id", 0)),
+		Category: c.Query("category"),
+		Search:   c.Query("search"),
+		Page:     parseIntQuery(c, "page", 1),
+		Limit:    parseIntQuery(c, "limit", 20),
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		if from, err := time.ParseInLocation("2006-01-02", fromStr, utils.IST); err == nil {
+			filters.From = &from
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err := time.ParseInLocation("2006-01-02", toStr, utils.IST); err == nil {
+			to = to.Add(24*time.Hour - time.Second)
+			filters.To = &to
+		}
+	}
+
+	donations, total, err := h.svc.ListInKindDonations(filters, accessContext)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        donations,
+		"total":       total,
+		"page":        filters.Page,
+		"limit":       filters.Limit,
+		"total_pages": (total + filters.Limit - 1) / filters.Limit,
+		"success":     true,
+	})
+}
+
+// GetInKindAcknowledgement returns the acknowledgement as JSON, or as a PDF with ?format=pdf
+func (h *Handler) GetInKindAcknowledgement(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	if c.Query("format") == "pdf" {
+		pdfBytes, filename, err := h.svc.GenerateInKindAcknowledgementPDF(uint(id), accessContext.UserID, &accessContext, entityID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "application/pdf", pdfBytes)
+		return
+	}
+
+	ack, err := h.svc.GetInKindAcknowledgement(uint(id), accessContext.UserID, &accessContext, entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": ack, "success": true})
+}
+
+// ==============================
+// 🔄 17. Payment Reconciliation
+// ==============================
+
+// ReconcilePayments runs the stale payment reconciler now and returns its report
+func (h *Handler) ReconcilePayments(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.svc.ReconcileStalePayments(c.Request.Context(), &accessContext.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile payments: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report, "success": true})
+}
+
+// ==============================
+// 📄 18. Annual Donor Statements
+// ==============================
+
+// statementFinancialYear reads ?financial_year=, defaulting to the last financial year that has ended
+func statementFinancialYear(c *gin.Context) string {
+	if fy := c.Query("financial_year"); fy != "" {
+		return fy
+	}
+	return previousFinancialYear(time.Now())
+}
+
+// statementTarget resolves the temple and the :user_id of a single-statement request
+func statementTarget(c *gin.Context) (middleware.AccessContext, uint, uint, bool) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return accessContext, 0, 0, false
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return accessContext, 0, 0, false
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return accessContext, 0, 0, false
+	}
+	return accessContext, entityID, uint(userID), true
+}
+
+// ListDonorStatements shows which donors have been sent their statement for a financial year
+func (h *Handler) ListDonorStatements(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	filters := DonorStatementFilters{
+		EntityID:      entityID,
+		FinancialYear: statementFinancialYear(c),
+		Status:        c.Query("status"),
+		Search:        c.Query("search"),
+		Page:          parseIntQuery(c, "page", 1),
+		Limit:         parseIntQuery(c, "limit", 20),
+	}
+	statements, total, err := h.svc.ListDonorStatements(filters, accessContext)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":           statements,
+		"financial_year": filters.FinancialYear,
+		"total":          total,
+		"page":           filters.Page,
+		"limit":          filters.Limit,
+		"total_pages":    (total + filters.Limit - 1) / filters.Limit,
+		"success":        true,
+	})
+}
+
+// GenerateDonorStatements builds and emails every donor's statement for a financial year
+func (h *Handler) GenerateDonorStatements(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+	if !accessContext.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "write access denied"})
+		return
+	}
+	var req StatementRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.svc.GenerateAnnualStatements(c.Request.Context(), entityID, req.FinancialYear, &accessContext.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": report, "success": true})
+}
+
+// PreviewDonorStatement returns a donor's statement PDF without sending it
+func (h *Handler) PreviewDonorStatement(c *gin.Context) {
+	accessContext, entityID, userID, ok := statementTarget(c)
+	if !ok {
+		return
+	}
+	pdfBytes, filename, err := h.svc.PreviewDonorStatement(entityID, userID, statementFinancialYear(c), accessContext)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", "inline; filename="+filename)
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// RegenerateDonorStatement recomputes a donor's statement without emailing it
+func (h *Handler) RegenerateDonorStatement(c *gin.Context) {
+	accessContext, entityID, userID, ok := statementTarget(c)
+	if !ok {
+		return
+	}
+	statement, err := h.svc.RegenerateDonorStatement(entityID, userID, statementFinancialYear(c), accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": statement, "success": true})
+}
+
+// ResendDonorStatement emails a donor's statement again
+func (h *Handler) ResendDonorStatement(c *gin.Context) {
+	accessContext, entityID, userID, ok := statementTarget(c)
+	if !ok {
+		return
+	}
+	statement, err := h.svc.ResendDonorStatement(entityID, userID, statementFinancialYear(c), accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": statement, "success": true})
+}
+
+// ==============================
+// 🧾 19. Form 10BE Certificate
+// ==============================
+
+// GetForm10BE downloads the Form 10BE certificate for the donor of a donation.
+// ?urn= and ?urn_date= print the URN allotted when Form 10BD was filed.
+func (h *Handler) GetForm10BE(c *gin.Context) {
+	donationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid donation ID"})
+		return
+	}
+
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	pdfBytes, filename, err := h.svc.GenerateForm10BE(uint(donationID), accessContext.UserID, &accessContext, entityID, c.Query("urn"), c.Query("urn_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// ==============================
+// 🤝 20. Pledges
+// ==============================
+func (h *Handler) CreatePledge(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	var req CreatePledgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.EntityID = entityID
+	req.CreatedBy = accessContext.UserID
+	req.IPAddress = middleware.GetIPFromContext(c)
+
+	pledge, err := h.svc.CreatePledge(req, accessContext)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": pledge, "success": true})
+}
+
+// ListPledges lists a temple's pledges with their outstanding and overdue
+// amounts, e.g. ?overdue=true for the ones behind schedule
+func (h *Handler) ListPledges(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	filters := PledgeFilters{
+		EntityID:   entityID,
+		UserID:     uint(parseIntQuery(c, "user_id", 0)),
+		CampaignID: uint(parseIntQuery(c, "campaign_id", 0)),
+		Status:     c.Query("status"),
+		Overdue:    c.Query("overdue") == "true",
+		Search:     c.Query("search"),
+		Page:       parseIntQuery(c, "page", 1),
+		Limit:      parseIntQuery(c, "limit", 20),
+	}
+
+	pledges, total, err := h.svc.ListPledges(filters, accessContext)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":        pledges,
+		"total":       total,
+		"page":        filters.Page,
+		"limit":       filters.Limit,
+		"total_pages": (total + filters.Limit - 1) / filters.Limit,
+		"success":     true,
+	})
+}
+
+func (h *Handler) GetMyPledges(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, _ := getEntityIDFromRequest(c, accessContext)
+
+	pledges, err := h.svc.GetMyPledges(accessContext.UserID, entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": pledges, "count": len(pledges), "success": true})
+}
+
+// GetPledge returns a pledge with its instalments and the donations paid towards it
+func (h *Handler) GetPledge(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pledge ID"})
+		return
+	}
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	pledge, err := h.svc.GetPledge(uint(id), accessContext.UserID, &accessContext, entityID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": pledge, "success": true})
+}
+
+func (h *Handler) LinkPledgeDonation(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pledge ID"})
+		return
+	}
+	var req LinkPledgeDonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pledge, err := h.svc.LinkPledgeDonation(uint(id), req, accessContext.UserID, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": pledge, "success": true})
+}
+
+func (h *Handler) CancelPledge(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid pledge ID"})
+		return
+	}
+	var req CancelPledgeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pledge, err := h.svc.CancelPledge(uint(id), req, accessContext.UserID, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": pledge, "success": true})
+}
+
+// ==============================
+// 📲 21. UPI Payment Links & QR Codes
+// ==============================
+func (h *Handler) CreatePaymentLink(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	var req CreatePaymentLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.EntityID = entityID
+	req.CreatedBy = accessContext.UserID
+	req.IPAddress = middleware.GetIPFromContext(c)
+
+	link, err := h.svc.CreatePaymentLink(req, accessContext)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": link, "success": true})
+}
+
+// ListPaymentLinks lists a temple's payment links with what each has collected,
+// e.g. ?target_type=seva&active=true
+func (h *Handler) ListPaymentLinks(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	links, err := h.svc.ListPaymentLinks(PaymentLinkFilters{
+		EntityID:   entityID,
+		TargetType: c.Query("target_type"),
+		ActiveOnly: c.Query("active") == "true",
+	}, accessContext)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": links, "count": len(links), "success": true})
+}
+
+func (h *Handler) DeactivatePaymentLink(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment link ID"})
+		return
+	}
+
+	link, err := h.svc.DeactivatePaymentLink(uint(id), accessContext.UserID, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": link, "success": true})
+}
+
+// MatchUPIPayment records a UPI payment from the bank statement against the
+// guest donation or payment link its reference points to
+func (h *Handler) MatchUPIPayment(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID, err := getEntityIDFromRequest(c, accessContext)
+	if err != nil || entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	var req MatchUPIPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.EntityID = entityID
+	req.RecordedBy = accessContext.UserID
+	req.IPAddress = middleware.GetIPFromContext(c)
+
+	donation, err := h.svc.MatchUPIPayment(req, accessContext)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": donation, "success": true})
+}
+
+// GetGuestPaymentLink is public: the guest donation page a short link opens
+func (h *Handler) GetGuestPaymentLink(c *gin.Context) {
+	link, err := h.svc.GetGuestPaymentLink(c.Param("code"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": link, "success": true})
+}
+
+// GetPaymentLinkQR is public so temples can print the QR or embed it on their
+// website. ?format=png|svg, ?content=upi|link, ?size= in pixels.
+func (h *Handler) GetPaymentLinkQR(c *gin.Context) {
+	code := c.Param("code")
+	format := c.DefaultQuery("format", "png")
+
+	image, contentType, err := h.svc.GetPaymentLinkQR(code, c.Query("content"), format, parseIntQuery(c, "size", 0))
+	if err != nil {
+		if errors.Is(err, errPaymentLinkNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "inline; filename=upi_qr_"+code+"."+format)
+	c.Data(http.StatusOK, contentType, image)
+}
+
+// StartGuestDonation is public: a payer who opened a short link gives their
+// name and amount and gets the UPI intent to pay with
+func (h *Handler) StartGuestDonation(c *gin.Context) {
+	var req GuestDonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.IPAddress = middleware.GetIPFromContext(c)
+
+	resp, err := h.svc.StartGuestDonation(c.Param("code"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": resp, "success": true})
+}
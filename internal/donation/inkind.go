@@ -0,0 +1,277 @@
+package donation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// ==============================
+// In-Kind (Material) Donations
+// ==============================
+
+// RecordInKindDonation records a material offering received at the temple and
+// stamps it with an acknowledgement number
+func (s *service) RecordInKindDonation(req RecordInKindDonationRequest, accessContext middleware.AccessContext) (*InKindDonationWithUser, error) {
+	ctx := context.Background()
+
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != req.EntityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+
+	donorName := strings.TrimSpace(req.DonorName)
+	donorPhone := strings.TrimSpace(req.DonorPhone)
+	userID, err := s.counterDonor(ctx, req.DevoteeID, donorName)
+	if err != nil {
+		return nil, err
+	}
+
+	itemName := strings.TrimSpace(req.ItemName)
+	unit := strings.TrimSpace(req.Unit)
+	if itemName == "" || unit == "" {
+		return nil, errors.New("itemName and unit are required")
+	}
+
+	receivedAt := time.Now()
+	if req.ReceivedAt != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.ReceivedAt, utils.IST)
+		if err != nil {
+			return nil, errors.New("receivedAt must be in YYYY-MM-DD format")
+		}
+		if parsed.After(receivedAt) {
+			return nil, errors.New("receivedAt cannot be in the future")
+		}
+		receivedAt = parsed
+	}
+
+	donation := &InKindDonation{
+		UserID:         userID,
+		EntityID:       req.EntityID,
+		ItemName:       itemName,
+		Category:       strings.ToLower(strings.TrimSpace(req.Category)),
+		Unit:           unit,
+		Quantity:       req.Quantity,
+		EstimatedValue: req.EstimatedValue,
+		Condition:      req.Condition,
+		Note:           req.Note,
+		RecordedBy:     req.RecordedBy,
+		ReceivedAt:     receivedAt,
+	}
+	if donorName != "" {
+		donation.DonorName = &donorName
+	}
+	if donorPhone != "" {
+		donation.DonorPhone = &donorPhone
+	}
+
+	if err := s.repo.CreateInKind(ctx, donation); err != nil {
+		s.auditSvc.LogAction(ctx, &req.RecordedBy, &req.EntityID, "IN_KIND_DONATION_RECORDED",
+			map[string]interface{}{"item_name": itemName, "quantity": req.Quantity, "error": err.Error()},
+			req.IPAddress, "failure")
+		return nil, fmt.Errorf("failed to record in-kind donation: %w", err)
+	}
+
+	s.auditSvc.LogAction(ctx, &req.RecordedBy, &req.EntityID, "IN_KIND_DONATION_RECORDED",
+		map[string]interface{}{
+			"in_kind_donation_id":    donation.ID,
+			"devotee_id":             userID,
+			"donor_name":             donorName,
+			"item_name":              donation.ItemName,
+			"quantity":               donation.Quantity,
+			"unit":                   donation.Unit,
+			"estimated_value":        donation.EstimatedValue,
+			"acknowledgement_number": donation.AcknowledgementNumber,
+		}, req.IPAddress, "success")
+
+	log.Printf("✅ In-kind donation=%d recorded: entity=%d item=%q qty=%v %s value=%.2f",
+		donation.ID, donation.EntityID, donation.ItemName, donation.Quantity, donation.Unit, donation.EstimatedValue)
+	return s.repo.GetInKindByIDWithUser(ctx, donation.ID)
+}
+
+func (s *service) ListInKindDonations(filters InKindFilters, accessContext middleware.AccessContext) ([]InKindDonationWithUser, int, error) {
+	if !accessContext.CanRead() {
+		return nil, 0, errors.New("read access denied")
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != filters.EntityID {
+		return nil, 0, errors.New("access denied to requested entity")
+	}
+	return s.repo.ListInKind(context.Background(), filters)
+}
+
+// GetInKindDonationsByUserAndEntity returns a devotee's in-kind offerings for their donor history
+func (s *service) GetInKindDonationsByUserAndEntity(userID uint, entityID uint) ([]InKindDonationWithUser, error) {
+	donations, _, err := s.repo.ListInKind(context.Background(), InKindFilters{EntityID: entityID, UserID: userID})
+	return donations, err
+}
+
+func (s *service) GetInKindAcknowledgement(id uint, userID uint, accessContext *middleware.AccessContext, entityID uint) (*InKindAcknowledgement, error) {
+	ack, _, err := s.buildInKindAcknowledgement(id, userID, accessContext, entityID)
+	return ack, err
+}
+
+func (s *service) GenerateInKindAcknowledgementPDF(id uint, userID uint, accessContext *middleware.AccessContext, entityID uint) ([]byte, string, error) {
+	ack, logoPath, err := s.buildInKindAcknowledgement(id, userID, accessContext, entityID)
+	if err != nil {
+		return nil, "", err
+	}
+	pdfBytes, err := renderInKindAcknowledgementPDF(ack, logoPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render acknowledgement: %w", err)
+	}
+	return pdfBytes, acknowledgementFileName(ack.AcknowledgementNumber), nil
+}
+
+// buildInKindAcknowledgement loads an in-kind donation the caller may see —
+// the donor's own, or any of the temple's for staff — with the temple logo path
+func (s *service) buildInKindAcknowledgement(id uint, userID uint, accessContext *middleware.AccessContext, entityID uint) (*InKindAcknowledgement, string, error) {
+	ctx := context.Background()
+	donation, err := s.repo.GetInKindByIDWithUser(ctx, id)
+	if err != nil {
+		return nil, "", errors.New("in-kind donation not found")
+	}
+
+	hasAccess := donation.UserID != 0 && donation.UserID == userID && donation.EntityID == entityID
+	if accessContext != nil {
+		if accessibleEntityID := accessContext.GetAccessibleEntityID(); accessibleEntityID != nil &&
+			*accessibleEntityID == donation.EntityID && accessContext.CanRead() {
+			hasAccess = true
+		}
+	}
+	if !hasAccess {
+		return nil, "", errors.New("unauthorized to access this donation")
+	}
+
+	ack := &InKindAcknowledgement{
+		ID:             donation.ID,
+		FinancialYear:  donation.FinancialYear,
+		DonorName:      donation.UserName,
+		DonorEmail:     donation.UserEmail,
+		ItemName:       donation.ItemName,
+		Category:       donation.Category,
+		Quantity:       donation.Quantity,
+		Unit:           donation.Unit,
+		Condition:      donation.Condition,
+		EstimatedValue: donation.EstimatedValue,
+		ReceivedAt:     donation.ReceivedAt,
+		EntityName:     donation.EntityName,
+		GeneratedAt:    donation.CreatedAt,
+	}
+	if donation.AcknowledgementNumber != nil {
+		ack.AcknowledgementNumber = *donation.AcknowledgementNumber
+	}
+	if donation.DonorPhone != nil {
+		ack.DonorPhone = *donation.DonorPhone
+	}
+
+	var logoPath string
+	if details, err := s.repo.GetReceiptEntityDetails(ctx, donation.EntityID); err != nil {
+		log.Printf("⚠️ Could not load receipt details for entity=%d: %v", donation.EntityID, err)
+	} else {
+		ack.EntityName = details.Name
+		ack.EntityAddress = formatEntityAddress(details)
+		ack.TrustPAN = details.TrustPAN
+		ack.LogoURL, logoPath = entityLogo(donation.EntityID, details.Media)
+	}
+
+	return ack, logoPath, nil
+}
+
+// counterDonor resolves the donor of a gift taken at the temple: a registered
+// devotee when devoteeID is set, otherwise a walk-in donor identified by name
+func (s *service) counterDonor(ctx context.Context, devoteeID *uint, donorName string) (uint, error) {
+	if devoteeID != nil && *devoteeID > 0 {
+		exists, err := s.repo.UserExists(ctx, *devoteeID)
+		if err != nil {
+			return 0, err
+		}
+		if !exists {
+			return 0, errors.New("devotee not found")
+		}
+		return *devoteeID, nil
+	}
+	if donorName == "" {
+		return 0, errors.New("either devoteeId or donorName is required")
+	}
+	return 0, nil
+}
+
+// formatAcknowledgementNumber builds the in-kind acknowledgement number, e.g. "ACK/12/2025-26/000042"
+func formatAcknowledgementNumber(entityID uint, financialYear string, id uint) string {
+	return fmt.Sprintf("ACK/%d/%s/%06d", entityID, financialYear, id)
+}
+
+// renderInKindAcknowledgementPDF draws the acknowledgement for a material
+// offering. Like 80G receipts, the output depends only on the stored record.
+func renderInKindAcknowledgementPDF(a *InKindAcknowledgement, logoPath string) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(a.GeneratedAt)
+	pdf.SetModificationDate(a.GeneratedAt)
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle("In-Kind Donation Acknowledgement "+a.AcknowledgementNumber, false)
+	pdf.SetAutoPageBreak(false, 10)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	drawEntityHeader(pdf, tr, a.EntityName, a.EntityAddress, a.TrustPAN, "", logoPath)
+
+	pdf.SetXY(10, 46)
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(190, 8, "ACKNOWLEDGEMENT OF IN-KIND DONATION", "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(95, 7, "Acknowledgement No: "+a.AcknowledgementNumber, "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 7, "Date: "+a.ReceivedAt.In(utils.IST).Format("02-01-2006"), "", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(95, 6, "Financial Year: "+a.FinancialYear, "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	condition := a.Condition
+	if condition != "" {
+		condition = strings.ToUpper(condition[:1]) + condition[1:]
+	}
+	rows := [][2]string{
+		{"Received with thanks from", a.DonorName},
+		{"Item", a.ItemName},
+		{"Category", a.Category},
+		{"Quantity", strconv.FormatFloat(a.Quantity, 'f', -1, 64) + " " + a.Unit},
+		{"Condition", condition},
+		{"Estimated Value", fmt.Sprintf("Rs. %.2f", a.EstimatedValue)},
+	}
+	drawDetailRows(pdf, tr, rows)
+	pdf.Ln(6)
+
+	pdf.SetFont("Arial", "", 9)
+	pdf.MultiCell(190, 5, "The estimated value is recorded by the temple for its accounts only. "+
+		"This acknowledgement is not a receipt under Section 80G of the Income Tax Act, 1961.", "", "L", false)
+	pdf.Ln(14)
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(190, 6, "Authorised Signatory", "", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "I", 8)
+	pdf.CellFormat(190, 5, "This is a computer-generated acknowledgement and does not require a physical signature.", "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// acknowledgementFileName turns an acknowledgement number into a safe download file name
+func acknowledgementFileName(number string) string {
+	return "acknowledgement_" + strings.ReplaceAll(number, "/", "-") + ".pdf"
+}
@@ -11,6 +11,10 @@ const (
 	StatusPending = "PENDING"
 	StatusSuccess = "SUCCESS"
 	StatusFailed  = "FAILED"
+
+	// StatusRefunded marks a donation whose full amount has been refunded.
+	// Partially refunded donations stay SUCCESS with RefundedAmount > 0.
+	StatusRefunded = "REFUNDED"
 )
 
 const (
@@ -18,6 +22,19 @@ const (
 	MethodCard       = "CARD"
 	MethodNetbanking = "NETBANKING"
 	MethodWallet     = "WALLET"
+
+	// Offline methods recorded at the temple counter
+	MethodCash         = "CASH"
+	MethodCheque       = "CHEQUE"
+	MethodDD           = "DD"
+	MethodBankTransfer = "BANK_TRANSFER"
+)
+
+// Cheque / DD clearance states for offline donations
+const (
+	ClearancePending = "pending"
+	ClearanceCleared = "cleared"
+	ClearanceBounced = "bounced"
 )
 
 const (
@@ -31,25 +48,173 @@ const (
 	TypeMaintenance  = "maintenance"
 )
 
+// Refund states
+const (
+	RefundPending   = "PENDING"
+	RefundProcessed = "PROCESSED"
+	RefundFailed    = "FAILED"
+)
+
+// Refund sources — a refund is raised against a donation or a paid seva booking
+const (
+	RefundSourceDonation    = "donation"
+	RefundSourceSevaBooking = "seva_booking"
+)
+
+// Campaign states, derived from the dates unless closed early by the temple
+const (
+	CampaignUpcoming = "upcoming"
+	CampaignActive   = "active"
+	CampaignEnded    = "ended"
+	CampaignClosed   = "closed"
+)
+
+// Condition of an in-kind (material) offering when received
+const (
+	ConditionNew  = "new"
+	ConditionGood = "good"
+	ConditionUsed = "used"
+)
+
+// Dedications — a gift made on behalf of, in memory of or in honour of
+// someone other than the payer
+const (
+	DedicationOnBehalfOf = "on_behalf_of"
+	DedicationInMemoryOf = "in_memory_of"
+	DedicationInHonourOf = "in_honour_of"
+)
+
+// Contribution filters — domestic gifts versus foreign contributions under FCRA
+const (
+	ContributionDomestic = "domestic"
+	ContributionForeign  = "foreign"
+)
+
+// Processing states of a stored payment webhook
+const (
+	WebhookReceived   = "received"
+	WebhookProcessing = "processing"
+	WebhookProcessed  = "processed"
+	WebhookFailed     = "failed"   // retried with backoff until maxWebhookAttempts
+	WebhookIgnored    = "ignored"  // an event type we do not act on
+	WebhookRejected   = "rejected" // bad signature or malformed body, never retried
+)
+
+// Delivery states of an annual donor statement
+const (
+	StatementPending = "pending" // generated, not emailed yet
+	StatementSent    = "sent"
+	StatementFailed  = "failed"   // retried by the statement worker until maxStatementSendAttempts
+	StatementNoEmail = "no_email" // the donor has no email address on file
+)
+
+// Recurring donation frequencies
+const (
+	FrequencyMonthly   = "monthly"
+	FrequencyQuarterly = "quarterly"
+	FrequencyAnnual    = "annual"
+)
+
+// Recurring donation states
+const (
+	RecurringActive    = "active"
+	RecurringPaused    = "paused"
+	RecurringCancelled = "cancelled"
+)
+
+// Pledge states. A pledge is fulfilled once its linked donations cover the
+// total; a refund that takes it below the total makes it active again.
+const (
+	PledgeActive    = "active"
+	PledgeFulfilled = "fulfilled"
+	PledgeCancelled = "cancelled"
+)
+
+// Pledge instalment states
+const (
+	InstalmentPending = "pending"
+	InstalmentPartial = "partial"
+	InstalmentPaid    = "paid"
+)
+
+// What a UPI payment link collects for
+const (
+	PaymentLinkTemple   = "temple"
+	PaymentLinkSeva     = "seva"
+	PaymentLinkCampaign = "campaign"
+)
+
 type Donation struct {
 	ID uint `gorm:"primaryKey" json:"id"`
 
-	UserID   uint `gorm:"not null;index" json:"user_id"`   // Devotee who donated
+	UserID   uint `gorm:"not null;index" json:"user_id"`   // Devotee who donated (0 for walk-in donors)
 	EntityID uint `gorm:"not null;index" json:"entity_id"` // Temple ID
 
 	Amount       float64 `gorm:"type:decimal(10,2);not null" json:"amount"`
 	DonationType string  `gorm:"size:50;index" json:"donation_type"`
 	ReferenceID  *uint   `gorm:"index" json:"reference_id,omitempty"`
+	CampaignID   *uint   `gorm:"index" json:"campaign_id,omitempty"` // Fundraising campaign this gift counts towards
 
 	Method string `gorm:"size:50;not null;index" json:"method"`
 	Status string `gorm:"size:20;default:'PENDING';index" json:"status"`
 
+	// Foreign-currency gifts: Amount is always the rupee value, converted at the
+	// rate captured when the payment went through. Foreign contributions under
+	// FCRA are paid into the temple's FCRA-designated account.
+	Currency              string  `gorm:"size:3;not null;default:'INR'" json:"currency"`
+	OriginalAmount        float64 `gorm:"type:decimal(12,2);default:0" json:"original_amount"` // In Currency
+	ExchangeRate          float64 `gorm:"type:decimal(14,6);default:1" json:"exchange_rate"`   // Rupees per unit of Currency
+	IsForeignContribution bool    `gorm:"default:false;index" json:"is_foreign_contribution"`
+	DonorCountry          *string `gorm:"size:100" json:"donor_country,omitempty"`
+	DonorNationality      *string `gorm:"size:100" json:"donor_nationality,omitempty"`
+
 	// FIX: json tags match what DonationWithUser returns so both are consistent
 	OrderID   string  `gorm:"size:100;uniqueIndex" json:"transactionId"`
 	PaymentID *string `gorm:"size:100;index" json:"paymentId,omitempty"`
 
 	Note *string `gorm:"type:text" json:"note,omitempty"`
 
+	// Offline (counter) donations — recorded by staff instead of paid online.
+	// UserID is 0 for walk-in donors, who are identified by name/phone only.
+	IsOffline        bool       `gorm:"default:false;index" json:"is_offline"`
+	RecordedBy       *uint      `gorm:"index" json:"recorded_by,omitempty"`
+	DonorName        *string    `gorm:"size:255" json:"donor_name,omitempty"`
+	DonorPhone       *string    `gorm:"size:20" json:"donor_phone,omitempty"`
+	InstrumentNumber *string    `gorm:"size:50" json:"instrument_number,omitempty"` // Cheque / DD / UTR number
+	InstrumentDate   *time.Time `json:"instrument_date,omitempty"`
+	DrawnOnBank      *string    `gorm:"size:255" json:"drawn_on_bank,omitempty"`
+	ClearanceStatus  string     `gorm:"size:20;index" json:"clearance_status,omitempty"` // pending / cleared / bounced
+	ClearedAt        *time.Time `json:"cleared_at,omitempty"`
+
+	// Sum of processed refunds against this donation
+	RefundedAmount float64 `gorm:"type:decimal(10,2);default:0" json:"refunded_amount"`
+
+	// Set when this donation is an occurrence of a recurring donation
+	RecurringDonationID *uint `gorm:"index" json:"recurring_donation_id,omitempty"`
+
+	// Set when this donation pays towards a pledge
+	PledgeID *uint `gorm:"index" json:"pledge_id,omitempty"`
+
+	// Set when this donation was paid by UPI through a temple payment link or QR
+	PaymentLinkID *uint `gorm:"index" json:"payment_link_id,omitempty"`
+
+	// Donor identity for Form 10BD / 10BE. The PAN is printed on the 80G
+	// receipt; the Aadhaar number is only ever shown masked.
+	DonorPAN     *string `gorm:"size:10" json:"donor_pan,omitempty"`
+	DonorAadhaar *string `gorm:"size:12" json:"-"`
+	DonorAddress *string `gorm:"type:text" json:"donor_address,omitempty"`
+
+	// Anonymous gifts hide the donor everywhere except the receipt and audit
+	// trail. A dedicated gift shows OnBehalfOf in donor listings instead of the payer.
+	IsAnonymous    bool    `gorm:"default:false;index" json:"is_anonymous"`
+	DedicationType string  `gorm:"size:20" json:"dedication_type,omitempty"` // on_behalf_of / in_memory_of / in_honour_of
+	OnBehalfOf     *string `gorm:"size:255" json:"on_behalf_of,omitempty"`
+
+	// 80G receipt numbering — assigned once when the donation succeeds and never reused
+	ReceiptNumber   *string    `gorm:"size:50;uniqueIndex" json:"receipt_number,omitempty"`
+	FinancialYear   string     `gorm:"size:7;index" json:"financial_year,omitempty"` // e.g. "2025-26"
+	ReceiptIssuedAt *time.Time `json:"receipt_issued_at,omitempty"`
+
 	AccountHolderName string `gorm:"size:255" json:"account_holder_name"`
 	AccountNumber     string `gorm:"size:30" json:"account_number"`
 	AccountType       string `gorm:"size:20" json:"account_type"`
@@ -65,4 +230,337 @@ type Donation struct {
 // TableName returns the table name for the Donation model
 func (Donation) TableName() string {
 	return "donations"
-}
\ No newline at end of file
+}
+
+// ReceiptSequence holds the last receipt number issued per entity per
+// Indian financial year (April–March). Rows are only ever incremented inside
+// the same transaction that stamps the donation, so numbers stay gap-free.
+type ReceiptSequence struct {
+	EntityID      uint      `gorm:"primaryKey;autoIncrement:false" json:"entity_id"`
+	FinancialYear string    `gorm:"primaryKey;size:7" json:"financial_year"`
+	LastNumber    int64     `gorm:"not null;default:0" json:"last_number"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the ReceiptSequence model
+func (ReceiptSequence) TableName() string {
+	return "donation_receipt_sequences"
+}
+
+// Campaign is a time-bound fundraising appeal (temple construction, a
+// festival, ...). Donations attached to it count towards its goal.
+type Campaign struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID uint `gorm:"not null;index" json:"entity_id"`
+
+	Title         string    `gorm:"size:255;not null" json:"title"`
+	Description   string    `gorm:"type:text" json:"description,omitempty"`
+	GoalAmount    float64   `gorm:"type:decimal(12,2);not null" json:"goal_amount"`
+	StartDate     time.Time `gorm:"not null;index" json:"start_date"`
+	EndDate       time.Time `gorm:"not null;index" json:"end_date"`
+	CoverImageURL string    `gorm:"size:500" json:"cover_image_url,omitempty"`
+	DonationType  string    `gorm:"size:50;not null" json:"donation_type"` // Applied to every donation made to the campaign
+
+	// ClosedAt ends the campaign before its end date
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+	CreatedBy uint       `gorm:"index" json:"created_by"`
+
+	// Computed from ClosedAt and the dates, never stored
+	Status string `gorm:"-" json:"status"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for the Campaign model
+func (Campaign) TableName() string {
+	return "donation_campaigns"
+}
+
+// StatusAt reports whether the campaign is upcoming, active, ended or closed at t
+func (c *Campaign) StatusAt(t time.Time) string {
+	switch {
+	case c.ClosedAt != nil:
+		return CampaignClosed
+	case t.Before(c.StartDate):
+		return CampaignUpcoming
+	case t.After(c.EndDate):
+		return CampaignEnded
+	}
+	return CampaignActive
+}
+
+// RecurringDonation is a devotee's standing instruction to give a fixed
+// amount every period. The scheduler turns each due period into a regular
+// PENDING Donation, which then completes through the normal verify/webhook path.
+type RecurringDonation struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	UserID   uint `gorm:"not null;index" json:"user_id"`
+	EntityID uint `gorm:"not null;index" json:"entity_id"`
+
+	Amount       float64 `gorm:"type:decimal(10,2);not null" json:"amount"`
+	DonationType string  `gorm:"size:50" json:"donation_type"`
+	ReferenceID  *uint   `json:"reference_id,omitempty"`
+	Note         *string `gorm:"type:text" json:"note,omitempty"`
+	DonorPAN     *string `gorm:"size:10" json:"donor_pan,omitempty"`
+	DonorAadhaar *string `gorm:"size:12" json:"-"`
+	DonorAddress *string `gorm:"type:text" json:"donor_address,omitempty"`
+
+	// Copied onto every occurrence
+	IsAnonymous    bool    `gorm:"default:false" json:"is_anonymous"`
+	DedicationType string  `gorm:"size:20" json:"dedication_type,omitempty"`
+	OnBehalfOf     *string `gorm:"size:255" json:"on_behalf_of,omitempty"`
+
+	Frequency string `gorm:"size:20;not null" json:"frequency"`
+	Status    string `gorm:"size:20;default:'active';index" json:"status"`
+
+	// StartDate anchors the schedule (day of month); NextChargeAt is the next due occurrence
+	StartDate     time.Time  `gorm:"not null" json:"start_date"`
+	NextChargeAt  time.Time  `gorm:"not null;index" json:"next_charge_at"`
+	LastChargedAt *time.Time `json:"last_charged_at,omitempty"`
+	ChargeCount   int        `gorm:"default:0" json:"charge_count"`
+	FailureCount  int        `gorm:"default:0" json:"failure_count"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+
+	// Gateway-side subscription reference, when the gateway manages the mandate
+	GatewaySubscriptionID *string `gorm:"size:100;index" json:"gateway_subscription_id,omitempty"`
+
+	PausedAt    *time.Time     `json:"paused_at,omitempty"`
+	CancelledAt *time.Time     `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for the RecurringDonation model
+func (RecurringDonation) TableName() string {
+	return "recurring_donations"
+}
+
+// Pledge is a donor's promise to give a total amount over time, due in
+// instalments. Instalments are never paid directly: regular donations are
+// linked to the pledge and their net amount is allocated to the instalments
+// in order of sequence.
+type Pledge struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID   uint    `gorm:"not null;index" json:"entity_id"`
+	UserID     uint    `gorm:"not null;default:0;index" json:"user_id"` // Devotee who pledged (0 for walk-in donors)
+	DonorName  *string `gorm:"size:255" json:"donor_name,omitempty"`
+	DonorPhone *string `gorm:"size:20" json:"donor_phone,omitempty"`
+
+	TotalAmount  float64 `gorm:"type:decimal(12,2);not null" json:"total_amount"`
+	PaidAmount   float64 `gorm:"type:decimal(12,2);default:0" json:"paid_amount"` // net of refunds
+	DonationType string  `gorm:"size:50;not null" json:"donation_type"`           // Applied to every linked donation
+	CampaignID   *uint   `gorm:"index" json:"campaign_id,omitempty"`
+	Note         *string `gorm:"type:text" json:"note,omitempty"`
+
+	// Frequency and StartDate describe a generated schedule; custom schedules keep only the dates
+	Frequency       string    `gorm:"size:20" json:"frequency,omitempty"`
+	InstalmentCount int       `gorm:"not null" json:"instalment_count"`
+	StartDate       time.Time `gorm:"not null" json:"start_date"`
+
+	Status      string     `gorm:"size:20;not null;default:'active';index" json:"status"`
+	CreatedBy   uint       `gorm:"index" json:"created_by"`
+	FulfilledAt *time.Time `json:"fulfilled_at,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+
+	Instalments []PledgeInstalment `gorm:"foreignKey:PledgeID" json:"instalments,omitempty"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for the Pledge model
+func (Pledge) TableName() string {
+	return "donation_pledges"
+}
+
+// PledgeInstalment is one due amount of a pledge
+type PledgeInstalment struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	PledgeID uint `gorm:"not null;uniqueIndex:idx_pledge_instalment" json:"pledge_id"`
+	EntityID uint `gorm:"not null;index" json:"entity_id"`
+	Sequence int  `gorm:"not null;uniqueIndex:idx_pledge_instalment" json:"sequence"` // 1-based
+
+	DueDate    time.Time  `gorm:"not null;index" json:"due_date"`
+	Amount     float64    `gorm:"type:decimal(12,2);not null" json:"amount"`
+	PaidAmount float64    `gorm:"type:decimal(12,2);default:0" json:"paid_amount"`
+	Status     string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	PaidAt     *time.Time `json:"paid_at,omitempty"`
+
+	// Overdue reminders sent for this instalment
+	ReminderCount  int        `gorm:"default:0" json:"reminder_count"`
+	LastReminderAt *time.Time `json:"last_reminder_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the PledgeInstalment model
+func (PledgeInstalment) TableName() string {
+	return "donation_pledge_instalments"
+}
+
+// PaymentLink is a static UPI QR code and shareable short link that collects
+// donations for the temple, one of its sevas or a campaign, straight into the
+// tenant's UPI ID. Code doubles as the UPI transaction reference of the static
+// QR, so payments made by scanning it can be matched back to the link.
+type PaymentLink struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID uint   `gorm:"not null;index" json:"entity_id"`
+	Code     string `gorm:"size:16;not null;uniqueIndex" json:"code"`
+
+	TargetType   string   `gorm:"size:20;not null;index" json:"target_type"`  // temple / seva / campaign
+	TargetID     *uint    `gorm:"index" json:"target_id,omitempty"`           // Seva or campaign ID
+	Title        string   `gorm:"size:255;not null" json:"title"`             // Shown to the payer and used as the UPI note
+	DonationType string   `gorm:"size:50;not null" json:"donation_type"`      // Applied to every donation made through the link
+	Amount       *float64 `gorm:"type:decimal(10,2)" json:"amount,omitempty"` // Fixed amount; the payer chooses when nil
+
+	IsActive  bool `gorm:"default:true;index" json:"is_active"`
+	CreatedBy uint `gorm:"index" json:"created_by"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for the PaymentLink model
+func (PaymentLink) TableName() string {
+	return "donation_payment_links"
+}
+
+// Refund records a full or partial refund of a donation or seva booking payment.
+// The source's refunded amount is only updated once the gateway confirms the
+// refund, either in the create response or via the refund.processed webhook.
+type Refund struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID   uint   `gorm:"not null;index" json:"entity_id"`
+	SourceType string `gorm:"size:20;not null;index:idx_refund_source" json:"source_type"` // donation / seva_booking
+	SourceID   uint   `gorm:"not null;index:idx_refund_source" json:"source_id"`
+	UserID     uint   `gorm:"index" json:"user_id"` // Devotee who paid
+
+	Amount    float64 `gorm:"type:decimal(10,2);not null" json:"amount"`
+	IsPartial bool    `gorm:"default:false" json:"is_partial"`
+	Reason    string  `gorm:"type:text;not null" json:"reason"`
+	Status    string  `gorm:"size:20;default:'PENDING';index" json:"status"`
+
+	InitiatedBy uint `gorm:"index" json:"initiated_by"` // 0 when raised from the gateway dashboard
+
+	GatewayOrderID   string  `gorm:"size:100" json:"gateway_order_id,omitempty"`
+	GatewayPaymentID string  `gorm:"size:100;index" json:"gateway_payment_id"`
+	GatewayRefundID  *string `gorm:"size:100;uniqueIndex" json:"gateway_refund_id,omitempty"`
+	FailureReason    string  `gorm:"type:text" json:"failure_reason,omitempty"`
+
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the Refund model
+func (Refund) TableName() string {
+	return "refunds"
+}
+
+// InKindDonation records a material offering — rice, ghee, oil, silk sarees,
+// silver articles and so on. It is valued separately from monetary donations
+// and gets an acknowledgement instead of an 80G receipt.
+type InKindDonation struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	UserID   uint `gorm:"not null;default:0;index" json:"user_id"` // Devotee who donated (0 for walk-in donors)
+	EntityID uint `gorm:"not null;index" json:"entity_id"`
+
+	DonorName  *string `gorm:"size:255" json:"donor_name,omitempty"`
+	DonorPhone *string `gorm:"size:20" json:"donor_phone,omitempty"`
+
+	ItemName       string  `gorm:"size:255;not null;index" json:"item_name"`
+	Category       string  `gorm:"size:50;index" json:"category,omitempty"` // e.g. grains, oil, textile, silver
+	Unit           string  `gorm:"size:20;not null" json:"unit"`            // kg, litre, piece, gram ...
+	Quantity       float64 `gorm:"type:decimal(12,3);not null" json:"quantity"`
+	EstimatedValue float64 `gorm:"type:decimal(12,2);default:0" json:"estimated_value"` // Total for the quantity, in INR
+	Condition      string  `gorm:"size:20" json:"condition,omitempty"`                  // new / good / used
+	Note           *string `gorm:"type:text" json:"note,omitempty"`
+
+	// Acknowledgement numbering — assigned when the offering is recorded
+	AcknowledgementNumber *string `gorm:"size:50;uniqueIndex" json:"acknowledgement_number,omitempty"`
+	FinancialYear         string  `gorm:"size:7;index" json:"financial_year,omitempty"`
+
+	RecordedBy uint      `gorm:"index" json:"recorded_by"`
+	ReceivedAt time.Time `gorm:"not null;index" json:"received_at"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for the InKindDonation model
+func (InKindDonation) TableName() string {
+	return "in_kind_donations"
+}
+
+// PaymentWebhookEvent is every payment webhook we receive, kept with its raw
+// payload so duplicates can be ignored and missed or failed events replayed.
+// Event ids are only unique among signature-verified events, so a forged
+// request can never block the genuine one.
+type PaymentWebhookEvent struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Provider  string `gorm:"size:20;not null;uniqueIndex:idx_webhook_provider_event,where:signature_valid = true" json:"provider"`
+	EventID   string `gorm:"size:100;not null;uniqueIndex:idx_webhook_provider_event" json:"event_id"` // gateway event id, or a hash of the payload
+	EventType string `gorm:"size:50;index" json:"event_type,omitempty"`
+	OrderID   string `gorm:"size:100;index" json:"order_id,omitempty"`
+	PaymentID string `gorm:"size:100;index" json:"payment_id,omitempty"`
+	Payload   string `gorm:"type:text;not null" json:"payload"`
+
+	SignatureValid bool   `gorm:"default:false" json:"signature_valid"`
+	Status         string `gorm:"size:20;not null;index" json:"status"`
+	Attempts       int    `gorm:"default:0" json:"attempts"`
+	LastError      string `gorm:"type:text" json:"last_error,omitempty"`
+
+	NextRetryAt *time.Time `gorm:"index" json:"next_retry_at,omitempty"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the PaymentWebhookEvent model
+func (PaymentWebhookEvent) TableName() string {
+	return "payment_webhook_events"
+}
+
+// DonorStatement tracks the consolidated annual statement of one donor at one
+// temple for a financial year. The PDF is rebuilt from the donations whenever
+// it is previewed or sent, so only the totals and delivery state are stored.
+type DonorStatement struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID      uint   `gorm:"not null;uniqueIndex:idx_donor_statement" json:"entity_id"`
+	UserID        uint   `gorm:"not null;uniqueIndex:idx_donor_statement" json:"user_id"`
+	FinancialYear string `gorm:"size:7;not null;uniqueIndex:idx_donor_statement;index" json:"financial_year"` // e.g. 2025-26
+
+	DonationCount int     `gorm:"default:0" json:"donation_count"`
+	TotalAmount   float64 `gorm:"type:decimal(12,2);default:0" json:"total_amount"` // net of refunds
+
+	Status    string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	Email     string     `gorm:"size:255" json:"email"`
+	SendCount int        `gorm:"default:0" json:"send_count"`
+	LastError string     `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+
+	GeneratedAt time.Time `json:"generated_at"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the DonorStatement model
+func (DonorStatement) TableName() string {
+	return "donor_statements"
+}
@@ -0,0 +1,231 @@
+package donation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// ==============================
+// Offline (Counter) Donations
+// ==============================
+
+// RecordOfflineDonation records a cash / cheque / DD / bank-transfer donation
+// taken at the temple counter. Cash and bank transfers are successful at once;
+// cheques and DDs stay PENDING until staff mark them cleared or bounced.
+func (s *service) RecordOfflineDonation(req RecordOfflineDonationRequest, accessContext middleware.AccessContext) (*DonationWithUser, error) {
+	ctx := context.Background()
+
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != req.EntityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+
+	// ── Donor: registered devotee or walk-in ─────────────────────────────
+	donorName := strings.TrimSpace(req.DonorName)
+	donorPhone := strings.TrimSpace(req.DonorPhone)
+	userID, err := s.counterDonor(ctx, req.DevoteeID, donorName)
+	if err != nil {
+		return nil, err
+	}
+
+	dedication, err := req.DonationDedication.normalized()
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := req.DonorIdentity.normalized()
+	if err != nil {
+		return nil, err
+	}
+
+	// ── Instrument details ───────────────────────────────────────────────
+	instrumentNumber := strings.TrimSpace(req.InstrumentNumber)
+	isInstrument := req.Method == MethodCheque || req.Method == MethodDD
+	if isInstrument && instrumentNumber == "" {
+		return nil, fmt.Errorf("instrumentNumber is required for %s donations", strings.ToLower(req.Method))
+	}
+
+	now := time.Now()
+	donatedAt := now
+	if req.DonatedAt != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.DonatedAt, utils.IST)
+		if err != nil {
+			return nil, errors.New("donatedAt must be in YYYY-MM-DD format")
+		}
+		if parsed.After(now) {
+			return nil, errors.New("donatedAt cannot be in the future")
+		}
+		donatedAt = parsed
+	}
+
+	// A pledge decides the donation type and campaign, even if the campaign has closed since
+	pledge, err := s.openPledge(ctx, req.PledgeID, req.EntityID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pledge != nil {
+		req.DonationType = pledge.DonationType
+		req.CampaignID = nil
+	}
+
+	campaign, err := s.openCampaign(ctx, req.CampaignID, req.EntityID, donatedAt)
+	if err != nil {
+		return nil, err
+	}
+	donationType := req.DonationType
+	if campaign != nil {
+		donationType = campaign.DonationType
+	}
+
+	var instrumentDate *time.Time
+	if req.InstrumentDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.InstrumentDate, utils.IST)
+		if err != nil {
+			return nil, errors.New("instrumentDate must be in YYYY-MM-DD format")
+		}
+		instrumentDate = &parsed
+	}
+
+	donation := &Donation{
+		UserID:         userID,
+		EntityID:       req.EntityID,
+		Amount:         req.Amount,
+		DonationType:   donationType,
+		ReferenceID:    req.ReferenceID,
+		Method:         req.Method,
+		OrderID:        "offline_" + uuid.NewString(),
+		Note:           req.Note,
+		DonorPAN:       identity.DonorPAN,
+		DonorAadhaar:   identity.DonorAadhaar,
+		DonorAddress:   identity.DonorAddress,
+		IsOffline:      true,
+		RecordedBy:     &req.RecordedBy,
+		InstrumentDate: instrumentDate,
+		IsAnonymous:    dedication.IsAnonymous,
+		DedicationType: dedication.DedicationType,
+		OnBehalfOf:     dedication.OnBehalfOf,
+	}
+	if campaign != nil {
+		donation.CampaignID = &campaign.ID
+	}
+	if pledge != nil {
+		donation.PledgeID = &pledge.ID
+		donation.CampaignID = pledge.CampaignID
+	}
+	if donorName != "" {
+		donation.DonorName = &donorName
+	}
+	if donorPhone != "" {
+		donation.DonorPhone = &donorPhone
+	}
+	if instrumentNumber != "" {
+		donation.InstrumentNumber = &instrumentNumber
+	}
+	if bank := strings.TrimSpace(req.DrawnOnBank); bank != "" {
+		donation.DrawnOnBank = &bank
+	}
+	if isInstrument {
+		donation.Status = StatusPending
+		donation.ClearanceStatus = ClearancePending
+	} else {
+		donation.Status = StatusSuccess
+		donation.DonatedAt = &donatedAt
+	}
+
+	if err := s.repo.Create(ctx, donation); err != nil {
+		s.auditSvc.LogAction(ctx, &req.RecordedBy, &req.EntityID, "OFFLINE_DONATION_RECORDED",
+			map[string]interface{}{"amount": req.Amount, "method": req.Method, "error": err.Error()},
+			req.IPAddress, "failure")
+		return nil, fmt.Errorf("failed to record donation: %w", err)
+	}
+
+	if donation.Status == StatusSuccess {
+		s.donationSucceeded(ctx, donation.ID)
+	}
+
+	s.auditSvc.LogAction(ctx, &req.RecordedBy, &req.EntityID, "OFFLINE_DONATION_RECORDED",
+		map[string]interface{}{
+			"donation_id":       donation.ID,
+			"devotee_id":        userID,
+			"donor_name":        donorName,
+			"amount":            donation.Amount,
+			"donation_type":     donation.DonationType,
+			"method":            donation.Method,
+			"instrument_number": instrumentNumber,
+			"status":            donation.Status,
+			"is_anonymous":      donation.IsAnonymous,
+			"on_behalf_of":      donation.OnBehalfOf,
+			"pledge_id":         donation.PledgeID,
+		}, req.IPAddress, "success")
+
+	log.Printf("✅ Offline donation=%d recorded: entity=%d method=%s amount=%.2f status=%s",
+		donation.ID, donation.EntityID, donation.Method, donation.Amount, donation.Status)
+	return s.repo.GetByIDWithUser(ctx, donation.ID)
+}
+
+// UpdateOfflineClearance marks a pending cheque / DD donation as cleared
+// (the donation becomes successful and gets its receipt number) or bounced.
+func (s *service) UpdateOfflineClearance(donationID uint, req UpdateClearanceRequest, userID uint, accessContext middleware.AccessContext, ip string) (*DonationWithUser, error) {
+	ctx := context.Background()
+
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	donation, err := s.repo.GetByID(ctx, donationID)
+	if err != nil {
+		return nil, errors.New("donation not found")
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != donation.EntityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+	if !donation.IsOffline || donation.ClearanceStatus == "" {
+		return nil, errors.New("only cheque and DD donations have a clearance status")
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"clearance_status": req.Status,
+		"cleared_at":       now,
+	}
+	if req.Status == ClearanceCleared {
+		updates["status"] = StatusSuccess
+		updates["donated_at"] = now
+	} else {
+		updates["status"] = StatusFailed
+	}
+
+	if err := s.repo.UpdateClearance(ctx, donationID, updates); err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &donation.EntityID, "OFFLINE_DONATION_CLEARANCE",
+			map[string]interface{}{"donation_id": donationID, "clearance_status": req.Status, "error": err.Error()},
+			ip, "failure")
+		return nil, err
+	}
+
+	if req.Status == ClearanceCleared {
+		s.donationSucceeded(ctx, donationID)
+	}
+
+	s.auditSvc.LogAction(ctx, &userID, &donation.EntityID, "OFFLINE_DONATION_CLEARANCE",
+		map[string]interface{}{
+			"donation_id":       donationID,
+			"clearance_status":  req.Status,
+			"instrument_number": donation.InstrumentNumber,
+			"amount":            donation.Amount,
+			"note":              req.Note,
+		}, ip, "success")
+
+	log.Printf("✅ Offline donation=%d clearance=%s", donationID, req.Status)
+	return s.repo.GetByIDWithUser(ctx, donationID)
+}
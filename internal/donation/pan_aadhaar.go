@@ -0,0 +1,117 @@
+package donation
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ==============================
+// Donor PAN / Aadhaar
+// ==============================
+
+// panPattern checks the PAN layout; the fourth letter is the holder type
+// (P person, C company, H HUF, F firm, A AOP, T trust, B BOI, L local authority,
+// J artificial juridical person, G government)
+var panPattern = regexp.MustCompile(`^[A-Z]{3}[ABCFGHJLPT][A-Z][0-9]{4}[A-Z]$`)
+
+var aadhaarPattern = regexp.MustCompile(`^[2-9][0-9]{11}$`)
+
+// Verhoeff checksum tables — the last digit of an Aadhaar number is a Verhoeff check digit
+var (
+	verhoeffMultiply = [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffPermute = [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+)
+
+// verhoeffValid reports whether a string of digits ends in a correct Verhoeff check digit
+func verhoeffValid(digits string) bool {
+	c := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		c = verhoeffMultiply[c][verhoeffPermute[i%8][d]]
+	}
+	return c == 0
+}
+
+// normalizePAN upper-cases and validates a PAN; an empty input returns ""
+func normalizePAN(pan string) (string, error) {
+	pan = strings.ToUpper(strings.TrimSpace(pan))
+	if pan == "" {
+		return "", nil
+	}
+	if !panPattern.MatchString(pan) {
+		return "", errors.New("invalid PAN format")
+	}
+	return pan, nil
+}
+
+// normalizeAadhaar strips spaces and hyphens and validates an Aadhaar number,
+// including its checksum; an empty input returns ""
+func normalizeAadhaar(aadhaar string) (string, error) {
+	aadhaar = strings.NewReplacer(" ", "", "-", "").Replace(aadhaar)
+	if aadhaar == "" {
+		return "", nil
+	}
+	if !aadhaarPattern.MatchString(aadhaar) || !verhoeffValid(aadhaar) {
+		return "", errors.New("invalid Aadhaar number")
+	}
+	return aadhaar, nil
+}
+
+// maskAadhaar hides all but the last four digits, e.g. "XXXX XXXX 1234"
+func maskAadhaar(aadhaar string) string {
+	if len(aadhaar) < 4 {
+		return ""
+	}
+	return "XXXX XXXX " + aadhaar[len(aadhaar)-4:]
+}
+
+// normalized validates the PAN and Aadhaar number and trims the address,
+// dropping whatever is empty
+func (d DonorIdentity) normalized() (DonorIdentity, error) {
+	var out DonorIdentity
+	if d.DonorPAN != nil {
+		pan, err := normalizePAN(*d.DonorPAN)
+		if err != nil {
+			return out, err
+		}
+		if pan != "" {
+			out.DonorPAN = &pan
+		}
+	}
+	if d.DonorAadhaar != nil {
+		aadhaar, err := normalizeAadhaar(*d.DonorAadhaar)
+		if err != nil {
+			return out, err
+		}
+		if aadhaar != "" {
+			out.DonorAadhaar = &aadhaar
+		}
+	}
+	if d.DonorAddress != nil {
+		if address := strings.TrimSpace(*d.DonorAddress); address != "" {
+			out.DonorAddress = &address
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,665 @@
+package donation
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Payment link codes and guest references avoid look-alike characters, as
+// staff read them off bank statements and payers may type them
+const paymentCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const (
+	paymentLinkCodeLength = 8 // also the UPI reference of the link's static QR
+	guestReferenceLength  = 6 // random characters a guest donation adds to the link code
+	maxUPINoteLength      = 50
+)
+
+// QR code rendering
+const (
+	qrFormatPNG   = "png"
+	qrFormatSVG   = "svg"
+	qrContentUPI  = "upi"  // the UPI intent, paid by scanning with any UPI app
+	qrContentLink = "link" // the short link to the guest donation page
+
+	defaultQRSize = 512
+	minQRSize     = 128
+	maxQRSize     = 2048
+	guestQRSize   = 320
+)
+
+var (
+	errNoUPIID             = errors.New("the temple has no UPI ID in its bank details")
+	errPaymentLinkNotFound = errors.New("payment link not found")
+)
+
+// ==============================
+// Payment Links
+// ==============================
+
+// CreatePaymentLink creates a static UPI QR code and short link for the
+// temple, one of its sevas or a campaign
+func (s *service) CreatePaymentLink(req CreatePaymentLinkRequest, accessContext middleware.AccessContext) (*PaymentLinkView, error) {
+	ctx := context.Background()
+
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != req.EntityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+
+	eb := s.getTenantBank(ctx, req.EntityID)
+	if eb == nil || strings.TrimSpace(eb.UPIID) == "" {
+		return nil, errNoUPIID
+	}
+
+	link := &PaymentLink{
+		EntityID:     req.EntityID,
+		TargetType:   req.TargetType,
+		Title:        strings.TrimSpace(req.Title),
+		DonationType: TypeGeneral,
+		IsActive:     true,
+		CreatedBy:    req.CreatedBy,
+	}
+	var defaultTitle string
+	var defaultAmount float64
+
+	// ── Target decides the donation type, title and amount ───────────────
+	switch req.TargetType {
+	case PaymentLinkTemple:
+		if req.DonationType != "" {
+			link.DonationType = req.DonationType
+		}
+		if details, err := s.repo.GetReceiptEntityDetails(ctx, req.EntityID); err == nil {
+			defaultTitle = details.Name
+		}
+	case PaymentLinkSeva:
+		if req.TargetID == nil {
+			return nil, errors.New("targetId is required for seva payment links")
+		}
+		seva, err := s.repo.GetLinkSeva(ctx, *req.TargetID)
+		if err != nil || seva.EntityID != req.EntityID {
+			return nil, errors.New("seva not found")
+		}
+		if !seva.IsActive {
+			return nil, errors.New("seva is not active")
+		}
+		link.TargetID = &seva.ID
+		link.DonationType = TypeSeva
+		defaultTitle, defaultAmount = seva.Name, seva.Price
+	case PaymentLinkCampaign:
+		if req.TargetID == nil {
+			return nil, errors.New("targetId is required for campaign payment links")
+		}
+		campaign, err := s.repo.GetCampaignByID(ctx, *req.TargetID)
+		if err != nil || campaign.EntityID != req.EntityID {
+			return nil, errors.New("campaign not found")
+		}
+		if status := campaign.StatusAt(time.Now()); status == CampaignEnded || status == CampaignClosed {
+			return nil, errors.New("campaign is " + status + " and not accepting donations")
+		}
+		link.TargetID = &campaign.ID
+		link.DonationType = campaign.DonationType
+		defaultTitle = campaign.Title
+	}
+
+	if link.Title == "" {
+		link.Title = defaultTitle
+	}
+	if link.Title == "" {
+		link.Title = "Donation"
+	}
+	if req.Amount != nil {
+		defaultAmount = *req.Amount
+	}
+	if amount := utils.RoundMoney(defaultAmount); amount > 0 {
+		link.Amount = &amount
+	}
+
+	code, err := randomPaymentCode(paymentLinkCodeLength)
+	if err != nil {
+		return nil, err
+	}
+	link.Code = code
+
+	if err := s.repo.CreatePaymentLink(ctx, link); err != nil {
+		s.auditSvc.LogAction(ctx, &req.CreatedBy, &req.EntityID, "PAYMENT_LINK_CREATED",
+			map[string]interface{}{"target_type": req.TargetType, "target_id": req.TargetID, "error": err.Error()},
+			req.IPAddress, "failure")
+		return nil, fmt.Errorf("failed to create payment link: %w", err)
+	}
+
+	s.auditSvc.LogAction(ctx, &req.CreatedBy, &req.EntityID, "PAYMENT_LINK_CREATED",
+		map[string]interface{}{
+			"payment_link_id": link.ID,
+			"code":            link.Code,
+			"target_type":     link.TargetType,
+			"target_id":       link.TargetID,
+			"donation_type":   link.DonationType,
+			"amount":          link.Amount,
+		}, req.IPAddress, "success")
+
+	log.Printf("✅ Payment link=%d (%s) created: entity=%d target=%s", link.ID, link.Code, link.EntityID, link.TargetType)
+	view := s.paymentLinkView(PaymentLinkView{PaymentLink: *link}, eb.UPIID, s.payeeName(ctx, link.EntityID, eb))
+	return &view, nil
+}
+
+func (s *service) ListPaymentLinks(filters PaymentLinkFilters, accessContext middleware.AccessContext) ([]PaymentLinkView, error) {
+	ctx := context.Background()
+
+	if !accessContext.CanRead() {
+		return nil, errors.New("read access denied")
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != filters.EntityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+
+	links, err := s.repo.ListPaymentLinks(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	eb := s.getTenantBank(ctx, filters.EntityID)
+	var upiID string
+	if eb != nil {
+		upiID = eb.UPIID
+	}
+	payee := s.payeeName(ctx, filters.EntityID, eb)
+	for i := range links {
+		links[i] = s.paymentLinkView(links[i], upiID, payee)
+	}
+	return links, nil
+}
+
+// DeactivatePaymentLink stops a link from taking new guest donations. Payments
+// already made through its static QR can still be matched to it.
+func (s *service) DeactivatePaymentLink(id uint, userID uint, accessContext middleware.AccessContext, ip string) (*PaymentLink, error) {
+	ctx := context.Background()
+
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	link, err := s.repo.GetPaymentLinkByID(ctx, id)
+	if err != nil {
+		return nil, errPaymentLinkNotFound
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != link.EntityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+	if !link.IsActive {
+		return link, nil
+	}
+
+	if err := s.repo.UpdatePaymentLink(ctx, id, map[string]interface{}{"is_active": false}); err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &link.EntityID, "PAYMENT_LINK_DEACTIVATED",
+			map[string]interface{}{"payment_link_id": id, "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+	link.IsActive = false
+
+	s.auditSvc.LogAction(ctx, &userID, &link.EntityID, "PAYMENT_LINK_DEACTIVATED",
+		map[string]interface{}{"payment_link_id": id, "code": link.Code}, ip, "success")
+	return link, nil
+}
+
+// paymentLinkView fills in the UPI intent of the link's static QR and its short link
+func (s *service) paymentLinkView(view PaymentLinkView, upiID, payee string) PaymentLinkView {
+	if upiID != "" {
+		view.UPIURI = upiIntentURI(upiID, payee, view.Amount, view.Title, view.Code)
+	}
+	view.ShortURL = s.shortPaymentURL(view.Code)
+	return view
+}
+
+func (s *service) shortPaymentURL(code string) string {
+	if s.cfg == nil {
+		return "/pay/" + code
+	}
+	return s.cfg.PaymentLinkBaseURL + "/" + code
+}
+
+// payeeName is the name UPI apps show the payer: the account holder of the
+// tenant's bank account, or the temple name when none is recorded
+func (s *service) payeeName(ctx context.Context, entityID uint, eb *EntityBankDetails) string {
+	if eb != nil && strings.TrimSpace(eb.AccountHolderName) != "" {
+		return strings.TrimSpace(eb.AccountHolderName)
+	}
+	if details, err := s.repo.GetReceiptEntityDetails(ctx, entityID); err == nil {
+		return details.Name
+	}
+	return ""
+}
+
+// ==============================
+// Guest Donation Flow
+// ==============================
+
+// activePaymentLink looks up a link by the code in its short link or QR
+func (s *service) activePaymentLink(ctx context.Context, code string) (*PaymentLink, error) {
+	link, err := s.repo.GetPaymentLinkByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil || !link.IsActive {
+		return nil, errPaymentLinkNotFound
+	}
+	return link, nil
+}
+
+// GetGuestPaymentLink returns what the public guest donation page shows
+func (s *service) GetGuestPaymentLink(code string) (*GuestPaymentLink, error) {
+	ctx := context.Background()
+
+	link, err := s.activePaymentLink(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.openCampaignOfLink(ctx, link); err != nil {
+		return nil, err
+	}
+	eb := s.getTenantBank(ctx, link.EntityID)
+	if eb == nil || strings.TrimSpace(eb.UPIID) == "" {
+		return nil, errNoUPIID
+	}
+
+	guest := &GuestPaymentLink{
+		Code:         link.Code,
+		Title:        link.Title,
+		TargetType:   link.TargetType,
+		DonationType: link.DonationType,
+		Amount:       link.Amount,
+		PayeeName:    s.payeeName(ctx, link.EntityID, eb),
+		PayeeUPIID:   eb.UPIID,
+	}
+	if details, err := s.repo.GetReceiptEntityDetails(ctx, link.EntityID); err == nil {
+		guest.TempleName = details.Name
+		guest.LogoURL, _ = entityLogo(link.EntityID, details.Media)
+	}
+	return guest, nil
+}
+
+// GetPaymentLinkQR renders the link's static UPI QR code, or a QR code of its
+// short link, as a PNG or SVG image
+func (s *service) GetPaymentLinkQR(code string, content string, format string, size int) ([]byte, string, error) {
+	ctx := context.Background()
+
+	link, err := s.activePaymentLink(ctx, code)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var text string
+	switch content {
+	case "", qrContentUPI:
+		eb := s.getTenantBank(ctx, link.EntityID)
+		if eb == nil || strings.TrimSpace(eb.UPIID) == "" {
+			return nil, "", errNoUPIID
+		}
+		text = upiIntentURI(eb.UPIID, s.payeeName(ctx, link.EntityID, eb), link.Amount, link.Title, link.Code)
+	case qrContentLink:
+		text = s.shortPaymentURL(link.Code)
+	default:
+		return nil, "", errors.New("content must be upi or link")
+	}
+	return renderQRCode(text, format, size)
+}
+
+// StartGuestDonation records a pending donation for a payer who opened a
+// payment link without logging in, and returns the UPI intent to pay it. The
+// intent carries the donation's own reference, which staff match against the
+// temple's bank statement to complete the donation.
+func (s *service) StartGuestDonation(code string, req GuestDonationRequest) (*GuestDonationResponse, error) {
+	ctx := context.Background()
+
+	link, err := s.activePaymentLink(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	eb := s.getTenantBank(ctx, link.EntityID)
+	if eb == nil || strings.TrimSpace(eb.UPIID) == "" {
+		return nil, errNoUPIID
+	}
+
+	amount := utils.RoundMoney(req.Amount)
+	if link.Amount != nil {
+		amount = *link.Amount
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount is required")
+	}
+	donorName := strings.TrimSpace(req.DonorName)
+	if donorName == "" {
+		return nil, errors.New("donorName is required")
+	}
+
+	campaign, err := s.openCampaignOfLink(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix, err := randomPaymentCode(guestReferenceLength)
+	if err != nil {
+		return nil, err
+	}
+	reference := link.Code + suffix
+
+	holder, number, ifsc, upiID, _ := eb.payeeAccount(false)
+	donation := &Donation{
+		EntityID:          link.EntityID,
+		Amount:            amount,
+		DonationType:      link.DonationType,
+		Method:            MethodUPI,
+		Status:            StatusPending,
+		OrderID:           reference,
+		Note:              req.Note,
+		IsOffline:         true,
+		DonorName:         &donorName,
+		PaymentLinkID:     &link.ID,
+		AccountHolderName: holder,
+		AccountNumber:     number,
+		AccountType:       MethodUPI,
+		IFSCCode:          ifsc,
+		UPIID:             upiID,
+	}
+	if link.TargetType == PaymentLinkSeva {
+		donation.ReferenceID = link.TargetID
+	}
+	if campaign != nil {
+		donation.CampaignID = &campaign.ID
+	}
+	if phone := strings.TrimSpace(req.DonorPhone); phone != "" {
+		donation.DonorPhone = &phone
+	}
+
+	if err := s.repo.Create(ctx, donation); err != nil {
+		s.auditSvc.LogAction(ctx, nil, &link.EntityID, "GUEST_DONATION_STARTED",
+			map[string]interface{}{"payment_link_id": link.ID, "amount": amount, "error": err.Error()},
+			req.IPAddress, "failure")
+		return nil, fmt.Errorf("failed to start donation: %w", err)
+	}
+
+	s.auditSvc.LogAction(ctx, nil, &link.EntityID, "GUEST_DONATION_STARTED",
+		map[string]interface{}{
+			"donation_id":     donation.ID,
+			"payment_link_id": link.ID,
+			"reference":       reference,
+			"donor_name":      donorName,
+			"amount":          amount,
+			"donation_type":   donation.DonationType,
+		}, req.IPAddress, "success")
+
+	uri := upiIntentURI(upiID, s.payeeName(ctx, link.EntityID, eb), &amount, link.Title, reference)
+	png, err := qrcode.Encode(uri, qrcode.Medium, guestQRSize)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ Guest donation=%d started through payment link=%d: ref=%s amount=%.2f", donation.ID, link.ID, reference, amount)
+	return &GuestDonationResponse{
+		DonationID: donation.ID,
+		Reference:  reference,
+		Amount:     amount,
+		UPIURI:     uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// openCampaignOfLink returns the campaign a link collects for, as long as the
+// campaign is still accepting donations
+func (s *service) openCampaignOfLink(ctx context.Context, link *PaymentLink) (*Campaign, error) {
+	if link.TargetType != PaymentLinkCampaign {
+		return nil, nil
+	}
+	return s.openCampaign(ctx, link.TargetID, link.EntityID, time.Now())
+}
+
+// ==============================
+// Matching Incoming UPI Payments
+// ==============================
+
+// MatchUPIPayment records a UPI payment found on the temple's bank statement.
+// A guest donation's reference completes that donation; the code of a payment
+// link records a new donation for a payer who scanned its static QR.
+func (s *service) MatchUPIPayment(req MatchUPIPaymentRequest, accessContext middleware.AccessContext) (*DonationWithUser, error) {
+	ctx := context.Background()
+
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != req.EntityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+
+	reference := strings.ToUpper(strings.TrimSpace(req.Reference))
+	utr := strings.TrimSpace(req.UTR)
+	amount := utils.RoundMoney(req.Amount)
+
+	now := time.Now()
+	paidAt := now
+	if req.PaidAt != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.PaidAt, utils.IST)
+		if err != nil {
+			return nil, errors.New("paidAt must be in YYYY-MM-DD format")
+		}
+		if parsed.After(now) {
+			return nil, errors.New("paidAt cannot be in the future")
+		}
+		paidAt = parsed
+	}
+
+	recorded, err := s.repo.IsUTRRecorded(ctx, req.EntityID, utr)
+	if err != nil {
+		return nil, err
+	}
+	if recorded {
+		return nil, errors.New("a payment with this UTR has already been matched")
+	}
+
+	// ── A guest donation's own reference ─────────────────────────────────
+	if donation, err := s.repo.GetByOrderID(ctx, reference); err == nil && donation.PaymentLinkID != nil && donation.EntityID == req.EntityID {
+		return s.completeGuestDonation(ctx, donation, req, utr, amount, paidAt)
+	}
+
+	// ── The code of a payment link's static QR ───────────────────────────
+	link, err := s.repo.GetPaymentLinkByCode(ctx, reference)
+	if err != nil || link.EntityID != req.EntityID {
+		return nil, errors.New("no guest donation or payment link matches this reference")
+	}
+
+	eb := s.getTenantBank(ctx, link.EntityID)
+	holder, number, ifsc, upiID, _ := eb.payeeAccount(false)
+	donation := &Donation{
+		EntityID:          link.EntityID,
+		Amount:            amount,
+		DonationType:      link.DonationType,
+		Method:            MethodUPI,
+		Status:            StatusSuccess,
+		OrderID:           fmt.Sprintf("upi_%d_%s", link.EntityID, utr),
+		PaymentID:         &utr,
+		IsOffline:         true,
+		RecordedBy:        &req.RecordedBy,
+		InstrumentNumber:  &utr,
+		PaymentLinkID:     &link.ID,
+		AccountHolderName: holder,
+		AccountNumber:     number,
+		AccountType:       MethodUPI,
+		IFSCCode:          ifsc,
+		UPIID:             upiID,
+		DonatedAt:         &paidAt,
+	}
+	// The payment has been made, so it counts towards the campaign even if it closed since
+	switch link.TargetType {
+	case PaymentLinkSeva:
+		donation.ReferenceID = link.TargetID
+	case PaymentLinkCampaign:
+		donation.CampaignID = link.TargetID
+	}
+	if name := strings.TrimSpace(req.PayerName); name != "" {
+		donation.DonorName = &name
+	}
+	if phone := strings.TrimSpace(req.PayerPhone); phone != "" {
+		donation.DonorPhone = &phone
+	}
+
+	if err := s.repo.Create(ctx, donation); err != nil {
+		s.auditSvc.LogAction(ctx, &req.RecordedBy, &req.EntityID, "UPI_PAYMENT_MATCHED",
+			map[string]interface{}{"reference": reference, "utr": utr, "amount": amount, "error": err.Error()},
+			req.IPAddress, "failure")
+		return nil, fmt.Errorf("failed to record UPI payment: %w", err)
+	}
+	s.donationSucceeded(ctx, donation.ID)
+
+	s.auditSvc.LogAction(ctx, &req.RecordedBy, &req.EntityID, "UPI_PAYMENT_MATCHED",
+		map[string]interface{}{
+			"donation_id":     donation.ID,
+			"payment_link_id": link.ID,
+			"reference":       reference,
+			"utr":             utr,
+			"amount":          amount,
+		}, req.IPAddress, "success")
+
+	log.Printf("✅ UPI payment %s matched to payment link=%d: donation=%d amount=%.2f", utr, link.ID, donation.ID, amount)
+	return s.repo.GetByIDWithUser(ctx, donation.ID)
+}
+
+// completeGuestDonation marks a pending guest donation as paid by the UPI
+// payment carrying its reference
+func (s *service) completeGuestDonation(ctx context.Context, donation *Donation, req MatchUPIPaymentRequest, utr string, amount float64, paidAt time.Time) (*DonationWithUser, error) {
+	if donation.Status != StatusPending {
+		return nil, errors.New("donation is already " + strings.ToLower(donation.Status))
+	}
+	if utils.RoundMoney(donation.Amount) != amount {
+		return nil, fmt.Errorf("payment of %.2f does not match the donation amount of %.2f", amount, donation.Amount)
+	}
+
+	completed, err := s.repo.CompleteLinkDonation(ctx, donation.ID, map[string]interface{}{
+		"status":            StatusSuccess,
+		"payment_id":        utr,
+		"instrument_number": utr,
+		"recorded_by":       req.RecordedBy,
+		"donated_at":        paidAt,
+	})
+	if err == nil && !completed {
+		err = errors.New("donation has already been matched")
+	}
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &req.RecordedBy, &req.EntityID, "UPI_PAYMENT_MATCHED",
+			map[string]interface{}{"donation_id": donation.ID, "utr": utr, "amount": amount, "error": err.Error()},
+			req.IPAddress, "failure")
+		return nil, err
+	}
+	s.donationSucceeded(ctx, donation.ID)
+
+	s.auditSvc.LogAction(ctx, &req.RecordedBy, &req.EntityID, "UPI_PAYMENT_MATCHED",
+		map[string]interface{}{
+			"donation_id":     donation.ID,
+			"payment_link_id": donation.PaymentLinkID,
+			"reference":       donation.OrderID,
+			"utr":             utr,
+			"amount":          amount,
+		}, req.IPAddress, "success")
+
+	log.Printf("✅ UPI payment %s matched to guest donation=%d (ref=%s)", utr, donation.ID, donation.OrderID)
+	return s.repo.GetByIDWithUser(ctx, donation.ID)
+}
+
+// ==============================
+// UPI Intents and QR Codes
+// ==============================
+
+// upiIntentURI builds a UPI deep link as specified by NPCI. The reference goes
+// in tr and at the start of the note, which most banks print on the statement.
+func upiIntentURI(upiID, payee string, amount *float64, title, reference string) string {
+	note := reference + " " + title
+	if runes := []rune(note); len(runes) > maxUPINoteLength {
+		note = string(runes[:maxUPINoteLength])
+	}
+
+	params := []string{"pa=" + upiEscape(strings.TrimSpace(upiID)), "pn=" + upiEscape(payee)}
+	if amount != nil && *amount > 0 {
+		params = append(params, "am="+strconv.FormatFloat(*amount, 'f', 2, 64))
+	}
+	params = append(params, "cu=INR", "tn="+upiEscape(strings.TrimSpace(note)), "tr="+upiEscape(reference))
+	return "upi://pay?" + strings.Join(params, "&")
+}
+
+// upiEscape percent-encodes a UPI parameter. Some UPI apps do not decode "+"
+// as a space or expect the @ of a UPI ID unescaped.
+func upiEscape(v string) string {
+	escaped := strings.ReplaceAll(url.QueryEscape(v), "+", "%20")
+	return strings.ReplaceAll(escaped, "%40", "@")
+}
+
+// renderQRCode encodes text as a QR code image, size pixels square
+func renderQRCode(text string, format string, size int) ([]byte, string, error) {
+	if size <= 0 {
+		size = defaultQRSize
+	}
+	if size < minQRSize {
+		size = minQRSize
+	}
+	if size > maxQRSize {
+		size = maxQRSize
+	}
+
+	qr, err := qrcode.New(text, qrcode.Medium)
+	if err != nil {
+		return nil, "", err
+	}
+	switch format {
+	case "", qrFormatPNG:
+		png, err := qr.PNG(size)
+		return png, "image/png", err
+	case qrFormatSVG:
+		return qrSVG(qr.Bitmap(), size), "image/svg+xml", nil
+	default:
+		return nil, "", errors.New("format must be png or svg")
+	}
+}
+
+// qrSVG draws a QR bitmap, quiet zone included, one unit per module
+func qrSVG(bitmap [][]bool, size int) []byte {
+	n := len(bitmap)
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#ffffff"/><path fill="#000000" d="`, n, n)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
+
+// randomPaymentCode returns n random characters of paymentCodeAlphabet
+func randomPaymentCode(n int) (string, error) {
+	max := big.NewInt(int64(len(paymentCodeAlphabet)))
+	code := make([]byte, n)
+	for i := range code {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = paymentCodeAlphabet[idx.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,444 @@
+package donation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// Overdue pledge reminders go out at most once per pledgeReminderGap for each
+// instalment, and stop after maxPledgeReminders
+const (
+	pledgeReminderGap  = 7 * 24 * time.Hour
+	maxPledgeReminders = 4
+)
+
+// ==============================
+// Pledges
+// ==============================
+
+// CreatePledge records a donor's pledge and its instalment schedule
+func (s *service) CreatePledge(req CreatePledgeRequest, accessContext middleware.AccessContext) (*PledgeDetail, error) {
+	ctx := context.Background()
+
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != req.EntityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+
+	donorName := strings.TrimSpace(req.DonorName)
+	donorPhone := strings.TrimSpace(req.DonorPhone)
+	userID, err := s.counterDonor(ctx, req.DevoteeID, donorName)
+	if err != nil {
+		return nil, err
+	}
+
+	// ── Campaign (optional) decides the donation type ────────────────────
+	now := time.Now()
+	campaign, err := s.openCampaign(ctx, req.CampaignID, req.EntityID, now)
+	if err != nil {
+		return nil, err
+	}
+	donationType := req.DonationType
+	if campaign != nil {
+		donationType = campaign.DonationType
+	}
+
+	instalments, err := pledgeSchedule(req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	pledge := &Pledge{
+		EntityID:        req.EntityID,
+		UserID:          userID,
+		TotalAmount:     utils.RoundMoney(req.TotalAmount),
+		DonationType:    donationType,
+		Note:            req.Note,
+		Frequency:       req.Frequency,
+		InstalmentCount: len(instalments),
+		StartDate:       instalments[0].DueDate,
+		Status:          PledgeActive,
+		CreatedBy:       req.CreatedBy,
+		Instalments:     instalments,
+	}
+	if len(req.Schedule) > 0 {
+		pledge.Frequency = ""
+	}
+	if campaign != nil {
+		pledge.CampaignID = &campaign.ID
+	}
+	if donorName != "" {
+		pledge.DonorName = &donorName
+	}
+	if donorPhone != "" {
+		pledge.DonorPhone = &donorPhone
+	}
+	for i := range pledge.Instalments {
+		pledge.Instalments[i].EntityID = req.EntityID
+	}
+
+	if err := s.repo.CreatePledge(ctx, pledge); err != nil {
+		s.auditSvc.LogAction(ctx, &req.CreatedBy, &req.EntityID, "PLEDGE_CREATED",
+			map[string]interface{}{"total_amount": req.TotalAmount, "error": err.Error()},
+			req.IPAddress, "failure")
+		return nil, fmt.Errorf("failed to create pledge: %w", err)
+	}
+
+	s.auditSvc.LogAction(ctx, &req.CreatedBy, &req.EntityID, "PLEDGE_CREATED",
+		map[string]interface{}{
+			"pledge_id":        pledge.ID,
+			"devotee_id":       userID,
+			"donor_name":       donorName,
+			"total_amount":     pledge.TotalAmount,
+			"donation_type":    pledge.DonationType,
+			"campaign_id":      pledge.CampaignID,
+			"instalment_count": pledge.InstalmentCount,
+			"start_date":       pledge.StartDate,
+		}, req.IPAddress, "success")
+
+	log.Printf("✅ Pledge=%d created: entity=%d total=%.2f instalments=%d", pledge.ID, pledge.EntityID, pledge.TotalAmount, pledge.InstalmentCount)
+	return s.pledgeDetail(ctx, pledge.ID)
+}
+
+// pledgeSchedule builds the instalments of a new pledge: the custom schedule
+// when one is given, which must add up to the total, otherwise InstalmentCount
+// equal instalments every Frequency from StartDate. The last generated
+// instalment absorbs the rounding difference.
+func pledgeSchedule(req CreatePledgeRequest, now time.Time) ([]PledgeInstalment, error) {
+	today := time.Date(now.In(utils.IST).Year(), now.In(utils.IST).Month(), now.In(utils.IST).Day(), 0, 0, 0, 0, utils.IST)
+	total := utils.RoundMoney(req.TotalAmount)
+
+	if len(req.Schedule) > 0 {
+		instalments := make([]PledgeInstalment, 0, len(req.Schedule))
+		sum := 0.0
+		for i, due := range req.Schedule {
+			dueDate, err := time.ParseInLocation("2006-01-02", due.DueDate, utils.IST)
+			if err != nil {
+				return nil, fmt.Errorf("schedule[%d].dueDate must be in YYYY-MM-DD format", i)
+			}
+			if i > 0 && !dueDate.After(instalments[i-1].DueDate) {
+				return nil, errors.New("schedule due dates must be in ascending order")
+			}
+			amount := utils.RoundMoney(due.Amount)
+			sum += amount
+			instalments = append(instalments, PledgeInstalment{Sequence: i + 1, DueDate: dueDate, Amount: amount, Status: InstalmentPending})
+		}
+		if utils.RoundMoney(sum) != total {
+			return nil, fmt.Errorf("schedule adds up to %.2f but the pledge total is %.2f", utils.RoundMoney(sum), total)
+		}
+		return instalments, nil
+	}
+
+	count := req.InstalmentCount
+	if count <= 0 {
+		count = 1
+	}
+	if count > 1 && req.Frequency == "" {
+		return nil, errors.New("frequency is required when the pledge has more than one instalment")
+	}
+	start := today
+	if req.StartDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.StartDate, utils.IST)
+		if err != nil {
+			return nil, errors.New("startDate must be in YYYY-MM-DD format")
+		}
+		start = parsed
+	}
+
+	share := utils.RoundMoney(total / float64(count))
+	if share <= 0 {
+		return nil, errors.New("totalAmount is too small for that many instalments")
+	}
+	instalments := make([]PledgeInstalment, 0, count)
+	for n := 0; n < count; n++ {
+		amount := share
+		if n == count-1 {
+			amount = utils.RoundMoney(total - share*float64(count-1))
+		}
+		instalments = append(instalments, PledgeInstalment{
+			Sequence: n + 1,
+			DueDate:  occurrenceDate(start, req.Frequency, n),
+			Amount:   amount,
+			Status:   InstalmentPending,
+		})
+	}
+	return instalments, nil
+}
+
+func (s *service) ListPledges(filters PledgeFilters, accessContext middleware.AccessContext) ([]PledgeWithBalance, int, error) {
+	if !accessContext.CanRead() {
+		return nil, 0, errors.New("read access denied")
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != filters.EntityID {
+		return nil, 0, errors.New("access denied to requested entity")
+	}
+	return s.repo.ListPledges(context.Background(), filters, time.Now())
+}
+
+// GetMyPledges returns a devotee's pledges at a temple with their balances
+func (s *service) GetMyPledges(userID uint, entityID uint) ([]PledgeWithBalance, error) {
+	pledges, _, err := s.repo.ListPledges(context.Background(), PledgeFilters{EntityID: entityID, UserID: userID}, time.Now())
+	return pledges, err
+}
+
+// GetPledge returns a pledge with its instalments and linked donations to the
+// devotee who made it, or to the temple's staff
+func (s *service) GetPledge(id uint, userID uint, accessContext *middleware.AccessContext, entityID uint) (*PledgeDetail, error) {
+	ctx := context.Background()
+	detail, err := s.pledgeDetail(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	hasAccess := detail.UserID != 0 && detail.UserID == userID && detail.EntityID == entityID
+	if accessContext != nil {
+		if accessibleEntityID := accessContext.GetAccessibleEntityID(); accessibleEntityID != nil &&
+			*accessibleEntityID == detail.EntityID && accessContext.CanRead() {
+			hasAccess = true
+		}
+	}
+	if !hasAccess {
+		return nil, errors.New("unauthorized to access this pledge")
+	}
+	return detail, nil
+}
+
+func (s *service) pledgeDetail(ctx context.Context, id uint) (*PledgeDetail, error) {
+	pledge, err := s.repo.GetPledgeWithBalance(ctx, id, time.Now())
+	if err != nil {
+		return nil, errors.New("pledge not found")
+	}
+	donations, err := s.repo.ListPledgeDonations(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &PledgeDetail{PledgeWithBalance: *pledge, Donations: s.enrichDonations(donations)}, nil
+}
+
+// LinkPledgeDonation counts an existing donation of the pledge's donor towards
+// the pledge, e.g. one made before the donor mentioned the pledge
+func (s *service) LinkPledgeDonation(pledgeID uint, req LinkPledgeDonationRequest, userID uint, accessContext middleware.AccessContext, ip string) (*PledgeDetail, error) {
+	ctx := context.Background()
+
+	pledge, err := s.pledgeForWrite(ctx, pledgeID, accessContext)
+	if err != nil {
+		return nil, err
+	}
+	if pledge.Status == PledgeCancelled {
+		return nil, errors.New("pledge is cancelled")
+	}
+
+	donation, err := s.repo.GetByID(ctx, req.DonationID)
+	if err != nil || donation.EntityID != pledge.EntityID {
+		return nil, errors.New("donation not found")
+	}
+	if donation.PledgeID != nil {
+		if *donation.PledgeID == pledge.ID {
+			return s.pledgeDetail(ctx, pledge.ID)
+		}
+		return nil, errors.New("donation already pays towards another pledge")
+	}
+	if donation.UserID != pledge.UserID {
+		return nil, errors.New("donation was made by a different donor")
+	}
+	if status := strings.ToUpper(donation.Status); status == StatusFailed || status == StatusRefunded {
+		return nil, errors.New("only successful or pending donations can be linked to a pledge")
+	}
+
+	linked, err := s.repo.LinkDonationToPledge(ctx, donation.ID, pledge.ID)
+	if err == nil && !linked {
+		err = errors.New("donation already pays towards another pledge")
+	}
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &pledge.EntityID, "PLEDGE_DONATION_LINKED",
+			map[string]interface{}{"pledge_id": pledge.ID, "donation_id": donation.ID, "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+	s.settlePledge(ctx, pledge.ID)
+
+	s.auditSvc.LogAction(ctx, &userID, &pledge.EntityID, "PLEDGE_DONATION_LINKED",
+		map[string]interface{}{"pledge_id": pledge.ID, "donation_id": donation.ID, "amount": donation.Amount}, ip, "success")
+	return s.pledgeDetail(ctx, pledge.ID)
+}
+
+// CancelPledge releases the donor from the unpaid remainder. Donations already
+// linked stay linked and keep counting towards the pledge's paid amount.
+func (s *service) CancelPledge(id uint, req CancelPledgeRequest, userID uint, accessContext middleware.AccessContext, ip string) (*PledgeDetail, error) {
+	ctx := context.Background()
+
+	pledge, err := s.pledgeForWrite(ctx, id, accessContext)
+	if err != nil {
+		return nil, err
+	}
+	if pledge.Status != PledgeActive {
+		return nil, errors.New("pledge is already " + pledge.Status)
+	}
+
+	if err := s.repo.UpdatePledge(ctx, id, map[string]interface{}{
+		"status":       PledgeCancelled,
+		"cancelled_at": time.Now(),
+	}); err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &pledge.EntityID, "PLEDGE_CANCELLED",
+			map[string]interface{}{"pledge_id": id, "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, &userID, &pledge.EntityID, "PLEDGE_CANCELLED",
+		map[string]interface{}{
+			"pledge_id":    id,
+			"total_amount": pledge.TotalAmount,
+			"paid_amount":  pledge.PaidAmount,
+			"reason":       req.Reason,
+		}, ip, "success")
+	return s.pledgeDetail(ctx, id)
+}
+
+func (s *service) pledgeForWrite(ctx context.Context, id uint, accessContext middleware.AccessContext) (*Pledge, error) {
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	pledge, err := s.repo.GetPledgeByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("pledge not found")
+	}
+	accessibleEntityID := accessContext.GetAccessibleEntityID()
+	if accessibleEntityID == nil || *accessibleEntityID != pledge.EntityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+	return pledge, nil
+}
+
+// openPledge returns the pledge a new donation pays towards, checking it
+// belongs to the temple and the donor and is still active
+func (s *service) openPledge(ctx context.Context, pledgeID *uint, entityID, userID uint) (*Pledge, error) {
+	if pledgeID == nil || *pledgeID == 0 {
+		return nil, nil
+	}
+	pledge, err := s.repo.GetPledgeByID(ctx, *pledgeID)
+	if err != nil || pledge.EntityID != entityID || pledge.UserID != userID {
+		return nil, errors.New("pledge not found")
+	}
+	if pledge.Status != PledgeActive {
+		return nil, errors.New("pledge is " + pledge.Status + " and not accepting donations")
+	}
+	return pledge, nil
+}
+
+// ==============================
+// Settlement
+// ==============================
+
+// settleDonationPledge brings the balance of the donation's pledge up to date
+// after the donation succeeded or was refunded
+func (s *service) settleDonationPledge(ctx context.Context, donationID uint) {
+	donation, err := s.repo.GetByID(ctx, donationID)
+	if err != nil {
+		log.Printf("⚠️ Could not load donation=%d to settle its pledge: %v", donationID, err)
+		return
+	}
+	if donation.PledgeID != nil {
+		s.settlePledge(ctx, *donation.PledgeID)
+	}
+}
+
+// settlePledge reallocates the pledge's payments to its instalments. Failure is
+// only logged — the next payment or refund settles the pledge again.
+func (s *service) settlePledge(ctx context.Context, pledgeID uint) {
+	pledge, fulfilled, err := s.repo.SettlePledge(ctx, pledgeID, time.Now())
+	if err != nil {
+		log.Printf("⚠️ Could not settle pledge=%d: %v", pledgeID, err)
+		return
+	}
+	log.Printf("🤝 Pledge=%d settled: paid=%.2f of %.2f status=%s", pledge.ID, pledge.PaidAmount, pledge.TotalAmount, pledge.Status)
+	if !fulfilled {
+		return
+	}
+
+	s.auditSvc.LogAction(ctx, nil, &pledge.EntityID, "PLEDGE_FULFILLED",
+		map[string]interface{}{"pledge_id": pledge.ID, "total_amount": pledge.TotalAmount, "paid_amount": pledge.PaidAmount},
+		"pledge_settlement", "success")
+	if s.notifSvc != nil && pledge.UserID != 0 {
+		_ = s.notifSvc.CreateInAppNotification(ctx, pledge.UserID, pledge.EntityID,
+			"Pledge fulfilled",
+			fmt.Sprintf("Thank you! Your %s pledge of ₹%.2f has been paid in full.", pledge.DonationType, pledge.TotalAmount),
+			"donation")
+	}
+}
+
+// ==============================
+// Overdue Reminders
+// ==============================
+
+// SendPledgeReminders sends an in-app reminder for every overdue instalment of
+// an active pledge, at most once a week per instalment. Returns how many were sent.
+func (s *service) SendPledgeReminders(ctx context.Context) (int, error) {
+	if s.notifSvc == nil {
+		return 0, nil
+	}
+	now := time.Now()
+	today := time.Date(now.In(utils.IST).Year(), now.In(utils.IST).Month(), now.In(utils.IST).Day(), 0, 0, 0, 0, utils.IST)
+	remindBefore := now.Add(-pledgeReminderGap)
+
+	overdue, err := s.repo.ListOverdueInstalments(ctx, today, remindBefore, maxPledgeReminders, 500)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, inst := range overdue {
+		claimed, err := s.repo.ClaimInstalmentReminder(ctx, inst.ID, remindBefore, now)
+		if err != nil {
+			log.Printf("❌ Pledge reminder for instalment=%d: %v", inst.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		due := utils.RoundMoney(inst.Amount - inst.PaidAmount)
+		if err := s.notifSvc.CreateInAppNotification(ctx, inst.UserID, inst.EntityID,
+			"Pledge instalment overdue",
+			fmt.Sprintf("Instalment %d of %d of your %s pledge (₹%.2f) was due on %s. ₹%.2f is still to be paid.",
+				inst.Sequence, inst.InstalmentCount, inst.DonationType, inst.PledgeTotal, inst.DueDate.In(utils.IST).Format("02 Jan 2006"), due),
+			"donation"); err != nil {
+			log.Printf("❌ Pledge reminder for instalment=%d: %v", inst.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// StartPledgeReminderWorker launches the background worker that reminds
+// devotees of overdue pledge instalments
+func StartPledgeReminderWorker(svc Service, interval time.Duration) {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	go func() {
+		log.Printf("🤝 Pledge reminder worker started (every %s)", interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			sent, err := svc.SendPledgeReminders(context.Background())
+			if err != nil {
+				log.Printf("❌ Pledge reminder worker: %v", err)
+			} else if sent > 0 {
+				log.Printf("🤝 Pledge reminder worker sent %d reminder(s)", sent)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,282 @@
+package donation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// ==============================
+// 80G Receipt Helpers
+// ==============================
+
+// financialYearOf returns the Indian financial year (April–March) for t, e.g. "2025-26"
+func financialYearOf(t time.Time) string {
+	t = t.In(utils.IST)
+	start := t.Year()
+	if t.Month() < time.April {
+		start--
+	}
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100)
+}
+
+// formatReceiptNumber builds the printed receipt number, e.g. "RCP/12/2025-26/000042"
+func formatReceiptNumber(entityID uint, financialYear string, seq int64) string {
+	return fmt.Sprintf("RCP/%d/%s/%06d", entityID, financialYear, seq)
+}
+
+// formatEntityAddress joins the non-empty address parts of a temple
+func formatEntityAddress(e *ReceiptEntityDetails) string {
+	var parts []string
+	for _, p := range []string{e.StreetAddress, e.City, e.District, e.State} {
+		if strings.TrimSpace(p) != "" {
+			parts = append(parts, strings.TrimSpace(p))
+		}
+	}
+	address := strings.Join(parts, ", ")
+	if e.Pincode != "" {
+		address += " - " + e.Pincode
+	}
+	return address
+}
+
+// entityLogo returns the logo URL stored in Entity.Media and its path on disk.
+// Logos are uploaded to <UploadPath>/<entityID>/<file>.
+func entityLogo(entityID uint, media string) (string, string) {
+	if media == "" {
+		return "", ""
+	}
+	var info struct {
+		Logo string `json:"logo"`
+	}
+	if err := json.Unmarshal([]byte(media), &info); err != nil || info.Logo == "" {
+		return "", ""
+	}
+	path := filepath.Join(config.UploadPath, strconv.FormatUint(uint64(entityID), 10), filepath.Base(info.Logo))
+	if _, err := os.Stat(path); err != nil {
+		return info.Logo, ""
+	}
+	return info.Logo, path
+}
+
+// ==============================
+// Amount in Words (Indian numbering)
+// ==============================
+
+var (
+	wordOnes = []string{"", "One", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine",
+		"Ten", "Eleven", "Twelve", "Thirteen", "Fourteen", "Fifteen", "Sixteen", "Seventeen", "Eighteen", "Nineteen"}
+	wordTens = []string{"", "", "Twenty", "Thirty", "Forty", "Fifty", "Sixty", "Seventy", "Eighty", "Ninety"}
+)
+
+func wordsBelowHundred(n int64) string {
+	if n < 20 {
+		return wordOnes[n]
+	}
+	if n%10 == 0 {
+		return wordTens[n/10]
+	}
+	return wordTens[n/10] + " " + wordOnes[n%10]
+}
+
+func wordsBelowThousand(n int64) string {
+	if n < 100 {
+		return wordsBelowHundred(n)
+	}
+	out := wordOnes[n/100] + " Hundred"
+	if n%100 != 0 {
+		out += " " + wordsBelowHundred(n%100)
+	}
+	return out
+}
+
+// integerInWords spells out n using crore / lakh / thousand grouping
+func integerInWords(n int64) string {
+	if n == 0 {
+		return "Zero"
+	}
+	var parts []string
+	if crore := n / 10000000; crore > 0 {
+		parts = append(parts, integerInWords(crore)+" Crore")
+		n %= 10000000
+	}
+	if lakh := n / 100000; lakh > 0 {
+		parts = append(parts, wordsBelowHundred(lakh)+" Lakh")
+		n %= 100000
+	}
+	if thousand := n / 1000; thousand > 0 {
+		parts = append(parts, wordsBelowHundred(thousand)+" Thousand")
+		n %= 1000
+	}
+	if n > 0 {
+		parts = append(parts, wordsBelowThousand(n))
+	}
+	return strings.Join(parts, " ")
+}
+
+// amountInWords renders an INR amount, e.g. "Rupees One Lakh Five Hundred and Fifty Paise Only"
+func amountInWords(amount float64) string {
+	paise := utils.Paise(amount)
+	rupees, rem := paise/100, paise%100
+	out := "Rupees " + integerInWords(rupees)
+	if rem > 0 {
+		out += " and " + wordsBelowHundred(rem) + " Paise"
+	}
+	return out + " Only"
+}
+
+// ==============================
+// PDF Rendering
+// ==============================
+
+// renderReceiptPDF draws an 80G receipt. All timestamps come from the receipt
+// itself, so the same donation always renders byte-for-byte identical output.
+func renderReceiptPDF(r *Receipt, logoPath string) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(r.GeneratedAt)
+	pdf.SetModificationDate(r.GeneratedAt)
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle("Donation Receipt "+r.ReceiptNumber, false)
+	pdf.SetAutoPageBreak(false, 10)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	// ── Header: logo + temple details ────────────────────────────────────
+	drawEntityHeader(pdf, tr, r.EntityName, r.EntityAddress, r.TrustPAN, r.Registration80G, logoPath)
+
+	// ── Title ────────────────────────────────────────────────────────────
+	pdf.SetXY(10, 46)
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(190, 8, "DONATION RECEIPT", "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "I", 9)
+	pdf.CellFormat(190, 5, "Eligible for deduction under Section 80G of the Income Tax Act, 1961", "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(95, 7, "Receipt No: "+r.ReceiptNumber, "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 7, "Date: "+r.DonatedAt.In(utils.IST).Format("02-01-2006"), "", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(95, 6, "Financial Year: "+r.FinancialYear, "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	// ── Donation details ─────────────────────────────────────────────────
+	purpose := r.DonationType
+	if purpose != "" {
+		purpose = strings.ToUpper(purpose[:1]) + purpose[1:]
+	}
+	donorPAN := r.DonorPAN
+	if donorPAN == "" {
+		donorPAN = "Not provided"
+	}
+	rows := [][2]string{
+		{"Received with thanks from", r.DonorName},
+		{"Donor Email", r.DonorEmail},
+		{"Donor PAN", donorPAN},
+	}
+	if r.OnBehalfOf != "" {
+		rows = append(rows, [2]string{dedicationLabel(r.DedicationType), r.OnBehalfOf})
+	}
+	if r.RefundedAmount > 0 {
+		rows = append(rows, [][2]string{
+			{"Amount Received", fmt.Sprintf("Rs. %.2f", r.DonationAmount)},
+			{"Less: Refunded", fmt.Sprintf("Rs. %.2f", r.RefundedAmount)},
+			{"Eligible Amount", fmt.Sprintf("Rs. %.2f", r.EligibleAmount)},
+		}...)
+	} else {
+		rows = append(rows, [2]string{"Amount", fmt.Sprintf("Rs. %.2f", r.EligibleAmount)})
+	}
+	rows = append(rows, [2]string{"Amount in Words", r.AmountInWords})
+	if r.Currency != "" && r.Currency != "INR" {
+		rows = append(rows,
+			[2]string{"Amount Paid", fmt.Sprintf("%s %.2f", r.Currency, r.OriginalAmount)},
+			[2]string{"Exchange Rate", fmt.Sprintf("1 %s = Rs. %.4f", r.Currency, r.ExchangeRate)})
+	}
+	if r.IsForeignContribution {
+		rows = append(rows, [2]string{"Foreign Contribution", "Received in FCRA account, Reg. No. " + r.FCRARegistrationNumber})
+	}
+	rows = append(rows, [][2]string{
+		{"Purpose", purpose},
+		{"Payment Mode", strings.ToUpper(r.Method)},
+		{"Transaction ID", r.TransactionID},
+	}...)
+	drawDetailRows(pdf, tr, rows)
+	pdf.Ln(6)
+
+	// ── Footer ───────────────────────────────────────────────────────────
+	pdf.SetFont("Arial", "", 9)
+	pdf.MultiCell(190, 5, "Donations to this trust are eligible for deduction under Section 80G of the Income Tax Act, 1961, "+
+		"subject to the conditions specified therein. Please retain this receipt for your tax records.", "", "L", false)
+	pdf.Ln(14)
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(190, 6, "Authorised Signatory", "", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "I", 8)
+	pdf.CellFormat(190, 5, "This is a computer-generated receipt and does not require a physical signature.", "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawEntityHeader prints the temple logo, name, address and registration
+// numbers at the top of a receipt, followed by a rule at y=42
+func drawEntityHeader(pdf *gofpdf.Fpdf, tr func(string) string, entityName, address, trustPAN, registration80G, logoPath string) {
+	textX := 10.0
+	if logoPath != "" {
+		opts := gofpdf.ImageOptions{ImageType: strings.TrimPrefix(strings.ToLower(filepath.Ext(logoPath)), "."), ReadDpi: true}
+		if info := pdf.RegisterImageOptions(logoPath, opts); info != nil && pdf.Ok() {
+			pdf.ImageOptions(logoPath, 10, 10, 25, 0, false, opts, 0, "")
+			textX = 40
+		} else {
+			pdf.ClearError()
+		}
+	}
+
+	pdf.SetXY(textX, 12)
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(200-textX, 8, tr(entityName), "", 2, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	if address != "" {
+		pdf.MultiCell(200-textX, 5, tr(address), "", "L", false)
+		pdf.SetX(textX)
+	}
+	if trustPAN != "" {
+		pdf.CellFormat(200-textX, 5, "PAN: "+trustPAN, "", 2, "L", false, 0, "")
+	}
+	if registration80G != "" {
+		pdf.CellFormat(200-textX, 5, tr("80G Registration No: "+registration80G), "", 2, "L", false, 0, "")
+	}
+
+	pdf.SetDrawColor(120, 120, 120)
+	pdf.Line(10, 42, 200, 42)
+}
+
+// drawDetailRows prints label / value pairs as a two-column bordered table
+func drawDetailRows(pdf *gofpdf.Fpdf, tr func(string) string, rows [][2]string) {
+	for _, row := range rows {
+		y := pdf.GetY()
+		pdf.SetFont("Arial", "B", 10)
+		pdf.CellFormat(55, 8, row[0], "1", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 10)
+		pdf.MultiCell(135, 8, tr(row[1]), "1", "L", false)
+		if pdf.GetY() < y+8 {
+			pdf.SetY(y + 8)
+		}
+	}
+}
+
+// receiptFileName turns a receipt number into a safe download file name
+func receiptFileName(receiptNumber string) string {
+	return "receipt_" + strings.ReplaceAll(receiptNumber, "/", "-") + ".pdf"
+}
@@ -0,0 +1,156 @@
+package donation
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/payment"
+)
+
+// ==============================
+// Stale Payment Reconciliation
+// ==============================
+
+// reconcileBatch caps how many orders of each kind one run checks with the gateways
+const reconcileBatch = 200
+
+// reconcilerIP is recorded as the IP on audit entries written by scheduled runs
+const reconcilerIP = "payment_reconciler"
+
+// SevaPaymentReconciler settles seva bookings whose payment callback never
+// arrived. Implemented by the seva service; injected with SetSevaReconciler.
+type SevaPaymentReconciler interface {
+	ReconcileStalePayments(ctx context.Context, createdBefore, abandonBefore time.Time, limit int) ([]payment.ReconcileResult, error)
+}
+
+func (s *service) SetSevaReconciler(r SevaPaymentReconciler) {
+	s.sevaReconciler = r
+}
+
+// ReconcileStalePayments asks the gateway about every PENDING donation and
+// unpaid seva booking older than the configured age. Paid orders go through
+// the same path as the payment.captured webhook, failed ones through the
+// payment.failed path; orders still unpaid after the abandon age are failed.
+func (s *service) ReconcileStalePayments(ctx context.Context, triggeredBy *uint, ip string) (*ReconcileReport, error) {
+	now := time.Now()
+	createdBefore := now.Add(-time.Duration(s.cfg.ReconcileMinAgeMinutes) * time.Minute)
+	abandonBefore := now.Add(-time.Duration(s.cfg.ReconcileAbandonHours) * time.Hour)
+	report := &ReconcileReport{StartedAt: now, Results: []payment.ReconcileResult{}}
+
+	donations, err := s.repo.ListStalePending(ctx, createdBefore, reconcileBatch)
+	if err != nil {
+		s.auditSvc.LogAction(ctx, triggeredBy, nil, "PAYMENT_RECONCILIATION_FAILED",
+			map[string]interface{}{"error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+	for i := range donations {
+		report.Results = append(report.Results, s.reconcileDonation(ctx, &donations[i], abandonBefore))
+	}
+
+	if s.sevaReconciler != nil {
+		results, err := s.sevaReconciler.ReconcileStalePayments(ctx, createdBefore, abandonBefore, reconcileBatch)
+		if err != nil {
+			log.Printf("❌ Reconciler: could not list stale seva bookings: %v", err)
+		}
+		report.Results = append(report.Results, results...)
+	}
+
+	for _, r := range report.Results {
+		report.Scanned++
+		switch r.Outcome {
+		case payment.OrderStatusPaid:
+			report.Succeeded++
+		case payment.OrderStatusFailed:
+			report.Failed++
+		case payment.OrderStatusPending:
+			report.StillPending++
+		case payment.ReconcileOutcomeError:
+			report.Errors++
+		}
+		if r.Outcome != payment.OrderStatusPaid && r.Outcome != payment.OrderStatusFailed {
+			continue
+		}
+		userID, entityID := r.UserID, r.EntityID
+		s.auditSvc.LogAction(ctx, &userID, &entityID, "PAYMENT_RECONCILED", map[string]interface{}{
+			"source":     r.Source,
+			"record_id":  r.RecordID,
+			"order_id":   r.OrderID,
+			"payment_id": r.PaymentID,
+			"outcome":    r.Outcome,
+			"abandoned":  r.Abandoned,
+		}, ip, "success")
+	}
+	report.FinishedAt = time.Now()
+
+	s.auditSvc.LogAction(ctx, triggeredBy, nil, "PAYMENT_RECONCILIATION_COMPLETED", map[string]interface{}{
+		"scanned":       report.Scanned,
+		"succeeded":     report.Succeeded,
+		"failed":        report.Failed,
+		"still_pending": report.StillPending,
+		"errors":        report.Errors,
+		"duration_ms":   report.FinishedAt.Sub(report.StartedAt).Milliseconds(),
+	}, ip, "success")
+	return report, nil
+}
+
+func (s *service) reconcileDonation(ctx context.Context, d *Donation, abandonBefore time.Time) payment.ReconcileResult {
+	result := payment.ReconcileResult{
+		Source:   payment.SourceDonation,
+		RecordID: d.ID,
+		EntityID: d.EntityID,
+		UserID:   d.UserID,
+		OrderID:  d.OrderID,
+	}
+	fail := func(err error) payment.ReconcileResult {
+		log.Printf("❌ Reconciler: donation=%d order=%s: %v", d.ID, d.OrderID, err)
+		result.Outcome, result.Error = payment.ReconcileOutcomeError, err.Error()
+		return result
+	}
+
+	provider, _, err := s.accountProvider(ctx, d.EntityID, d.IsForeignContribution)
+	if err != nil {
+		return fail(err)
+	}
+	status, abandoned, err := payment.ResolveStaleOrder(ctx, provider, d.OrderID, d.CreatedAt, abandonBefore)
+	if err != nil {
+		return fail(err)
+	}
+	result.Outcome, result.Abandoned = status.Status, abandoned
+
+	switch status.Status {
+	case payment.OrderStatusPaid:
+		result.PaymentID = status.Payment.ID
+		err = s.HandlePaymentCapturedWebhook(d.OrderID, status.Payment.ID, status.Payment.Method, status.Payment.Amount)
+	case payment.OrderStatusFailed:
+		err = s.HandleFailedPaymentWebhook(d.OrderID, "")
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return result
+}
+
+// StartPaymentReconciler checks stale pending payments with the gateways
+// every interval until the process exits
+func StartPaymentReconciler(svc Service, interval time.Duration) {
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	go func() {
+		log.Printf("🔄 Payment reconciler started (every %s)", interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			report, err := svc.ReconcileStalePayments(context.Background(), nil, reconcilerIP)
+			if err != nil {
+				log.Printf("❌ Payment reconciler: %v", err)
+			} else if report.Scanned > 0 {
+				log.Printf("🔄 Payment reconciler: scanned=%d succeeded=%d failed=%d pending=%d errors=%d",
+					report.Scanned, report.Succeeded, report.Failed, report.StillPending, report.Errors)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,371 @@
+package donation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/payment"
+)
+
+// maxRecurringFailures pauses a recurring donation after this many failed attempts in a row
+const maxRecurringFailures = 3
+
+// ==============================
+// Subscription Gateway
+// ==============================
+
+// SubscriptionGateway raises the payment for one occurrence of a recurring
+// donation and returns the gateway order ID. The resulting PENDING donation is
+// completed by VerifyAndUpdateDonation or the payment.captured webhook.
+// provider is the tenant's payment provider, nil when none is configured.
+type SubscriptionGateway interface {
+	CreateOccurrenceOrder(ctx context.Context, rd *RecurringDonation, provider payment.Provider) (string, error)
+}
+
+// NewSubscriptionGateway returns the gateway for the configured mode ("fake" or the tenant's provider)
+func NewSubscriptionGateway(mode string) SubscriptionGateway {
+	if mode == "fake" {
+		return &FakeSubscriptionGateway{}
+	}
+	return &providerSubscriptionGateway{}
+}
+
+// providerSubscriptionGateway creates each occurrence's order with the tenant's provider
+type providerSubscriptionGateway struct{}
+
+func (g *providerSubscriptionGateway) CreateOccurrenceOrder(ctx context.Context, rd *RecurringDonation, provider payment.Provider) (string, error) {
+	if provider == nil {
+		return "", payment.ErrNotConfigured
+	}
+
+	notes := map[string]interface{}{
+		"user_id":               rd.UserID,
+		"entity_id":             rd.EntityID,
+		"donation_type":         rd.DonationType,
+		"recurring_donation_id": rd.ID,
+	}
+	if rd.ReferenceID != nil {
+		notes["reference_id"] = *rd.ReferenceID
+	}
+
+	order, err := provider.CreateOrder(ctx, payment.OrderRequest{
+		Amount:     rd.Amount,
+		Currency:   "INR",
+		CustomerID: strconv.FormatUint(uint64(rd.UserID), 10),
+		Notes:      notes,
+	})
+	if err != nil {
+		return "", err
+	}
+	return order.ID, nil
+}
+
+// FakeSubscriptionGateway issues local order IDs without calling any gateway.
+// Use SUBSCRIPTION_GATEWAY=fake for local development.
+type FakeSubscriptionGateway struct{}
+
+func (g *FakeSubscriptionGateway) CreateOccurrenceOrder(ctx context.Context, rd *RecurringDonation, provider payment.Provider) (string, error) {
+	return fmt.Sprintf("order_fake_%d_%d", rd.ID, time.Now().UnixNano()), nil
+}
+
+// ==============================
+// Schedule helpers
+// ==============================
+
+// frequencyMonths returns the number of months between two occurrences
+func frequencyMonths(frequency string) int {
+	switch frequency {
+	case FrequencyQuarterly:
+		return 3
+	case FrequencyAnnual:
+		return 12
+	default:
+		return 1
+	}
+}
+
+// occurrenceDate returns the n-th (0-based) occurrence of a schedule anchored
+// at start. The day of month is kept, clamped to the last day of short months.
+func occurrenceDate(start time.Time, frequency string, n int) time.Time {
+	firstOfMonth := time.Date(start.Year(), start.Month(), 1, start.Hour(), start.Minute(), 0, 0, start.Location())
+	target := firstOfMonth.AddDate(0, n*frequencyMonths(frequency), 0)
+	lastDay := target.AddDate(0, 1, -1).Day()
+	day := start.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(target.Year(), target.Month(), day, start.Hour(), start.Minute(), 0, 0, start.Location())
+}
+
+// nextOccurrenceAfter returns the first occurrence strictly after t
+func nextOccurrenceAfter(start time.Time, frequency string, t time.Time) time.Time {
+	for n := 0; ; n++ {
+		if d := occurrenceDate(start, frequency, n); d.After(t) {
+			return d
+		}
+	}
+}
+
+// ==============================
+// Recurring Donation Service
+// ==============================
+
+func (s *service) SetSubscriptionGateway(g SubscriptionGateway) {
+	s.subGateway = g
+}
+
+func (s *service) CreateRecurringDonation(req CreateRecurringDonationRequest) (*RecurringDonation, error) {
+	ctx := context.Background()
+
+	dedication, err := req.DonationDedication.normalized()
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := req.DonorIdentity.normalized()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	start := now
+	if req.StartDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.StartDate, now.Location())
+		if err != nil {
+			return nil, errors.New("invalid startDate, expected YYYY-MM-DD")
+		}
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		if parsed.Before(today) {
+			return nil, errors.New("startDate cannot be in the past")
+		}
+		if parsed.After(today) {
+			start = parsed
+		}
+	}
+
+	rd := &RecurringDonation{
+		UserID:       req.UserID,
+		EntityID:     req.EntityID,
+		Amount:       req.Amount,
+		DonationType: req.DonationType,
+		ReferenceID:  req.ReferenceID,
+		Note:         req.Note,
+		DonorPAN:     identity.DonorPAN,
+		DonorAadhaar: identity.DonorAadhaar,
+		DonorAddress: identity.DonorAddress,
+		Frequency:    req.Frequency,
+		Status:       RecurringActive,
+		StartDate:    start,
+		NextChargeAt: start,
+
+		IsAnonymous:    dedication.IsAnonymous,
+		DedicationType: dedication.DedicationType,
+		OnBehalfOf:     dedication.OnBehalfOf,
+	}
+	if err := s.repo.CreateRecurring(ctx, rd); err != nil {
+		s.auditSvc.LogAction(ctx, &req.UserID, &req.EntityID, "RECURRING_DONATION_CREATED",
+			map[string]interface{}{"amount": req.Amount, "frequency": req.Frequency, "error": err.Error()},
+			req.IPAddress, "failure")
+		return nil, fmt.Errorf("failed to create recurring donation: %w", err)
+	}
+
+	s.auditSvc.LogAction(ctx, &req.UserID, &req.EntityID, "RECURRING_DONATION_CREATED",
+		map[string]interface{}{
+			"recurring_donation_id": rd.ID,
+			"amount":                rd.Amount,
+			"frequency":             rd.Frequency,
+			"next_charge_at":        rd.NextChargeAt,
+		}, req.IPAddress, "success")
+
+	return rd, nil
+}
+
+func (s *service) GetMyRecurringDonations(userID uint, entityID uint) ([]RecurringDonation, error) {
+	return s.repo.ListRecurringByUser(context.Background(), userID, entityID)
+}
+
+// UpdateRecurringDonationStatus lets the owning devotee pause, resume or cancel.
+// Cancelled is final; resuming skips any periods missed while paused.
+func (s *service) UpdateRecurringDonationStatus(id uint, userID uint, status string, ip string) (*RecurringDonation, error) {
+	ctx := context.Background()
+
+	rd, err := s.repo.GetRecurringByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("recurring donation not found")
+	}
+	if rd.UserID != userID {
+		return nil, errors.New("unauthorized to modify this recurring donation")
+	}
+	if rd.Status == RecurringCancelled {
+		return nil, errors.New("recurring donation is already cancelled")
+	}
+	if rd.Status == status {
+		return rd, nil
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": status}
+	action := ""
+
+	switch status {
+	case RecurringPaused:
+		updates["paused_at"] = now
+		action = "RECURRING_DONATION_PAUSED"
+	case RecurringActive:
+		if rd.NextChargeAt.Before(now) {
+			updates["next_charge_at"] = nextOccurrenceAfter(rd.StartDate, rd.Frequency, now)
+		}
+		updates["paused_at"] = nil
+		updates["failure_count"] = 0
+		updates["last_error"] = ""
+		action = "RECURRING_DONATION_RESUMED"
+	case RecurringCancelled:
+		updates["cancelled_at"] = now
+		action = "RECURRING_DONATION_CANCELLED"
+	default:
+		return nil, errors.New("invalid status")
+	}
+
+	if err := s.repo.UpdateRecurring(ctx, rd.ID, updates); err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &rd.EntityID, action,
+			map[string]interface{}{"recurring_donation_id": rd.ID, "error": err.Error()}, ip, "failure")
+		return nil, fmt.Errorf("failed to update recurring donation: %w", err)
+	}
+
+	s.auditSvc.LogAction(ctx, &userID, &rd.EntityID, action,
+		map[string]interface{}{"recurring_donation_id": rd.ID, "previous_status": rd.Status}, ip, "success")
+
+	return s.repo.GetRecurringByID(ctx, rd.ID)
+}
+
+// ProcessDueRecurringDonations raises a PENDING donation for every active
+// recurring donation whose next charge date has passed. Returns how many were raised.
+func (s *service) ProcessDueRecurringDonations(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := s.repo.ListDueRecurring(ctx, now, 200)
+	if err != nil {
+		return 0, err
+	}
+
+	raised := 0
+	for i := range due {
+		if err := s.raiseRecurringOccurrence(ctx, &due[i], now); err != nil {
+			log.Printf("❌ Recurring donation=%d: %v", due[i].ID, err)
+			continue
+		}
+		raised++
+	}
+	return raised, nil
+}
+
+func (s *service) raiseRecurringOccurrence(ctx context.Context, rd *RecurringDonation, now time.Time) error {
+	// ── Claim this occurrence so no other instance raises it ─────────────
+	dueAt := rd.NextChargeAt
+	next := nextOccurrenceAfter(rd.StartDate, rd.Frequency, now)
+	claimed, err := s.repo.ClaimRecurringOccurrence(ctx, rd.ID, dueAt, next)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	// ── Create the gateway order ─────────────────────────────────────────
+	provider, _, _ := s.paymentProvider(ctx, rd.EntityID) // nil when the temple has no gateway
+	orderID, err := s.subGateway.CreateOccurrenceOrder(ctx, rd, provider)
+	if err == nil {
+		err = s.repo.Create(ctx, &Donation{
+			UserID:              rd.UserID,
+			EntityID:            rd.EntityID,
+			Amount:              rd.Amount,
+			DonationType:        rd.DonationType,
+			ReferenceID:         rd.ReferenceID,
+			Method:              " ",
+			Status:              StatusPending,
+			OrderID:             orderID,
+			Note:                rd.Note,
+			DonorPAN:            rd.DonorPAN,
+			DonorAadhaar:        rd.DonorAadhaar,
+			DonorAddress:        rd.DonorAddress,
+			IsAnonymous:         rd.IsAnonymous,
+			DedicationType:      rd.DedicationType,
+			OnBehalfOf:          rd.OnBehalfOf,
+			RecurringDonationID: &rd.ID,
+		})
+	}
+
+	if err != nil {
+		// Put the occurrence back so the next run retries it, pausing after repeated failures
+		updates := map[string]interface{}{
+			"next_charge_at": dueAt,
+			"charge_count":   rd.ChargeCount,
+			"failure_count":  rd.FailureCount + 1,
+			"last_error":     err.Error(),
+		}
+		if rd.FailureCount+1 >= maxRecurringFailures {
+			updates["status"] = RecurringPaused
+			updates["paused_at"] = now
+		}
+		_ = s.repo.UpdateRecurring(ctx, rd.ID, updates)
+
+		s.auditSvc.LogAction(ctx, &rd.UserID, &rd.EntityID, "RECURRING_DONATION_OCCURRENCE",
+			map[string]interface{}{"recurring_donation_id": rd.ID, "due_at": dueAt, "error": err.Error()},
+			"recurring_scheduler", "failure")
+		return err
+	}
+
+	_ = s.repo.UpdateRecurring(ctx, rd.ID, map[string]interface{}{
+		"last_charged_at": now,
+		"failure_count":   0,
+		"last_error":      "",
+	})
+
+	s.auditSvc.LogAction(ctx, &rd.UserID, &rd.EntityID, "RECURRING_DONATION_OCCURRENCE",
+		map[string]interface{}{
+			"recurring_donation_id": rd.ID,
+			"order_id":              orderID,
+			"amount":                rd.Amount,
+			"due_at":                dueAt,
+			"next_charge_at":        next,
+		}, "recurring_scheduler", "success")
+
+	if s.notifSvc != nil {
+		_ = s.notifSvc.CreateInAppNotification(ctx, rd.UserID, rd.EntityID,
+			"Recurring donation due",
+			fmt.Sprintf("Your %s donation of ₹%.2f is due. Please complete the payment.", rd.Frequency, rd.Amount),
+			"donation")
+	}
+
+	log.Printf("✅ Recurring donation=%d raised order=%s next=%s", rd.ID, orderID, next.Format("2006-01-02"))
+	return nil
+}
+
+// ==============================
+// Scheduler
+// ==============================
+
+// StartRecurringScheduler launches the background worker that raises due recurring donations
+func StartRecurringScheduler(svc Service, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	go func() {
+		log.Printf("🔁 Recurring donation scheduler started (every %s)", interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			raised, err := svc.ProcessDueRecurringDonations(context.Background())
+			if err != nil {
+				log.Printf("❌ Recurring donation scheduler: %v", err)
+			} else if raised > 0 {
+				log.Printf("🔁 Recurring donation scheduler raised %d donation(s)", raised)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,264 @@
+package donation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/sharath018/temple-management-backend/internal/payment"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// ==============================
+// Refunds
+// ==============================
+
+// InitiateRefund raises a full or partial refund for a paid donation or seva
+// booking and submits it to the gateway. The refunded amount is applied to the
+// source once the gateway reports the refund as processed.
+func (s *service) InitiateRefund(req InitiateRefundRequest, accessContext middleware.AccessContext) (*Refund, error) {
+	ctx := context.Background()
+
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+
+	src, err := s.repo.GetRefundSource(ctx, req.SourceType, req.SourceID)
+	if err != nil {
+		return nil, fmt.Errorf("%s %d not found", req.SourceType, req.SourceID)
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != src.EntityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+	return s.raiseRefund(ctx, req, src)
+}
+
+// RefundSevaBooking refunds amount of a paid seva booking the devotee cancelled,
+// as worked out by the seva's cancellation policy. Implements the seva
+// package's BookingRefunder; returns the refund's id and status.
+func (s *service) RefundSevaBooking(ctx context.Context, bookingID uint, amount float64, reason string, initiatedBy uint, ip string) (uint, string, error) {
+	if amount <= 0 {
+		return 0, "", errors.New("refund amount must be positive")
+	}
+	src, err := s.repo.GetRefundSource(ctx, RefundSourceSevaBooking, bookingID)
+	if err != nil {
+		return 0, "", fmt.Errorf("%s %d not found", RefundSourceSevaBooking, bookingID)
+	}
+	refund, err := s.raiseRefund(ctx, InitiateRefundRequest{
+		SourceType:  RefundSourceSevaBooking,
+		SourceID:    bookingID,
+		Amount:      amount,
+		Reason:      reason,
+		InitiatedBy: initiatedBy,
+		IPAddress:   ip,
+	}, src)
+	if err != nil {
+		return 0, "", err
+	}
+	return refund.ID, refund.Status, nil
+}
+
+// raiseRefund records the refund of a source the caller may refund and
+// submits it to the gateway
+func (s *service) raiseRefund(ctx context.Context, req InitiateRefundRequest, src *RefundSource) (*Refund, error) {
+	if !src.Paid || src.PaymentID == "" {
+		return nil, errors.New("only paid records with a gateway payment can be refunded")
+	}
+
+	refund := &Refund{
+		EntityID:         src.EntityID,
+		SourceType:       req.SourceType,
+		SourceID:         req.SourceID,
+		UserID:           src.UserID,
+		Amount:           req.Amount,
+		Reason:           req.Reason,
+		InitiatedBy:      req.InitiatedBy,
+		GatewayOrderID:   src.OrderID,
+		GatewayPaymentID: src.PaymentID,
+	}
+	if err := s.repo.CreateRefund(ctx, refund, src.Amount); err != nil {
+		s.auditSvc.LogAction(ctx, &req.InitiatedBy, &src.EntityID, "REFUND_INITIATED",
+			map[string]interface{}{"source_type": req.SourceType, "source_id": req.SourceID, "amount": req.Amount, "error": err.Error()},
+			req.IPAddress, "failure")
+		return nil, err
+	}
+
+	// ── Submit to gateway ────────────────────────────────────────────────
+	gatewayRefundID, gatewayStatus, err := s.createGatewayRefund(ctx, refund)
+	if err != nil {
+		_, _ = s.repo.MarkRefundFailed(ctx, refund.ID, err.Error())
+		s.auditSvc.LogAction(ctx, &req.InitiatedBy, &src.EntityID, "REFUND_INITIATED",
+			map[string]interface{}{"refund_id": refund.ID, "source_type": req.SourceType, "source_id": req.SourceID, "amount": refund.Amount, "error": err.Error()},
+			req.IPAddress, "failure")
+		return nil, fmt.Errorf("gateway refund failed: %w", err)
+	}
+	if err := s.repo.SetRefundGatewayID(ctx, refund.ID, gatewayRefundID); err != nil {
+		log.Printf("⚠️ Could not store gateway refund id=%s for refund=%d: %v", gatewayRefundID, refund.ID, err)
+	}
+
+	// Gateways may process instant refunds synchronously
+	if gatewayStatus == payment.RefundStatusProcessed {
+		if _, err := s.repo.MarkRefundProcessed(ctx, refund.ID, gatewayRefundID); err != nil {
+			log.Printf("❌ Could not apply processed refund=%d: %v", refund.ID, err)
+		} else if refund.SourceType == RefundSourceDonation {
+			s.settleDonationPledge(ctx, refund.SourceID)
+		}
+	}
+
+	s.auditSvc.LogAction(ctx, &req.InitiatedBy, &src.EntityID, "REFUND_INITIATED",
+		map[string]interface{}{
+			"refund_id":         refund.ID,
+			"source_type":       refund.SourceType,
+			"source_id":         refund.SourceID,
+			"amount":            refund.Amount,
+			"is_partial":        refund.IsPartial,
+			"reason":            refund.Reason,
+			"gateway_refund_id": gatewayRefundID,
+		}, req.IPAddress, "success")
+
+	log.Printf("✅ Refund=%d initiated for %s=%d amount=%.2f gateway_refund=%s",
+		refund.ID, refund.SourceType, refund.SourceID, refund.Amount, gatewayRefundID)
+	return s.repo.GetRefundByID(ctx, refund.ID)
+}
+
+// createGatewayRefund submits the refund through the tenant's payment provider.
+// Foreign-currency payments are refunded in their own currency at the rate
+// they were captured at, from the account they were paid into.
+func (s *service) createGatewayRefund(ctx context.Context, refund *Refund) (string, string, error) {
+	src, err := s.repo.GetRefundSource(ctx, refund.SourceType, refund.SourceID)
+	if err != nil {
+		return "", "", err
+	}
+	provider, _, err := s.accountProvider(ctx, refund.EntityID, src.IsForeignContribution)
+	if err != nil {
+		return "", "", err
+	}
+
+	amount := refund.Amount
+	if src.Currency != payment.CurrencyINR && src.ExchangeRate > 0 {
+		amount = utils.RoundMoney(refund.Amount / src.ExchangeRate)
+	}
+	res, err := provider.Refund(ctx, payment.RefundRequest{
+		OrderID:     refund.GatewayOrderID,
+		PaymentID:   refund.GatewayPaymentID,
+		Amount:      amount,
+		ReferenceID: strconv.FormatUint(uint64(refund.ID), 10),
+		Reason:      refund.Reason,
+		Notes: map[string]interface{}{
+			"source_type": refund.SourceType,
+			"source_id":   refund.SourceID,
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return res.ID, res.Status, nil
+}
+
+// HandleRefundWebhook applies gateway refund.processed / refund.failed events
+// verified with tenantID's credentials. Refunds raised from the gateway
+// dashboard are recorded on first sight.
+func (s *service) HandleRefundWebhook(tenantID uint, gatewayRefundID, paymentID string, localRefundID uint, amount float64, processed bool, reason string) error {
+	ctx := context.Background()
+
+	refund, err := s.repo.GetRefundByGatewayID(ctx, gatewayRefundID)
+	if err != nil && localRefundID != 0 {
+		// Webhook can arrive before the gateway refund id is stored. Our refund
+		// id is only a sequence number, so it must name a refund of this very
+		// payment at the temple that signed the event.
+		refund, err = s.repo.GetRefundByID(ctx, localRefundID)
+		if err == nil && (paymentID == "" || refund.GatewayPaymentID != paymentID || refund.EntityID != tenantID) {
+			log.Printf("⚠️ Webhook(Refund): reference %d is not a refund of payment=%s at entity=%d", localRefundID, paymentID, tenantID)
+			refund, err = nil, errors.New("refund reference does not match the payment")
+		}
+	}
+	if err != nil {
+		refund, err = s.recordExternalRefund(ctx, gatewayRefundID, paymentID, amount)
+		if err != nil {
+			log.Printf("❌ Webhook(Refund): no refund or payment found for refund=%s payment=%s: %v", gatewayRefundID, paymentID, err)
+			return err
+		}
+	}
+
+	action := "REFUND_PROCESSED_WEBHOOK"
+	var applied bool
+	if processed {
+		applied, err = s.repo.MarkRefundProcessed(ctx, refund.ID, gatewayRefundID)
+	} else {
+		action = "REFUND_FAILED_WEBHOOK"
+		applied, err = s.repo.MarkRefundFailed(ctx, refund.ID, reason)
+	}
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &refund.UserID, &refund.EntityID, action,
+			map[string]interface{}{"refund_id": refund.ID, "gateway_refund_id": gatewayRefundID, "error": err.Error()},
+			"payment_webhook", "failure")
+		return err
+	}
+	if !applied {
+		log.Printf("⚠️ Webhook(Refund): refund=%d already %s", refund.ID, refund.Status)
+		return nil
+	}
+	if processed && refund.SourceType == RefundSourceDonation {
+		s.settleDonationPledge(ctx, refund.SourceID)
+	}
+
+	s.auditSvc.LogAction(ctx, &refund.UserID, &refund.EntityID, action,
+		map[string]interface{}{
+			"refund_id":         refund.ID,
+			"gateway_refund_id": gatewayRefundID,
+			"source_type":       refund.SourceType,
+			"source_id":         refund.SourceID,
+			"amount":            refund.Amount,
+			"reason":            reason,
+		}, "payment_webhook", "success")
+	log.Printf("✅ Webhook(Refund): refund=%d %s", refund.ID, action)
+	return nil
+}
+
+// recordExternalRefund stores a refund that was created outside this system
+func (s *service) recordExternalRefund(ctx context.Context, gatewayRefundID, paymentID string, amount float64) (*Refund, error) {
+	if paymentID == "" {
+		return nil, errors.New("missing payment id")
+	}
+	sourceType, sourceID, err := s.repo.FindRefundSourceByPaymentID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	src, err := s.repo.GetRefundSource(ctx, sourceType, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	// The gateway reports the amount in the payment's currency
+	if src.Currency != payment.CurrencyINR && src.ExchangeRate > 0 {
+		amount = utils.RoundMoney(amount * src.ExchangeRate)
+	}
+	refund := &Refund{
+		EntityID:         src.EntityID,
+		SourceType:       sourceType,
+		SourceID:         sourceID,
+		UserID:           src.UserID,
+		Amount:           amount,
+		Reason:           "Refund raised from payment gateway dashboard",
+		GatewayPaymentID: paymentID,
+		GatewayRefundID:  &gatewayRefundID,
+	}
+	if err := s.repo.CreateRefund(ctx, refund, src.Amount); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func (s *service) ListRefunds(filters RefundFilters, accessContext middleware.AccessContext) ([]Refund, int, error) {
+	if !accessContext.CanRead() {
+		return nil, 0, errors.New("read access denied")
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != filters.EntityID {
+		return nil, 0, errors.New("access denied to requested entity")
+	}
+	return s.repo.ListRefunds(context.Background(), filters)
+}
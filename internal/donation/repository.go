@@ -2,9 +2,13 @@ package donation
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/sharath018/temple-management-backend/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
@@ -13,6 +17,94 @@ type Repository interface {
 	GetByOrderID(ctx context.Context, orderID string) (*Donation, error)
 	GetByIDWithUser(ctx context.Context, donationID uint) (*DonationWithUser, error)
 	UpdatePaymentDetails(ctx context.Context, orderID string, params UpdatePaymentDetailsParams) error
+	ListStalePending(ctx context.Context, createdBefore time.Time, limit int) ([]Donation, error)
+
+	// Receipts
+	AssignReceiptNumber(ctx context.Context, donationID uint) (*Donation, error)
+	GetReceiptEntityDetails(ctx context.Context, entityID uint) (*ReceiptEntityDetails, error)
+
+	// Offline donations
+	GetByID(ctx context.Context, id uint) (*Donation, error)
+	UpdateClearance(ctx context.Context, id uint, updates map[string]interface{}) error
+	UserExists(ctx context.Context, userID uint) (bool, error)
+	GetUserContact(ctx context.Context, userID uint) (*UserContact, error)
+	FindEntityByOrderID(ctx context.Context, orderID string) (uint, error)
+
+	// Refunds
+	GetRefundSource(ctx context.Context, sourceType string, sourceID uint) (*RefundSource, error)
+	FindRefundSourceByPaymentID(ctx context.Context, paymentID string) (string, uint, error)
+	CreateRefund(ctx context.Context, refund *Refund, paidAmount float64) error
+	GetRefundByID(ctx context.Context, id uint) (*Refund, error)
+	GetRefundByGatewayID(ctx context.Context, gatewayRefundID string) (*Refund, error)
+	SetRefundGatewayID(ctx context.Context, id uint, gatewayRefundID string) error
+	MarkRefundProcessed(ctx context.Context, id uint, gatewayRefundID string) (bool, error)
+	MarkRefundFailed(ctx context.Context, id uint, reason string) (bool, error)
+	ListRefunds(ctx context.Context, filters RefundFilters) ([]Refund, int, error)
+
+	// Payment webhook events
+	CreateWebhookEvent(ctx context.Context, event *PaymentWebhookEvent) (bool, error)
+	GetWebhookEventByID(ctx context.Context, id uint) (*PaymentWebhookEvent, error)
+	GetWebhookEventByEventID(ctx context.Context, provider, eventID string) (*PaymentWebhookEvent, error)
+	ClaimWebhookEvent(ctx context.Context, id uint, staleBefore time.Time) (bool, error)
+	UpdateWebhookEvent(ctx context.Context, id uint, updates map[string]interface{}) error
+	ListWebhookEvents(ctx context.Context, filters WebhookEventFilters) ([]PaymentWebhookEvent, int, error)
+	ListDueWebhookEvents(ctx context.Context, now, staleBefore time.Time, limit int) ([]PaymentWebhookEvent, error)
+
+	// Campaigns
+	CreateCampaign(ctx context.Context, campaign *Campaign) error
+	GetCampaignByID(ctx context.Context, id uint) (*Campaign, error)
+	UpdateCampaign(ctx context.Context, id uint, updates map[string]interface{}) error
+	ListCampaigns(ctx context.Context, entityID uint) ([]Campaign, error)
+	GetCampaignTotals(ctx context.Context, campaignID uint) (*CampaignTotals, error)
+	GetCampaignRecentDonors(ctx context.Context, campaignID uint, limit int) ([]CampaignDonor, error)
+
+	// In-kind donations
+	CreateInKind(ctx context.Context, donation *InKindDonation) error
+	GetInKindByIDWithUser(ctx context.Context, id uint) (*InKindDonationWithUser, error)
+	ListInKind(ctx context.Context, filters InKindFilters) ([]InKindDonationWithUser, int, error)
+
+	// Recurring donations
+	CreateRecurring(ctx context.Context, rd *RecurringDonation) error
+	GetRecurringByID(ctx context.Context, id uint) (*RecurringDonation, error)
+	ListRecurringByUser(ctx context.Context, userID uint, entityID uint) ([]RecurringDonation, error)
+	ListDueRecurring(ctx context.Context, now time.Time, limit int) ([]RecurringDonation, error)
+	ClaimRecurringOccurrence(ctx context.Context, id uint, dueAt, nextChargeAt time.Time) (bool, error)
+	UpdateRecurring(ctx context.Context, id uint, updates map[string]interface{}) error
+
+	// Pledges
+	CreatePledge(ctx context.Context, pledge *Pledge) error
+	GetPledgeByID(ctx context.Context, id uint) (*Pledge, error)
+	GetPledgeWithBalance(ctx context.Context, id uint, now time.Time) (*PledgeWithBalance, error)
+	ListPledges(ctx context.Context, filters PledgeFilters, now time.Time) ([]PledgeWithBalance, int, error)
+	ListPledgeDonations(ctx context.Context, pledgeID uint) ([]DonationWithUser, error)
+	UpdatePledge(ctx context.Context, id uint, updates map[string]interface{}) error
+	LinkDonationToPledge(ctx context.Context, donationID, pledgeID uint) (bool, error)
+	SettlePledge(ctx context.Context, pledgeID uint, now time.Time) (*Pledge, bool, error)
+	ListOverdueInstalments(ctx context.Context, dueBefore, remindBefore time.Time, maxReminders, limit int) ([]OverdueInstalment, error)
+	ClaimInstalmentReminder(ctx context.Context, id uint, remindBefore, now time.Time) (bool, error)
+
+	// UPI payment links
+	CreatePaymentLink(ctx context.Context, link *PaymentLink) error
+	GetPaymentLinkByID(ctx context.Context, id uint) (*PaymentLink, error)
+	GetPaymentLinkByCode(ctx context.Context, code string) (*PaymentLink, error)
+	ListPaymentLinks(ctx context.Context, filters PaymentLinkFilters) ([]PaymentLinkView, error)
+	UpdatePaymentLink(ctx context.Context, id uint, updates map[string]interface{}) error
+	GetLinkSeva(ctx context.Context, sevaID uint) (*LinkSeva, error)
+	IsUTRRecorded(ctx context.Context, entityID uint, utr string) (bool, error)
+	CompleteLinkDonation(ctx context.Context, id uint, updates map[string]interface{}) (bool, error)
+
+	// Annual donor statements
+	ListStatementEntities(ctx context.Context, from, to time.Time) ([]uint, error)
+	ListStatementTotals(ctx context.Context, entityID uint, from, to time.Time) ([]DonorStatementTotal, error)
+	ListStatementDonations(ctx context.Context, entityID, userID uint, from, to time.Time) ([]DonationWithUser, error)
+	SaveDonorStatement(ctx context.Context, statement *DonorStatement) error
+	GetDonorStatement(ctx context.Context, entityID, userID uint, financialYear string) (*DonorStatement, error)
+	UpdateDonorStatement(ctx context.Context, id uint, updates map[string]interface{}) error
+	ListDonorStatements(ctx context.Context, filters DonorStatementFilters) ([]DonorStatementWithUser, int, error)
+
+	// Form 10BD / 10BE
+	ListForm10BDDonations(ctx context.Context, entityID uint, from, to time.Time) ([]Form10BDDonation, error)
+	CountForm10BDMissingIdentity(ctx context.Context, entityID uint, from, to time.Time) (int, error)
 
 	// Data retrieval with filtering
 	ListByUserID(ctx context.Context, userID uint) ([]DonationWithUser, error)
@@ -24,9 +116,10 @@ type Repository interface {
 	GetStatsInDateRange(ctx context.Context, entityID uint, from, to time.Time) (*StatsResult, error)
 	GetUniqueDonorCount(ctx context.Context, entityID uint) (int, error)
 	GetTopDonors(ctx context.Context, entityID uint, limit int) ([]TopDonor, error)
-	GetDonationTrends(ctx context.Context, entityID uint, days int) ([]TrendData, error)
-	GetDonationsByType(ctx context.Context, entityID uint) ([]TypeData, error)
-	GetDonationsByMethod(ctx context.Context, entityID uint) ([]MethodData, error)
+	GetDonationTrends(ctx context.Context, entityID uint, days int, campaignID uint) ([]TrendData, error)
+	GetDonationsByType(ctx context.Context, entityID uint, campaignID uint) ([]TypeData, error)
+	GetDonationsByMethod(ctx context.Context, entityID uint, campaignID uint) ([]MethodData, error)
+	GetDonationsByCampaign(ctx context.Context, entityID uint) ([]CampaignData, error)
 
 	// Recent donations
 	GetRecentDonationsByUser(ctx context.Context, userID uint, limit int) ([]RecentDonation, error)
@@ -61,20 +154,70 @@ func (r *repository) GetByOrderID(ctx context.Context, orderID string) (*Donatio
 	return &donation, nil
 }
 
+// ListStalePending returns online donations still PENDING that were created
+// before the cutoff. Offline donations never go through a gateway.
+func (r *repository) ListStalePending(ctx context.Context, createdBefore time.Time, limit int) ([]Donation, error) {
+	var list []Donation
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND is_offline = ? AND order_id <> '' AND created_at < ?", StatusPending, false, createdBefore).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
 const donationSelectFields = `
 	d.id, d.user_id, d.entity_id, d.amount, d.donation_type, d.reference_id,
+	d.campaign_id, COALESCE((SELECT dc.title FROM donation_campaigns dc WHERE dc.id = d.campaign_id), '') as campaign_title,
 	d.method, d.status, d.order_id, d.payment_id, d.note, d.donated_at,
+	d.recurring_donation_id, d.pledge_id, d.payment_link_id, COALESCE(d.refunded_amount, 0) as refunded_amount, d.receipt_number, COALESCE(d.financial_year, '') as financial_year, d.receipt_issued_at,
+	COALESCE(d.is_anonymous, false) as is_anonymous, COALESCE(d.dedication_type, '') as dedication_type, d.on_behalf_of,
+	CASE WHEN COALESCE(d.is_anonymous, false) THEN NULL ELSE d.donor_pan END as donor_pan,
+	CASE WHEN COALESCE(d.is_anonymous, false) OR NULLIF(d.donor_aadhaar, '') IS NULL THEN NULL
+		ELSE 'XXXX XXXX ' || RIGHT(d.donor_aadhaar, 4) END as donor_aadhaar,
+	CASE WHEN COALESCE(d.is_anonymous, false) THEN NULL ELSE d.donor_address END as donor_address,
+	COALESCE(d.currency, 'INR') as currency, COALESCE(d.original_amount, d.amount) as original_amount,
+	COALESCE(d.exchange_rate, 1) as exchange_rate, COALESCE(d.is_foreign_contribution, false) as is_foreign_contribution,
+	d.donor_country, d.donor_nationality,
 	d.created_at, d.updated_at,
 	COALESCE(d.account_holder_name, '') as account_holder_name,
 	COALESCE(d.account_number, '') as account_number,
 	COALESCE(d.account_type, '') as account_type,
 	COALESCE(d.ifsc_code, '') as ifsc_code,
 	COALESCE(d.upi_id, '') as upi_id,
-	COALESCE(NULLIF(u.full_name, ''), u.email, 'Anonymous') as user_name,
-	COALESCE(u.email, '') as user_email,
+	COALESCE(d.is_offline, false) as is_offline, d.recorded_by,
+	CASE WHEN COALESCE(d.is_anonymous, false) THEN NULL ELSE d.donor_phone END as donor_phone,
+	d.instrument_number, d.instrument_date, d.drawn_on_bank,
+	COALESCE(d.clearance_status, '') as clearance_status,
+	`+publicDonorNameExpr+` as user_name,
+	`+publicDonorEmailExpr+` as user_email,
+	`+donorNameExpr+` as payer_name,
+	COALESCE(u.email, '') as payer_email,
+	d.donor_pan as payer_pan,
 	COALESCE(e.name, '') as entity_name
 `
 
+// donorNameExpr prefers the walk-in donor name recorded at the counter,
+// then the registered devotee's name
+const donorNameExpr = `COALESCE(NULLIF(d.donor_name, ''), NULLIF(u.full_name, ''), u.email, 'Anonymous')`
+
+// donorKeyExpr identifies a donor across registered devotees and walk-ins
+const donorKeyExpr = `CASE WHEN d.user_id > 0 THEN 'u:' || d.user_id::text ELSE 'w:' || COALESCE(NULLIF(d.donor_phone, ''), NULLIF(d.donor_name, ''), d.id::text) END`
+
+// publicDonorNameExpr is the name shown in donor listings, including public
+// campaign pages. Anonymous gifts never reveal the donor; dedicated gifts show
+// the person named instead of the payer. A donor without a name is shown as
+// "Devotee", never by their email.
+const publicDonorNameExpr = `CASE WHEN COALESCE(d.is_anonymous, false) THEN 'Anonymous' ELSE COALESCE(NULLIF(d.on_behalf_of, ''), NULLIF(d.donor_name, ''), NULLIF(u.full_name, ''), 'Devotee') END`
+
+// publicDonorEmailExpr hides the payer's email wherever the payer is not the name shown
+const publicDonorEmailExpr = `CASE WHEN COALESCE(d.is_anonymous, false) OR NULLIF(d.on_behalf_of, '') IS NOT NULL THEN '' ELSE COALESCE(u.email, '') END`
+
+// publicDonorKeyExpr groups donations the way donor listings show them: each
+// anonymous gift on its own, and dedicated gifts under the person named
+const publicDonorKeyExpr = `CASE WHEN COALESCE(d.is_anonymous, false) THEN 'a:' || d.id::text ` +
+	`WHEN NULLIF(d.on_behalf_of, '') IS NOT NULL THEN 'b:' || LOWER(d.on_behalf_of) ELSE ` + donorKeyExpr + ` END`
+
 func (r *repository) GetByIDWithUser(ctx context.Context, donationID uint) (*DonationWithUser, error) {
 	var result DonationWithUser
 	err := r.db.WithContext(ctx).
@@ -110,12 +253,1181 @@ func (r *repository) UpdatePaymentDetails(ctx context.Context, orderID string, p
 	updates["ifsc_code"] = params.IFSCCode
 	updates["upi_id"] = params.UPIID // FIX: string now, no pointer needed
 
+	if params.ExchangeRate > 0 {
+		updates["exchange_rate"] = params.ExchangeRate
+		updates["original_amount"] = params.OriginalAmount
+	}
+
 	return r.db.WithContext(ctx).
 		Model(&Donation{}).
 		Where("order_id = ?", orderID).
 		Updates(updates).Error
 }
 
+// ==============================
+// Receipts
+// ==============================
+
+// AssignReceiptNumber stamps the donation with the next receipt number for its
+// entity and financial year. The donation row and the sequence row are locked
+// in one transaction, so concurrent calls never skip or reuse a number, and a
+// donation that already has a number keeps it.
+func (r *repository) AssignReceiptNumber(ctx context.Context, donationID uint) (*Donation, error) {
+	var donation Donation
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&donation, donationID).Error; err != nil {
+			return err
+		}
+		if donation.ReceiptNumber != nil && *donation.ReceiptNumber != "" {
+			return nil
+		}
+
+		issuedAt := donation.CreatedAt
+		if donation.DonatedAt != nil {
+			issuedAt = *donation.DonatedAt
+		}
+		fy := financialYearOf(issuedAt)
+
+		var next int64
+		if err := tx.Raw(`
+			INSERT INTO donation_receipt_sequences (entity_id, financial_year, last_number, updated_at)
+			VALUES (?, ?, 1, NOW())
+			ON CONFLICT (entity_id, financial_year)
+			DO UPDATE SET last_number = donation_receipt_sequences.last_number + 1, updated_at = NOW()
+			RETURNING last_number
+		`, donation.EntityID, fy).Scan(&next).Error; err != nil {
+			return err
+		}
+		if next == 0 {
+			return fmt.Errorf("failed to allocate receipt number for entity %d", donation.EntityID)
+		}
+
+		number := formatReceiptNumber(donation.EntityID, fy, next)
+		if err := tx.Model(&Donation{}).
+			Where("id = ?", donation.ID).
+			Updates(map[string]interface{}{
+				"receipt_number":    number,
+				"financial_year":    fy,
+				"receipt_issued_at": issuedAt,
+			}).Error; err != nil {
+			return err
+		}
+
+		donation.ReceiptNumber = &number
+		donation.FinancialYear = fy
+		donation.ReceiptIssuedAt = &issuedAt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &donation, nil
+}
+
+func (r *repository) GetReceiptEntityDetails(ctx context.Context, entityID uint) (*ReceiptEntityDetails, error) {
+	var details ReceiptEntityDetails
+	err := r.db.WithContext(ctx).
+		Table("entities").
+		Select(`
+			name,
+			COALESCE(street_address, '') as street_address,
+			COALESCE(city, '') as city,
+			COALESCE(district, '') as district,
+			COALESCE(state, '') as state,
+			COALESCE(pincode, '') as pincode,
+			COALESCE(phone, '') as phone,
+			COALESCE(email, '') as email,
+			COALESCE(media, '') as media,
+			COALESCE(registration_80g, '') as registration_80g,
+			COALESCE(trust_pan, '') as trust_pan
+		`).
+		Where("id = ?", entityID).
+		Take(&details).Error
+	if err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// ==============================
+// Offline Donations
+// ==============================
+
+func (r *repository) GetByID(ctx context.Context, id uint) (*Donation, error) {
+	var donation Donation
+	if err := r.db.WithContext(ctx).First(&donation, id).Error; err != nil {
+		return nil, err
+	}
+	return &donation, nil
+}
+
+// UpdateClearance updates a cheque / DD donation only while its clearance is still pending
+func (r *repository) UpdateClearance(ctx context.Context, id uint, updates map[string]interface{}) error {
+	res := r.db.WithContext(ctx).
+		Model(&Donation{}).
+		Where("id = ? AND clearance_status = ?", id, ClearancePending).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("donation is not awaiting clearance")
+	}
+	return nil
+}
+
+func (r *repository) UserExists(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("users").Where("id = ?", userID).Count(&count).Error
+	return count > 0, err
+}
+
+// GetUserContact returns the email and phone prefilled on the gateway checkout
+func (r *repository) GetUserContact(ctx context.Context, userID uint) (*UserContact, error) {
+	var contact UserContact
+	err := r.db.WithContext(ctx).Table("users").Select("email, phone").Where("id = ?", userID).Take(&contact).Error
+	if err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// FindEntityByOrderID returns the temple that owns a gateway order, whether it
+// belongs to a donation or a seva booking
+func (r *repository) FindEntityByOrderID(ctx context.Context, orderID string) (uint, error) {
+	var entityID uint
+	r.db.WithContext(ctx).Raw(`SELECT entity_id FROM donations WHERE order_id = ? AND deleted_at IS NULL LIMIT 1`, orderID).Scan(&entityID)
+	if entityID != 0 {
+		return entityID, nil
+	}
+	r.db.WithContext(ctx).Raw(`SELECT entity_id FROM seva_bookings WHERE razorpay_order_id = ? LIMIT 1`, orderID).Scan(&entityID)
+	if entityID != 0 {
+		return entityID, nil
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+// ==============================
+// Refunds
+// ==============================
+
+// refundSourceTable maps a refund source type to its table
+func refundSourceTable(sourceType string) (string, error) {
+	switch sourceType {
+	case RefundSourceDonation:
+		return "donations", nil
+	case RefundSourceSevaBooking:
+		return "seva_bookings", nil
+	}
+	return "", fmt.Errorf("unknown refund source %q", sourceType)
+}
+
+func (r *repository) GetRefundSource(ctx context.Context, sourceType string, sourceID uint) (*RefundSource, error) {
+	var src RefundSource
+	var err error
+	switch sourceType {
+	case RefundSourceDonation:
+		err = r.db.WithContext(ctx).Raw(`
+			SELECT entity_id, user_id, amount, order_id, COALESCE(payment_id, '') AS payment_id,
+			       UPPER(status) IN ('SUCCESS', 'REFUNDED') AS paid,
+			       COALESCE(currency, 'INR') AS currency, COALESCE(exchange_rate, 1) AS exchange_rate,
+			       COALESCE(is_foreign_contribution, false) AS is_foreign_contribution
+			FROM donations
+			WHERE id = ? AND deleted_at IS NULL
+		`, sourceID).Scan(&src).Error
+	case RefundSourceSevaBooking:
+		err = r.db.WithContext(ctx).Raw(`
+			SELECT entity_id, user_id, amount, COALESCE(razorpay_order_id, '') AS order_id,
+			       COALESCE(razorpay_payment_id, '') AS payment_id,
+			       payment_verified_at IS NOT NULL AS paid
+			FROM seva_bookings
+			WHERE id = ?
+		`, sourceID).Scan(&src).Error
+	default:
+		return nil, fmt.Errorf("unknown refund source %q", sourceType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if src.EntityID == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if src.Currency == "" {
+		src.Currency, src.ExchangeRate = "INR", 1
+	}
+	return &src, nil
+}
+
+// FindRefundSourceByPaymentID locates the donation or seva booking paid with paymentID
+func (r *repository) FindRefundSourceByPaymentID(ctx context.Context, paymentID string) (string, uint, error) {
+	var id uint
+	r.db.WithContext(ctx).Raw(`SELECT id FROM donations WHERE payment_id = ? AND deleted_at IS NULL LIMIT 1`, paymentID).Scan(&id)
+	if id != 0 {
+		return RefundSourceDonation, id, nil
+	}
+	r.db.WithContext(ctx).Raw(`SELECT id FROM seva_bookings WHERE razorpay_payment_id = ? LIMIT 1`, paymentID).Scan(&id)
+	if id != 0 {
+		return RefundSourceSevaBooking, id, nil
+	}
+	return "", 0, gorm.ErrRecordNotFound
+}
+
+// CreateRefund inserts a PENDING refund after checking, under a lock on the
+// source row, that pending and processed refunds never exceed paidAmount.
+// An Amount of 0 refunds the remaining balance.
+func (r *repository) CreateRefund(ctx context.Context, refund *Refund, paidAmount float64) error {
+	table, err := refundSourceTable(refund.SourceType)
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT id FROM "+table+" WHERE id = ? FOR UPDATE", refund.SourceID).Error; err != nil {
+			return err
+		}
+
+		var committed float64
+		if err := tx.Model(&Refund{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("source_type = ? AND source_id = ? AND status <> ?", refund.SourceType, refund.SourceID, RefundFailed).
+			Scan(&committed).Error; err != nil {
+			return err
+		}
+
+		remaining := paidAmount - committed
+		if refund.Amount <= 0 {
+			refund.Amount = remaining
+		}
+		if remaining < 0.01 {
+			return errors.New("payment has already been fully refunded")
+		}
+		if refund.Amount > remaining+0.001 {
+			return fmt.Errorf("refund amount %.2f exceeds refundable balance %.2f", refund.Amount, remaining)
+		}
+		refund.IsPartial = committed+refund.Amount < paidAmount-0.001
+		refund.Status = RefundPending
+		return tx.Create(refund).Error
+	})
+}
+
+func (r *repository) GetRefundByID(ctx context.Context, id uint) (*Refund, error) {
+	var refund Refund
+	if err := r.db.WithContext(ctx).First(&refund, id).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *repository) GetRefundByGatewayID(ctx context.Context, gatewayRefundID string) (*Refund, error) {
+	var refund Refund
+	if err := r.db.WithContext(ctx).Where("gateway_refund_id = ?", gatewayRefundID).First(&refund).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *repository) SetRefundGatewayID(ctx context.Context, id uint, gatewayRefundID string) error {
+	return r.db.WithContext(ctx).
+		Model(&Refund{}).
+		Where("id = ?", id).
+		Update("gateway_refund_id", gatewayRefundID).Error
+}
+
+// MarkRefundProcessed moves a PENDING refund to PROCESSED and adds its amount
+// to the source in the same transaction. Returns false if it was not pending,
+// so repeated webhooks never double-count.
+func (r *repository) MarkRefundProcessed(ctx context.Context, id uint, gatewayRefundID string) (bool, error) {
+	applied := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":       RefundProcessed,
+			"processed_at": time.Now(),
+		}
+		if gatewayRefundID != "" {
+			updates["gateway_refund_id"] = gatewayRefundID
+		}
+		res := tx.Model(&Refund{}).Where("id = ? AND status = ?", id, RefundPending).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		var refund Refund
+		if err := tx.First(&refund, id).Error; err != nil {
+			return err
+		}
+
+		switch refund.SourceType {
+		case RefundSourceDonation:
+			if err := tx.Exec(`
+				UPDATE donations
+				SET refunded_amount = COALESCE(refunded_amount, 0) + ?,
+				    status = CASE WHEN COALESCE(refunded_amount, 0) + ? >= amount - 0.001 THEN ? ELSE status END,
+				    updated_at = NOW()
+				WHERE id = ?
+			`, refund.Amount, refund.Amount, StatusRefunded, refund.SourceID).Error; err != nil {
+				return err
+			}
+		case RefundSourceSevaBooking:
+			if err := tx.Exec(`
+				UPDATE seva_bookings
+				SET refunded_amount = COALESCE(refunded_amount, 0) + ?,
+				    refund_status = CASE WHEN COALESCE(refunded_amount, 0) + ? >= amount - 0.001 THEN 'full' ELSE 'partial' END,
+				    updated_at = NOW()
+				WHERE id = ?
+			`, refund.Amount, refund.Amount, refund.SourceID).Error; err != nil {
+				return err
+			}
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
+}
+
+func (r *repository) MarkRefundFailed(ctx context.Context, id uint, reason string) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&Refund{}).
+		Where("id = ? AND status = ?", id, RefundPending).
+		Updates(map[string]interface{}{
+			"status":         RefundFailed,
+			"failure_reason": reason,
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *repository) ListRefunds(ctx context.Context, filters RefundFilters) ([]Refund, int, error) {
+	var refunds []Refund
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&Refund{}).Where("entity_id = ?", filters.EntityID)
+	if filters.SourceType != "" {
+		query = query.Where("source_type = ?", filters.SourceType)
+	}
+	if filters.SourceID != 0 {
+		query = query.Where("source_id = ?", filters.SourceID)
+	}
+	if filters.Status != "" && filters.Status != "all" {
+		query = query.Where("UPPER(status) = UPPER(?)", filters.Status)
+	}
+	query.Count(&total)
+
+	if filters.Page > 0 && filters.Limit > 0 {
+		query = query.Offset((filters.Page - 1) * filters.Limit).Limit(filters.Limit)
+	}
+	err := query.Order("created_at DESC").Find(&refunds).Error
+	return refunds, int(total), err
+}
+
+// ==============================
+// Payment Webhook Events
+// ==============================
+
+// CreateWebhookEvent stores a webhook delivery. It returns false when a
+// verified event with the same provider and event id is already stored.
+func (r *repository) CreateWebhookEvent(ctx context.Context, event *PaymentWebhookEvent) (bool, error) {
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *repository) GetWebhookEventByID(ctx context.Context, id uint) (*PaymentWebhookEvent, error) {
+	var event PaymentWebhookEvent
+	if err := r.db.WithContext(ctx).First(&event, id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *repository) GetWebhookEventByEventID(ctx context.Context, provider, eventID string) (*PaymentWebhookEvent, error) {
+	var event PaymentWebhookEvent
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND event_id = ? AND signature_valid = ?", provider, eventID, true).
+		First(&event).Error
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// ClaimWebhookEvent marks the event as processing unless another worker is
+// already on it. A claim older than staleBefore is treated as abandoned.
+func (r *repository) ClaimWebhookEvent(ctx context.Context, id uint, staleBefore time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&PaymentWebhookEvent{}).
+		Where("id = ? AND (status <> ? OR updated_at < ?)", id, WebhookProcessing, staleBefore).
+		Updates(map[string]interface{}{"status": WebhookProcessing, "attempts": gorm.Expr("attempts + 1")})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *repository) UpdateWebhookEvent(ctx context.Context, id uint, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&PaymentWebhookEvent{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *repository) ListWebhookEvents(ctx context.Context, filters WebhookEventFilters) ([]PaymentWebhookEvent, int, error) {
+	var events []PaymentWebhookEvent
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&PaymentWebhookEvent{})
+	if filters.Provider != "" {
+		query = query.Where("provider = ?", filters.Provider)
+	}
+	if filters.Status != "" && filters.Status != "all" {
+		query = query.Where("status = ?", filters.Status)
+	}
+	if filters.EventType != "" {
+		query = query.Where("event_type = ?", filters.EventType)
+	}
+	if filters.OrderID != "" {
+		query = query.Where("order_id = ?", filters.OrderID)
+	}
+	if filters.From != nil {
+		query = query.Where("created_at >= ?", *filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("created_at <= ?", *filters.To)
+	}
+	query.Count(&total)
+
+	if filters.Page > 0 && filters.Limit > 0 {
+		query = query.Offset((filters.Page - 1) * filters.Limit).Limit(filters.Limit)
+	}
+	err := query.Order("created_at DESC").Find(&events).Error
+	return events, int(total), err
+}
+
+// ListDueWebhookEvents returns failed events whose backoff has elapsed, and
+// events left in processing by a worker that died
+func (r *repository) ListDueWebhookEvents(ctx context.Context, now, staleBefore time.Time, limit int) ([]PaymentWebhookEvent, error) {
+	var events []PaymentWebhookEvent
+	err := r.db.WithContext(ctx).
+		Where("(status = ? AND next_retry_at <= ?) OR (status = ? AND updated_at < ?)",
+			WebhookFailed, now, WebhookProcessing, staleBefore).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// ==============================
+// Campaigns
+// ==============================
+
+func (r *repository) CreateCampaign(ctx context.Context, campaign *Campaign) error {
+	return r.db.WithContext(ctx).Create(campaign).Error
+}
+
+func (r *repository) GetCampaignByID(ctx context.Context, id uint) (*Campaign, error) {
+	var campaign Campaign
+	if err := r.db.WithContext(ctx).First(&campaign, id).Error; err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+func (r *repository) UpdateCampaign(ctx context.Context, id uint, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Model(&Campaign{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *repository) ListCampaigns(ctx context.Context, entityID uint) ([]Campaign, error) {
+	var campaigns []Campaign
+	err := r.db.WithContext(ctx).
+		Where("entity_id = ?", entityID).
+		Order("start_date DESC").
+		Find(&campaigns).Error
+	return campaigns, err
+}
+
+// GetCampaignTotals sums what a campaign has raised, net of refunds
+func (r *repository) GetCampaignTotals(ctx context.Context, campaignID uint) (*CampaignTotals, error) {
+	var totals CampaignTotals
+	err := r.db.WithContext(ctx).
+		Table("donations d").
+		Select(`
+			COALESCE(SUM(d.amount - COALESCE(d.refunded_amount, 0)), 0) as raised,
+			COUNT(*) as donation_count,
+			COUNT(DISTINCT `+donorKeyExpr+`) as donor_count
+		`).
+		Where("d.campaign_id = ? AND LOWER(d.status) IN ('success', 'refunded') AND d.deleted_at IS NULL", campaignID).
+		Scan(&totals).Error
+	return &totals, err
+}
+
+func (r *repository) GetCampaignRecentDonors(ctx context.Context, campaignID uint, limit int) ([]CampaignDonor, error) {
+	var donors []CampaignDonor
+	err := r.db.WithContext(ctx).
+		Table("donations d").
+		Select(`
+			`+publicDonorNameExpr+` as name,
+			d.amount,
+			COALESCE(d.donated_at, d.created_at) as donated_at
+		`).
+		Joins("LEFT JOIN users u ON d.user_id = u.id").
+		Where("d.campaign_id = ? AND LOWER(d.status) = 'success' AND d.deleted_at IS NULL", campaignID).
+		Order("COALESCE(d.donated_at, d.created_at) DESC").
+		Limit(limit).
+		Scan(&donors).Error
+	return donors, err
+}
+
+// ==============================
+// In-Kind Donations
+// ==============================
+
+// CreateInKind stores the offering and stamps its acknowledgement number in
+// the same transaction
+func (r *repository) CreateInKind(ctx context.Context, donation *InKindDonation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(donation).Error; err != nil {
+			return err
+		}
+		fy := financialYearOf(donation.ReceivedAt)
+		number := formatAcknowledgementNumber(donation.EntityID, fy, donation.ID)
+		if err := tx.Model(&InKindDonation{}).
+			Where("id = ?", donation.ID).
+			Updates(map[string]interface{}{"acknowledgement_number": number, "financial_year": fy}).Error; err != nil {
+			return err
+		}
+		donation.AcknowledgementNumber = &number
+		donation.FinancialYear = fy
+		return nil
+	})
+}
+
+// in_kind_donations shares the donor columns of donations, so the same alias
+// lets it reuse donorNameExpr and donorKeyExpr
+const inKindSelectFields = `
+	d.*,
+	`+donorNameExpr+` as user_name,
+	COALESCE(u.email, '') as user_email,
+	COALESCE(e.name, '') as entity_name
+`
+
+func (r *repository) GetInKindByIDWithUser(ctx context.Context, id uint) (*InKindDonationWithUser, error) {
+	var result InKindDonationWithUser
+	err := r.db.WithContext(ctx).
+		Table("in_kind_donations d").
+		Select(inKindSelectFields).
+		Joins("LEFT JOIN users u ON d.user_id = u.id").
+		Joins("LEFT JOIN entities e ON d.entity_id = e.id").
+		Where("d.id = ? AND d.deleted_at IS NULL", id).
+		Take(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *repository) ListInKind(ctx context.Context, filters InKindFilters) ([]InKindDonationWithUser, int, error) {
+	var donations []InKindDonationWithUser
+	var total int64
+
+	countQuery := r.db.WithContext(ctx).
+		Table("in_kind_donations d").
+		Joins("LEFT JOIN users u ON d.user_id = u.id")
+	if err := applyInKindFilters(countQuery, filters).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := r.db.WithContext(ctx).
+		Table("in_kind_donations d").
+		Select(inKindSelectFields).
+		Joins("LEFT JOIN users u ON d.user_id = u.id").
+		Joins("LEFT JOIN entities e ON d.entity_id = e.id")
+	query = applyInKindFilters(query, filters)
+	if filters.Page > 0 && filters.Limit > 0 {
+		query = query.Offset((filters.Page - 1) * filters.Limit).Limit(filters.Limit)
+	}
+
+	err := query.Order("d.received_at DESC, d.id DESC").Scan(&donations).Error
+	return donations, int(total), err
+}
+
+func applyInKindFilters(query *gorm.DB, filters InKindFilters) *gorm.DB {
+	query = query.Where("d.deleted_at IS NULL")
+	if filters.EntityID != 0 {
+		query = query.Where("d.entity_id = ?", filters.EntityID)
+	}
+	if filters.UserID != 0 {
+		query = query.Where("d.user_id = ?", filters.UserID)
+	}
+	if filters.Category != "" && filters.Category != "all" {
+		query = query.Where("LOWER(d.category) = LOWER(?)", filters.Category)
+	}
+	if filters.From != nil {
+		query = query.Where("d.received_at >= ?", filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("d.received_at <= ?", filters.To)
+	}
+	if filters.Search != "" {
+		searchTerm := "%" + filters.Search + "%"
+		query = query.Where(`(
+			`+donorNameExpr+` ILIKE ? OR
+			u.email ILIKE ? OR
+			d.donor_phone ILIKE ? OR
+			d.item_name ILIKE ? OR
+			d.acknowledgement_number ILIKE ?
+		)`, searchTerm, searchTerm, searchTerm, searchTerm, searchTerm)
+	}
+	return query
+}
+
+// ==============================
+// Recurring Donations
+// ==============================
+
+func (r *repository) CreateRecurring(ctx context.Context, rd *RecurringDonation) error {
+	return r.db.WithContext(ctx).Create(rd).Error
+}
+
+func (r *repository) GetRecurringByID(ctx context.Context, id uint) (*RecurringDonation, error) {
+	var rd RecurringDonation
+	if err := r.db.WithContext(ctx).First(&rd, id).Error; err != nil {
+		return nil, err
+	}
+	return &rd, nil
+}
+
+func (r *repository) ListRecurringByUser(ctx context.Context, userID uint, entityID uint) ([]RecurringDonation, error) {
+	var list []RecurringDonation
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if entityID != 0 {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	err := query.Order("created_at DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *repository) ListDueRecurring(ctx context.Context, now time.Time, limit int) ([]RecurringDonation, error) {
+	var list []RecurringDonation
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_charge_at <= ?", RecurringActive, now).
+		Order("next_charge_at ASC").
+		Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+// ClaimRecurringOccurrence moves next_charge_at forward only if it still equals
+// dueAt, so two scheduler instances can never charge the same occurrence.
+func (r *repository) ClaimRecurringOccurrence(ctx context.Context, id uint, dueAt, nextChargeAt time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&RecurringDonation{}).
+		Where("id = ? AND status = ? AND next_charge_at = ?", id, RecurringActive, dueAt).
+		Updates(map[string]interface{}{
+			"next_charge_at": nextChargeAt,
+			"charge_count":   gorm.Expr("charge_count + 1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *repository) UpdateRecurring(ctx context.Context, id uint, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).
+		Model(&RecurringDonation{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// ==============================
+// Pledges
+// ==============================
+
+// CreatePledge stores the pledge together with its instalments
+func (r *repository) CreatePledge(ctx context.Context, pledge *Pledge) error {
+	return r.db.WithContext(ctx).Create(pledge).Error
+}
+
+func (r *repository) GetPledgeByID(ctx context.Context, id uint) (*Pledge, error) {
+	var pledge Pledge
+	err := r.db.WithContext(ctx).
+		Preload("Instalments", func(db *gorm.DB) *gorm.DB { return db.Order("sequence ASC") }).
+		First(&pledge, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pledge, nil
+}
+
+// donation_pledges shares the donor columns of donations, so the same alias
+// lets it reuse donorNameExpr. The overdue amount and next due date only count
+// for active pledges; a cancelled pledge has nothing outstanding.
+const pledgeSelectFields = `
+	d.*,
+	` + donorNameExpr + ` as donor_display_name,
+	COALESCE(u.email, '') as donor_email,
+	COALESCE(c.title, '') as campaign_title,
+	CASE WHEN d.status = 'active' THEN GREATEST(d.total_amount - d.paid_amount, 0) ELSE 0 END as outstanding,
+	CASE WHEN d.status = 'active' THEN COALESCE((SELECT SUM(i.amount - i.paid_amount) FROM donation_pledge_instalments i
+		WHERE i.pledge_id = d.id AND i.status <> 'paid' AND i.due_date < ?), 0) ELSE 0 END as overdue_amount,
+	CASE WHEN d.status = 'active' THEN (SELECT MIN(i.due_date) FROM donation_pledge_instalments i
+		WHERE i.pledge_id = d.id AND i.status <> 'paid') END as next_due_date
+`
+
+func (r *repository) pledgeQuery(ctx context.Context, now time.Time) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Table("donation_pledges d").
+		Select(pledgeSelectFields, now).
+		Joins("LEFT JOIN users u ON d.user_id = u.id").
+		Joins("LEFT JOIN donation_campaigns c ON d.campaign_id = c.id")
+}
+
+// GetPledgeWithBalance returns the pledge with its balance and instalments
+func (r *repository) GetPledgeWithBalance(ctx context.Context, id uint, now time.Time) (*PledgeWithBalance, error) {
+	var pledge PledgeWithBalance
+	err := r.pledgeQuery(ctx, now).
+		Where("d.id = ? AND d.deleted_at IS NULL", id).
+		Take(&pledge).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.WithContext(ctx).
+		Where("pledge_id = ?", id).
+		Order("sequence ASC").
+		Find(&pledge.Instalments).Error
+	if err != nil {
+		return nil, err
+	}
+	return &pledge, nil
+}
+
+func (r *repository) ListPledges(ctx context.Context, filters PledgeFilters, now time.Time) ([]PledgeWithBalance, int, error) {
+	var pledges []PledgeWithBalance
+	var total int64
+
+	countQuery := r.db.WithContext(ctx).
+		Table("donation_pledges d").
+		Joins("LEFT JOIN users u ON d.user_id = u.id")
+	if err := applyPledgeFilters(countQuery, filters, now).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query := applyPledgeFilters(r.pledgeQuery(ctx, now), filters, now)
+	if filters.Page > 0 && filters.Limit > 0 {
+		query = query.Offset((filters.Page - 1) * filters.Limit).Limit(filters.Limit)
+	}
+	err := query.Order("d.created_at DESC, d.id DESC").Scan(&pledges).Error
+	return pledges, int(total), err
+}
+
+func applyPledgeFilters(query *gorm.DB, filters PledgeFilters, now time.Time) *gorm.DB {
+	query = query.Where("d.deleted_at IS NULL")
+	if filters.EntityID != 0 {
+		query = query.Where("d.entity_id = ?", filters.EntityID)
+	}
+	if filters.UserID != 0 {
+		query = query.Where("d.user_id = ?", filters.UserID)
+	}
+	if filters.CampaignID != 0 {
+		query = query.Where("d.campaign_id = ?", filters.CampaignID)
+	}
+	if filters.Status != "" && filters.Status != "all" {
+		query = query.Where("d.status = ?", filters.Status)
+	}
+	if filters.Overdue {
+		query = query.Where(`d.status = 'active' AND EXISTS (SELECT 1 FROM donation_pledge_instalments i
+			WHERE i.pledge_id = d.id AND i.status <> 'paid' AND i.due_date < ?)`, now)
+	}
+	if filters.Search != "" {
+		searchTerm := "%" + filters.Search + "%"
+		query = query.Where(`(
+			`+donorNameExpr+` ILIKE ? OR
+			u.email ILIKE ? OR
+			d.donor_phone ILIKE ?
+		)`, searchTerm, searchTerm, searchTerm)
+	}
+	return query
+}
+
+// ListPledgeDonations returns the donations linked to a pledge, oldest first
+func (r *repository) ListPledgeDonations(ctx context.Context, pledgeID uint) ([]DonationWithUser, error) {
+	var donations []DonationWithUser
+	err := r.db.WithContext(ctx).
+		Table("donations d").
+		Select(donationSelectFields).
+		Joins("LEFT JOIN users u ON d.user_id = u.id").
+		Joins("LEFT JOIN entities e ON d.entity_id = e.id").
+		Where("d.pledge_id = ? AND d.deleted_at IS NULL", pledgeID).
+		Order("COALESCE(d.donated_at, d.created_at) ASC, d.id ASC").
+		Scan(&donations).Error
+	return donations, err
+}
+
+func (r *repository) UpdatePledge(ctx context.Context, id uint, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).
+		Model(&Pledge{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// LinkDonationToPledge attaches a donation to a pledge unless it already pays towards one
+func (r *repository) LinkDonationToPledge(ctx context.Context, donationID, pledgeID uint) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&Donation{}).
+		Where("id = ? AND pledge_id IS NULL", donationID).
+		Update("pledge_id", pledgeID)
+	return res.RowsAffected == 1, res.Error
+}
+
+// SettlePledge recomputes what has been paid towards a pledge from its
+// successful donations, net of refunds, and allocates it to the instalments in
+// order of sequence. The pledge row is locked so concurrent payments settle one
+// after the other. The bool reports whether this settlement fulfilled the pledge.
+func (r *repository) SettlePledge(ctx context.Context, pledgeID uint, now time.Time) (*Pledge, bool, error) {
+	var pledge Pledge
+	fulfilled := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&pledge, pledgeID).Error; err != nil {
+			return err
+		}
+
+		var paid float64
+		if err := tx.Model(&Donation{}).
+			Select("COALESCE(SUM(amount - COALESCE(refunded_amount, 0)), 0)").
+			Where("pledge_id = ? AND UPPER(status) = 'SUCCESS'", pledgeID).
+			Scan(&paid).Error; err != nil {
+			return err
+		}
+		paid = utils.RoundMoney(paid)
+
+		var instalments []PledgeInstalment
+		if err := tx.Where("pledge_id = ?", pledgeID).
+			Order("sequence ASC").
+			Find(&instalments).Error; err != nil {
+			return err
+		}
+
+		remaining := paid
+		for i := range instalments {
+			inst := &instalments[i]
+			applied := inst.Amount
+			if remaining < applied {
+				applied = remaining
+			}
+			remaining = utils.RoundMoney(remaining - applied)
+
+			status, paidAt := InstalmentPending, (*time.Time)(nil)
+			switch {
+			case applied >= inst.Amount:
+				status, paidAt = InstalmentPaid, inst.PaidAt
+				if paidAt == nil {
+					paidAt = &now
+				}
+			case applied > 0:
+				status = InstalmentPartial
+			}
+			if inst.PaidAmount == applied && inst.Status == status {
+				continue
+			}
+			if err := tx.Model(&PledgeInstalment{}).
+				Where("id = ?", inst.ID).
+				Updates(map[string]interface{}{"paid_amount": applied, "status": status, "paid_at": paidAt}).Error; err != nil {
+				return err
+			}
+			inst.PaidAmount, inst.Status, inst.PaidAt = applied, status, paidAt
+		}
+
+		updates := map[string]interface{}{"paid_amount": paid}
+		switch {
+		case pledge.Status == PledgeActive && paid >= pledge.TotalAmount:
+			updates["status"], updates["fulfilled_at"] = PledgeFulfilled, now
+			pledge.Status, pledge.FulfilledAt = PledgeFulfilled, &now
+			fulfilled = true
+		case pledge.Status == PledgeFulfilled && paid < pledge.TotalAmount:
+			updates["status"], updates["fulfilled_at"] = PledgeActive, nil
+			pledge.Status, pledge.FulfilledAt = PledgeActive, nil
+		}
+		if err := tx.Model(&Pledge{}).Where("id = ?", pledgeID).Updates(updates).Error; err != nil {
+			return err
+		}
+		pledge.PaidAmount = paid
+		pledge.Instalments = instalments
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &pledge, fulfilled, nil
+}
+
+// ListOverdueInstalments returns unpaid instalments of active pledges from
+// registered devotees that fell due before dueBefore and were last reminded
+// before remindBefore, oldest first
+func (r *repository) ListOverdueInstalments(ctx context.Context, dueBefore, remindBefore time.Time, maxReminders, limit int) ([]OverdueInstalment, error) {
+	var list []OverdueInstalment
+	err := r.db.WithContext(ctx).
+		Table("donation_pledge_instalments i").
+		Select("i.*, p.user_id, p.donation_type, p.total_amount as pledge_total, p.instalment_count").
+		Joins("JOIN donation_pledges p ON i.pledge_id = p.id").
+		Where("p.status = ? AND p.deleted_at IS NULL AND p.user_id > 0", PledgeActive).
+		Where("i.status <> ? AND i.due_date < ? AND i.reminder_count < ?", InstalmentPaid, dueBefore, maxReminders).
+		Where("(i.last_reminder_at IS NULL OR i.last_reminder_at < ?)", remindBefore).
+		Order("i.due_date ASC, i.id ASC").
+		Limit(limit).
+		Scan(&list).Error
+	return list, err
+}
+
+// ClaimInstalmentReminder records a reminder only if none was sent since
+// remindBefore, so two workers never remind the same instalment twice
+func (r *repository) ClaimInstalmentReminder(ctx context.Context, id uint, remindBefore, now time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&PledgeInstalment{}).
+		Where("id = ? AND (last_reminder_at IS NULL OR last_reminder_at < ?)", id, remindBefore).
+		Updates(map[string]interface{}{
+			"last_reminder_at": now,
+			"reminder_count":   gorm.Expr("reminder_count + 1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// ==============================
+// UPI Payment Links
+// ==============================
+
+func (r *repository) CreatePaymentLink(ctx context.Context, link *PaymentLink) error {
+	return r.db.WithContext(ctx).Create(link).Error
+}
+
+func (r *repository) GetPaymentLinkByID(ctx context.Context, id uint) (*PaymentLink, error) {
+	var link PaymentLink
+	if err := r.db.WithContext(ctx).First(&link, id).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *repository) GetPaymentLinkByCode(ctx context.Context, code string) (*PaymentLink, error) {
+	var link PaymentLink
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// ListPaymentLinks returns the temple's links, newest first, with the
+// successful donations collected through each
+func (r *repository) ListPaymentLinks(ctx context.Context, filters PaymentLinkFilters) ([]PaymentLinkView, error) {
+	var links []PaymentLinkView
+	query := r.db.WithContext(ctx).
+		Table("donation_payment_links l").
+		Select(`l.*,
+			(SELECT COUNT(*) FROM donations d WHERE d.payment_link_id = l.id AND UPPER(d.status) = 'SUCCESS' AND d.deleted_at IS NULL) as donation_count,
+			COALESCE((SELECT SUM(d.amount - COALESCE(d.refunded_amount, 0)) FROM donations d
+				WHERE d.payment_link_id = l.id AND UPPER(d.status) = 'SUCCESS' AND d.deleted_at IS NULL), 0) as collected`).
+		Where("l.entity_id = ? AND l.deleted_at IS NULL", filters.EntityID)
+	if filters.TargetType != "" {
+		query = query.Where("l.target_type = ?", filters.TargetType)
+	}
+	if filters.ActiveOnly {
+		query = query.Where("l.is_active = ?", true)
+	}
+	err := query.Order("l.created_at DESC, l.id DESC").Scan(&links).Error
+	return links, err
+}
+
+func (r *repository) UpdatePaymentLink(ctx context.Context, id uint, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).
+		Model(&PaymentLink{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// GetLinkSeva reads the seva a payment link collects for
+func (r *repository) GetLinkSeva(ctx context.Context, sevaID uint) (*LinkSeva, error) {
+	var seva LinkSeva
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT id, entity_id, name, COALESCE(price, 0) AS price, COALESCE(is_active, true) AS is_active
+		FROM sevas
+		WHERE id = ?
+	`, sevaID).Scan(&seva).Error
+	if err != nil {
+		return nil, err
+	}
+	if seva.ID == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &seva, nil
+}
+
+// IsUTRRecorded reports whether a UPI payment with this UTR has already been
+// matched to a donation of the temple
+func (r *repository) IsUTRRecorded(ctx context.Context, entityID uint, utr string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&Donation{}).
+		Where("entity_id = ? AND payment_link_id IS NOT NULL AND payment_id = ?", entityID, utr).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CompleteLinkDonation marks a pending guest donation as paid, unless a
+// concurrent match got there first
+func (r *repository) CompleteLinkDonation(ctx context.Context, id uint, updates map[string]interface{}) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&Donation{}).
+		Where("id = ? AND status = ?", id, StatusPending).
+		Updates(updates)
+	return res.RowsAffected == 1, res.Error
+}
+
+// ==============================
+// Annual Donor Statements
+// ==============================
+
+// statementDonationsWhere selects a temple's successful gifts from registered
+// donors in [from, to). Walk-in donors have no account to send a statement to.
+const statementDonationsWhere = `d.deleted_at IS NULL AND UPPER(d.status) = 'SUCCESS' AND d.user_id > 0
+	AND COALESCE(d.donated_at, d.created_at) >= ? AND COALESCE(d.donated_at, d.created_at) < ?`
+
+// ListStatementEntities returns the temples that received successful donations in [from, to)
+func (r *repository) ListStatementEntities(ctx context.Context, from, to time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).
+		Table("donations d").
+		Distinct("d.entity_id").
+		Where(statementDonationsWhere, from, to).
+		Pluck("d.entity_id", &ids).Error
+	return ids, err
+}
+
+// ListStatementTotals sums each donor's successful giving at a temple in [from, to), net of refunds
+func (r *repository) ListStatementTotals(ctx context.Context, entityID uint, from, to time.Time) ([]DonorStatementTotal, error) {
+	var totals []DonorStatementTotal
+	err := r.db.WithContext(ctx).
+		Table("donations d").
+		Select(`d.user_id, COUNT(*) as donation_count,
+			COALESCE(SUM(d.amount - COALESCE(d.refunded_amount, 0)), 0) as total_amount`).
+		Where("d.entity_id = ?", entityID).
+		Where(statementDonationsWhere, from, to).
+		Group("d.user_id").
+		Order("d.user_id").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// ListStatementDonations returns the donations listed on one donor's statement, oldest first
+func (r *repository) ListStatementDonations(ctx context.Context, entityID, userID uint, from, to time.Time) ([]DonationWithUser, error) {
+	var donations []DonationWithUser
+	err := r.db.WithContext(ctx).
+		Table("donations d").
+		Select(donationSelectFields).
+		Joins("LEFT JOIN users u ON d.user_id = u.id").
+		Joins("LEFT JOIN entities e ON d.entity_id = e.id").
+		Where("d.entity_id = ? AND d.user_id = ?", entityID, userID).
+		Where(statementDonationsWhere, from, to).
+		Order("COALESCE(d.donated_at, d.created_at) ASC, d.id ASC").
+		Scan(&donations).Error
+	return donations, err
+}
+
+// SaveDonorStatement inserts the statement or refreshes the totals of the
+// existing one for the same donor, temple and financial year
+func (r *repository) SaveDonorStatement(ctx context.Context, statement *DonorStatement) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "entity_id"}, {Name: "user_id"}, {Name: "financial_year"}},
+			DoUpdates: clause.AssignmentColumns([]string{"donation_count", "total_amount", "email", "generated_at", "updated_at"}),
+		}).
+		Create(statement).Error
+}
+
+func (r *repository) GetDonorStatement(ctx context.Context, entityID, userID uint, financialYear string) (*DonorStatement, error) {
+	var statement DonorStatement
+	err := r.db.WithContext(ctx).
+		Where("entity_id = ? AND user_id = ? AND financial_year = ?", entityID, userID, financialYear).
+		First(&statement).Error
+	if err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+func (r *repository) UpdateDonorStatement(ctx context.Context, id uint, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).
+		Model(&DonorStatement{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+func (r *repository) ListDonorStatements(ctx context.Context, filters DonorStatementFilters) ([]DonorStatementWithUser, int, error) {
+	var statements []DonorStatementWithUser
+	var total int64
+
+	query := r.db.WithContext(ctx).
+		Table("donor_statements s").
+		Joins("LEFT JOIN users u ON s.user_id = u.id").
+		Where("s.entity_id = ? AND s.financial_year = ?", filters.EntityID, filters.FinancialYear)
+	if filters.Status != "" && filters.Status != "all" {
+		query = query.Where("s.status = ?", filters.Status)
+	}
+	if filters.Search != "" {
+		searchTerm := "%" + filters.Search + "%"
+		query = query.Where("(u.full_name ILIKE ? OR u.email ILIKE ?)", searchTerm, searchTerm)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Select("s.*, COALESCE(NULLIF(u.full_name, ''), u.email, '') as donor_name")
+	if filters.Page > 0 && filters.Limit > 0 {
+		query = query.Offset((filters.Page - 1) * filters.Limit).Limit(filters.Limit)
+	}
+	err := query.Order("donor_name ASC, s.id ASC").Scan(&statements).Error
+	return statements, int(total), err
+}
+
+// ==============================
+// Form 10BD / 10BE
+// ==============================
+
+// ListForm10BDDonations returns a temple's successful gifts in [from, to) from
+// donors who gave a PAN or an Aadhaar number, net of refunds. The address
+// recorded on the gift wins over the one on the devotee's profile.
+func (r *repository) ListForm10BDDonations(ctx context.Context, entityID uint, from, to time.Time) ([]Form10BDDonation, error) {
+	var donations []Form10BDDonation
+	err := r.db.WithContext(ctx).
+		Table("donations d").
+		Select(`d.id, d.user_id, d.amount - COALESCE(d.refunded_amount, 0) as net_amount,
+			d.donation_type, d.method, COALESCE(d.donated_at, d.created_at) as donated_at,
+			COALESCE(d.donor_pan, '') as donor_pan, COALESCE(d.donor_aadhaar, '') as donor_aadhaar,
+			`+donorNameExpr+` as donor_name,
+			COALESCE(NULLIF(d.donor_address, ''), NULLIF(CONCAT_WS(', ', NULLIF(p.street_address, ''), NULLIF(p.city, ''),
+				NULLIF(p.state, ''), NULLIF(p.pincode, '')), ''), '') as donor_address`).
+		Joins("LEFT JOIN users u ON d.user_id = u.id").
+		Joins(`LEFT JOIN LATERAL (
+			SELECT dp.street_address, dp.city, dp.state, dp.pincode FROM devotee_profiles dp
+			WHERE dp.user_id = d.user_id AND d.user_id > 0 AND dp.deleted_at IS NULL
+			ORDER BY (dp.entity_id = d.entity_id) DESC, dp.id DESC LIMIT 1
+		) p ON true`).
+		Where("d.entity_id = ? AND d.deleted_at IS NULL AND UPPER(d.status) = 'SUCCESS'", entityID).
+		Where("COALESCE(d.donated_at, d.created_at) >= ? AND COALESCE(d.donated_at, d.created_at) < ?", from, to).
+		Where("(NULLIF(d.donor_pan, '') IS NOT NULL OR NULLIF(d.donor_aadhaar, '') IS NOT NULL)").
+		Where("d.amount - COALESCE(d.refunded_amount, 0) > 0").
+		Order("COALESCE(d.donated_at, d.created_at) ASC, d.id ASC").
+		Scan(&donations).Error
+	return donations, err
+}
+
+// CountForm10BDMissingIdentity counts successful gifts in [from, to) that
+// cannot go into Form 10BD because the donor gave neither PAN nor Aadhaar
+func (r *repository) CountForm10BDMissingIdentity(ctx context.Context, entityID uint, from, to time.Time) (int, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("donations d").
+		Where("d.entity_id = ? AND d.deleted_at IS NULL AND UPPER(d.status) = 'SUCCESS'", entityID).
+		Where("COALESCE(d.donated_at, d.created_at) >= ? AND COALESCE(d.donated_at, d.created_at) < ?", from, to).
+		Where("NULLIF(d.donor_pan, '') IS NULL AND NULLIF(d.donor_aadhaar, '') IS NULL").
+		Where("d.amount - COALESCE(d.refunded_amount, 0) > 0").
+		Count(&count).Error
+	return int(count), err
+}
+
 // ==============================
 // Data Retrieval with Filtering
 // ==============================
@@ -204,6 +1516,15 @@ func (r *repository) applyFilters(query *gorm.DB, filters DonationFilters) *gorm
 	if filters.Method != "" && filters.Method != "all" {
 		query = query.Where("LOWER(d.method) = LOWER(?)", filters.Method)
 	}
+	if filters.CampaignID != 0 {
+		query = query.Where("d.campaign_id = ?", filters.CampaignID)
+	}
+	switch filters.Contribution {
+	case ContributionDomestic:
+		query = query.Where("COALESCE(d.is_foreign_contribution, false) = false")
+	case ContributionForeign:
+		query = query.Where("d.is_foreign_contribution = true")
+	}
 	if filters.From != nil {
 		query = query.Where("d.created_at >= ?", filters.From)
 	}
@@ -219,11 +1540,13 @@ func (r *repository) applyFilters(query *gorm.DB, filters DonationFilters) *gorm
 	if filters.Search != "" {
 		searchTerm := "%" + filters.Search + "%"
 		query = query.Where(`
-			COALESCE(NULLIF(u.full_name, ''), u.email, 'Anonymous') ILIKE ? OR 
-			u.email ILIKE ? OR 
+			`+publicDonorNameExpr+` ILIKE ? OR 
+			`+publicDonorEmailExpr+` ILIKE ? OR 
+			(NOT COALESCE(d.is_anonymous, false) AND d.donor_phone ILIKE ?) OR 
 			d.payment_id ILIKE ? OR 
-			d.order_id ILIKE ?
-		`, searchTerm, searchTerm, searchTerm, searchTerm)
+			d.order_id ILIKE ? OR 
+			d.instrument_number ILIKE ?
+		`, searchTerm, searchTerm, searchTerm, searchTerm, searchTerm, searchTerm)
 	}
 	return query
 }
@@ -232,16 +1555,24 @@ func (r *repository) applyFilters(query *gorm.DB, filters DonationFilters) *gorm
 // Analytics Queries
 // ==============================
 
+// netAmountExpr is a donation's value after processed refunds. Fully refunded
+// donations (status REFUNDED) net to zero; pending and failed ones count as zero.
+const netAmountExpr = `CASE WHEN LOWER(status) IN ('success', 'refunded') THEN amount - COALESCE(refunded_amount, 0) ELSE 0 END`
+
 func (r *repository) GetTotalStats(ctx context.Context, entityID uint) (*StatsResult, error) {
 	var result StatsResult
 	err := r.db.WithContext(ctx).
 		Table("donations").
 		Select(`
-			COALESCE(SUM(CASE WHEN LOWER(status) = 'success' THEN amount ELSE 0 END), 0) as amount,
+			COALESCE(SUM(`+netAmountExpr+`), 0) as amount,
 			COUNT(*) as count,
 			COALESCE(SUM(CASE WHEN LOWER(status) = 'success' THEN 1 ELSE 0 END), 0) as completed_count,
 			COALESCE(SUM(CASE WHEN LOWER(status) = 'pending' THEN 1 ELSE 0 END), 0) as pending_count,
-			COALESCE(SUM(CASE WHEN LOWER(status) = 'failed' THEN 1 ELSE 0 END), 0) as failed_count
+			COALESCE(SUM(CASE WHEN LOWER(status) = 'failed' THEN 1 ELSE 0 END), 0) as failed_count,
+			COALESCE(SUM(COALESCE(refunded_amount, 0)), 0) as refunded_amount,
+			COALESCE(SUM(CASE WHEN COALESCE(refunded_amount, 0) > 0 THEN 1 ELSE 0 END), 0) as refunded_count,
+			COALESCE(SUM(CASE WHEN COALESCE(is_foreign_contribution, false) THEN `+netAmountExpr+` ELSE 0 END), 0) as foreign_amount,
+			COALESCE(SUM(CASE WHEN COALESCE(is_foreign_contribution, false) AND LOWER(status) IN ('success', 'refunded') THEN 1 ELSE 0 END), 0) as foreign_count
 		`).
 		Where("entity_id = ?", entityID).
 		Scan(&result).Error
@@ -253,11 +1584,15 @@ func (r *repository) GetStatsInDateRange(ctx context.Context, entityID uint, fro
 	err := r.db.WithContext(ctx).
 		Table("donations").
 		Select(`
-			COALESCE(SUM(CASE WHEN LOWER(status) = 'success' THEN amount ELSE 0 END), 0) as amount,
+			COALESCE(SUM(`+netAmountExpr+`), 0) as amount,
 			COUNT(*) as count,
 			COALESCE(SUM(CASE WHEN LOWER(status) = 'success' THEN 1 ELSE 0 END), 0) as completed_count,
 			COALESCE(SUM(CASE WHEN LOWER(status) = 'pending' THEN 1 ELSE 0 END), 0) as pending_count,
-			COALESCE(SUM(CASE WHEN LOWER(status) = 'failed' THEN 1 ELSE 0 END), 0) as failed_count
+			COALESCE(SUM(CASE WHEN LOWER(status) = 'failed' THEN 1 ELSE 0 END), 0) as failed_count,
+			COALESCE(SUM(COALESCE(refunded_amount, 0)), 0) as refunded_amount,
+			COALESCE(SUM(CASE WHEN COALESCE(refunded_amount, 0) > 0 THEN 1 ELSE 0 END), 0) as refunded_count,
+			COALESCE(SUM(CASE WHEN COALESCE(is_foreign_contribution, false) THEN `+netAmountExpr+` ELSE 0 END), 0) as foreign_amount,
+			COALESCE(SUM(CASE WHEN COALESCE(is_foreign_contribution, false) AND LOWER(status) IN ('success', 'refunded') THEN 1 ELSE 0 END), 0) as foreign_count
 		`).
 		Where("entity_id = ? AND created_at >= ? AND created_at <= ?", entityID, from, to).
 		Scan(&result).Error
@@ -267,83 +1602,128 @@ func (r *repository) GetStatsInDateRange(ctx context.Context, entityID uint, fro
 func (r *repository) GetUniqueDonorCount(ctx context.Context, entityID uint) (int, error) {
 	var count int64
 	err := r.db.WithContext(ctx).
-		Table("donations").
-		Where("entity_id = ? AND LOWER(status) = 'success'", entityID).
-		Distinct("user_id").
-		Count(&count).Error
+		Table("donations d").
+		Select("COUNT(DISTINCT "+donorKeyExpr+")").
+		Where("d.entity_id = ? AND LOWER(d.status) = 'success'", entityID).
+		Scan(&count).Error
 	return int(count), err
 }
 
+// GetTopDonors ranks donors on their net monetary donations plus the
+// estimated value of their in-kind offerings; both are returned separately
 func (r *repository) GetTopDonors(ctx context.Context, entityID uint, limit int) ([]TopDonor, error) {
 	var donors []TopDonor
-	err := r.db.WithContext(ctx).
-		Table("donations d").
-		Select(`
-			COALESCE(NULLIF(u.full_name, ''), u.email, 'Anonymous') as name,
-			COALESCE(u.email, '') as email,
-			SUM(d.amount) as total_amount,
-			COUNT(d.id) as donation_count
-		`).
-		Joins("JOIN users u ON d.user_id = u.id").
-		Where("d.entity_id = ? AND LOWER(d.status) = 'success'", entityID).
-		Group("u.id, u.full_name, u.email").
-		Order("total_amount DESC").
-		Limit(limit).
-		Scan(&donors).Error
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			MAX(name) as name,
+			MAX(email) as email,
+			SUM(amount) as total_amount,
+			SUM(donation_count) as donation_count,
+			SUM(in_kind_value) as in_kind_value,
+			SUM(in_kind_count) as in_kind_count
+		FROM (
+			SELECT `+publicDonorKeyExpr+` as donor_key, `+publicDonorNameExpr+` as name, `+publicDonorEmailExpr+` as email,
+				d.amount - COALESCE(d.refunded_amount, 0) as amount, 1 as donation_count,
+				0 as in_kind_value, 0 as in_kind_count
+			FROM donations d
+			LEFT JOIN users u ON d.user_id = u.id
+			WHERE d.entity_id = ? AND LOWER(d.status) IN ('success', 'refunded')
+			UNION ALL
+			SELECT `+donorKeyExpr+`, `+donorNameExpr+`, COALESCE(u.email, ''),
+				0, 0,
+				d.estimated_value, 1
+			FROM in_kind_donations d
+			LEFT JOIN users u ON d.user_id = u.id
+			WHERE d.entity_id = ? AND d.deleted_at IS NULL
+		) contributions
+		GROUP BY donor_key
+		ORDER BY SUM(amount) + SUM(in_kind_value) DESC
+		LIMIT ?
+	`, entityID, entityID, limit).Scan(&donors).Error
 	return donors, err
 }
 
-func (r *repository) GetDonationTrends(ctx context.Context, entityID uint, days int) ([]TrendData, error) {
+func (r *repository) GetDonationTrends(ctx context.Context, entityID uint, days int, campaignID uint) ([]TrendData, error) {
 	var trends []TrendData
 	endDate := time.Now()
 	startDate := endDate.AddDate(0, 0, -days)
 
-	err := r.db.WithContext(ctx).
+	query := r.db.WithContext(ctx).
 		Table("donations").
 		Select(`
 			DATE(created_at) as date,
-			COALESCE(SUM(CASE WHEN LOWER(status) = 'success' THEN amount ELSE 0 END), 0) as amount,
+			COALESCE(SUM(`+netAmountExpr+`), 0) as amount,
 			COUNT(*) as count
 		`).
-		Where("entity_id = ? AND created_at >= ? AND created_at <= ?", entityID, startDate, endDate).
+		Where("entity_id = ? AND created_at >= ? AND created_at <= ?", entityID, startDate, endDate)
+	err := scopeCampaign(query, campaignID).
 		Group("DATE(created_at)").
 		Order("date ASC").
 		Scan(&trends).Error
 	return trends, err
 }
 
-func (r *repository) GetDonationsByType(ctx context.Context, entityID uint) ([]TypeData, error) {
+func (r *repository) GetDonationsByType(ctx context.Context, entityID uint, campaignID uint) ([]TypeData, error) {
 	var typeData []TypeData
-	err := r.db.WithContext(ctx).
+	query := r.db.WithContext(ctx).
 		Table("donations").
 		Select(`
 			donation_type as type,
-			COALESCE(SUM(CASE WHEN LOWER(status) = 'success' THEN amount ELSE 0 END), 0) as amount,
+			COALESCE(SUM(`+netAmountExpr+`), 0) as amount,
 			COUNT(*) as count
 		`).
-		Where("entity_id = ?", entityID).
+		Where("entity_id = ?", entityID)
+	err := scopeCampaign(query, campaignID).
 		Group("donation_type").
 		Order("amount DESC").
 		Scan(&typeData).Error
 	return typeData, err
 }
 
-func (r *repository) GetDonationsByMethod(ctx context.Context, entityID uint) ([]MethodData, error) {
+func (r *repository) GetDonationsByMethod(ctx context.Context, entityID uint, campaignID uint) ([]MethodData, error) {
 	var methodData []MethodData
-	err := r.db.WithContext(ctx).
+	query := r.db.WithContext(ctx).
 		Table("donations").
 		Select(`
 			method,
-			COALESCE(SUM(CASE WHEN LOWER(status) = 'success' THEN amount ELSE 0 END), 0) as amount,
+			COALESCE(SUM(`+netAmountExpr+`), 0) as amount,
 			COUNT(*) as count
 		`).
-		Where("entity_id = ? AND LOWER(status) = 'success'", entityID).
+		Where("entity_id = ? AND LOWER(status) IN ('success', 'refunded')", entityID)
+	err := scopeCampaign(query, campaignID).
 		Group("method").
 		Order("amount DESC").
 		Scan(&methodData).Error
 	return methodData, err
 }
 
+func (r *repository) GetDonationsByCampaign(ctx context.Context, entityID uint) ([]CampaignData, error) {
+	var campaignData []CampaignData
+	err := r.db.WithContext(ctx).
+		Table("donation_campaigns c").
+		Select(`
+			c.id as campaign_id,
+			c.title,
+			c.goal_amount,
+			COALESCE(SUM(CASE WHEN LOWER(d.status) IN ('success', 'refunded') THEN d.amount - COALESCE(d.refunded_amount, 0) ELSE 0 END), 0) as amount,
+			COUNT(d.id) as count
+		`).
+		Joins("LEFT JOIN donations d ON d.campaign_id = c.id AND d.deleted_at IS NULL").
+		Where("c.entity_id = ? AND c.deleted_at IS NULL", entityID).
+		Group("c.id, c.title, c.goal_amount").
+		Order("amount DESC").
+		Scan(&campaignData).Error
+	return campaignData, err
+}
+
+// scopeCampaign narrows an analytics query to one campaign (0 = all donations)
+func scopeCampaign(query *gorm.DB, campaignID uint) *gorm.DB {
+	if campaignID != 0 {
+		return query.Where("campaign_id = ?", campaignID)
+	}
+	return query
+}
+
 // ==============================
 // Recent Donations
 // ==============================
@@ -351,7 +1731,7 @@ func (r *repository) GetDonationsByMethod(ctx context.Context, entityID uint) ([
 const recentDonationSelect = `
 	d.amount, d.donation_type, d.method, d.status,
 	COALESCE(d.donated_at, d.created_at) as donated_at,
-	COALESCE(NULLIF(u.full_name, ''), u.email, 'Anonymous') as user_name,
+	`+publicDonorNameExpr+` as user_name,
 	COALESCE(e.name, '') as entity_name
 `
 
@@ -1,6 +1,10 @@
 package donation
 
-import "time"
+import (
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/payment"
+)
 
 // ==============================
 // DTOs and Request/Response Models
@@ -13,18 +17,411 @@ type CreateDonationRequest struct {
 	Amount       float64 `json:"amount" binding:"required,gt=0"`                                                                     // Donation amount in INR
 	DonationType string  `json:"donationType" binding:"required,oneof=general seva event festival construction annadanam education maintenance"` // Donation type
 	ReferenceID  *uint   `json:"referenceID,omitempty"`                                                                              // Optional: SevaID or EventID
+	CampaignID   *uint   `json:"campaignId,omitempty"`                                                                               // Optional: fundraising campaign
+	PledgeID     *uint   `json:"pledgeId,omitempty"`                                                                                 // Optional: pledge this donation pays towards
 	Note         *string `json:"note,omitempty"`                                                                                     // Optional donor message
 	IPAddress    string  `json:"-"`                                                                                                  // For audit logging (filled from middleware)
+	DonorIdentity
+	DonationDedication
+	ForeignContribution
+}
+
+// DonorIdentity is what Form 10BD needs to know about a donor: a PAN or an
+// Aadhaar number, and a postal address. All three are optional on a gift,
+// but donations without a PAN or Aadhaar are left out of Form 10BD.
+type DonorIdentity struct {
+	DonorPAN     *string `json:"donorPan,omitempty"`     // Printed on the 80G receipt
+	DonorAadhaar *string `json:"donorAadhaar,omitempty"` // 12 digits, spaces and hyphens allowed
+	DonorAddress *string `json:"donorAddress,omitempty"` // Falls back to the devotee profile address
+}
+
+// ForeignContribution carries the currency of the gift and, for contributions
+// from a foreign source under FCRA, the donor details the annual return needs.
+// Amount is in Currency; INR is assumed when Currency is empty.
+type ForeignContribution struct {
+	Currency              string  `json:"currency,omitempty"`
+	IsForeignContribution bool    `json:"isForeignContribution,omitempty"`
+	DonorCountry          *string `json:"donorCountry,omitempty"`
+	DonorNationality      *string `json:"donorNationality,omitempty"`
+}
+
+// DonationDedication lets a donor give anonymously and/or on behalf of,
+// in memory of or in honour of someone else
+type DonationDedication struct {
+	IsAnonymous    bool    `json:"isAnonymous,omitempty"`
+	DedicationType string  `json:"dedicationType,omitempty" binding:"omitempty,oneof=on_behalf_of in_memory_of in_honour_of"`
+	OnBehalfOf     *string `json:"onBehalfOf,omitempty"` // Name printed on the receipt and shown in donor listings
+}
+
+// CreateRecurringDonationRequest sets up a standing monthly/quarterly/annual donation
+type CreateRecurringDonationRequest struct {
+	UserID       uint    `json:"-"`
+	EntityID     uint    `json:"-"`
+	Amount       float64 `json:"amount" binding:"required,gt=0"`
+	DonationType string  `json:"donationType" binding:"required,oneof=general seva event festival construction annadanam education maintenance"`
+	Frequency    string  `json:"frequency" binding:"required,oneof=monthly quarterly annual"`
+	StartDate    string  `json:"startDate,omitempty"` // YYYY-MM-DD; defaults to today
+	ReferenceID  *uint   `json:"referenceID,omitempty"`
+	Note         *string `json:"note,omitempty"`
+	IPAddress    string  `json:"-"`
+	DonorIdentity
+	DonationDedication
+}
+
+// UpdateRecurringStatusRequest pauses, resumes or cancels a recurring donation
+type UpdateRecurringStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active paused cancelled"`
+}
+
+// RecordOfflineDonationRequest is sent by temple staff to record a counter donation,
+// either for a registered devotee (DevoteeID) or a walk-in donor (DonorName).
+type RecordOfflineDonationRequest struct {
+	EntityID         uint     `json:"-"`
+	RecordedBy       uint     `json:"-"`
+	DevoteeID        *uint    `json:"devoteeId,omitempty"`
+	DonorName        string   `json:"donorName,omitempty"`
+	DonorPhone       string   `json:"donorPhone,omitempty"`
+	Amount           float64  `json:"amount" binding:"required,gt=0"`
+	DonationType     string   `json:"donationType" binding:"required,oneof=general seva event festival construction annadanam education maintenance"`
+	Method           string   `json:"method" binding:"required,oneof=CASH CHEQUE DD BANK_TRANSFER"`
+	InstrumentNumber string   `json:"instrumentNumber,omitempty"` // Cheque / DD / UTR number
+	InstrumentDate   string   `json:"instrumentDate,omitempty"`   // YYYY-MM-DD
+	DrawnOnBank      string   `json:"drawnOnBank,omitempty"`
+	DonatedAt        string   `json:"donatedAt,omitempty"` // YYYY-MM-DD; defaults to today
+	ReferenceID      *uint    `json:"referenceID,omitempty"`
+	CampaignID       *uint    `json:"campaignId,omitempty"`
+	PledgeID         *uint    `json:"pledgeId,omitempty"`
+	Note             *string  `json:"note,omitempty"`
+	IPAddress        string   `json:"-"`
+	DonorIdentity
+	DonationDedication
+}
+
+// UpdateClearanceRequest marks a cheque / DD as cleared or bounced
+type UpdateClearanceRequest struct {
+	Status string `json:"status" binding:"required,oneof=cleared bounced"`
+	Note   string `json:"note,omitempty"`
+}
+
+// CampaignRequest creates or updates a fundraising campaign
+type CampaignRequest struct {
+	Title         string  `json:"title" binding:"required"`
+	Description   string  `json:"description,omitempty"`
+	GoalAmount    float64 `json:"goalAmount" binding:"required,gt=0"`
+	StartDate     string  `json:"startDate" binding:"required"` // YYYY-MM-DD
+	EndDate       string  `json:"endDate" binding:"required"`   // YYYY-MM-DD, inclusive
+	CoverImageURL string  `json:"coverImageUrl,omitempty"`
+	DonationType  string  `json:"donationType" binding:"required,oneof=general seva event festival construction annadanam education maintenance"`
+}
+
+// CampaignProgress is the public progress of a campaign towards its goal
+type CampaignProgress struct {
+	Campaign      Campaign        `json:"campaign"`
+	Raised        float64         `json:"raised"`
+	DonationCount int             `json:"donation_count"`
+	DonorCount    int             `json:"donor_count"`
+	Percentage    float64         `json:"percentage"` // may exceed 100 once the goal is passed
+	RecentDonors  []CampaignDonor `json:"recent_donors"`
+}
+
+// CampaignTotals for campaign progress aggregation
+type CampaignTotals struct {
+	Raised        float64 `json:"raised"`
+	DonationCount int     `json:"donation_count"`
+	DonorCount    int     `json:"donor_count"`
+}
+
+// CampaignDonor is one entry in a campaign's public recent donors list
+type CampaignDonor struct {
+	Name      string    `json:"name" db:"name"`
+	Amount    float64   `json:"amount" db:"amount"`
+	DonatedAt time.Time `json:"donated_at" db:"donated_at"`
+}
+
+// RecordInKindDonationRequest is sent by temple staff to record a material
+// offering from a registered devotee or a walk-in donor
+type RecordInKindDonationRequest struct {
+	EntityID       uint    `json:"-"`
+	RecordedBy     uint    `json:"-"`
+	DevoteeID      *uint   `json:"devoteeId,omitempty"`
+	DonorName      string  `json:"donorName,omitempty"`
+	DonorPhone     string  `json:"donorPhone,omitempty"`
+	ItemName       string  `json:"itemName" binding:"required"`
+	Category       string  `json:"category,omitempty"`
+	Unit           string  `json:"unit" binding:"required"`
+	Quantity       float64 `json:"quantity" binding:"required,gt=0"`
+	EstimatedValue float64 `json:"estimatedValue" binding:"gte=0"`
+	Condition      string  `json:"condition,omitempty" binding:"omitempty,oneof=new good used"`
+	ReceivedAt     string  `json:"receivedAt,omitempty"` // YYYY-MM-DD; defaults to today
+	Note           *string `json:"note,omitempty"`
+	IPAddress      string  `json:"-"`
+}
+
+// InKindFilters for searching in-kind donations
+type InKindFilters struct {
+	EntityID uint       `json:"entity_id"`
+	UserID   uint       `json:"user_id,omitempty"`
+	Category string     `json:"category,omitempty"`
+	Search   string     `json:"search,omitempty"` // donor name / phone / email or item name
+	From     *time.Time `json:"from,omitempty"`
+	To       *time.Time `json:"to,omitempty"`
+	Page     int        `json:"page"`
+	Limit    int        `json:"limit"`
+}
+
+// InKindDonationWithUser is an in-kind donation with donor and temple names
+type InKindDonationWithUser struct {
+	InKindDonation
+	UserName   string `json:"userName"`
+	UserEmail  string `json:"userEmail"`
+	EntityName string `json:"entityName"`
+}
+
+// InKindAcknowledgement is the acknowledgement issued for an in-kind donation
+type InKindAcknowledgement struct {
+	ID                    uint      `json:"id"`
+	AcknowledgementNumber string    `json:"acknowledgementNumber"`
+	FinancialYear         string    `json:"financialYear"`
+	DonorName             string    `json:"donorName"`
+	DonorEmail            string    `json:"donorEmail,omitempty"`
+	DonorPhone            string    `json:"donorPhone,omitempty"`
+	ItemName              string    `json:"itemName"`
+	Category              string    `json:"category,omitempty"`
+	Quantity              float64   `json:"quantity"`
+	Unit                  string    `json:"unit"`
+	Condition             string    `json:"condition,omitempty"`
+	EstimatedValue        float64   `json:"estimatedValue"`
+	ReceivedAt            time.Time `json:"receivedAt"`
+	EntityName            string    `json:"entityName"`
+	EntityAddress         string    `json:"entityAddress,omitempty"`
+	TrustPAN              string    `json:"trustPan,omitempty"`
+	LogoURL               string    `json:"logoUrl,omitempty"`
+	GeneratedAt           time.Time `json:"generatedAt"`
+}
+
+// CreatePledgeRequest is sent by temple staff to record a pledge from a
+// registered devotee (DevoteeID) or a walk-in donor (DonorName). The schedule
+// is either generated — InstalmentCount equal instalments every Frequency from
+// StartDate — or given instalment by instalment in Schedule.
+type CreatePledgeRequest struct {
+	EntityID        uint             `json:"-"`
+	CreatedBy       uint             `json:"-"`
+	DevoteeID       *uint            `json:"devoteeId,omitempty"`
+	DonorName       string           `json:"donorName,omitempty"`
+	DonorPhone      string           `json:"donorPhone,omitempty"`
+	TotalAmount     float64          `json:"totalAmount" binding:"required,gt=0"`
+	DonationType    string           `json:"donationType" binding:"required,oneof=general seva event festival construction annadanam education maintenance"`
+	CampaignID      *uint            `json:"campaignId,omitempty"`
+	Frequency       string           `json:"frequency,omitempty" binding:"omitempty,oneof=monthly quarterly annual"`
+	InstalmentCount int              `json:"instalmentCount,omitempty" binding:"omitempty,min=1,max=120"`
+	StartDate       string           `json:"startDate,omitempty"` // YYYY-MM-DD; first due date, defaults to today
+	Schedule        []PledgeDueInput `json:"schedule,omitempty" binding:"omitempty,max=120,dive"`
+	Note            *string          `json:"note,omitempty"`
+	IPAddress       string           `json:"-"`
+}
+
+// PledgeDueInput is one instalment of a custom pledge schedule
+type PledgeDueInput struct {
+	DueDate string  `json:"dueDate" binding:"required"` // YYYY-MM-DD
+	Amount  float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// LinkPledgeDonationRequest links an existing donation to a pledge
+type LinkPledgeDonationRequest struct {
+	DonationID uint `json:"donationId" binding:"required"`
+}
+
+// CancelPledgeRequest cancels the unpaid remainder of a pledge
+type CancelPledgeRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// PledgeFilters for listing pledges
+type PledgeFilters struct {
+	EntityID   uint   `json:"entity_id"`
+	UserID     uint   `json:"user_id,omitempty"`
+	CampaignID uint   `json:"campaign_id,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Overdue    bool   `json:"overdue,omitempty"` // only pledges with an instalment past due
+	Search     string `json:"search,omitempty"`  // donor name / phone / email
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+}
+
+// PledgeWithBalance is a pledge with its donor, outstanding balance and the
+// amount already past due
+type PledgeWithBalance struct {
+	Pledge
+	DonorDisplayName string     `json:"donorDisplayName"`
+	DonorEmail       string     `json:"donorEmail,omitempty"`
+	CampaignTitle    string     `json:"campaignTitle,omitempty"`
+	Outstanding      float64    `json:"outstanding"`
+	OverdueAmount    float64    `json:"overdueAmount"`
+	NextDueDate      *time.Time `json:"nextDueDate,omitempty"`
+}
+
+// PledgeDetail is a pledge with its instalments and the donations paid towards it
+type PledgeDetail struct {
+	PledgeWithBalance
+	Donations []DonationWithUser `json:"donations"`
+}
+
+// OverdueInstalment is an unpaid instalment past its due date, with what the
+// reminder needs to know about its pledge
+type OverdueInstalment struct {
+	PledgeInstalment
+	UserID          uint    `json:"user_id"`
+	DonationType    string  `json:"donation_type"`
+	PledgeTotal     float64 `json:"pledge_total"`
+	InstalmentCount int     `json:"instalment_count"`
+}
+
+// CreatePaymentLinkRequest creates a UPI QR code and short payment link for
+// the temple, one of its sevas or a campaign (TargetID is the seva or campaign)
+type CreatePaymentLinkRequest struct {
+	EntityID   uint   `json:"-"`
+	CreatedBy  uint   `json:"-"`
+	TargetType string `json:"targetType" binding:"required,oneof=temple seva campaign"`
+	TargetID   *uint  `json:"targetId,omitempty"`
+	Title      string `json:"title,omitempty"` // Defaults to the temple, seva or campaign name
+
+	// Temple links only; defaults to general
+	DonationType string `json:"donationType,omitempty" binding:"omitempty,oneof=general seva event festival construction annadanam education maintenance"`
+
+	// Fixed amount; seva links default to the seva price
+	Amount    *float64 `json:"amount,omitempty" binding:"omitempty,gt=0"`
+	IPAddress string   `json:"-"`
+}
+
+// PaymentLinkFilters for listing payment links
+type PaymentLinkFilters struct {
+	EntityID   uint   `json:"entity_id"`
+	TargetType string `json:"target_type,omitempty"`
+	ActiveOnly bool   `json:"active_only,omitempty"`
+}
+
+// PaymentLinkView is a payment link with the UPI intent its QR code encodes,
+// its short link and what has been collected through it
+type PaymentLinkView struct {
+	PaymentLink
+	UPIURI        string  `json:"upiUri"`   // upi://pay intent of the static QR code
+	ShortURL      string  `json:"shortUrl"` // Opens the guest donation flow
+	DonationCount int     `json:"donationCount"`
+	Collected     float64 `json:"collected"`
+}
+
+// GuestPaymentLink is what the public guest donation page shows for a link
+type GuestPaymentLink struct {
+	Code         string   `json:"code"`
+	Title        string   `json:"title"`
+	TargetType   string   `json:"targetType"`
+	DonationType string   `json:"donationType"`
+	Amount       *float64 `json:"amount,omitempty"` // Fixed amount; the payer chooses when absent
+	TempleName   string   `json:"templeName"`
+	PayeeName    string   `json:"payeeName"`
+	PayeeUPIID   string   `json:"payeeUpiId"`
+	LogoURL      string   `json:"logoUrl,omitempty"`
+}
+
+// GuestDonationRequest is sent from the guest donation page, without logging in
+type GuestDonationRequest struct {
+	Amount     float64 `json:"amount,omitempty" binding:"omitempty,gt=0"` // Required unless the link has a fixed amount
+	DonorName  string  `json:"donorName" binding:"required"`
+	DonorPhone string  `json:"donorPhone,omitempty"`
+	Note       *string `json:"note,omitempty"`
+	IPAddress  string  `json:"-"`
 }
 
-// CreateDonationResponse is returned to frontend after creating Razorpay order
-// CreateDonationResponse is returned to frontend after creating Razorpay order
+// GuestDonationResponse carries the UPI intent of a guest donation. Reference
+// is sent as the UPI transaction reference, so the payment can be matched
+// back to the pending donation when it reaches the temple's account.
+type GuestDonationResponse struct {
+	DonationID uint    `json:"donationId"`
+	Reference  string  `json:"reference"`
+	Amount     float64 `json:"amount"`
+	UPIURI     string  `json:"upiUri"`
+	QRCode     string  `json:"qrCode"` // PNG data URL of UPIURI, for payers on a desktop
+}
+
+// MatchUPIPaymentRequest records a UPI payment seen on the temple's bank
+// statement against the reference it carries: either a guest donation's
+// reference or the code of a payment link whose static QR was scanned
+type MatchUPIPaymentRequest struct {
+	EntityID   uint    `json:"-"`
+	RecordedBy uint    `json:"-"`
+	Reference  string  `json:"reference" binding:"required"`
+	UTR        string  `json:"utr" binding:"required,max=35"` // UPI transaction reference number of the payment
+	Amount     float64 `json:"amount" binding:"required,gt=0"`
+	PaidAt     string  `json:"paidAt,omitempty"` // YYYY-MM-DD; defaults to today
+	PayerName  string  `json:"payerName,omitempty"`
+	PayerPhone string  `json:"payerPhone,omitempty"`
+	IPAddress  string  `json:"-"`
+}
+
+// LinkSeva is what a seva payment link needs to know about the seva
+type LinkSeva struct {
+	ID       uint    `db:"id"`
+	EntityID uint    `db:"entity_id"`
+	Name     string  `db:"name"`
+	Price    float64 `db:"price"`
+	IsActive bool    `db:"is_active"`
+}
+
+// InitiateRefundRequest is sent by temple admins to refund a donation or seva booking.
+// Amount 0 refunds whatever has not been refunded yet.
+type InitiateRefundRequest struct {
+	SourceType  string  `json:"-"`
+	SourceID    uint    `json:"-"`
+	Amount      float64 `json:"amount" binding:"gte=0"`
+	Reason      string  `json:"reason" binding:"required"`
+	InitiatedBy uint    `json:"-"`
+	IPAddress   string  `json:"-"`
+}
+
+// RefundFilters for listing refunds
+type RefundFilters struct {
+	EntityID   uint   `json:"entity_id"`
+	SourceType string `json:"source_type,omitempty"`
+	SourceID   uint   `json:"source_id,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+}
+
+// RefundSource is the paid record a refund is raised against
+type RefundSource struct {
+	EntityID  uint    `db:"entity_id"`
+	UserID    uint    `db:"user_id"`
+	Amount    float64 `db:"amount"`
+	OrderID   string  `db:"order_id"`
+	PaymentID string  `db:"payment_id"`
+	Paid      bool    `db:"paid"`
+
+	// Currency the gateway was paid in; Amount stays in rupees
+	Currency              string  `db:"currency"`
+	ExchangeRate          float64 `db:"exchange_rate"`
+	IsForeignContribution bool    `db:"is_foreign_contribution"`
+}
+
+// UserContact is the devotee contact prefilled on the gateway checkout
+type UserContact struct {
+	Email string
+	Phone string
+}
+
+// CreateDonationResponse is returned to frontend after creating the gateway order
 type CreateDonationResponse struct {
-	OrderID     string             `json:"order_id"`
-	Amount      float64            `json:"amount"`
-	Currency    string             `json:"currency"`
-	RazorpayKey string             `json:"razorpay_key"`
-	Tenant      TenantPaymentInfo  `json:"tenant"`
+	OrderID          string             `json:"order_id"`
+	Amount           float64            `json:"amount"`
+	Currency         string             `json:"currency"`
+	Provider         string             `json:"provider"`                     // razorpay | cashfree | fake
+	RazorpayKey      string             `json:"razorpay_key"`                 // public key for Razorpay checkout
+	PaymentSessionID string             `json:"payment_session_id,omitempty"` // Cashfree checkout session
+	Tenant           TenantPaymentInfo  `json:"tenant"`
+
+	AmountINR             float64 `json:"amount_inr"`    // Rupee value at the current rate
+	ExchangeRate          float64 `json:"exchange_rate"` // Rupees per unit of Currency
+	IsForeignContribution bool    `json:"is_foreign_contribution"`
 }
 
 // TenantPaymentInfo holds the tenant's registered bank details
@@ -37,14 +434,17 @@ type TenantPaymentInfo struct {
 	IFSCCode          string `json:"ifsc_code"`
 	AccountType       string `json:"account_type"`
 	UPIID             string `json:"upi_id"`
+
+	FCRARegistrationNumber string `json:"fcra_registration_number,omitempty"` // Set when paying into the FCRA account
 }
 
 // VerifyPaymentRequest is used by frontend to confirm payment success.
 // Frontend sends: { paymentID, orderID, razorpaySig } — these match the json tags below ✅
+// Cashfree checkouts have no client-side signature; only orderID is required there.
 type VerifyPaymentRequest struct {
-	OrderID     string `json:"orderID" binding:"required"`     // Razorpay order ID from frontend
-	PaymentID   string `json:"paymentID" binding:"required"`   // Razorpay payment ID from frontend
-	RazorpaySig string `json:"razorpaySig" binding:"required"` // Signature to verify payment
+	OrderID     string `json:"orderID" binding:"required"` // Gateway order ID from frontend
+	PaymentID   string `json:"paymentID"`                  // Gateway payment ID from frontend
+	RazorpaySig string `json:"razorpaySig"`                // Razorpay signature to verify payment
 	IPAddress   string `json:"-"`                              // For audit logging (filled from middleware)
 }
 
@@ -63,15 +463,57 @@ type DonationWithUser struct {
 	Amount       float64   `json:"amount" db:"amount"`
 	DonationType string    `json:"donationType" db:"donation_type"`
 	ReferenceID  *uint     `json:"referenceID,omitempty" db:"reference_id"`
+	CampaignID    *uint    `json:"campaignId,omitempty" db:"campaign_id"`
+	CampaignTitle string   `json:"campaignTitle,omitempty" db:"campaign_title"`
 	Method       string    `json:"paymentMethod" db:"method"` // primary JSON key: paymentMethod
 	Status       string    `json:"status" db:"status"`
 	OrderID      string    `json:"transactionId" db:"order_id"` // frontend getOrderId() looks for transactionId ✅
 	PaymentID    *string   `json:"paymentId,omitempty" db:"payment_id"` // frontend getPaymentId() looks for paymentId ✅
 	Note         *string   `json:"note,omitempty" db:"note"`
 	DonatedAt    *time.Time `json:"donatedAt,omitempty" db:"donated_at"`
+	DonorPAN     *string    `json:"donorPan,omitempty" db:"donor_pan"`
+	DonorAadhaar *string    `json:"donorAadhaar,omitempty" db:"donor_aadhaar"` // masked
+	DonorAddress *string    `json:"donorAddress,omitempty" db:"donor_address"`
+
+	// Currency and FCRA details (Amount is always in rupees)
+	Currency              string  `json:"currency" db:"currency"`
+	OriginalAmount        float64 `json:"originalAmount" db:"original_amount"`
+	ExchangeRate          float64 `json:"exchangeRate" db:"exchange_rate"`
+	IsForeignContribution bool    `json:"isForeignContribution" db:"is_foreign_contribution"`
+	DonorCountry          *string `json:"donorCountry,omitempty" db:"donor_country"`
+	DonorNationality      *string `json:"donorNationality,omitempty" db:"donor_nationality"`
+
+	// Donor privacy and dedication
+	IsAnonymous    bool    `json:"isAnonymous" db:"is_anonymous"`
+	DedicationType string  `json:"dedicationType,omitempty" db:"dedication_type"`
+	OnBehalfOf     *string `json:"onBehalfOf,omitempty" db:"on_behalf_of"`
+
+	// The actual payer, even for anonymous gifts — used for the receipt only
+	PayerName  string  `json:"-" db:"payer_name"`
+	PayerEmail string  `json:"-" db:"payer_email"`
+	PayerPAN   *string `json:"-" db:"payer_pan"`
+
+	RecurringDonationID *uint   `json:"recurringDonationId,omitempty" db:"recurring_donation_id"`
+	PledgeID            *uint   `json:"pledgeId,omitempty" db:"pledge_id"`
+	PaymentLinkID       *uint   `json:"paymentLinkId,omitempty" db:"payment_link_id"`
+	RefundedAmount      float64 `json:"refundedAmount" db:"refunded_amount"`
+
+	// Offline donation details
+	IsOffline        bool       `json:"isOffline" db:"is_offline"`
+	RecordedBy       *uint      `json:"recordedBy,omitempty" db:"recorded_by"`
+	DonorPhone       *string    `json:"donorPhone,omitempty" db:"donor_phone"`
+	InstrumentNumber *string    `json:"instrumentNumber,omitempty" db:"instrument_number"`
+	InstrumentDate   *time.Time `json:"instrumentDate,omitempty" db:"instrument_date"`
+	DrawnOnBank      *string    `json:"drawnOnBank,omitempty" db:"drawn_on_bank"`
+	ClearanceStatus  string     `json:"clearanceStatus,omitempty" db:"clearance_status"`
 	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
 
+	// 80G receipt numbering (empty until the donation succeeds)
+	ReceiptNumber   *string    `json:"receiptNumber,omitempty" db:"receipt_number"`
+	FinancialYear   string     `json:"financialYear,omitempty" db:"financial_year"`
+	ReceiptIssuedAt *time.Time `json:"receiptIssuedAt,omitempty" db:"receipt_issued_at"`
+
 	// User information
 	UserName  string `json:"userName" db:"user_name"`
 	UserEmail string `json:"userEmail" db:"user_email"`
@@ -104,6 +546,8 @@ type DonationFilters struct {
 	Status    string     `json:"status,omitempty"`
 	Type      string     `json:"type,omitempty"`
 	Method    string     `json:"method,omitempty"`
+	CampaignID uint      `json:"campaign_id,omitempty"`
+	Contribution string  `json:"contribution,omitempty"` // ContributionDomestic | ContributionForeign
 	From      *time.Time `json:"from,omitempty"`
 	To        *time.Time `json:"to,omitempty"`
 	MinAmount *float64   `json:"min_amount,omitempty"`
@@ -126,6 +570,10 @@ type UpdatePaymentDetailsParams struct {
 	AccountType       string // UPI / CARD / BANK_TRANSFER / WALLET etc.
 	IFSCCode          string // Temple's IFSC code
 	UPIID             string // Temple's UPI ID
+
+	// Foreign-currency gifts only: Amount is then the rupee value of OriginalAmount
+	OriginalAmount float64
+	ExchangeRate   float64
 }
 
 // ==============================
@@ -143,6 +591,13 @@ type DashboardStats struct {
 	Today          float64 `json:"today"`
 	TotalDonors    int     `json:"totalDonors"`
 	AverageAmount  float64 `json:"averageAmount"`
+	RefundedAmount float64 `json:"refundedAmount"` // already netted out of TotalAmount
+	RefundedCount  int     `json:"refunded"`
+
+	// FCRA split of TotalAmount
+	DomesticAmount float64 `json:"domesticAmount"`
+	ForeignAmount  float64 `json:"foreignAmount"`
+	ForeignCount   int     `json:"foreignCount"`
 }
 
 // StatsResult for database aggregation queries
@@ -152,6 +607,10 @@ type StatsResult struct {
 	CompletedCount int     `json:"completed_count"`
 	PendingCount   int     `json:"pending_count"`
 	FailedCount    int     `json:"failed_count"`
+	RefundedAmount float64 `json:"refunded_amount"`
+	RefundedCount  int     `json:"refunded_count"`
+	ForeignAmount  float64 `json:"foreign_amount"`
+	ForeignCount   int     `json:"foreign_count"`
 }
 
 // TopDonor represents a top donor
@@ -160,6 +619,10 @@ type TopDonor struct {
 	Email         string  `json:"email"`
 	TotalAmount   float64 `json:"total_amount"`
 	DonationCount int     `json:"donation_count"`
+
+	// In-kind offerings are valued separately; donors are ranked on both together
+	InKindValue float64 `json:"in_kind_value"`
+	InKindCount int     `json:"in_kind_count"`
 }
 
 // TrendData for donation trends over time
@@ -183,11 +646,21 @@ type MethodData struct {
 	Count  int     `json:"count"`
 }
 
+// CampaignData for donations by campaign
+type CampaignData struct {
+	CampaignID uint    `json:"campaignId"`
+	Title      string  `json:"title"`
+	GoalAmount float64 `json:"goalAmount"`
+	Amount     float64 `json:"amount"`
+	Count      int     `json:"count"`
+}
+
 // AnalyticsData combines all analytics information
 type AnalyticsData struct {
-	Trends   []TrendData  `json:"trends"`
-	ByType   []TypeData   `json:"byType"`
-	ByMethod []MethodData `json:"byMethod"`
+	Trends     []TrendData    `json:"trends"`
+	ByType     []TypeData     `json:"byType"`
+	ByMethod   []MethodData   `json:"byMethod"`
+	ByCampaign []CampaignData `json:"byCampaign"`
 }
 
 // Receipt represents a donation receipt
@@ -203,6 +676,44 @@ type Receipt struct {
 	EntityName     string    `json:"entityName"`
 	ReceiptNumber  string    `json:"receiptNumber"`
 	GeneratedAt    time.Time `json:"generatedAt"`
+
+	// 80G details
+	FinancialYear   string `json:"financialYear"`
+	DonorPAN        string `json:"donorPan,omitempty"`
+	DedicationType  string `json:"dedicationType,omitempty"`
+	OnBehalfOf      string `json:"onBehalfOf,omitempty"`
+	AmountInWords   string `json:"amountInWords"`
+
+	// Partially refunded gifts: only what the temple kept is eligible
+	RefundedAmount float64 `json:"refundedAmount,omitempty"`
+	EligibleAmount float64 `json:"eligibleAmount"`
+
+	// Foreign-currency gifts
+	Currency               string  `json:"currency"`
+	OriginalAmount         float64 `json:"originalAmount,omitempty"`
+	ExchangeRate           float64 `json:"exchangeRate,omitempty"`
+	IsForeignContribution  bool    `json:"isForeignContribution,omitempty"`
+	FCRARegistrationNumber string  `json:"fcraRegistrationNumber,omitempty"`
+
+	EntityAddress   string `json:"entityAddress,omitempty"`
+	Registration80G string `json:"registration80G,omitempty"`
+	TrustPAN        string `json:"trustPan,omitempty"`
+	LogoURL         string `json:"logoUrl,omitempty"`
+}
+
+// ReceiptEntityDetails holds the temple fields printed on a receipt
+type ReceiptEntityDetails struct {
+	Name            string `db:"name"`
+	StreetAddress   string `db:"street_address"`
+	City            string `db:"city"`
+	District        string `db:"district"`
+	State           string `db:"state"`
+	Pincode         string `db:"pincode"`
+	Phone           string `db:"phone"`
+	Email           string `db:"email"`
+	Media           string `db:"media"`
+	Registration80G string `db:"registration_80g"`
+	TrustPAN        string `db:"trust_pan"`
 }
 
 // DonationListResponse represents paginated donation list response
@@ -224,4 +735,142 @@ type RecentDonation struct {
 	DonatedAt    time.Time `json:"donated_at" db:"donated_at"`
 	UserName     string    `json:"user_name" db:"user_name"`
 	EntityName   string    `json:"entity_name" db:"entity_name"`
-}
\ No newline at end of file
+}
+
+// WebhookEventFilters narrows the stored payment webhook list
+type WebhookEventFilters struct {
+	Provider  string
+	Status    string
+	EventType string
+	OrderID   string
+	From      *time.Time
+	To        *time.Time
+	Page      int
+	Limit     int
+}
+
+// ReconcileReport summarises one run of the stale payment reconciler
+type ReconcileReport struct {
+	StartedAt    time.Time                 `json:"started_at"`
+	FinishedAt   time.Time                 `json:"finished_at"`
+	Scanned      int                       `json:"scanned"`
+	Succeeded    int                       `json:"succeeded"`
+	Failed       int                       `json:"failed"`
+	StillPending int                       `json:"still_pending"`
+	Errors       int                       `json:"errors"`
+	Results      []payment.ReconcileResult `json:"results"`
+}
+
+// DonorStatementFilters for the annual statement status list
+type DonorStatementFilters struct {
+	EntityID      uint   `json:"entity_id"`
+	FinancialYear string `json:"financial_year"`
+	Status        string `json:"status,omitempty"`
+	Search        string `json:"search,omitempty"` // donor name or email
+	Page          int    `json:"page"`
+	Limit         int    `json:"limit"`
+}
+
+// DonorStatementWithUser is a statement row with the donor's name
+type DonorStatementWithUser struct {
+	DonorStatement
+	DonorName string `json:"donor_name"`
+}
+
+// DonorStatementTotal is one donor's successful giving at a temple in a financial year
+type DonorStatementTotal struct {
+	UserID        uint    `db:"user_id"`
+	DonationCount int     `db:"donation_count"`
+	TotalAmount   float64 `db:"total_amount"`
+}
+
+// StatementRunRequest starts statement generation for a financial year
+type StatementRunRequest struct {
+	FinancialYear string `json:"financial_year" binding:"required"` // e.g. 2025-26
+}
+
+// StatementRunReport summarises one statement run for a temple
+type StatementRunReport struct {
+	EntityID      uint   `json:"entity_id"`
+	FinancialYear string `json:"financial_year"`
+	Donors        int    `json:"donors"`
+	Sent          int    `json:"sent"`
+	Failed        int    `json:"failed"`
+	NoEmail       int    `json:"no_email"`
+	Skipped       int    `json:"skipped"` // already sent earlier
+}
+
+// AnnualStatement is the consolidated statement printed for one donor
+type AnnualStatement struct {
+	FinancialYear   string          `json:"financialYear"`
+	DonorName       string          `json:"donorName"`
+	DonorEmail      string          `json:"donorEmail,omitempty"`
+	DonorPAN        string          `json:"donorPan,omitempty"`
+	Lines           []StatementLine `json:"lines"`
+	TotalAmount     float64         `json:"totalAmount"`
+	AmountInWords   string          `json:"amountInWords"`
+	EntityName      string          `json:"entityName"`
+	EntityAddress   string          `json:"entityAddress,omitempty"`
+	Registration80G string          `json:"registration80G,omitempty"`
+	TrustPAN        string          `json:"trustPan,omitempty"`
+	GeneratedAt     time.Time       `json:"generatedAt"`
+}
+
+// StatementLine is one receipt on an annual statement
+type StatementLine struct {
+	ReceiptNumber string    `json:"receiptNumber"`
+	DonatedAt     time.Time `json:"donatedAt"`
+	DonationType  string    `json:"donationType"`
+	Method        string    `json:"method"`
+	Amount        float64   `json:"amount"`
+	Refunded      float64   `json:"refunded"`
+	NetAmount     float64   `json:"netAmount"`
+}
+
+// Form10BDDonation is a successful gift with the donor identity Form 10BD needs
+type Form10BDDonation struct {
+	ID           uint      `db:"id"`
+	UserID       uint      `db:"user_id"`
+	NetAmount    float64   `db:"net_amount"`
+	DonationType string    `db:"donation_type"`
+	Method       string    `db:"method"`
+	DonatedAt    time.Time `db:"donated_at"`
+	DonorPAN     string    `db:"donor_pan"`
+	DonorAadhaar string    `db:"donor_aadhaar"`
+	DonorName    string    `db:"donor_name"`
+	DonorAddress string    `db:"donor_address"`
+}
+
+// Form10BDRow is one line of Form 10BD: a donor's gifts of one donation type
+// received by one mode, summed over the financial year
+type Form10BDRow struct {
+	IDCode       string  `json:"idCode"`
+	IDNumber     string  `json:"-"` // full PAN / Aadhaar, only written to the 10BD file
+	SectionCode  string  `json:"sectionCode"`
+	DonorName    string  `json:"donorName"`
+	DonorAddress string  `json:"donorAddress"`
+	DonationType string  `json:"donationType"`
+	Mode         string  `json:"mode"`
+	Amount       float64 `json:"amount"`
+	DonationIDs  []uint  `json:"donationIds"`
+}
+
+// Form10BECertificate is the certificate of donation issued to a donor from
+// the rows filed for them in Form 10BD
+type Form10BECertificate struct {
+	FinancialYear   string        `json:"financialYear"`
+	DonorName       string        `json:"donorName"`
+	DonorAddress    string        `json:"donorAddress"`
+	IDCode          string        `json:"idCode"`
+	IDNumber        string        `json:"idNumber"` // Aadhaar numbers are masked
+	URN             string        `json:"urn,omitempty"`
+	URNDate         string        `json:"urnDate,omitempty"`
+	Rows            []Form10BDRow `json:"rows"`
+	TotalAmount     float64       `json:"totalAmount"`
+	AmountInWords   string        `json:"amountInWords"`
+	EntityName      string        `json:"entityName"`
+	EntityAddress   string        `json:"entityAddress,omitempty"`
+	Registration80G string        `json:"registration80G,omitempty"`
+	TrustPAN        string        `json:"trustPan,omitempty"`
+	GeneratedAt     time.Time     `json:"generatedAt"`
+}
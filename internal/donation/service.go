@@ -3,23 +3,24 @@ package donation
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
 	"encoding/csv"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
-	razorpay "github.com/razorpay/razorpay-go"
 	"github.com/sharath018/temple-management-backend/config"
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/payment"
 	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
 )
 
-// EntityBankDetails - temple's registered bank/UPI info including Razorpay credentials.
+// EntityBankDetails - temple's registered bank/UPI info including payment gateway credentials.
 type EntityBankDetails struct {
 	AccountHolderName string
 	AccountNumber     string
@@ -30,8 +31,60 @@ type EntityBankDetails struct {
 	AccountType       string
 	RazorpayKeyID     string // tenant's own Razorpay key — stored in tenant_bank_account_details
 	RazorpaySecret    string // tenant's own Razorpay secret — stored in tenant_bank_account_details
+	PaymentProvider   string // provider chosen by the tenant: razorpay (default) | cashfree
+	CashfreeAppID     string
+	CashfreeSecret    string
+
+	// FCRA-designated account for foreign contributions, paid through the same
+	// provider with its own gateway credentials
+	FCRARegistrationNumber string
+	FCRAAccountHolderName  string
+	FCRAAccountNumber      string
+	FCRABankName           string
+	FCRABranchName         string
+	FCRAIFSCCode           string
+	FCRARazorpayKeyID      string
+	FCRARazorpaySecret     string
+	FCRACashfreeAppID      string
+	FCRACashfreeSecret     string
 }
 
+// paymentSettings returns the provider selection and credentials for payment.Resolver
+func (eb *EntityBankDetails) paymentSettings() payment.TenantSettings {
+	if eb == nil {
+		return payment.TenantSettings{}
+	}
+	return payment.TenantSettings{
+		Provider:       eb.PaymentProvider,
+		RazorpayKeyID:  eb.RazorpayKeyID,
+		RazorpaySecret: eb.RazorpaySecret,
+		CashfreeAppID:  eb.CashfreeAppID,
+		CashfreeSecret: eb.CashfreeSecret,
+	}
+}
+
+// hasFCRAAccount reports whether the tenant registered an FCRA account to receive foreign contributions
+func (eb *EntityBankDetails) hasFCRAAccount() bool {
+	return eb != nil && eb.FCRARegistrationNumber != "" && eb.FCRAAccountNumber != ""
+}
+
+// fcraPaymentSettings returns the gateway credentials of the FCRA account
+func (eb *EntityBankDetails) fcraPaymentSettings() payment.TenantSettings {
+	if eb == nil {
+		return payment.TenantSettings{}
+	}
+	return payment.TenantSettings{
+		Provider:       eb.PaymentProvider,
+		RazorpayKeyID:  eb.FCRARazorpayKeyID,
+		RazorpaySecret: eb.FCRARazorpaySecret,
+		CashfreeAppID:  eb.FCRACashfreeAppID,
+		CashfreeSecret: eb.FCRACashfreeSecret,
+	}
+}
+
+// ErrFCRANotConfigured is returned for a foreign contribution to a temple without an FCRA account
+var ErrFCRANotConfigured = errors.New("temple has no FCRA account configured to accept foreign contributions")
+
 // EntityRepository - minimal interface to fetch temple bank details.
 type EntityRepository interface {
 	GetBankDetailsByEntityID(ctx context.Context, entityID uint) (*EntityBankDetails, error)
@@ -41,23 +94,97 @@ type EntityRepository interface {
 type Service interface {
 	StartDonation(req CreateDonationRequest) (*CreateDonationResponse, error)
 	VerifyAndUpdateDonation(req VerifyPaymentRequest) error
-	HandleRazorpayWebhook(orderID, paymentID, method string, amount float64) error
+	ParsePaymentWebhook(provider string, body []byte, headers http.Header) (*payment.WebhookEvent, error)
+	HandlePaymentCapturedWebhook(orderID, paymentID, method string, amount float64) error
 	HandleFailedPaymentWebhook(orderID, paymentID string) error
 
+	// Stored payment webhooks
+	ReceivePaymentWebhook(provider string, body []byte, headers http.Header) (*PaymentWebhookEvent, bool, error)
+	RetryFailedWebhooks(ctx context.Context) (int, error)
+	ListWebhookEvents(filters WebhookEventFilters) ([]PaymentWebhookEvent, int, error)
+	ReplayWebhookEvent(id uint, userID uint, ip string) (*PaymentWebhookEvent, error)
+
 	GetDonationsByUser(userID uint) ([]DonationWithUser, error)
 	GetDonationsByUserAndEntity(userID uint, entityID uint) ([]DonationWithUser, error)
 	GetDonationsWithFilters(filters DonationFilters, accessContext middleware.AccessContext) ([]DonationWithUser, int, error)
 
 	GetDashboardStats(entityID uint, accessContext middleware.AccessContext) (*DashboardStats, error)
 	GetTopDonors(entityID uint, limit int, accessContext middleware.AccessContext) ([]TopDonor, error)
-	GetAnalytics(entityID uint, days int, accessContext middleware.AccessContext) (*AnalyticsData, error)
+	GetAnalytics(entityID uint, days int, campaignID uint, accessContext middleware.AccessContext) (*AnalyticsData, error)
 
 	GenerateReceipt(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint) (*Receipt, error)
+	GenerateReceiptPDF(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint) ([]byte, string, error)
 	ExportDonations(filters DonationFilters, format string, accessContext middleware.AccessContext) ([]byte, string, error)
+	ExportForm10BD(entityID uint, financialYear string, accessContext middleware.AccessContext) ([]byte, string, int, error)
+	GenerateForm10BE(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint, urn, urnDate string) ([]byte, string, error)
 
 	GetRecentDonationsByUser(ctx context.Context, userID uint, limit int) ([]RecentDonation, error)
 	GetRecentDonationsByUserAndEntity(ctx context.Context, userID uint, entityID uint, limit int) ([]RecentDonation, error)
 	GetRecentDonationsByEntity(ctx context.Context, entityID uint, limit int, accessContext middleware.AccessContext) ([]RecentDonation, error)
+
+	// Refunds
+	InitiateRefund(req InitiateRefundRequest, accessContext middleware.AccessContext) (*Refund, error)
+	RefundSevaBooking(ctx context.Context, bookingID uint, amount float64, reason string, initiatedBy uint, ip string) (uint, string, error)
+	HandleRefundWebhook(tenantID uint, gatewayRefundID, paymentID string, localRefundID uint, amount float64, processed bool, reason string) error
+	ListRefunds(filters RefundFilters, accessContext middleware.AccessContext) ([]Refund, int, error)
+
+	// Offline (counter) donations
+	RecordOfflineDonation(req RecordOfflineDonationRequest, accessContext middleware.AccessContext) (*DonationWithUser, error)
+	UpdateOfflineClearance(donationID uint, req UpdateClearanceRequest, userID uint, accessContext middleware.AccessContext, ip string) (*DonationWithUser, error)
+
+	// In-kind (material) donations
+	RecordInKindDonation(req RecordInKindDonationRequest, accessContext middleware.AccessContext) (*InKindDonationWithUser, error)
+	ListInKindDonations(filters InKindFilters, accessContext middleware.AccessContext) ([]InKindDonationWithUser, int, error)
+	GetInKindDonationsByUserAndEntity(userID uint, entityID uint) ([]InKindDonationWithUser, error)
+	GetInKindAcknowledgement(id uint, userID uint, accessContext *middleware.AccessContext, entityID uint) (*InKindAcknowledgement, error)
+	GenerateInKindAcknowledgementPDF(id uint, userID uint, accessContext *middleware.AccessContext, entityID uint) ([]byte, string, error)
+
+	// Fundraising campaigns
+	CreateCampaign(req CampaignRequest, entityID uint, userID uint, accessContext middleware.AccessContext, ip string) (*Campaign, error)
+	UpdateCampaign(id uint, req CampaignRequest, userID uint, accessContext middleware.AccessContext, ip string) (*Campaign, error)
+	CloseCampaign(id uint, userID uint, accessContext middleware.AccessContext, ip string) (*Campaign, error)
+	ListCampaigns(entityID uint, accessContext middleware.AccessContext) ([]Campaign, error)
+	GetCampaignProgress(id uint) (*CampaignProgress, error)
+
+	// Recurring donations
+	CreateRecurringDonation(req CreateRecurringDonationRequest) (*RecurringDonation, error)
+	GetMyRecurringDonations(userID uint, entityID uint) ([]RecurringDonation, error)
+	UpdateRecurringDonationStatus(id uint, userID uint, status string, ip string) (*RecurringDonation, error)
+	ProcessDueRecurringDonations(ctx context.Context) (int, error)
+
+	// Pledges
+	CreatePledge(req CreatePledgeRequest, accessContext middleware.AccessContext) (*PledgeDetail, error)
+	ListPledges(filters PledgeFilters, accessContext middleware.AccessContext) ([]PledgeWithBalance, int, error)
+	GetMyPledges(userID uint, entityID uint) ([]PledgeWithBalance, error)
+	GetPledge(id uint, userID uint, accessContext *middleware.AccessContext, entityID uint) (*PledgeDetail, error)
+	LinkPledgeDonation(pledgeID uint, req LinkPledgeDonationRequest, userID uint, accessContext middleware.AccessContext, ip string) (*PledgeDetail, error)
+	CancelPledge(id uint, req CancelPledgeRequest, userID uint, accessContext middleware.AccessContext, ip string) (*PledgeDetail, error)
+	SendPledgeReminders(ctx context.Context) (int, error)
+
+	// UPI payment links and QR codes
+	CreatePaymentLink(req CreatePaymentLinkRequest, accessContext middleware.AccessContext) (*PaymentLinkView, error)
+	ListPaymentLinks(filters PaymentLinkFilters, accessContext middleware.AccessContext) ([]PaymentLinkView, error)
+	DeactivatePaymentLink(id uint, userID uint, accessContext middleware.AccessContext, ip string) (*PaymentLink, error)
+	MatchUPIPayment(req MatchUPIPaymentRequest, accessContext middleware.AccessContext) (*DonationWithUser, error)
+	GetGuestPaymentLink(code string) (*GuestPaymentLink, error)
+	GetPaymentLinkQR(code string, content string, format string, size int) ([]byte, string, error)
+	StartGuestDonation(code string, req GuestDonationRequest) (*GuestDonationResponse, error)
+
+	// Stale payment reconciliation
+	ReconcileStalePayments(ctx context.Context, triggeredBy *uint, ip string) (*ReconcileReport, error)
+
+	// Annual donor statements
+	GenerateAnnualStatements(ctx context.Context, entityID uint, financialYear string, triggeredBy *uint, ip string) (*StatementRunReport, error)
+	SendPendingStatements(ctx context.Context) (int, error)
+	ListDonorStatements(filters DonorStatementFilters, accessContext middleware.AccessContext) ([]DonorStatementWithUser, int, error)
+	PreviewDonorStatement(entityID, userID uint, financialYear string, accessContext middleware.AccessContext) ([]byte, string, error)
+	RegenerateDonorStatement(entityID, userID uint, financialYear string, accessContext middleware.AccessContext, ip string) (*DonorStatement, error)
+	ResendDonorStatement(entityID, userID uint, financialYear string, accessContext middleware.AccessContext, ip string) (*DonorStatement, error)
+
+	SetSubscriptionGateway(g SubscriptionGateway)
+	SetNotifService(n notification.Service)
+	SetSevaReconciler(r SevaPaymentReconciler)
+	SetStatementMailer(m StatementMailer)
 }
 
 type service struct {
@@ -65,16 +192,27 @@ type service struct {
 	entityRepo EntityRepository
 	cfg        *config.Config // kept for non-Razorpay config
 	auditSvc   auditlog.Service
+	notifSvc   notification.Service
+	subGateway SubscriptionGateway // raises recurring donation occurrences
+	payments   *payment.Resolver   // picks each tenant's payment provider
+	fx         payment.ExchangeRates // rupee rates for foreign-currency gifts
+
+	sevaReconciler SevaPaymentReconciler // settles stale seva booking payments
+	mailer         StatementMailer       // emails annual donor statements
 }
 
 // NewService creates a donation service without entity repo
 func NewService(repo Repository, cfg *config.Config, auditSvc auditlog.Service) Service {
-	return &service{repo: repo, cfg: cfg, auditSvc: auditSvc}
+	return &service{repo: repo, cfg: cfg, auditSvc: auditSvc, subGateway: NewSubscriptionGateway(cfg.SubscriptionGateway), payments: payment.NewResolver(cfg), fx: payment.NewExchangeRates(cfg)}
 }
 
-// NewServiceWithEntityRepo creates a donation service with entity repo (required for Razorpay)
+// NewServiceWithEntityRepo creates a donation service with entity repo (required for online payments)
 func NewServiceWithEntityRepo(repo Repository, entityRepo EntityRepository, cfg *config.Config, auditSvc auditlog.Service) Service {
-	return &service{repo: repo, entityRepo: entityRepo, cfg: cfg, auditSvc: auditSvc}
+	return &service{repo: repo, entityRepo: entityRepo, cfg: cfg, auditSvc: auditSvc, subGateway: NewSubscriptionGateway(cfg.SubscriptionGateway), payments: payment.NewResolver(cfg), fx: payment.NewExchangeRates(cfg)}
+}
+
+func (s *service) SetNotifService(n notification.Service) {
+	s.notifSvc = n
 }
 
 // ==============================
@@ -92,96 +230,248 @@ func (s *service) getTenantBank(ctx context.Context, entityID uint) *EntityBankD
 	return eb
 }
 
+// paymentProvider returns the payment provider chosen by the temple's tenant,
+// along with the tenant bank details (nil when none are registered)
+func (s *service) paymentProvider(ctx context.Context, entityID uint) (payment.Provider, *EntityBankDetails, error) {
+	eb := s.getTenantBank(ctx, entityID)
+	provider, err := s.payments.ForTenant(eb.paymentSettings())
+	if err != nil {
+		return nil, eb, err
+	}
+	return provider, eb, nil
+}
+
+// accountProvider returns the provider for the account a payment is made into:
+// the FCRA account for foreign contributions, the tenant's main account otherwise
+func (s *service) accountProvider(ctx context.Context, entityID uint, foreign bool) (payment.Provider, *EntityBankDetails, error) {
+	if !foreign {
+		return s.paymentProvider(ctx, entityID)
+	}
+	eb := s.getTenantBank(ctx, entityID)
+	if !eb.hasFCRAAccount() {
+		return nil, eb, ErrFCRANotConfigured
+	}
+	provider, err := s.payments.ForTenant(eb.fcraPaymentSettings())
+	if err != nil {
+		return nil, eb, err
+	}
+	return provider, eb, nil
+}
+
+// payeeAccount returns the bank account details recorded against a payment
+func (eb *EntityBankDetails) payeeAccount(foreign bool) (holder, number, ifsc, upi, accountType string) {
+	if eb == nil {
+		return
+	}
+	if foreign {
+		return eb.FCRAAccountHolderName, eb.FCRAAccountNumber, eb.FCRAIFSCCode, "", eb.AccountType
+	}
+	return eb.AccountHolderName, eb.AccountNumber, eb.IFSCCode, eb.UPIID, eb.AccountType
+}
+
+// rupeeValue converts an amount paid in the donation's currency to rupees at
+// the current rate, falling back to the rate captured when the order was created
+func (s *service) rupeeValue(ctx context.Context, d *Donation, original float64) (float64, float64) {
+	if d.Currency == "" || d.Currency == payment.CurrencyINR {
+		return original, 1
+	}
+	rate, err := s.fx.Rate(ctx, d.Currency)
+	if err != nil {
+		log.Printf("⚠️ No %s rate at capture for donation=%d, using order rate %.4f: %v", d.Currency, d.ID, d.ExchangeRate, err)
+		rate = d.ExchangeRate
+	}
+	return utils.RoundMoney(original * rate), rate
+}
+
 // ==============================
 // StartDonation
 // ==============================
 func (s *service) StartDonation(req CreateDonationRequest) (*CreateDonationResponse, error) {
 	ctx := context.Background()
 
-	// ── Fetch tenant bank details (includes Razorpay keys) ───────────────
-	eb := s.getTenantBank(ctx, req.EntityID)
-
-	// ── Require tenant's own Razorpay key — NO platform fallback ─────────
-	// Keys are stored per-tenant in tenant_bank_account_details table.
-	// If tenant hasn't configured Razorpay, they should use UPI Direct instead.
-	if eb == nil || eb.RazorpayKeyID == "" || eb.RazorpaySecret == "" {
-		log.Printf("❌ [StartDonation] Tenant entity=%d has no Razorpay keys in DB", req.EntityID)
-		return nil, fmt.Errorf("temple has not configured Razorpay payment gateway yet. Please use UPI Direct or contact the temple admin")
-	}
-
-	// ── Create per-tenant Razorpay client using tenant's own credentials ──
-	razorpayClient := razorpay.NewClient(eb.RazorpayKeyID, eb.RazorpaySecret)
-	log.Printf("🔑 [StartDonation] Using tenant Razorpay key for entity=%d key=%s",
-		req.EntityID, maskKey(eb.RazorpayKeyID))
-
-	// ── Create Razorpay order ─────────────────────────────────────────────
-	amountInPaise := int(req.Amount * 100)
-	data := map[string]interface{}{
-		"amount":          amountInPaise,
-		"currency":        "INR",
-		"payment_capture": 1,
-		"notes": map[string]interface{}{
-			"user_id":       req.UserID,
-			"entity_id":     req.EntityID,
-			"donation_type": req.DonationType,
-		},
+	// ── Validate donor PAN / Aadhaar (optional, used for 80G and Form 10BD) ─
+	identity, err := req.DonorIdentity.normalized()
+	if err != nil {
+		return nil, err
+	}
+
+	dedication, err := req.DonationDedication.normalized()
+	if err != nil {
+		return nil, err
+	}
+
+	// ── Pledge (optional) decides the donation type and campaign ─────────
+	pledge, err := s.openPledge(ctx, req.PledgeID, req.EntityID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if pledge != nil {
+		req.DonationType = pledge.DonationType
+		req.CampaignID = nil
+	}
+
+	// ── Campaign (optional) decides the donation type ────────────────────
+	campaign, err := s.openCampaign(ctx, req.CampaignID, req.EntityID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if campaign != nil {
+		req.DonationType = campaign.DonationType
+	}
+
+	// ── Currency: the order is raised in the donor's currency and the ────
+	// rupee value recorded at today's rate
+	currency, err := payment.NormalizeCurrency(req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := s.fx.Rate(ctx, currency)
+	if err != nil {
+		log.Printf("❌ [StartDonation] No exchange rate for %s: %v", currency, err)
+		return nil, fmt.Errorf("donations in %s are not accepted at the moment", currency)
+	}
+	amountINR := utils.RoundMoney(req.Amount * rate)
+	foreign := req.IsForeignContribution
+	if foreign && (req.DonorCountry == nil || strings.TrimSpace(*req.DonorCountry) == "") {
+		return nil, errors.New("donor country is required for a foreign contribution")
+	}
+
+	// ── Resolve the tenant's payment provider — NO platform fallback ─────
+	// Credentials are stored per-tenant in tenant_bank_account_details.
+	// If tenant hasn't configured a gateway, they should use UPI Direct instead.
+	// Foreign contributions may only be received into the FCRA account.
+	provider, eb, err := s.accountProvider(ctx, req.EntityID, foreign)
+	if err != nil {
+		log.Printf("❌ [StartDonation] Tenant entity=%d has no usable payment provider (foreign=%t): %v", req.EntityID, foreign, err)
+		return nil, err
+	}
+	log.Printf("🔑 [StartDonation] Using %s for entity=%d", provider.Name(), req.EntityID)
+
+	// ── Create gateway order ─────────────────────────────────────────────
+	notes := map[string]interface{}{
+		"user_id":       req.UserID,
+		"entity_id":     req.EntityID,
+		"donation_type": req.DonationType,
 	}
 	if req.ReferenceID != nil {
-		data["notes"].(map[string]interface{})["reference_id"] = *req.ReferenceID
+		notes["reference_id"] = *req.ReferenceID
+	}
+	if campaign != nil {
+		notes["campaign_id"] = campaign.ID
+	}
+	if pledge != nil {
+		notes["pledge_id"] = pledge.ID
+	}
+	if foreign {
+		notes["foreign_contribution"] = true
+	}
+	orderReq := payment.OrderRequest{
+		Amount:     req.Amount,
+		Currency:   currency,
+		CustomerID: strconv.FormatUint(uint64(req.UserID), 10),
+		Notes:      notes,
+	}
+	if contact, err := s.repo.GetUserContact(ctx, req.UserID); err == nil {
+		orderReq.CustomerEmail, orderReq.CustomerPhone = contact.Email, contact.Phone
 	}
 
-	order, err := razorpayClient.Order.Create(data, nil)
+	order, err := provider.CreateOrder(ctx, orderReq)
 	if err != nil {
 		s.auditSvc.LogAction(ctx, &req.UserID, &req.EntityID, "DONATION_INITIATED",
-			map[string]interface{}{"amount": req.Amount, "error": err.Error()},
+			map[string]interface{}{"amount": req.Amount, "currency": currency, "provider": provider.Name(), "error": err.Error()},
 			req.IPAddress, "failure")
-		return nil, fmt.Errorf("razorpay order creation failed: %w", err)
-	}
-
-	orderID, ok := order["id"].(string)
-	if !ok {
-		return nil, errors.New("unable to extract order_id from Razorpay response")
+		return nil, err
 	}
+	orderID := order.ID
 
 	// ── Save donation record ─────────────────────────────────────────────
 	donation := &Donation{
 		UserID:       req.UserID,
 		EntityID:     req.EntityID,
-		Amount:       req.Amount,
+		Amount:       amountINR,
 		DonationType: req.DonationType,
 		ReferenceID:  req.ReferenceID,
 		Method:       " ",
 		Status:       StatusPending,
 		OrderID:      orderID,
 		Note:         req.Note,
+		DonorPAN:     identity.DonorPAN,
+		DonorAadhaar: identity.DonorAadhaar,
+		DonorAddress: identity.DonorAddress,
+
+		IsAnonymous:    dedication.IsAnonymous,
+		DedicationType: dedication.DedicationType,
+		OnBehalfOf:     dedication.OnBehalfOf,
+
+		Currency:              currency,
+		OriginalAmount:        req.Amount,
+		ExchangeRate:          rate,
+		IsForeignContribution: foreign,
+		DonorCountry:          req.DonorCountry,
+		DonorNationality:      req.DonorNationality,
+	}
+	if campaign != nil {
+		donation.CampaignID = &campaign.ID
+	}
+	if pledge != nil {
+		donation.PledgeID = &pledge.ID
+		donation.CampaignID = pledge.CampaignID
 	}
 	if err := s.repo.Create(context.Background(), donation); err != nil {
 		return nil, fmt.Errorf("failed to create donation record: %w", err)
 	}
 
 	s.auditSvc.LogAction(ctx, &req.UserID, &req.EntityID, "DONATION_INITIATED",
-		map[string]interface{}{"amount": req.Amount, "order_id": orderID},
-		req.IPAddress, "success")
+		map[string]interface{}{
+			"amount":               amountINR,
+			"currency":             currency,
+			"original_amount":      req.Amount,
+			"exchange_rate":        rate,
+			"foreign_contribution": foreign,
+			"order_id":             orderID,
+			"provider":             order.Provider,
+			"is_anonymous":         dedication.IsAnonymous,
+			"dedication_type":      dedication.DedicationType,
+			"on_behalf_of":         dedication.OnBehalfOf,
+		}, req.IPAddress, "success")
 
 	// ── Build tenant info for frontend display ───────────────────────────
-	tenantInfo := TenantPaymentInfo{
-		AccountHolderName: eb.AccountHolderName,
-		AccountNumber:     eb.AccountNumber,
-		BankName:          eb.BankName,
-		BranchName:        eb.BranchName,
-		IFSCCode:          eb.IFSCCode,
-		AccountType:       eb.AccountType,
-		UPIID:             eb.UPIID,
-	}
-	log.Printf("🏦 Tenant bank for entity=%d: holder=%s upi=%s bank=%s",
-		req.EntityID, eb.AccountHolderName, eb.UPIID, eb.BankName)
+	var tenantInfo TenantPaymentInfo
+	if foreign {
+		tenantInfo = TenantPaymentInfo{
+			AccountHolderName:      eb.FCRAAccountHolderName,
+			AccountNumber:          eb.FCRAAccountNumber,
+			BankName:               eb.FCRABankName,
+			BranchName:             eb.FCRABranchName,
+			IFSCCode:               eb.FCRAIFSCCode,
+			FCRARegistrationNumber: eb.FCRARegistrationNumber,
+		}
+	} else if eb != nil {
+		tenantInfo = TenantPaymentInfo{
+			AccountHolderName: eb.AccountHolderName,
+			AccountNumber:     eb.AccountNumber,
+			BankName:          eb.BankName,
+			BranchName:        eb.BranchName,
+			IFSCCode:          eb.IFSCCode,
+			AccountType:       eb.AccountType,
+			UPIID:             eb.UPIID,
+		}
+		log.Printf("🏦 Tenant bank for entity=%d: holder=%s upi=%s bank=%s",
+			req.EntityID, eb.AccountHolderName, eb.UPIID, eb.BankName)
+	}
 
 	return &CreateDonationResponse{
-		OrderID:     orderID,
-		Amount:      req.Amount,
-		Currency:    "INR",
-		RazorpayKey: eb.RazorpayKeyID, // ✅ tenant's own key sent to frontend
-		Tenant:      tenantInfo,
+		OrderID:          orderID,
+		Amount:           req.Amount,
+		Currency:         order.Currency,
+		Provider:         order.Provider,
+		RazorpayKey:      order.KeyID, // ✅ tenant's own key sent to frontend
+		PaymentSessionID: order.SessionID,
+		Tenant:           tenantInfo,
+
+		AmountINR:             amountINR,
+		ExchangeRate:          rate,
+		IsForeignContribution: foreign,
 	}, nil
 }
 
@@ -198,63 +488,59 @@ func (s *service) VerifyAndUpdateDonation(req VerifyPaymentRequest) error {
 		return fmt.Errorf("donation not found: %w", err)
 	}
 
-	// ── Step 2: Fetch tenant bank (must have Razorpay secret) ────────────
-	eb := s.getTenantBank(ctx, donation.EntityID)
+	// ── Step 2: Resolve the provider that created the order ──────────────
+	provider, eb, err := s.accountProvider(ctx, donation.EntityID, donation.IsForeignContribution)
+	if err != nil {
+		log.Printf("❌ [Verify] Tenant entity=%d has no usable payment provider: %v", donation.EntityID, err)
+		return fmt.Errorf("temple payment gateway not configured — cannot verify payment")
+	}
 
-	// ── Step 3: Require tenant's Razorpay secret for HMAC ────────────────
-	// Must use the SAME secret that was used to CREATE the order.
-	// No platform fallback — if tenant has no secret, verification cannot proceed.
-	if eb == nil || eb.RazorpaySecret == "" {
-		log.Printf("❌ [Verify] Tenant entity=%d has no Razorpay secret in DB", donation.EntityID)
-		return fmt.Errorf("temple Razorpay secret not configured — cannot verify payment")
-	}
-	log.Printf("🔑 [Verify] Using tenant secret for entity=%d", donation.EntityID)
-
-	// ── Step 4: HMAC Signature Verification ──────────────────────────────
-	// Razorpay format: "<order_id>|<payment_id>" signed with secret
-	signatureData := req.OrderID + "|" + req.PaymentID
-	mac := hmac.New(sha256.New, []byte(eb.RazorpaySecret))
-	mac.Write([]byte(signatureData))
-	expectedSig := hex.EncodeToString(mac.Sum(nil))
-
-	log.Printf("🔐 Verify: order=%s payment=%s", req.OrderID, req.PaymentID)
-	log.Printf("🔐 Expected sig: %s", expectedSig)
-	log.Printf("🔐 Received sig: %s", req.RazorpaySig)
-
-	if expectedSig != req.RazorpaySig {
-		log.Printf("❌ Signature mismatch for order=%s", req.OrderID)
-		return fmt.Errorf("invalid payment signature")
-	}
-	log.Printf("✅ Signature verified for order=%s", req.OrderID)
-
-	// ── Step 5: Extract tenant bank fields for donation record ────────────
-	accountHolder := eb.AccountHolderName
-	accountNumber := eb.AccountNumber
-	ifscCode      := eb.IFSCCode
-	upiID         := eb.UPIID
-	accountType   := eb.AccountType
+	// ── Step 3: Verify the checkout callback with the provider ───────────
+	log.Printf("🔐 Verify(%s): order=%s payment=%s", provider.Name(), req.OrderID, req.PaymentID)
+	pay, err := provider.VerifyPayment(ctx, req.OrderID, req.PaymentID, req.RazorpaySig)
+	if err != nil {
+		log.Printf("❌ Payment verification failed for order=%s: %v", req.OrderID, err)
+		return err
+	}
+	log.Printf("✅ Payment verified for order=%s", req.OrderID)
+
+	// ── Step 4: Extract tenant bank fields for donation record ────────────
+	accountHolder, accountNumber, ifscCode, upiID, accountType := eb.payeeAccount(donation.IsForeignContribution)
 	log.Printf("🏦 Tenant bank: entity=%d holder=%s upi=%s", donation.EntityID, accountHolder, upiID)
 
-	// ── Step 6: Update donation → SUCCESS ────────────────────────────────
+	// ── Step 5: Update donation → SUCCESS ────────────────────────────────
 	now       := time.Now()
-	paymentID := req.PaymentID
+	paymentID := pay.ID
+	method    := pay.Method
+	if method == "" {
+		method = "upi"
+	}
+	original := donation.OriginalAmount
+	if original == 0 {
+		original = donation.Amount
+	}
+	amount, rate := s.rupeeValue(ctx, donation, original)
 
 	if err := s.repo.UpdatePaymentDetails(ctx, req.OrderID, UpdatePaymentDetailsParams{
 		Status:            StatusSuccess,
 		PaymentID:         &paymentID,
-		Method:            "upi",
-		Amount:            donation.Amount,
+		Method:            method,
+		Amount:            amount,
 		DonatedAt:         &now,
 		AccountHolderName: accountHolder,
 		AccountNumber:     accountNumber,
 		IFSCCode:          ifscCode,
 		UPIID:             upiID,
 		AccountType:       accountType,
+		OriginalAmount:    original,
+		ExchangeRate:      rate,
 	}); err != nil {
 		log.Printf("❌ Failed to update donation order=%s: %v", req.OrderID, err)
 		return fmt.Errorf("failed to update donation: %w", err)
 	}
 
+	s.donationSucceeded(ctx, donation.ID)
+
 	log.Printf("✅ Donation SUCCESS order=%s payment=%s tenant=%s", req.OrderID, paymentID, accountHolder)
 	return nil
 }
@@ -392,6 +678,11 @@ func (s *service) GetDashboardStats(entityID uint, accessContext middleware.Acce
 		Today:          todayStats.Amount,
 		TotalDonors:    donorCount,
 		AverageAmount:  avgAmount,
+		RefundedAmount: totalStats.RefundedAmount,
+		RefundedCount:  totalStats.RefundedCount,
+		DomesticAmount: totalStats.Amount - totalStats.ForeignAmount,
+		ForeignAmount:  totalStats.ForeignAmount,
+		ForeignCount:   totalStats.ForeignCount,
 	}, nil
 }
 
@@ -416,7 +707,7 @@ func (s *service) GetTopDonors(entityID uint, limit int, accessContext middlewar
     return s.repo.GetTopDonors(context.Background(), entityID, limit)
 }
 
-func (s *service) GetAnalytics(entityID uint, days int, accessContext middleware.AccessContext) (*AnalyticsData, error) {
+func (s *service) GetAnalytics(entityID uint, days int, campaignID uint, accessContext middleware.AccessContext) (*AnalyticsData, error) {
 	if !accessContext.CanRead() {
 		return nil, errors.New("read access denied")
 	}
@@ -426,19 +717,23 @@ func (s *service) GetAnalytics(entityID uint, days int, accessContext middleware
 	}
 
 	ctx := context.Background()
-	trends, err := s.repo.GetDonationTrends(ctx, entityID, days)
+	trends, err := s.repo.GetDonationTrends(ctx, entityID, days, campaignID)
 	if err != nil {
 		return nil, err
 	}
-	byType, err := s.repo.GetDonationsByType(ctx, entityID)
+	byType, err := s.repo.GetDonationsByType(ctx, entityID, campaignID)
 	if err != nil {
 		return nil, err
 	}
-	byMethod, err := s.repo.GetDonationsByMethod(ctx, entityID)
+	byMethod, err := s.repo.GetDonationsByMethod(ctx, entityID, campaignID)
 	if err != nil {
 		return nil, err
 	}
-	return &AnalyticsData{Trends: trends, ByType: byType, ByMethod: byMethod}, nil
+	byCampaign, err := s.repo.GetDonationsByCampaign(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	return &AnalyticsData{Trends: trends, ByType: byType, ByMethod: byMethod, ByCampaign: byCampaign}, nil
 }
 
 // ==============================
@@ -446,48 +741,144 @@ func (s *service) GetAnalytics(entityID uint, days int, accessContext middleware
 // ==============================
 
 func (s *service) GenerateReceipt(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint) (*Receipt, error) {
+	receipt, _, err := s.buildReceipt(donationID, userID, accessContext, entityID)
+	return receipt, err
+}
+
+// GenerateReceiptPDF renders the 80G receipt. The receipt number and issue
+// time are stored on the donation, so every download produces the same file.
+func (s *service) GenerateReceiptPDF(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint) ([]byte, string, error) {
+	receipt, logoPath, err := s.buildReceipt(donationID, userID, accessContext, entityID)
+	if err != nil {
+		return nil, "", err
+	}
+	pdfBytes, err := renderReceiptPDF(receipt, logoPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render receipt: %w", err)
+	}
+	return pdfBytes, receiptFileName(receipt.ReceiptNumber), nil
+}
+
+// buildReceipt loads a successful donation the caller may see, makes sure it
+// has a receipt number, and returns the receipt with the temple logo path.
+func (s *service) buildReceipt(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint) (*Receipt, string, error) {
 	ctx := context.Background()
 	donation, err := s.repo.GetByIDWithUser(ctx, donationID)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	hasAccess := (donation.UserID == userID && donation.EntityID == entityID)
-	if accessContext != nil {
-		if accessibleEntityID := accessContext.GetAccessibleEntityID(); accessibleEntityID != nil &&
-			*accessibleEntityID == donation.EntityID && accessContext.CanRead() {
-			hasAccess = true
-		}
-	}
-	if !hasAccess {
-		return nil, errors.New("unauthorized to access this donation")
+	if !canViewDonation(donation, userID, accessContext, entityID) {
+		return nil, "", errors.New("unauthorized to access this donation")
 	}
 	if donation.Status != StatusSuccess {
-		return nil, errors.New("receipt can only be generated for successful donations")
+		return nil, "", errors.New("receipt can only be generated for successful donations")
 	}
 
-	txnID := donation.OrderID
-	if donation.PaymentID != nil {
-		txnID = *donation.PaymentID
+	// Donations that succeeded before receipt numbering existed get their number on first request
+	if donation.ReceiptNumber == nil || *donation.ReceiptNumber == "" {
+		stamped, err := s.repo.AssignReceiptNumber(ctx, donation.ID)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to assign receipt number: %w", err)
+		}
+		donation.ReceiptNumber = stamped.ReceiptNumber
+		donation.FinancialYear = stamped.FinancialYear
+		donation.ReceiptIssuedAt = stamped.ReceiptIssuedAt
 	}
+
+	txnID := transactionReference(*donation)
 	donatedAt := donation.CreatedAt
 	if donation.DonatedAt != nil {
 		donatedAt = *donation.DonatedAt
 	}
+	issuedAt := donatedAt
+	if donation.ReceiptIssuedAt != nil {
+		issuedAt = *donation.ReceiptIssuedAt
+	}
+
+	// A partial refund leaves the donation successful; the receipt covers
+	// only the amount the temple kept
+	eligible := utils.RoundMoney(netDonationAmount(*donation))
 
-	return &Receipt{
+	receipt := &Receipt{
 		ID:             donation.ID,
 		DonationAmount: donation.Amount,
 		DonationType:   donation.DonationType,
-		DonorName:      donation.UserName,
-		DonorEmail:     donation.UserEmail,
+		DonorName:      donation.PayerName,
+		DonorEmail:     donation.PayerEmail,
 		TransactionID:  txnID,
 		DonatedAt:      donatedAt,
 		Method:         donation.Method,
 		EntityName:     donation.EntityName,
-		ReceiptNumber:  fmt.Sprintf("RCP-%d-%d", donation.EntityID, donation.ID),
-		GeneratedAt:    time.Now(),
-	}, nil
+		ReceiptNumber:  *donation.ReceiptNumber,
+		GeneratedAt:    issuedAt,
+		FinancialYear:  donation.FinancialYear,
+		AmountInWords:  amountInWords(eligible),
+		RefundedAmount: donation.RefundedAmount,
+		EligibleAmount: eligible,
+
+		Currency:              donation.Currency,
+		OriginalAmount:        donation.OriginalAmount,
+		ExchangeRate:          donation.ExchangeRate,
+		IsForeignContribution: donation.IsForeignContribution,
+	}
+	if donation.IsForeignContribution {
+		if eb := s.getTenantBank(ctx, donation.EntityID); eb != nil {
+			receipt.FCRARegistrationNumber = eb.FCRARegistrationNumber
+		}
+	}
+	if donation.PayerPAN != nil {
+		receipt.DonorPAN = *donation.PayerPAN
+	}
+	if donation.OnBehalfOf != nil {
+		receipt.DedicationType = donation.DedicationType
+		receipt.OnBehalfOf = *donation.OnBehalfOf
+	}
+
+	var logoPath string
+	if details, err := s.repo.GetReceiptEntityDetails(ctx, donation.EntityID); err != nil {
+		log.Printf("⚠️ Could not load receipt details for entity=%d: %v", donation.EntityID, err)
+	} else {
+		receipt.EntityName = details.Name
+		receipt.EntityAddress = formatEntityAddress(details)
+		receipt.Registration80G = details.Registration80G
+		receipt.TrustPAN = details.TrustPAN
+		receipt.LogoURL, logoPath = entityLogo(donation.EntityID, details.Media)
+	}
+
+	return receipt, logoPath, nil
+}
+
+// canViewDonation allows the devotee who gave and readers of the donation's temple
+func canViewDonation(donation *DonationWithUser, userID uint, accessContext *middleware.AccessContext, entityID uint) bool {
+	if donation.UserID == userID && donation.EntityID == entityID {
+		return true
+	}
+	if accessContext != nil {
+		if accessibleEntityID := accessContext.GetAccessibleEntityID(); accessibleEntityID != nil &&
+			*accessibleEntityID == donation.EntityID && accessContext.CanRead() {
+			return true
+		}
+	}
+	return false
+}
+
+// donationSucceeded runs everything that follows a donation turning SUCCESS:
+// its receipt number and, when it pays towards a pledge, the pledge balance
+func (s *service) donationSucceeded(ctx context.Context, donationID uint) {
+	s.issueReceiptNumber(ctx, donationID)
+	s.settleDonationPledge(ctx, donationID)
+}
+
+// issueReceiptNumber stamps a freshly successful donation with its receipt
+// number. Failure is only logged — the number is assigned on first download.
+func (s *service) issueReceiptNumber(ctx context.Context, donationID uint) {
+	donation, err := s.repo.AssignReceiptNumber(ctx, donationID)
+	if err != nil {
+		log.Printf("⚠️ Could not assign receipt number for donation=%d: %v", donationID, err)
+		return
+	}
+	log.Printf("🧾 Receipt %s issued for donation=%d", *donation.ReceiptNumber, donationID)
 }
 
 func (s *service) ExportDonations(filters DonationFilters, format string, accessContext middleware.AccessContext) ([]byte, string, error) {
@@ -516,30 +907,36 @@ func (s *service) exportAsCSV(donations []DonationWithUser) ([]byte, string, err
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
-	_ = writer.Write([]string{"ID", "Date", "Donor Name", "Donor Email", "Amount", "Type", "Method", "Status", "Transaction ID", "Reference ID", "Note"})
+	_ = writer.Write([]string{"ID", "Date", "Donor Name", "Donor Email", "Donor Phone", "Amount", "Refunded", "Net Amount", "Currency", "Original Amount", "Exchange Rate", "Contribution", "Type", "Campaign", "Method", "Status", "Clearance", "Transaction ID", "Reference ID", "Note"})
 
 	for _, d := range donations {
 		donatedAt := d.CreatedAt
 		if d.DonatedAt != nil {
 			donatedAt = *d.DonatedAt
 		}
-		txnID := d.OrderID
-		if d.PaymentID != nil {
-			txnID = *d.PaymentID
-		}
-		refID, note := "", ""
+		txnID := transactionReference(d)
+		refID, note, phone := "", "", ""
 		if d.ReferenceID != nil {
 			refID = strconv.FormatUint(uint64(*d.ReferenceID), 10)
 		}
 		if d.Note != nil {
 			note = *d.Note
 		}
+		if d.DonorPhone != nil {
+			phone = *d.DonorPhone
+		}
 		_ = writer.Write([]string{
 			strconv.FormatUint(uint64(d.ID), 10),
 			donatedAt.Format("2006-01-02 15:04:05"),
-			d.UserName, d.UserEmail,
+			d.UserName, d.UserEmail, phone,
 			fmt.Sprintf("%.2f", d.Amount),
-			d.DonationType, d.Method, d.Status,
+			fmt.Sprintf("%.2f", d.RefundedAmount),
+			fmt.Sprintf("%.2f", netDonationAmount(d)),
+			d.Currency,
+			fmt.Sprintf("%.2f", d.OriginalAmount),
+			fmt.Sprintf("%.4f", d.ExchangeRate),
+			contributionType(d.IsForeignContribution),
+			d.DonationType, d.CampaignTitle, d.Method, d.Status, d.ClearanceStatus,
 			txnID, refID, note,
 		})
 	}
@@ -551,6 +948,35 @@ func (s *service) exportAsCSV(donations []DonationWithUser) ([]byte, string, err
 	return buf.Bytes(), fmt.Sprintf("donations_%d.csv", time.Now().Unix()), nil
 }
 
+// transactionReference is the payment id for gateway donations and the
+// cheque / DD / UTR number for offline ones
+func transactionReference(d DonationWithUser) string {
+	if d.IsOffline && d.InstrumentNumber != nil && *d.InstrumentNumber != "" {
+		return *d.InstrumentNumber
+	}
+	if d.PaymentID != nil {
+		return *d.PaymentID
+	}
+	return d.OrderID
+}
+
+// contributionType labels a donation domestic or foreign for FCRA reporting
+func contributionType(foreign bool) string {
+	if foreign {
+		return "Foreign (FCRA)"
+	}
+	return "Domestic"
+}
+
+// netDonationAmount is what the temple keeps from a donation after refunds
+func netDonationAmount(d DonationWithUser) float64 {
+	switch strings.ToUpper(d.Status) {
+	case StatusSuccess, StatusRefunded:
+		return d.Amount - d.RefundedAmount
+	}
+	return 0
+}
+
 // ==============================
 // Recent Donations
 // ==============================
@@ -578,7 +1004,79 @@ func (s *service) GetRecentDonationsByEntity(ctx context.Context, entityID uint,
 // Webhook Handlers
 // ==============================
 
-func (s *service) HandleRazorpayWebhook(orderID, paymentID, method string, amount float64) error {
+// ParsePaymentWebhook verifies a provider webhook and decodes it into a
+// normalised event. The temple the event belongs to is looked up from the
+// order (or payment) it refers to, and must take payments through the
+// provider that sent it. Razorpay webhooks are signed with the platform
+// webhook secret; Cashfree signs with each tenant's client secret.
+func (s *service) ParsePaymentWebhook(providerName string, body []byte, headers http.Header) (*payment.WebhookEvent, error) {
+	ctx := context.Background()
+
+	provider, err := s.payments.ByName(providerName)
+	if err != nil {
+		return nil, err
+	}
+	ev, err := provider.ParseWebhook(body)
+	if err != nil {
+		return nil, err
+	}
+
+	entityID, foreign, err := s.webhookTenant(ctx, ev)
+	if err != nil {
+		log.Printf("❌ Webhook(%s): cannot resolve tenant for order=%s payment=%s: %v", providerName, ev.OrderID, ev.PaymentID, err)
+		return nil, payment.ErrNoWebhookSecret
+	}
+	tenantProvider, _, err := s.accountProvider(ctx, entityID, foreign)
+	if err != nil {
+		return nil, payment.ErrNoWebhookSecret
+	}
+	if tenantProvider.Name() != provider.Name() {
+		return nil, fmt.Errorf("%w: entity %d does not use %s", payment.ErrInvalidSignature, entityID, provider.Name())
+	}
+
+	err = provider.VerifyWebhook(body, headers)
+	if err == nil {
+		return ev, nil
+	}
+	if !errors.Is(err, payment.ErrNoWebhookSecret) {
+		return nil, err
+	}
+	// Tenant-signed webhook
+	if err := tenantProvider.VerifyWebhook(body, headers); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// webhookTenant finds the temple a webhook event belongs to, and whether it
+// was paid into its FCRA account, which has its own credentials
+func (s *service) webhookTenant(ctx context.Context, ev *payment.WebhookEvent) (uint, bool, error) {
+	switch {
+	case ev.OrderID != "":
+		entityID, err := s.repo.FindEntityByOrderID(ctx, ev.OrderID)
+		if err != nil {
+			return 0, false, err
+		}
+		foreign := false
+		if d, err := s.repo.GetByOrderID(ctx, ev.OrderID); err == nil {
+			foreign = d.IsForeignContribution
+		}
+		return entityID, foreign, nil
+	case ev.PaymentID != "":
+		sourceType, sourceID, err := s.repo.FindRefundSourceByPaymentID(ctx, ev.PaymentID)
+		if err != nil {
+			return 0, false, err
+		}
+		src, err := s.repo.GetRefundSource(ctx, sourceType, sourceID)
+		if err != nil {
+			return 0, false, err
+		}
+		return src.EntityID, src.IsForeignContribution, nil
+	}
+	return 0, false, errors.New("webhook does not reference an order or payment")
+}
+
+func (s *service) HandlePaymentCapturedWebhook(orderID, paymentID, method string, amount float64) error {
 	ctx := context.Background()
 
 	donation, err := s.repo.GetByOrderID(ctx, orderID)
@@ -586,19 +1084,18 @@ func (s *service) HandleRazorpayWebhook(orderID, paymentID, method string, amoun
 		log.Printf("❌ Webhook: Donation not found for order %s: %v", orderID, err)
 		return fmt.Errorf("donation not found for order_id: %s", orderID)
 	}
-	if donation.Status == StatusSuccess {
-		log.Printf("⚠️ Webhook: Already successful for order %s", orderID)
+	if donation.Status == StatusSuccess || donation.Status == StatusRefunded {
+		log.Printf("⚠️ Webhook: Already %s for order %s", donation.Status, orderID)
 		return nil
 	}
 
 	// Fetch tenant bank for account holder name and UPI
 	eb := s.getTenantBank(ctx, donation.EntityID)
+	accountHolderName, _, _, upiID, _ := eb.payeeAccount(donation.IsForeignContribution)
 
-	var accountHolderName, upiID string
-	if eb != nil {
-		accountHolderName = eb.AccountHolderName
-		upiID             = eb.UPIID
-	}
+	// The gateway reports the amount in the order's currency
+	original := amount
+	amount, rate := s.rupeeValue(ctx, donation, original)
 
 	now := time.Now()
 	if err = s.repo.UpdatePaymentDetails(ctx, orderID, UpdatePaymentDetailsParams{
@@ -609,17 +1106,21 @@ func (s *service) HandleRazorpayWebhook(orderID, paymentID, method string, amoun
 		DonatedAt:         &now,
 		AccountHolderName: accountHolderName,
 		UPIID:             upiID,
+		OriginalAmount:    original,
+		ExchangeRate:      rate,
 	}); err != nil {
 		log.Printf("❌ Webhook: Failed to update order %s: %v", orderID, err)
 		s.auditSvc.LogAction(ctx, &donation.UserID, &donation.EntityID, "WEBHOOK_UPDATE_FAILED",
 			map[string]interface{}{"order_id": orderID, "payment_id": paymentID, "error": err.Error()},
-			"razorpay_webhook", "failure")
+			"payment_webhook", "failure")
 		return err
 	}
 
+	s.donationSucceeded(ctx, donation.ID)
+
 	s.auditSvc.LogAction(ctx, &donation.UserID, &donation.EntityID, "DONATION_SUCCESS_WEBHOOK",
-		map[string]interface{}{"order_id": orderID, "payment_id": paymentID, "amount": amount, "method": method},
-		"razorpay_webhook", "success")
+		map[string]interface{}{"order_id": orderID, "payment_id": paymentID, "amount": amount, "currency": donation.Currency, "exchange_rate": rate, "method": method},
+		"payment_webhook", "success")
 	log.Printf("✅ Webhook: Updated order %s (method=%s amount=%.2f payee=%s)", orderID, method, amount, accountHolderName)
 	return nil
 }
@@ -636,6 +1137,11 @@ func (s *service) HandleFailedPaymentWebhook(orderID, paymentID string) error {
 		log.Printf("⚠️ Webhook(Failed): Already failed for order %s", orderID)
 		return nil
 	}
+	// A failed retry must not undo a payment that has already been captured
+	if donation.Status == StatusSuccess || donation.Status == StatusRefunded {
+		log.Printf("⚠️ Webhook(Failed): Order %s is already %s, ignoring", orderID, donation.Status)
+		return nil
+	}
 
 	if err = s.repo.UpdatePaymentDetails(ctx, orderID, UpdatePaymentDetailsParams{
 		Status:    StatusFailed,
@@ -646,13 +1152,13 @@ func (s *service) HandleFailedPaymentWebhook(orderID, paymentID string) error {
 		log.Printf("❌ Webhook(Failed): Failed to update order %s: %v", orderID, err)
 		s.auditSvc.LogAction(ctx, &donation.UserID, &donation.EntityID, "WEBHOOK_FAILED_UPDATE_ERROR",
 			map[string]interface{}{"order_id": orderID, "error": err.Error()},
-			"razorpay_webhook", "failure")
+			"payment_webhook", "failure")
 		return err
 	}
 
 	s.auditSvc.LogAction(ctx, &donation.UserID, &donation.EntityID, "DONATION_FAILED_WEBHOOK",
 		map[string]interface{}{"order_id": orderID, "payment_id": paymentID},
-		"razorpay_webhook", "success")
+		"payment_webhook", "success")
 	log.Printf("✅ Webhook(Failed): Updated order %s to FAILED", orderID)
 	return nil
 }
@@ -663,4 +1169,4 @@ func maskKey(key string) string {
 		return key
 	}
 	return key[:8] + "..."
-}
\ No newline at end of file
+}
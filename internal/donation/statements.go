@@ -0,0 +1,506 @@
+package donation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// ==============================
+// Annual Donor Statements
+// ==============================
+
+// maxStatementSendAttempts caps how often the worker retries a failed statement email
+const maxStatementSendAttempts = 3
+
+// statementWorkerIP is recorded as the IP on audit entries written by scheduled runs
+const statementWorkerIP = "donor_statement_worker"
+
+// pendingReceiptNumber stands in for the receipt numbers a preview does not assign
+const pendingReceiptNumber = "Pending"
+
+var (
+	errNoStatementDonations = errors.New("no successful donations for this donor in the financial year")
+	errNoStatementEmail     = errors.New("donor has no email address on file")
+)
+
+// StatementMailer emails a statement PDF to a donor. Implemented by
+// notification.EmailSender; injected with SetStatementMailer.
+type StatementMailer interface {
+	SendWithAttachment(to []string, subject string, body string, attachment notification.Attachment) error
+}
+
+func (s *service) SetStatementMailer(m StatementMailer) {
+	s.mailer = m
+}
+
+// financialYearBounds returns [1 April, next 1 April) in IST for a financial year like "2025-26"
+func financialYearBounds(financialYear string) (time.Time, time.Time, error) {
+	var start, end int
+	if _, err := fmt.Sscanf(financialYear, "%4d-%2d", &start, &end); err != nil ||
+		len(financialYear) != 7 || (start+1)%100 != end {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid financial year %q, expected e.g. 2025-26", financialYear)
+	}
+	from := time.Date(start, time.April, 1, 0, 0, 0, 0, utils.IST)
+	return from, from.AddDate(1, 0, 0), nil
+}
+
+// previousFinancialYear is the last financial year that has fully ended
+func previousFinancialYear(now time.Time) string {
+	return financialYearOf(now.AddDate(-1, 0, 0))
+}
+
+// GenerateAnnualStatements builds, stores and emails the statement of every
+// donor who gave to the temple in the financial year. Statements already sent
+// are skipped; use ResendDonorStatement to send one again.
+func (s *service) GenerateAnnualStatements(ctx context.Context, entityID uint, financialYear string, triggeredBy *uint, ip string) (*StatementRunReport, error) {
+	report, err := s.runStatements(ctx, entityID, financialYear, false)
+	if err != nil {
+		s.auditSvc.LogAction(ctx, triggeredBy, &entityID, "DONOR_STATEMENTS_GENERATED",
+			map[string]interface{}{"financial_year": financialYear, "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+	s.auditSvc.LogAction(ctx, triggeredBy, &entityID, "DONOR_STATEMENTS_GENERATED", map[string]interface{}{
+		"financial_year": financialYear,
+		"donors":         report.Donors,
+		"sent":           report.Sent,
+		"failed":         report.Failed,
+		"no_email":       report.NoEmail,
+		"skipped":        report.Skipped,
+	}, ip, "success")
+	return report, nil
+}
+
+// SendPendingStatements emails the statements for the last financial year that
+// have not gone out yet, across all temples. Donors without an email and
+// statements that failed maxStatementSendAttempts times are left alone.
+func (s *service) SendPendingStatements(ctx context.Context) (int, error) {
+	financialYear := previousFinancialYear(time.Now())
+	from, to, _ := financialYearBounds(financialYear)
+
+	entityIDs, err := s.repo.ListStatementEntities(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, entityID := range entityIDs {
+		report, err := s.runStatements(ctx, entityID, financialYear, true)
+		if err != nil {
+			log.Printf("❌ Statements: entity=%d fy=%s: %v", entityID, financialYear, err)
+			continue
+		}
+		if report.Sent > 0 || report.Failed > 0 {
+			entityID := entityID
+			s.auditSvc.LogAction(ctx, nil, &entityID, "DONOR_STATEMENTS_GENERATED", map[string]interface{}{
+				"financial_year": financialYear,
+				"donors":         report.Donors,
+				"sent":           report.Sent,
+				"failed":         report.Failed,
+				"no_email":       report.NoEmail,
+				"skipped":        report.Skipped,
+			}, statementWorkerIP, "success")
+		}
+		sent += report.Sent
+	}
+	return sent, nil
+}
+
+// runStatements sends the statement of every donor of one temple. Scheduled
+// runs also skip donors without an email and statements out of retries.
+func (s *service) runStatements(ctx context.Context, entityID uint, financialYear string, scheduled bool) (*StatementRunReport, error) {
+	from, to, err := financialYearBounds(financialYear)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.repo.ListStatementTotals(ctx, entityID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StatementRunReport{EntityID: entityID, FinancialYear: financialYear, Donors: len(totals)}
+	for _, t := range totals {
+		if existing, err := s.repo.GetDonorStatement(ctx, entityID, t.UserID, financialYear); err == nil {
+			done := existing.Status == StatementSent
+			if scheduled {
+				done = done || existing.Status == StatementNoEmail ||
+					(existing.Status == StatementFailed && existing.SendCount >= maxStatementSendAttempts)
+			}
+			if done {
+				report.Skipped++
+				continue
+			}
+		}
+
+		_, err := s.deliverStatement(ctx, entityID, t.UserID, financialYear)
+		switch {
+		case err == nil:
+			report.Sent++
+		case errors.Is(err, errNoStatementEmail):
+			report.NoEmail++
+		default:
+			log.Printf("❌ Statements: entity=%d user=%d fy=%s: %v", entityID, t.UserID, financialYear, err)
+			report.Failed++
+		}
+	}
+	return report, nil
+}
+
+// buildAnnualStatement assembles one donor's statement with the temple logo
+// path. Donations without a receipt number are assigned one when assign is
+// set, and shown as pending otherwise, as previews are.
+func (s *service) buildAnnualStatement(ctx context.Context, entityID, userID uint, financialYear string, assign bool) (*AnnualStatement, string, error) {
+	from, to, err := financialYearBounds(financialYear)
+	if err != nil {
+		return nil, "", err
+	}
+	donations, err := s.repo.ListStatementDonations(ctx, entityID, userID, from, to)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(donations) == 0 {
+		return nil, "", errNoStatementDonations
+	}
+
+	statement := &AnnualStatement{
+		FinancialYear: financialYear,
+		DonorName:     donations[0].PayerName,
+		DonorEmail:    donations[0].PayerEmail,
+		EntityName:    donations[0].EntityName,
+		GeneratedAt:   time.Now(),
+	}
+	for _, d := range donations {
+		receiptNumber := pendingReceiptNumber
+		if d.ReceiptNumber != nil && *d.ReceiptNumber != "" {
+			receiptNumber = *d.ReceiptNumber
+		} else if assign {
+			stamped, err := s.repo.AssignReceiptNumber(ctx, d.ID)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to assign receipt number: %w", err)
+			}
+			receiptNumber = *stamped.ReceiptNumber
+		}
+		if d.PayerPAN != nil && *d.PayerPAN != "" {
+			statement.DonorPAN = *d.PayerPAN
+		}
+		donatedAt := d.CreatedAt
+		if d.DonatedAt != nil {
+			donatedAt = *d.DonatedAt
+		}
+		line := StatementLine{
+			ReceiptNumber: receiptNumber,
+			DonatedAt:     donatedAt,
+			DonationType:  d.DonationType,
+			Method:        d.Method,
+			Amount:        d.Amount,
+			Refunded:      d.RefundedAmount,
+			NetAmount:     netDonationAmount(d),
+		}
+		statement.Lines = append(statement.Lines, line)
+		statement.TotalAmount += line.NetAmount
+	}
+	statement.TotalAmount = utils.RoundMoney(statement.TotalAmount)
+	statement.AmountInWords = amountInWords(statement.TotalAmount)
+
+	var logoPath string
+	if details, err := s.repo.GetReceiptEntityDetails(ctx, entityID); err != nil {
+		log.Printf("⚠️ Could not load receipt details for entity=%d: %v", entityID, err)
+	} else {
+		statement.EntityName = details.Name
+		statement.EntityAddress = formatEntityAddress(details)
+		statement.Registration80G = details.Registration80G
+		statement.TrustPAN = details.TrustPAN
+		_, logoPath = entityLogo(entityID, details.Media)
+	}
+	return statement, logoPath, nil
+}
+
+// saveStatement stores the statement's totals. A statement that was already
+// sent goes back to pending when its totals have changed since.
+func (s *service) saveStatement(ctx context.Context, entityID, userID uint, statement *AnnualStatement) (*DonorStatement, error) {
+	previous, _ := s.repo.GetDonorStatement(ctx, entityID, userID, statement.FinancialYear)
+
+	record := &DonorStatement{
+		EntityID:      entityID,
+		UserID:        userID,
+		FinancialYear: statement.FinancialYear,
+		DonationCount: len(statement.Lines),
+		TotalAmount:   statement.TotalAmount,
+		Status:        StatementPending,
+		Email:         statement.DonorEmail,
+		GeneratedAt:   statement.GeneratedAt,
+	}
+	if err := s.repo.SaveDonorStatement(ctx, record); err != nil {
+		return nil, err
+	}
+
+	if previous != nil && previous.Status == StatementSent &&
+		(previous.DonationCount != record.DonationCount || previous.TotalAmount != record.TotalAmount) {
+		if err := s.repo.UpdateDonorStatement(ctx, previous.ID, map[string]interface{}{"status": StatementPending}); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetDonorStatement(ctx, entityID, userID, statement.FinancialYear)
+}
+
+// deliverStatement rebuilds a donor's statement, stores it and emails it,
+// recording the outcome on the stored statement
+func (s *service) deliverStatement(ctx context.Context, entityID, userID uint, financialYear string) (*DonorStatement, error) {
+	statement, logoPath, err := s.buildAnnualStatement(ctx, entityID, userID, financialYear, true)
+	if err != nil {
+		return nil, err
+	}
+	record, err := s.saveStatement(ctx, entityID, userID, statement)
+	if err != nil {
+		return nil, err
+	}
+
+	sendErr := s.emailStatement(statement, logoPath, userID)
+	updates := map[string]interface{}{"send_count": record.SendCount + 1}
+	switch {
+	case errors.Is(sendErr, errNoStatementEmail):
+		updates["status"], updates["last_error"] = StatementNoEmail, sendErr.Error()
+	case sendErr != nil:
+		updates["status"], updates["last_error"] = StatementFailed, sendErr.Error()
+	default:
+		now := time.Now()
+		updates["status"], updates["last_error"], updates["sent_at"] = StatementSent, "", now
+		record.SentAt = &now
+	}
+	if err := s.repo.UpdateDonorStatement(ctx, record.ID, updates); err != nil {
+		log.Printf("❌ Statement=%d: could not record delivery: %v", record.ID, err)
+	}
+	record.Status = updates["status"].(string)
+	record.LastError = updates["last_error"].(string)
+	record.SendCount++
+	return record, sendErr
+}
+
+func (s *service) emailStatement(statement *AnnualStatement, logoPath string, userID uint) error {
+	if strings.TrimSpace(statement.DonorEmail) == "" {
+		return errNoStatementEmail
+	}
+	if s.mailer == nil {
+		return errors.New("statement email is not configured")
+	}
+	pdfBytes, err := renderAnnualStatementPDF(statement, logoPath)
+	if err != nil {
+		return fmt.Errorf("failed to render statement: %w", err)
+	}
+
+	subject := fmt.Sprintf("Your donation statement for FY %s — %s", statement.FinancialYear, statement.EntityName)
+	body := fmt.Sprintf("Dear %s,<br><br>Thank you for your generous support. Attached is your consolidated statement "+
+		"of %d donation(s) to %s in the financial year %s, totalling Rs. %.2f, for your income tax records.",
+		statement.DonorName, len(statement.Lines), statement.EntityName, statement.FinancialYear, statement.TotalAmount)
+	return s.mailer.SendWithAttachment([]string{statement.DonorEmail}, subject, body, notification.Attachment{
+		FileName:    statementFileName(statement.FinancialYear, userID),
+		ContentType: "application/pdf",
+		Data:        pdfBytes,
+	})
+}
+
+func (s *service) ListDonorStatements(filters DonorStatementFilters, accessContext middleware.AccessContext) ([]DonorStatementWithUser, int, error) {
+	if !accessContext.CanRead() {
+		return nil, 0, errors.New("read access denied")
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil || *entityID != filters.EntityID {
+		return nil, 0, errors.New("access denied to requested entity")
+	}
+	if _, _, err := financialYearBounds(filters.FinancialYear); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListDonorStatements(context.Background(), filters)
+}
+
+// PreviewDonorStatement renders a donor's statement as it would be emailed,
+// without storing or sending it. Receipt numbers are only assigned when the
+// statement is regenerated or sent, so donations without one show as pending.
+func (s *service) PreviewDonorStatement(entityID, userID uint, financialYear string, accessContext middleware.AccessContext) ([]byte, string, error) {
+	if !accessContext.CanRead() {
+		return nil, "", errors.New("read access denied")
+	}
+	if accessible := accessContext.GetAccessibleEntityID(); accessible == nil || *accessible != entityID {
+		return nil, "", errors.New("access denied to requested entity")
+	}
+	statement, logoPath, err := s.buildAnnualStatement(context.Background(), entityID, userID, financialYear, false)
+	if err != nil {
+		return nil, "", err
+	}
+	pdfBytes, err := renderAnnualStatementPDF(statement, logoPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render statement: %w", err)
+	}
+	return pdfBytes, statementFileName(financialYear, userID), nil
+}
+
+// RegenerateDonorStatement recomputes a donor's stored statement from their donations without emailing it
+func (s *service) RegenerateDonorStatement(entityID, userID uint, financialYear string, accessContext middleware.AccessContext, ip string) (*DonorStatement, error) {
+	ctx := context.Background()
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	if accessible := accessContext.GetAccessibleEntityID(); accessible == nil || *accessible != entityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+
+	statement, _, err := s.buildAnnualStatement(ctx, entityID, userID, financialYear, true)
+	if err != nil {
+		return nil, err
+	}
+	record, err := s.saveStatement(ctx, entityID, userID, statement)
+	if err != nil {
+		return nil, err
+	}
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "DONOR_STATEMENT_REGENERATED", map[string]interface{}{
+		"statement_id":   record.ID,
+		"donor_id":       userID,
+		"financial_year": financialYear,
+		"donation_count": record.DonationCount,
+		"total_amount":   record.TotalAmount,
+	}, ip, "success")
+	return record, nil
+}
+
+// ResendDonorStatement rebuilds a donor's statement and emails it again, whatever its status
+func (s *service) ResendDonorStatement(entityID, userID uint, financialYear string, accessContext middleware.AccessContext, ip string) (*DonorStatement, error) {
+	ctx := context.Background()
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	if accessible := accessContext.GetAccessibleEntityID(); accessible == nil || *accessible != entityID {
+		return nil, errors.New("access denied to requested entity")
+	}
+
+	record, err := s.deliverStatement(ctx, entityID, userID, financialYear)
+	if record == nil {
+		return nil, err
+	}
+	status := "success"
+	details := map[string]interface{}{
+		"statement_id":   record.ID,
+		"donor_id":       userID,
+		"financial_year": financialYear,
+		"email":          record.Email,
+		"total_amount":   record.TotalAmount,
+	}
+	if err != nil {
+		status, details["error"] = "failure", err.Error()
+	}
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "DONOR_STATEMENT_SENT", details, ip, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send statement: %w", err)
+	}
+	return record, nil
+}
+
+// StartAnnualStatementWorker emails the statements for the last financial year
+// that have not been sent yet, every interval until the process exits
+func StartAnnualStatementWorker(svc Service, interval time.Duration) {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	go func() {
+		log.Printf("📄 Donor statement worker started (every %s)", interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			sent, err := svc.SendPendingStatements(context.Background())
+			if err != nil {
+				log.Printf("❌ Donor statement worker: %v", err)
+			} else if sent > 0 {
+				log.Printf("📄 Donor statement worker sent %d statement(s)", sent)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// renderAnnualStatementPDF draws a donor's consolidated statement for a
+// financial year: one row per 80G receipt, followed by the net total
+func renderAnnualStatementPDF(st *AnnualStatement, logoPath string) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(st.GeneratedAt)
+	pdf.SetModificationDate(st.GeneratedAt)
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle("Donation Statement FY "+st.FinancialYear, false)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	drawEntityHeader(pdf, tr, st.EntityName, st.EntityAddress, st.TrustPAN, st.Registration80G, logoPath)
+
+	pdf.SetXY(10, 46)
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(190, 8, "CONSOLIDATED DONATION STATEMENT", "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(190, 6, "Financial Year "+st.FinancialYear+" (1 April to 31 March)", "", 1, "C", false, 0, "")
+	pdf.Ln(3)
+
+	donorPAN := st.DonorPAN
+	if donorPAN == "" {
+		donorPAN = "Not provided"
+	}
+	drawDetailRows(pdf, tr, [][2]string{
+		{"Donor Name", st.DonorName},
+		{"Donor Email", st.DonorEmail},
+		{"Donor PAN", donorPAN},
+		{"Statement Date", st.GeneratedAt.In(utils.IST).Format("02-01-2006")},
+	})
+	pdf.Ln(5)
+
+	// ── Receipts ─────────────────────────────────────────────────────────
+	widths := []float64{45, 22, 38, 25, 20, 20, 20}
+	headers := []string{"Receipt No", "Date", "Purpose", "Mode", "Amount", "Refunded", "Net"}
+	pdf.SetFont("Arial", "B", 9)
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Arial", "", 9)
+	for _, line := range st.Lines {
+		pdf.CellFormat(widths[0], 6, line.ReceiptNumber, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, line.DonatedAt.In(utils.IST).Format("02-01-2006"), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[2], 6, tr(line.DonationType), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, strings.ToUpper(line.Method), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[4], 6, fmt.Sprintf("%.2f", line.Amount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[5], 6, fmt.Sprintf("%.2f", line.Refunded), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[6], 6, fmt.Sprintf("%.2f", line.NetAmount), "1", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Arial", "B", 9)
+	pdf.CellFormat(170, 7, "Total", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(20, 7, fmt.Sprintf("%.2f", st.TotalAmount), "1", 1, "R", false, 0, "")
+	pdf.Ln(3)
+	pdf.SetFont("Arial", "", 10)
+	pdf.MultiCell(190, 6, tr("Total donations of Rs. "+fmt.Sprintf("%.2f", st.TotalAmount)+" ("+st.AmountInWords+")"), "", "L", false)
+	pdf.Ln(4)
+
+	pdf.SetFont("Arial", "", 9)
+	pdf.MultiCell(190, 5, "This statement summarises the receipts issued to you during the financial year. Each receipt listed "+
+		"above is eligible for deduction under Section 80G of the Income Tax Act, 1961, subject to the conditions specified therein.", "", "L", false)
+	pdf.Ln(10)
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(190, 6, "Authorised Signatory", "", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "I", 8)
+	pdf.CellFormat(190, 5, "This is a computer-generated statement and does not require a physical signature.", "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// statementFileName is the download and attachment name of a donor statement
+func statementFileName(financialYear string, userID uint) string {
+	return fmt.Sprintf("donation_statement_%s_%d.pdf", financialYear, userID)
+}
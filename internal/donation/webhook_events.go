@@ -0,0 +1,275 @@
+package donation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/payment"
+)
+
+// ==============================
+// Payment Webhook Event Store
+// ==============================
+
+const (
+	maxWebhookAttempts  = 8                // failed events are retried until this many attempts
+	webhookRetryBase    = time.Minute      // first retry delay, doubled on every attempt
+	webhookRetryMax     = 6 * time.Hour    // longest delay between retries
+	webhookClaimTimeout = 10 * time.Minute // a processing claim older than this is abandoned
+)
+
+// errMalformedWebhook marks a verified webhook that is missing the ids we need; it is never retried
+var errMalformedWebhook = errors.New("missing order_id or payment_id")
+
+// webhookRetryDelay is the backoff before the next attempt after `attempts` failures
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMax {
+		delay = webhookRetryMax
+	}
+	return delay
+}
+
+// ReceivePaymentWebhook stores the delivery and processes it once. Deliveries
+// failing signature checks are kept as rejected and the parse error returned.
+// A repeat of an already stored event is not processed again (duplicate is
+// true) unless the earlier attempt failed, in which case this counts as a retry.
+func (s *service) ReceivePaymentWebhook(providerName string, body []byte, headers http.Header) (*PaymentWebhookEvent, bool, error) {
+	ctx := context.Background()
+
+	rec := &PaymentWebhookEvent{
+		Provider: providerName,
+		EventID:  payment.WebhookEventID(providerName, body, headers),
+		Payload:  string(body),
+		Status:   WebhookReceived,
+	}
+
+	ev, err := s.ParsePaymentWebhook(providerName, body, headers)
+	if err != nil {
+		rec.Status, rec.LastError = WebhookRejected, err.Error()
+		if _, cerr := s.repo.CreateWebhookEvent(ctx, rec); cerr != nil {
+			log.Printf("❌ Webhook(%s): could not store rejected event: %v", providerName, cerr)
+		}
+		return rec, false, err
+	}
+
+	rec.SignatureValid = true
+	rec.EventType, rec.OrderID, rec.PaymentID = ev.Type, ev.OrderID, ev.PaymentID
+	created, err := s.repo.CreateWebhookEvent(ctx, rec)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to store webhook event: %w", err)
+	}
+	if !created {
+		existing, err := s.repo.GetWebhookEventByEventID(ctx, providerName, rec.EventID)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to load webhook event: %w", err)
+		}
+		if existing.Status != WebhookFailed {
+			log.Printf("⚠️ Webhook(%s): duplicate event %s (%s) ignored", providerName, existing.EventID, existing.Status)
+			return existing, true, nil
+		}
+		rec = existing
+	}
+
+	return s.processWebhookEvent(ctx, rec, ev), false, nil
+}
+
+// processWebhookEvent claims the stored event, applies it and records the outcome.
+// If another worker holds the claim the event is returned untouched.
+func (s *service) processWebhookEvent(ctx context.Context, rec *PaymentWebhookEvent, ev *payment.WebhookEvent) *PaymentWebhookEvent {
+	claimed, err := s.repo.ClaimWebhookEvent(ctx, rec.ID, time.Now().Add(-webhookClaimTimeout))
+	if err != nil || !claimed {
+		if err != nil {
+			log.Printf("❌ Webhook event=%d: claim failed: %v", rec.ID, err)
+		}
+		rec.Status = WebhookProcessing
+		return rec
+	}
+	rec.Attempts++
+
+	handled, err := s.applyWebhookEvent(ev)
+	now := time.Now()
+	updates := map[string]interface{}{"next_retry_at": nil}
+	switch {
+	case errors.Is(err, errMalformedWebhook):
+		rec.Status, rec.LastError = WebhookRejected, err.Error()
+	case err != nil:
+		rec.Status, rec.LastError = WebhookFailed, err.Error()
+		if rec.Attempts < maxWebhookAttempts {
+			next := now.Add(webhookRetryDelay(rec.Attempts))
+			rec.NextRetryAt = &next
+		}
+		updates["next_retry_at"] = rec.NextRetryAt
+	case !handled:
+		rec.Status, rec.LastError = WebhookIgnored, ""
+	default:
+		rec.Status, rec.LastError = WebhookProcessed, ""
+		rec.ProcessedAt = &now
+		updates["processed_at"] = now
+	}
+	updates["status"] = rec.Status
+	updates["last_error"] = rec.LastError
+
+	if err := s.repo.UpdateWebhookEvent(ctx, rec.ID, updates); err != nil {
+		log.Printf("❌ Webhook event=%d: could not record outcome %s: %v", rec.ID, rec.Status, err)
+	}
+	return rec
+}
+
+// applyWebhookEvent runs the state change for a verified event.
+// It returns false for event types we do not act on.
+func (s *service) applyWebhookEvent(ev *payment.WebhookEvent) (bool, error) {
+	switch ev.Type {
+	case payment.EventPaymentCaptured:
+		if ev.PaymentID == "" {
+			return true, errMalformedWebhook
+		}
+		if err := s.HandlePaymentCapturedWebhook(ev.OrderID, ev.PaymentID, ev.Method, ev.Amount); err != nil {
+			return true, err
+		}
+		log.Printf("✅ Webhook processed successfully for order %s", ev.OrderID)
+
+	case payment.EventPaymentFailed:
+		if err := s.HandleFailedPaymentWebhook(ev.OrderID, ev.PaymentID); err != nil {
+			return true, err
+		}
+		log.Printf("✅ Failed payment webhook processed for order %s", ev.OrderID)
+
+	case payment.EventRefundProcessed, payment.EventRefundFailed:
+		// Our own refund id is echoed back so early webhooks can still be matched
+		var localRefundID uint
+		if id, err := strconv.ParseUint(ev.ReferenceID, 10, 32); err == nil {
+			localRefundID = uint(id)
+		}
+		// The temple whose credentials verified the event
+		tenantID, _, err := s.webhookTenant(context.Background(), ev)
+		if err != nil {
+			return true, err
+		}
+		processed := ev.Type == payment.EventRefundProcessed
+		if err := s.HandleRefundWebhook(tenantID, ev.RefundID, ev.PaymentID, localRefundID, ev.Amount, processed, ev.Reason); err != nil {
+			return true, err
+		}
+		log.Printf("✅ Refund webhook processed for refund %s", ev.RefundID)
+
+	default:
+		log.Printf("⚠️ Unhandled webhook event: %s", ev.Type)
+		return false, nil
+	}
+	return true, nil
+}
+
+// parseStoredWebhook decodes a stored payload again. Only events whose
+// signature was verified on arrival may be re-applied.
+func (s *service) parseStoredWebhook(rec *PaymentWebhookEvent) (*payment.WebhookEvent, error) {
+	if !rec.SignatureValid {
+		return nil, errors.New("only events with a verified signature can be replayed")
+	}
+	provider, err := s.payments.ByName(rec.Provider)
+	if err != nil {
+		return nil, err
+	}
+	return provider.ParseWebhook([]byte(rec.Payload))
+}
+
+// RetryFailedWebhooks re-applies failed events whose backoff has elapsed.
+// Returns how many were processed successfully.
+func (s *service) RetryFailedWebhooks(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := s.repo.ListDueWebhookEvents(ctx, now, now.Add(-webhookClaimTimeout), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range due {
+		rec := &due[i]
+		ev, err := s.parseStoredWebhook(rec)
+		if err != nil {
+			log.Printf("❌ Webhook event=%d: cannot retry: %v", rec.ID, err)
+			_ = s.repo.UpdateWebhookEvent(ctx, rec.ID, map[string]interface{}{
+				"status": WebhookRejected, "last_error": err.Error(), "next_retry_at": nil,
+			})
+			continue
+		}
+		if s.processWebhookEvent(ctx, rec, ev).Status == WebhookProcessed {
+			processed++
+		}
+	}
+	return processed, nil
+}
+
+func (s *service) ListWebhookEvents(filters WebhookEventFilters) ([]PaymentWebhookEvent, int, error) {
+	return s.repo.ListWebhookEvents(context.Background(), filters)
+}
+
+// ReplayWebhookEvent applies a stored event again, whatever its status.
+// The payment handlers are idempotent, so replaying a processed event is safe.
+func (s *service) ReplayWebhookEvent(id uint, userID uint, ip string) (*PaymentWebhookEvent, error) {
+	ctx := context.Background()
+
+	rec, err := s.repo.GetWebhookEventByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("webhook event not found")
+	}
+	ev, err := s.parseStoredWebhook(rec)
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &userID, nil, "WEBHOOK_EVENT_REPLAYED",
+			map[string]interface{}{"webhook_event_id": id, "event_id": rec.EventID, "error": err.Error()},
+			ip, "failure")
+		return nil, err
+	}
+
+	previous := rec.Status
+	rec = s.processWebhookEvent(ctx, rec, ev)
+	if rec.Status == WebhookProcessing {
+		return nil, errors.New("webhook event is already being processed")
+	}
+
+	status := "success"
+	if rec.Status == WebhookFailed || rec.Status == WebhookRejected {
+		status = "failure"
+	}
+	s.auditSvc.LogAction(ctx, &userID, nil, "WEBHOOK_EVENT_REPLAYED", map[string]interface{}{
+		"webhook_event_id": rec.ID,
+		"provider":         rec.Provider,
+		"event_id":         rec.EventID,
+		"event_type":       rec.EventType,
+		"order_id":         rec.OrderID,
+		"previous_status":  previous,
+		"status":           rec.Status,
+		"error":            rec.LastError,
+	}, ip, status)
+	return rec, nil
+}
+
+// StartWebhookRetryWorker retries failed webhook events every interval until
+// the process exits
+func StartWebhookRetryWorker(svc Service, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	go func() {
+		log.Printf("📨 Webhook retry worker started (every %s)", interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			processed, err := svc.RetryFailedWebhooks(context.Background())
+			if err != nil {
+				log.Printf("❌ Webhook retry worker: %v", err)
+			} else if processed > 0 {
+				log.Printf("📨 Webhook retry worker processed %d event(s)", processed)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -278,6 +278,8 @@ func (h *Handler) handleMultipartFormData(c *gin.Context, input *Entity, tempFil
 	input.Pincode = h.getFormValue(form, "pincode")
 	input.Landmark = h.getFormValue(form, "landmark")
 	input.MapLink = h.getFormValue(form, "map_link")
+	input.Registration80G = h.getFormValue(form, "registration_80g")
+	input.TrustPAN = strings.ToUpper(h.getFormValue(form, "trust_pan"))
 
 	if err := h.processFileUploadsToTemp(form, tempFiles); err != nil {
 		return fmt.Errorf("failed to process file uploads: %v", err)
@@ -1044,6 +1046,12 @@ func (h *Handler) UpdateEntity(c *gin.Context) {
 		input.AdditionalDocsURLs = existingEntity.AdditionalDocsURLs
 		input.AdditionalDocsInfo = existingEntity.AdditionalDocsInfo
 	}
+	if input.Registration80G == "" {
+		input.Registration80G = existingEntity.Registration80G
+	}
+	if input.TrustPAN == "" {
+		input.TrustPAN = existingEntity.TrustPAN
+	}
 
 	if wasRejected && user.Role.RoleName != "superadmin" {
 		input.Status = "pending"
@@ -37,6 +37,10 @@ type Entity struct {
 	PropertyDocsInfo     string `json:"property_docs_info"`     // JSON metadata
 	AdditionalDocsInfo   string `json:"additional_docs_info"`   // JSON metadata
 
+	// Income-tax details printed on 80G donation receipts
+	Registration80G string `json:"registration_80g" gorm:"column:registration_80g;size:100"`
+	TrustPAN        string `json:"trust_pan" gorm:"column:trust_pan;size:10"`
+
 	// Stores JSON: {"logo": "url/to/logo.jpg", "video": "url/to/video.mp4"}
 	Media string `json:"media" gorm:"type:text"` // JSON string containing logo and video URLs
 
@@ -0,0 +1,263 @@
+package hundi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func getAccessContextFromContext(c *gin.Context) (middleware.AccessContext, bool) {
+	accessContextRaw, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return middleware.AccessContext{}, false
+	}
+	accessContext, ok := accessContextRaw.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid access context"})
+		return middleware.AccessContext{}, false
+	}
+	return accessContext, true
+}
+
+func getEntityIDFromRequest(c *gin.Context, accessContext middleware.AccessContext) uint {
+	if entityIDQuery := c.Query("entity_id"); entityIDQuery != "" {
+		if id, err := strconv.ParseUint(entityIDQuery, 10, 32); err == nil {
+			return uint(id)
+		}
+	}
+	if contextEntityID := accessContext.GetAccessibleEntityID(); contextEntityID != nil {
+		return *contextEntityID
+	}
+	return 0
+}
+
+func parseIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ==============================
+// 🏺 Hundis
+// ==============================
+
+func (h *Handler) CreateHundi(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID := getEntityIDFromRequest(c, accessContext)
+	if entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+	var req CreateHundiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hundi, err := h.svc.CreateHundi(c.Request.Context(), req, entityID, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": hundi, "success": true})
+}
+
+func (h *Handler) UpdateHundi(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req CreateHundiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hundi, err := h.svc.UpdateHundi(c.Request.Context(), id, req, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": hundi, "success": true})
+}
+
+func (h *Handler) ListHundis(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID := getEntityIDFromRequest(c, accessContext)
+	if entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	hundis, err := h.svc.ListHundis(c.Request.Context(), entityID, accessContext)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": hundis, "success": true})
+}
+
+// ==============================
+// 🧮 Counting Sessions
+// ==============================
+
+func (h *Handler) OpenSession(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	hundiID, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req OpenSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.svc.OpenSession(c.Request.Context(), hundiID, req, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": session, "success": true})
+}
+
+func (h *Handler) ListSessions(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID := getEntityIDFromRequest(c, accessContext)
+	if entityID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	filter := SessionFilter{
+		EntityID: entityID,
+		Status:   c.Query("status"),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	if hundiID, err := strconv.ParseUint(c.Query("hundi_id"), 10, 32); err == nil {
+		filter.HundiID = uint(hundiID)
+	}
+
+	sessions, total, err := h.svc.ListSessions(c.Request.Context(), filter, accessContext)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": sessions, "total": total, "success": true})
+}
+
+func (h *Handler) GetSession(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.svc.GetSession(c.Request.Context(), id, accessContext)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": session, "success": true})
+}
+
+func (h *Handler) SubmitTally(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req SubmitTallyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.svc.SubmitTally(c.Request.Context(), id, req, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": session, "success": true})
+}
+
+func (h *Handler) ApproveSession(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req ReviewSessionRequest
+	_ = c.ShouldBindJSON(&req)
+
+	session, err := h.svc.ApproveSession(c.Request.Context(), id, req, accessContext, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": session, "success": true})
+}
+
+func (h *Handler) RejectSession(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c)
+	if !ok {
+		return
+	}
+	var req ReviewSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.svc.RejectSession(c.Request.Context(), id, req, accessContext, middleware.GetIPFromContext(c)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Counting session rejected", "success": true})
+}
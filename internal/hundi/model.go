@@ -0,0 +1,142 @@
+package hundi
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Counting session lifecycle
+const (
+	SessionOpen     = "open"     // waiting for every counter to submit a tally
+	SessionMismatch = "mismatch" // tallies disagree — counters must recount
+	SessionMatched  = "matched"  // all tallies agree — awaiting templeadmin sign-off
+	SessionApproved = "approved" // signed off and posted as a donation
+	SessionRejected = "rejected"
+)
+
+// MinCounters is the number of independent counters required per session
+const MinCounters = 2
+
+// ValidDenominations lists the INR note / coin face values accepted in a tally
+var ValidDenominations = map[float64]bool{
+	2000: true, 500: true, 200: true, 100: true, 50: true,
+	20: true, 10: true, 5: true, 2: true, 1: true,
+}
+
+// Hundi is an offering box registered under a temple
+type Hundi struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	EntityID    uint           `gorm:"not null;index" json:"entity_id"`
+	Name        string         `gorm:"size:150;not null" json:"name"`
+	Location    string         `gorm:"size:255" json:"location"`
+	Description string         `gorm:"type:text" json:"description"`
+	IsActive    bool           `gorm:"default:true" json:"is_active"`
+	CreatedBy   uint           `gorm:"not null" json:"created_by"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Hundi) TableName() string {
+	return "hundis"
+}
+
+// CountingSession is one opening of a hundi, counted by two or more counters
+type CountingSession struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	EntityID         uint       `gorm:"not null;index" json:"entity_id"`
+	HundiID          uint       `gorm:"not null;index" json:"hundi_id"`
+	OpenedOn         time.Time  `gorm:"type:date;not null" json:"opened_on"`
+	Status           string     `gorm:"size:20;not null;default:'open';index" json:"status"`
+	RequiredCounters int        `gorm:"not null;default:2" json:"required_counters"`
+	CountedTotal     float64    `gorm:"not null;default:0" json:"counted_total"`
+	DonationID       *uint      `json:"donation_id,omitempty"` // donation posted on approval
+	ApprovedBy       *uint      `json:"approved_by,omitempty"`
+	ApprovedAt       *time.Time `json:"approved_at,omitempty"`
+	Remarks          string     `gorm:"type:text" json:"remarks"`
+	CreatedBy        uint       `gorm:"not null" json:"created_by"`
+	CreatedAt        time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Tallies    []CounterTally         `gorm:"foreignKey:SessionID" json:"tallies,omitempty"`
+	Mismatches []DenominationMismatch `gorm:"-" json:"mismatches,omitempty"`
+	HundiName  string                 `gorm:"-" json:"hundi_name,omitempty"`
+}
+
+func (CountingSession) TableName() string {
+	return "hundi_counting_sessions"
+}
+
+// CounterTally is one counter's independent denomination-wise count
+type CounterTally struct {
+	ID            uint                `gorm:"primaryKey" json:"id"`
+	SessionID     uint                `gorm:"not null;uniqueIndex:idx_hundi_tally_counter" json:"session_id"`
+	CounterUserID uint                `gorm:"not null;uniqueIndex:idx_hundi_tally_counter" json:"counter_user_id"`
+	Total         float64             `gorm:"not null" json:"total"`
+	Lines         []DenominationCount `gorm:"foreignKey:TallyID" json:"lines"`
+	CreatedAt     time.Time           `gorm:"autoCreateTime" json:"submitted_at"`
+	UpdatedAt     time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (CounterTally) TableName() string {
+	return "hundi_counter_tallies"
+}
+
+// DenominationCount is the number of notes / coins of one face value
+type DenominationCount struct {
+	ID           uint    `gorm:"primaryKey" json:"-"`
+	TallyID      uint    `gorm:"not null;index" json:"-"`
+	Denomination float64 `gorm:"not null" json:"denomination"`
+	Count        int     `gorm:"not null" json:"count"`
+	Amount       float64 `gorm:"not null" json:"amount"`
+}
+
+func (DenominationCount) TableName() string {
+	return "hundi_denomination_counts"
+}
+
+// DenominationMismatch reports a face value the counters disagree on.
+// Counts is keyed by counter user id and is hidden from the counters themselves.
+type DenominationMismatch struct {
+	Denomination float64      `json:"denomination"`
+	Counts       map[uint]int `json:"counts,omitempty"`
+}
+
+// ============================
+// Requests
+// ============================
+
+type CreateHundiRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Location    string `json:"location"`
+	Description string `json:"description"`
+	IsActive    *bool  `json:"is_active,omitempty"`
+}
+
+type OpenSessionRequest struct {
+	OpenedOn         string `json:"opened_on" binding:"required"` // YYYY-MM-DD
+	RequiredCounters int    `json:"required_counters,omitempty"`
+	Remarks          string `json:"remarks"`
+}
+
+type DenominationInput struct {
+	Denomination float64 `json:"denomination" binding:"required,gt=0"`
+	Count        int     `json:"count" binding:"gte=0"`
+}
+
+type SubmitTallyRequest struct {
+	Lines []DenominationInput `json:"lines" binding:"required,min=1,dive"`
+}
+
+type ReviewSessionRequest struct {
+	Remarks string `json:"remarks"`
+}
+
+type SessionFilter struct {
+	EntityID uint
+	HundiID  uint
+	Status   string
+	Limit    int
+	Offset   int
+}
@@ -0,0 +1,203 @@
+package hundi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/donation"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	// Hundis
+	CreateHundi(ctx context.Context, h *Hundi) error
+	UpdateHundi(ctx context.Context, h *Hundi) error
+	GetHundiByID(ctx context.Context, id uint) (*Hundi, error)
+	ListHundis(ctx context.Context, entityID uint) ([]Hundi, error)
+
+	// Counting sessions
+	CreateSession(ctx context.Context, s *CountingSession) error
+	GetSessionByID(ctx context.Context, id uint) (*CountingSession, error)
+	ListSessions(ctx context.Context, filter SessionFilter) ([]CountingSession, int64, error)
+	SubmitTally(ctx context.Context, sessionID uint, tally *CounterTally) (*CountingSession, error)
+	ApproveSession(ctx context.Context, sessionID uint, approvedBy uint, remarks string, d *donation.Donation) (*CountingSession, error)
+	RejectSession(ctx context.Context, sessionID uint, remarks string) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// ==============================
+// Hundis
+// ==============================
+
+func (r *repository) CreateHundi(ctx context.Context, h *Hundi) error {
+	return r.db.WithContext(ctx).Create(h).Error
+}
+
+func (r *repository) UpdateHundi(ctx context.Context, h *Hundi) error {
+	return r.db.WithContext(ctx).Save(h).Error
+}
+
+func (r *repository) GetHundiByID(ctx context.Context, id uint) (*Hundi, error) {
+	var h Hundi
+	if err := r.db.WithContext(ctx).First(&h, id).Error; err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (r *repository) ListHundis(ctx context.Context, entityID uint) ([]Hundi, error) {
+	var hundis []Hundi
+	err := r.db.WithContext(ctx).
+		Where("entity_id = ?", entityID).
+		Order("name ASC").
+		Find(&hundis).Error
+	return hundis, err
+}
+
+// ==============================
+// Counting Sessions
+// ==============================
+
+func (r *repository) CreateSession(ctx context.Context, s *CountingSession) error {
+	return r.db.WithContext(ctx).Create(s).Error
+}
+
+func (r *repository) GetSessionByID(ctx context.Context, id uint) (*CountingSession, error) {
+	var s CountingSession
+	err := r.db.WithContext(ctx).
+		Preload("Tallies", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Preload("Tallies.Lines", func(db *gorm.DB) *gorm.DB { return db.Order("denomination DESC") }).
+		First(&s, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *repository) ListSessions(ctx context.Context, filter SessionFilter) ([]CountingSession, int64, error) {
+	query := r.db.WithContext(ctx).Model(&CountingSession{}).Where("entity_id = ?", filter.EntityID)
+	if filter.HundiID != 0 {
+		query = query.Where("hundi_id = ?", filter.HundiID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var sessions []CountingSession
+	err := query.
+		Order("opened_on DESC, id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&sessions).Error
+	return sessions, total, err
+}
+
+// SubmitTally stores (or replaces) a counter's tally and re-evaluates the
+// session under a row lock, so simultaneous submissions see each other.
+func (r *repository) SubmitTally(ctx context.Context, sessionID uint, tally *CounterTally) (*CountingSession, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var session CountingSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, sessionID).Error; err != nil {
+			return err
+		}
+		if session.Status == SessionApproved || session.Status == SessionRejected {
+			return errors.New("counting session is already closed")
+		}
+
+		// A recount replaces the counter's previous tally
+		var existing CounterTally
+		err := tx.Where("session_id = ? AND counter_user_id = ?", sessionID, tally.CounterUserID).First(&existing).Error
+		switch {
+		case err == nil:
+			if err := tx.Where("tally_id = ?", existing.ID).Delete(&DenominationCount{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&existing).Error; err != nil {
+				return err
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		tally.SessionID = sessionID
+		if err := tx.Create(tally).Error; err != nil {
+			return err
+		}
+
+		var tallies []CounterTally
+		if err := tx.Preload("Lines").Where("session_id = ?", sessionID).Find(&tallies).Error; err != nil {
+			return err
+		}
+		status, total, _ := evaluateTallies(tallies, session.RequiredCounters)
+		return tx.Model(&CountingSession{}).
+			Where("id = ?", sessionID).
+			Updates(map[string]interface{}{"status": status, "counted_total": total}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetSessionByID(ctx, sessionID)
+}
+
+// ApproveSession signs off a matched session and posts its total as a donation
+// in the same transaction, so a session can never be posted twice.
+func (r *repository) ApproveSession(ctx context.Context, sessionID uint, approvedBy uint, remarks string, d *donation.Donation) (*CountingSession, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var session CountingSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, sessionID).Error; err != nil {
+			return err
+		}
+		if session.Status != SessionMatched {
+			return errors.New("only sessions whose tallies match can be approved")
+		}
+
+		d.Amount = session.CountedTotal
+		if err := tx.Create(d).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":      SessionApproved,
+			"donation_id": d.ID,
+			"approved_by": approvedBy,
+			"approved_at": now,
+		}
+		if remarks != "" {
+			updates["remarks"] = remarks
+		}
+		return tx.Model(&CountingSession{}).Where("id = ?", sessionID).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetSessionByID(ctx, sessionID)
+}
+
+func (r *repository) RejectSession(ctx context.Context, sessionID uint, remarks string) error {
+	res := r.db.WithContext(ctx).
+		Model(&CountingSession{}).
+		Where("id = ? AND status NOT IN ?", sessionID, []string{SessionApproved, SessionRejected}).
+		Updates(map[string]interface{}{"status": SessionRejected, "remarks": remarks})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("counting session is already closed")
+	}
+	return nil
+}
@@ -0,0 +1,416 @@
+package hundi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/donation"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+type Service interface {
+	// Hundis
+	CreateHundi(ctx context.Context, req CreateHundiRequest, entityID uint, accessContext middleware.AccessContext, ip string) (*Hundi, error)
+	UpdateHundi(ctx context.Context, id uint, req CreateHundiRequest, accessContext middleware.AccessContext, ip string) (*Hundi, error)
+	ListHundis(ctx context.Context, entityID uint, accessContext middleware.AccessContext) ([]Hundi, error)
+
+	// Counting sessions
+	OpenSession(ctx context.Context, hundiID uint, req OpenSessionRequest, accessContext middleware.AccessContext, ip string) (*CountingSession, error)
+	ListSessions(ctx context.Context, filter SessionFilter, accessContext middleware.AccessContext) ([]CountingSession, int64, error)
+	GetSession(ctx context.Context, id uint, accessContext middleware.AccessContext) (*CountingSession, error)
+	SubmitTally(ctx context.Context, sessionID uint, req SubmitTallyRequest, accessContext middleware.AccessContext, ip string) (*CountingSession, error)
+	ApproveSession(ctx context.Context, sessionID uint, req ReviewSessionRequest, accessContext middleware.AccessContext, ip string) (*CountingSession, error)
+	RejectSession(ctx context.Context, sessionID uint, req ReviewSessionRequest, accessContext middleware.AccessContext, ip string) error
+}
+
+type service struct {
+	repo     Repository
+	auditSvc auditlog.Service
+}
+
+func NewService(repo Repository, auditSvc auditlog.Service) Service {
+	return &service{repo: repo, auditSvc: auditSvc}
+}
+
+// checkEntity verifies the caller works for the temple that owns the record
+func checkEntity(accessContext middleware.AccessContext, entityID uint) error {
+	accessible := accessContext.GetAccessibleEntityID()
+	if accessible == nil || *accessible != entityID {
+		return errors.New("access denied to requested entity")
+	}
+	return nil
+}
+
+// ==============================
+// Hundis
+// ==============================
+
+func (s *service) CreateHundi(ctx context.Context, req CreateHundiRequest, entityID uint, accessContext middleware.AccessContext, ip string) (*Hundi, error) {
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	if err := checkEntity(accessContext, entityID); err != nil {
+		return nil, err
+	}
+
+	h := &Hundi{
+		EntityID:    entityID,
+		Name:        strings.TrimSpace(req.Name),
+		Location:    req.Location,
+		Description: req.Description,
+		IsActive:    true,
+		CreatedBy:   accessContext.UserID,
+	}
+	if req.IsActive != nil {
+		h.IsActive = *req.IsActive
+	}
+	if err := s.repo.CreateHundi(ctx, h); err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "HUNDI_CREATED",
+			map[string]interface{}{"name": h.Name, "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "HUNDI_CREATED",
+		map[string]interface{}{"hundi_id": h.ID, "name": h.Name, "location": h.Location}, ip, "success")
+	return h, nil
+}
+
+func (s *service) UpdateHundi(ctx context.Context, id uint, req CreateHundiRequest, accessContext middleware.AccessContext, ip string) (*Hundi, error) {
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	h, err := s.repo.GetHundiByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("hundi not found")
+	}
+	if err := checkEntity(accessContext, h.EntityID); err != nil {
+		return nil, err
+	}
+
+	h.Name = strings.TrimSpace(req.Name)
+	h.Location = req.Location
+	h.Description = req.Description
+	if req.IsActive != nil {
+		h.IsActive = *req.IsActive
+	}
+	if err := s.repo.UpdateHundi(ctx, h); err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &h.EntityID, "HUNDI_UPDATED",
+			map[string]interface{}{"hundi_id": id, "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &h.EntityID, "HUNDI_UPDATED",
+		map[string]interface{}{"hundi_id": id, "name": h.Name, "is_active": h.IsActive}, ip, "success")
+	return h, nil
+}
+
+func (s *service) ListHundis(ctx context.Context, entityID uint, accessContext middleware.AccessContext) ([]Hundi, error) {
+	if !accessContext.CanRead() {
+		return nil, errors.New("read access denied")
+	}
+	if err := checkEntity(accessContext, entityID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListHundis(ctx, entityID)
+}
+
+// ==============================
+// Counting Sessions
+// ==============================
+
+func (s *service) OpenSession(ctx context.Context, hundiID uint, req OpenSessionRequest, accessContext middleware.AccessContext, ip string) (*CountingSession, error) {
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	h, err := s.repo.GetHundiByID(ctx, hundiID)
+	if err != nil {
+		return nil, errors.New("hundi not found")
+	}
+	if err := checkEntity(accessContext, h.EntityID); err != nil {
+		return nil, err
+	}
+	if !h.IsActive {
+		return nil, errors.New("hundi is inactive")
+	}
+
+	openedOn, err := time.Parse("2006-01-02", req.OpenedOn)
+	if err != nil {
+		return nil, errors.New("invalid opened_on format. Use YYYY-MM-DD")
+	}
+	required := req.RequiredCounters
+	if required == 0 {
+		required = MinCounters
+	}
+	if required < MinCounters {
+		return nil, fmt.Errorf("at least %d independent counters are required", MinCounters)
+	}
+
+	session := &CountingSession{
+		EntityID:         h.EntityID,
+		HundiID:          h.ID,
+		OpenedOn:         openedOn,
+		Status:           SessionOpen,
+		RequiredCounters: required,
+		Remarks:          req.Remarks,
+		CreatedBy:        accessContext.UserID,
+	}
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &h.EntityID, "HUNDI_SESSION_OPENED",
+			map[string]interface{}{"hundi_id": h.ID, "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &h.EntityID, "HUNDI_SESSION_OPENED",
+		map[string]interface{}{
+			"session_id":        session.ID,
+			"hundi_id":          h.ID,
+			"opened_on":         req.OpenedOn,
+			"required_counters": required,
+		}, ip, "success")
+	session.HundiName = h.Name
+	return session, nil
+}
+
+func (s *service) ListSessions(ctx context.Context, filter SessionFilter, accessContext middleware.AccessContext) ([]CountingSession, int64, error) {
+	if !accessContext.CanRead() {
+		return nil, 0, errors.New("read access denied")
+	}
+	if err := checkEntity(accessContext, filter.EntityID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListSessions(ctx, filter)
+}
+
+func (s *service) GetSession(ctx context.Context, id uint, accessContext middleware.AccessContext) (*CountingSession, error) {
+	if !accessContext.CanRead() {
+		return nil, errors.New("read access denied")
+	}
+	session, err := s.repo.GetSessionByID(ctx, id)
+	if err != nil {
+		return nil, errors.New("counting session not found")
+	}
+	if err := checkEntity(accessContext, session.EntityID); err != nil {
+		return nil, err
+	}
+	return s.present(session, accessContext), nil
+}
+
+// SubmitTally records the caller's own denomination-wise count. Each counter
+// submits independently; resubmitting replaces the earlier count (a recount).
+func (s *service) SubmitTally(ctx context.Context, sessionID uint, req SubmitTallyRequest, accessContext middleware.AccessContext, ip string) (*CountingSession, error) {
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, errors.New("counting session not found")
+	}
+	if err := checkEntity(accessContext, session.EntityID); err != nil {
+		return nil, err
+	}
+
+	tally, err := buildTally(req.Lines)
+	if err != nil {
+		return nil, err
+	}
+	tally.CounterUserID = accessContext.UserID
+
+	updated, err := s.repo.SubmitTally(ctx, sessionID, tally)
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &session.EntityID, "HUNDI_TALLY_SUBMITTED",
+			map[string]interface{}{"session_id": sessionID, "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &session.EntityID, "HUNDI_TALLY_SUBMITTED",
+		map[string]interface{}{
+			"session_id":     sessionID,
+			"tally_total":    tally.Total,
+			"session_status": updated.Status,
+		}, ip, "success")
+	if updated.Status == SessionMismatch {
+		log.Printf("⚠️ Hundi session=%d: counter tallies do not match", sessionID)
+	}
+	return s.present(updated, accessContext), nil
+}
+
+// ApproveSession is the templeadmin sign-off. The agreed total is posted as a
+// general donation so it shows up in the donation dashboard and reports.
+func (s *service) ApproveSession(ctx context.Context, sessionID uint, req ReviewSessionRequest, accessContext middleware.AccessContext, ip string) (*CountingSession, error) {
+	if accessContext.RoleName != middleware.RoleTempleAdmin {
+		return nil, errors.New("only the temple admin can sign off a counting session")
+	}
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, errors.New("counting session not found")
+	}
+	if err := checkEntity(accessContext, session.EntityID); err != nil {
+		return nil, err
+	}
+	h, err := s.repo.GetHundiByID(ctx, session.HundiID)
+	if err != nil {
+		return nil, errors.New("hundi not found")
+	}
+
+	donorName := "Hundi collection - " + h.Name
+	note := fmt.Sprintf("Hundi counting session #%d opened on %s", session.ID, session.OpenedOn.Format("2006-01-02"))
+	openedOn := session.OpenedOn
+	d := &donation.Donation{
+		EntityID:     session.EntityID,
+		DonationType: "general",
+		Method:       donation.MethodCash,
+		Status:       donation.StatusSuccess,
+		OrderID:      fmt.Sprintf("hundi_%d", session.ID),
+		DonatedAt:    &openedOn,
+		Note:         &note,
+		DonorName:    &donorName,
+		IsOffline:    true,
+		RecordedBy:   &accessContext.UserID,
+	}
+
+	approved, err := s.repo.ApproveSession(ctx, sessionID, accessContext.UserID, req.Remarks, d)
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &session.EntityID, "HUNDI_SESSION_APPROVED",
+			map[string]interface{}{"session_id": sessionID, "error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &session.EntityID, "HUNDI_SESSION_APPROVED",
+		map[string]interface{}{
+			"session_id":  sessionID,
+			"hundi_id":    h.ID,
+			"total":       approved.CountedTotal,
+			"donation_id": d.ID,
+		}, ip, "success")
+	log.Printf("✅ Hundi session=%d approved: total=%.2f posted as donation=%d", sessionID, approved.CountedTotal, d.ID)
+	approved.HundiName = h.Name
+	return s.present(approved, accessContext), nil
+}
+
+func (s *service) RejectSession(ctx context.Context, sessionID uint, req ReviewSessionRequest, accessContext middleware.AccessContext, ip string) error {
+	if accessContext.RoleName != middleware.RoleTempleAdmin {
+		return errors.New("only the temple admin can reject a counting session")
+	}
+	if strings.TrimSpace(req.Remarks) == "" {
+		return errors.New("remarks are required when rejecting a session")
+	}
+	session, err := s.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return errors.New("counting session not found")
+	}
+	if err := checkEntity(accessContext, session.EntityID); err != nil {
+		return err
+	}
+
+	if err := s.repo.RejectSession(ctx, sessionID, req.Remarks); err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &session.EntityID, "HUNDI_SESSION_REJECTED",
+			map[string]interface{}{"session_id": sessionID, "error": err.Error()}, ip, "failure")
+		return err
+	}
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &session.EntityID, "HUNDI_SESSION_REJECTED",
+		map[string]interface{}{"session_id": sessionID, "remarks": req.Remarks}, ip, "success")
+	return nil
+}
+
+// ==============================
+// Tally Helpers
+// ==============================
+
+// buildTally validates denominations, merges repeated face values and totals the count
+func buildTally(lines []DenominationInput) (*CounterTally, error) {
+	counts := make(map[float64]int)
+	for _, l := range lines {
+		if !ValidDenominations[l.Denomination] {
+			return nil, fmt.Errorf("invalid denomination: %v", l.Denomination)
+		}
+		counts[l.Denomination] += l.Count
+	}
+
+	tally := &CounterTally{}
+	for denom, count := range counts {
+		if count == 0 {
+			continue
+		}
+		amount := denom * float64(count)
+		tally.Lines = append(tally.Lines, DenominationCount{Denomination: denom, Count: count, Amount: amount})
+		tally.Total += amount
+	}
+	if len(tally.Lines) == 0 {
+		return nil, errors.New("tally must contain at least one non-zero count")
+	}
+	sort.Slice(tally.Lines, func(i, j int) bool { return tally.Lines[i].Denomination > tally.Lines[j].Denomination })
+	return tally, nil
+}
+
+// evaluateTallies compares every counter's count denomination by denomination.
+// The session stays open until enough counters have submitted, and only
+// matches when all tallies agree exactly.
+func evaluateTallies(tallies []CounterTally, required int) (string, float64, []DenominationMismatch) {
+	if len(tallies) < required {
+		return SessionOpen, 0, nil
+	}
+
+	perCounter := make(map[uint]map[float64]int, len(tallies))
+	denoms := make(map[float64]bool)
+	for _, t := range tallies {
+		counts := make(map[float64]int, len(t.Lines))
+		for _, l := range t.Lines {
+			counts[l.Denomination] += l.Count
+			denoms[l.Denomination] = true
+		}
+		perCounter[t.CounterUserID] = counts
+	}
+
+	var mismatches []DenominationMismatch
+	for denom := range denoms {
+		byCounter := make(map[uint]int, len(perCounter))
+		agreed := true
+		first := -1
+		for userID, counts := range perCounter {
+			byCounter[userID] = counts[denom]
+			if first == -1 {
+				first = counts[denom]
+			} else if counts[denom] != first {
+				agreed = false
+			}
+		}
+		if !agreed {
+			mismatches = append(mismatches, DenominationMismatch{Denomination: denom, Counts: byCounter})
+		}
+	}
+	if len(mismatches) > 0 {
+		sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].Denomination > mismatches[j].Denomination })
+		return SessionMismatch, 0, mismatches
+	}
+	return SessionMatched, tallies[0].Total, nil
+}
+
+// present fills in mismatch details. Until the session is closed, counters
+// only see their own tally so each count stays independent; the templeadmin
+// sees everything.
+func (s *service) present(session *CountingSession, accessContext middleware.AccessContext) *CountingSession {
+	_, _, session.Mismatches = evaluateTallies(session.Tallies, session.RequiredCounters)
+
+	closed := session.Status == SessionApproved || session.Status == SessionRejected
+	if accessContext.RoleName == middleware.RoleTempleAdmin || closed {
+		return session
+	}
+
+	own := session.Tallies[:0:0]
+	for _, t := range session.Tallies {
+		if t.CounterUserID == accessContext.UserID {
+			own = append(own, t)
+		}
+	}
+	session.Tallies = own
+	for i := range session.Mismatches {
+		session.Mismatches[i].Counts = nil
+	}
+	return session
+}
@@ -3,11 +3,14 @@ package notification
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
 	"html/template"
+	"mime"
 	"net/smtp"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/sharath018/temple-management-backend/config"
 )
@@ -44,23 +47,10 @@ func (e *EmailSender) Send(to []string, subject string, body string) error {
 
 	fmt.Printf("📧 Sending one email to %d BCC recipients\n", len(to))
 
-	// Step 1: Load and parse the template
-	tmplPath := filepath.Join("templates", "example.html")
-	tmpl, err := template.ParseFiles(tmplPath)
+	// Step 1 + 2: Load the template and inject subject + body
+	htmlBody, err := renderEmailTemplate(subject, body)
 	if err != nil {
-		fmt.Println("❌ Failed to parse email template:", err)
-		return fmt.Errorf("failed to parse email template: %w", err)
-	}
-
-	// Step 2: Inject subject + body
-	var htmlBody bytes.Buffer
-	err = tmpl.Execute(&htmlBody, map[string]string{
-		"Subject": subject,
-		"Body":    body,
-	})
-	if err != nil {
-		fmt.Println("❌ Failed to render email template:", err)
-		return fmt.Errorf("failed to render email template: %w", err)
+		return err
 	}
 
 	// Step 3: Build headers with sender in To field
@@ -100,6 +90,83 @@ func (e *EmailSender) Send(to []string, subject string, body string) error {
 	return nil
 }
 
+// renderEmailTemplate wraps the subject and body in templates/example.html
+func renderEmailTemplate(subject, body string) (*bytes.Buffer, error) {
+	tmplPath := filepath.Join("templates", "example.html")
+	tmpl, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		fmt.Println("❌ Failed to parse email template:", err)
+		return nil, fmt.Errorf("failed to parse email template: %w", err)
+	}
+
+	var htmlBody bytes.Buffer
+	err = tmpl.Execute(&htmlBody, map[string]string{
+		"Subject": subject,
+		"Body":    body,
+	})
+	if err != nil {
+		fmt.Println("❌ Failed to render email template:", err)
+		return nil, fmt.Errorf("failed to render email template: %w", err)
+	}
+	return &htmlBody, nil
+}
+
+// Attachment is a file sent along with an email
+type Attachment struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// SendWithAttachment sends a personal email (recipients in the To field, not
+// BCC) with the rendered HTML body and one file attached
+func (e *EmailSender) SendWithAttachment(to []string, subject string, body string, attachment Attachment) error {
+	if len(to) == 0 {
+		return fmt.Errorf("no recipients provided")
+	}
+
+	htmlBody, err := renderEmailTemplate(subject, body)
+	if err != nil {
+		return err
+	}
+
+	boundary := fmt.Sprintf("temple-%d", time.Now().UnixNano())
+	var msg strings.Builder
+	msg.WriteString("From: Temple Management System <noreply@templemanagement.com>\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: multipart/mixed; boundary=\"" + boundary + "\"\r\n\r\n")
+
+	msg.WriteString("--" + boundary + "\r\n")
+	msg.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n\r\n")
+	msg.WriteString(htmlBody.String() + "\r\n")
+
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	msg.WriteString("--" + boundary + "\r\n")
+	msg.WriteString("Content-Type: " + contentType + "; name=\"" + attachment.FileName + "\"\r\n")
+	msg.WriteString("Content-Transfer-Encoding: base64\r\n")
+	msg.WriteString("Content-Disposition: attachment; filename=\"" + attachment.FileName + "\"\r\n\r\n")
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	for len(encoded) > 76 {
+		msg.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	msg.WriteString(encoded + "\r\n")
+	msg.WriteString("--" + boundary + "--\r\n")
+
+	addr := fmt.Sprintf("%s:%s", e.Host, e.Port)
+	fmt.Printf("📤 Sending %s to %d recipient(s) via %s\n", attachment.FileName, len(to), addr)
+	if err := e.sendMailWithTLS(addr, to, []byte(msg.String())); err != nil {
+		fmt.Println("❌ Email send failed:", err)
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
 // ✅ Custom send function with proper TLS handling
 func (e *EmailSender) sendMailWithTLS(addr string, to []string, message []byte) error {
 	// Create TLS config - skip verification for Docker environments
@@ -0,0 +1,339 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==============================
+// Cashfree Payments (PG API 2023-08-01)
+// ==============================
+
+const (
+	cashfreeProductionURL = "https://api.cashfree.com/pg"
+	cashfreeSandboxURL    = "https://sandbox.cashfree.com/pg"
+	cashfreeAPIVersion    = "2023-08-01"
+)
+
+type cashfreeProvider struct {
+	appID   string
+	secret  string
+	baseURL string
+	http    *http.Client
+}
+
+func NewCashfreeProvider(appID, secret string, sandbox bool) Provider {
+	baseURL := cashfreeProductionURL
+	if sandbox {
+		baseURL = cashfreeSandboxURL
+	}
+	return &cashfreeProvider{
+		appID:   appID,
+		secret:  secret,
+		baseURL: baseURL,
+		http:    &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *cashfreeProvider) Name() string { return ProviderCashfree }
+
+// do sends an authenticated request and decodes the JSON response into out
+func (p *cashfreeProvider) do(ctx context.Context, method, path string, in, out interface{}) error {
+	if p.appID == "" || p.secret == "" {
+		return ErrNotConfigured
+	}
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-client-id", p.appID)
+	req.Header.Set("x-client-secret", p.secret)
+	req.Header.Set("x-api-version", cashfreeAPIVersion)
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Message string `json:"message"`
+			Code    string `json:"code"`
+		}
+		_ = json.Unmarshal(raw, &apiErr)
+		return fmt.Errorf("cashfree %s %s: %d %s", method, path, resp.StatusCode, apiErr.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (p *cashfreeProvider) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	currency := req.Currency
+	if currency == "" {
+		currency = "INR"
+	}
+	customerID := req.CustomerID
+	if customerID == "" {
+		customerID = "guest"
+	}
+	// Cashfree only accepts string tags
+	tags := make(map[string]string, len(req.Notes))
+	for k, v := range req.Notes {
+		tags[k] = fmt.Sprint(v)
+	}
+
+	in := map[string]interface{}{
+		"order_amount":   float64(toPaise(req.Amount)) / 100,
+		"order_currency": currency,
+		"customer_details": map[string]interface{}{
+			"customer_id":    customerID,
+			"customer_email": req.CustomerEmail,
+			"customer_phone": req.CustomerPhone,
+		},
+		"order_tags": tags,
+	}
+	if req.Receipt != "" {
+		in["order_note"] = req.Receipt
+	}
+
+	var out struct {
+		OrderID          string `json:"order_id"`
+		PaymentSessionID string `json:"payment_session_id"`
+	}
+	if err := p.do(ctx, http.MethodPost, "/orders", in, &out); err != nil {
+		return nil, fmt.Errorf("cashfree order creation failed: %w", err)
+	}
+	if out.OrderID == "" {
+		return nil, errors.New("unable to extract order_id from Cashfree response")
+	}
+	return &Order{ID: out.OrderID, Amount: req.Amount, Currency: currency, Provider: ProviderCashfree, SessionID: out.PaymentSessionID}, nil
+}
+
+// cashfreePayment is one entry of GET /orders/{order_id}/payments
+type cashfreePayment struct {
+	CFPaymentID   json.Number `json:"cf_payment_id"`
+	OrderID       string      `json:"order_id"`
+	PaymentStatus string      `json:"payment_status"`
+	PaymentAmount float64     `json:"payment_amount"`
+	PaymentGroup  string      `json:"payment_group"`
+}
+
+func (c cashfreePayment) toPayment() *Payment {
+	return &Payment{
+		ID:      c.CFPaymentID.String(),
+		OrderID: c.OrderID,
+		Amount:  c.PaymentAmount,
+		Method:  c.PaymentGroup,
+		Status:  strings.ToLower(c.PaymentStatus),
+	}
+}
+
+// VerifyPayment confirms with Cashfree that the order has a successful
+// payment (the given one, if the frontend knows its id).
+func (p *cashfreeProvider) VerifyPayment(ctx context.Context, orderID, paymentID, signature string) (*Payment, error) {
+	var payments []cashfreePayment
+	if err := p.do(ctx, http.MethodGet, "/orders/"+url.PathEscape(orderID)+"/payments", nil, &payments); err != nil {
+		return nil, err
+	}
+	for _, c := range payments {
+		if c.PaymentStatus != "SUCCESS" {
+			continue
+		}
+		if paymentID == "" || c.CFPaymentID.String() == paymentID {
+			return c.toPayment(), nil
+		}
+	}
+	return nil, ErrPaymentNotCaptured
+}
+
+func (p *cashfreeProvider) FetchPayment(ctx context.Context, orderID, paymentID string) (*Payment, error) {
+	var c cashfreePayment
+	path := "/orders/" + url.PathEscape(orderID) + "/payments/" + url.PathEscape(paymentID)
+	if err := p.do(ctx, http.MethodGet, path, nil, &c); err != nil {
+		return nil, err
+	}
+	return c.toPayment(), nil
+}
+
+// FetchOrderStatus maps Cashfree's order_status: PAID orders are matched to
+// their successful payment, EXPIRED and TERMINATED orders can no longer be paid.
+func (p *cashfreeProvider) FetchOrderStatus(ctx context.Context, orderID string) (*OrderStatus, error) {
+	var order struct {
+		OrderStatus string `json:"order_status"`
+	}
+	if err := p.do(ctx, http.MethodGet, "/orders/"+url.PathEscape(orderID), nil, &order); err != nil {
+		return nil, err
+	}
+	switch order.OrderStatus {
+	case "PAID":
+		pay, err := p.VerifyPayment(ctx, orderID, "", "")
+		if err != nil {
+			return nil, err
+		}
+		return &OrderStatus{Status: OrderStatusPaid, Payment: pay}, nil
+	case "EXPIRED", "TERMINATED":
+		return &OrderStatus{Status: OrderStatusFailed}, nil
+	}
+	return &OrderStatus{Status: OrderStatusPending}, nil
+}
+
+func (p *cashfreeProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	if req.OrderID == "" || req.ReferenceID == "" {
+		return nil, errors.New("cashfree refunds need the order id and a refund reference")
+	}
+	in := map[string]interface{}{
+		"refund_amount": float64(toPaise(req.Amount)) / 100,
+		"refund_id":     req.ReferenceID,
+		"refund_note":   req.Reason,
+	}
+	var out struct {
+		CFRefundID   json.Number `json:"cf_refund_id"`
+		RefundStatus string      `json:"refund_status"`
+	}
+	if err := p.do(ctx, http.MethodPost, "/orders/"+url.PathEscape(req.OrderID)+"/refunds", in, &out); err != nil {
+		return nil, err
+	}
+	if out.CFRefundID == "" {
+		return nil, errors.New("unable to extract refund id from Cashfree response")
+	}
+	return &RefundResult{ID: out.CFRefundID.String(), Status: cashfreeRefundStatus(out.RefundStatus)}, nil
+}
+
+func cashfreeRefundStatus(status string) string {
+	switch status {
+	case "SUCCESS":
+		return RefundStatusProcessed
+	case "CANCELLED", "FAILED":
+		return RefundStatusFailed
+	}
+	return RefundStatusPending
+}
+
+// cashfreeWebhook is the part of a Cashfree webhook body we use
+type cashfreeWebhook struct {
+	Type string `json:"type"`
+	Data struct {
+		Order struct {
+			OrderID string `json:"order_id"`
+		} `json:"order"`
+		Payment struct {
+			CFPaymentID    json.Number `json:"cf_payment_id"`
+			PaymentAmount  float64     `json:"payment_amount"`
+			PaymentGroup   string      `json:"payment_group"`
+			PaymentMessage string      `json:"payment_message"`
+		} `json:"payment"`
+		Refund struct {
+			CFRefundID        json.Number `json:"cf_refund_id"`
+			RefundID          string      `json:"refund_id"`
+			OrderID           string      `json:"order_id"`
+			CFPaymentID       json.Number `json:"cf_payment_id"`
+			RefundAmount      float64     `json:"refund_amount"`
+			RefundStatus      string      `json:"refund_status"`
+			StatusDescription string      `json:"status_description"`
+		} `json:"refund"`
+	} `json:"data"`
+}
+
+func (p *cashfreeProvider) ParseWebhook(body []byte) (*WebhookEvent, error) {
+	var wh cashfreeWebhook
+	if err := json.Unmarshal(body, &wh); err != nil {
+		return nil, errors.New("invalid JSON")
+	}
+
+	switch wh.Type {
+	case "PAYMENT_SUCCESS_WEBHOOK", "PAYMENT_FAILED_WEBHOOK", "PAYMENT_USER_DROPPED_WEBHOOK":
+		ev := &WebhookEvent{
+			Type:      EventPaymentFailed,
+			OrderID:   wh.Data.Order.OrderID,
+			PaymentID: wh.Data.Payment.CFPaymentID.String(),
+			Method:    wh.Data.Payment.PaymentGroup,
+			Amount:    wh.Data.Payment.PaymentAmount,
+			Reason:    wh.Data.Payment.PaymentMessage,
+		}
+		if wh.Type == "PAYMENT_SUCCESS_WEBHOOK" {
+			ev.Type = EventPaymentCaptured
+		}
+		if ev.OrderID == "" {
+			return nil, errors.New("missing order_id")
+		}
+		return ev, nil
+
+	case "REFUND_STATUS_WEBHOOK":
+		ref := wh.Data.Refund
+		ev := &WebhookEvent{
+			OrderID:     ref.OrderID,
+			PaymentID:   ref.CFPaymentID.String(),
+			Amount:      ref.RefundAmount,
+			RefundID:    ref.CFRefundID.String(),
+			ReferenceID: ref.RefundID,
+		}
+		switch cashfreeRefundStatus(ref.RefundStatus) {
+		case RefundStatusProcessed:
+			ev.Type = EventRefundProcessed
+		case RefundStatusFailed:
+			ev.Type = EventRefundFailed
+			ev.Reason = ref.StatusDescription
+		default:
+			ev.Type = "refund.pending"
+		}
+		if ev.RefundID == "" {
+			return nil, errors.New("missing refund id")
+		}
+		return ev, nil
+	}
+
+	if wh.Type == "" {
+		return nil, errors.New("missing event type")
+	}
+	return &WebhookEvent{Type: wh.Type}, nil
+}
+
+// VerifyWebhook checks x-webhook-signature: base64(HMAC-SHA256(timestamp + body, client secret))
+func (p *cashfreeProvider) VerifyWebhook(body []byte, headers http.Header) error {
+	if p.secret == "" {
+		return ErrNoWebhookSecret
+	}
+	signature := headers.Get("x-webhook-signature")
+	timestamp := headers.Get("x-webhook-timestamp")
+	if signature == "" || timestamp == "" {
+		return errors.New("missing signature")
+	}
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		return errors.New("invalid webhook timestamp")
+	}
+	mac := hmac.New(sha256.New, []byte(p.secret))
+	mac.Write([]byte(timestamp))
+	mac.Write(body)
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ==============================
+// Fake Provider (local development and tests)
+// ==============================
+
+// FakeSecret signs fake checkout callbacks and webhooks. Tests complete a
+// payment with FakeSignature and post webhooks signed with FakeWebhookSignature.
+const FakeSecret = "fake_secret"
+
+var (
+	fakeSeq    int64
+	fakeOrders sync.Map // order id → amount
+)
+
+type fakeProvider struct{}
+
+func NewFakeProvider() Provider {
+	return &fakeProvider{}
+}
+
+func (p *fakeProvider) Name() string { return ProviderFake }
+
+func (p *fakeProvider) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	currency := req.Currency
+	if currency == "" {
+		currency = "INR"
+	}
+	id := fmt.Sprintf("order_fake_%d_%d", time.Now().UnixNano(), atomic.AddInt64(&fakeSeq, 1))
+	fakeOrders.Store(id, req.Amount)
+	return &Order{ID: id, Amount: req.Amount, Currency: currency, Provider: ProviderFake, KeyID: "fake_key"}, nil
+}
+
+func (p *fakeProvider) VerifyPayment(ctx context.Context, orderID, paymentID, signature string) (*Payment, error) {
+	if orderID == "" || paymentID == "" || signature != FakeSignature(orderID, paymentID) {
+		return nil, ErrInvalidSignature
+	}
+	return p.FetchPayment(ctx, orderID, paymentID)
+}
+
+func (p *fakeProvider) FetchPayment(ctx context.Context, orderID, paymentID string) (*Payment, error) {
+	pay := &Payment{ID: paymentID, OrderID: orderID, Method: "upi", Status: "captured"}
+	if amount, ok := fakeOrders.Load(orderID); ok {
+		pay.Amount = amount.(float64)
+	}
+	return pay, nil
+}
+
+// FetchOrderStatus reports every order as pending: fake payments only
+// complete through the checkout callback or a signed fake webhook.
+func (p *fakeProvider) FetchOrderStatus(ctx context.Context, orderID string) (*OrderStatus, error) {
+	return &OrderStatus{Status: OrderStatusPending}, nil
+}
+
+func (p *fakeProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	if req.PaymentID == "" {
+		return nil, errors.New("missing payment id")
+	}
+	return &RefundResult{
+		ID:     fmt.Sprintf("rfnd_fake_%d_%d", time.Now().UnixNano(), atomic.AddInt64(&fakeSeq, 1)),
+		Status: RefundStatusProcessed,
+	}, nil
+}
+
+// ParseWebhook accepts the normalised WebhookEvent JSON as-is
+func (p *fakeProvider) ParseWebhook(body []byte) (*WebhookEvent, error) {
+	var ev WebhookEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, errors.New("invalid JSON")
+	}
+	if ev.Type == "" {
+		return nil, errors.New("missing event type")
+	}
+	return &ev, nil
+}
+
+func (p *fakeProvider) VerifyWebhook(body []byte, headers http.Header) error {
+	if headers.Get("X-Fake-Signature") != FakeWebhookSignature(body) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// FakeSignature is the checkout signature the fake provider expects
+func FakeSignature(orderID, paymentID string) string {
+	return hmacHex(FakeSecret, orderID+"|"+paymentID)
+}
+
+// FakeWebhookSignature is the X-Fake-Signature header for a fake webhook body
+func FakeWebhookSignature(body []byte) string {
+	return hmacHex(FakeSecret, string(body))
+}
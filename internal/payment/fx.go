@@ -0,0 +1,170 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/config"
+)
+
+// ==============================
+// Currencies and Exchange Rates
+// ==============================
+
+// CurrencyINR is the currency every amount is reported and accounted in
+const CurrencyINR = "INR"
+
+// SupportedCurrencies are the currencies devotees may donate in. All of them
+// have two decimal places, so the gateways' smallest unit is amount × 100.
+var SupportedCurrencies = map[string]bool{
+	"INR": true, "USD": true, "EUR": true, "GBP": true, "AUD": true,
+	"CAD": true, "SGD": true, "AED": true, "NZD": true, "CHF": true,
+}
+
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// NormalizeCurrency upper-cases the code and defaults an empty one to INR
+func NormalizeCurrency(currency string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(currency))
+	if code == "" {
+		return CurrencyINR, nil
+	}
+	if !SupportedCurrencies[code] {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedCurrency, code)
+	}
+	return code, nil
+}
+
+// ExchangeRates returns how many rupees one unit of a currency is worth
+type ExchangeRates interface {
+	Rate(ctx context.Context, currency string) (float64, error)
+}
+
+// NewExchangeRates builds the rate source from the app config. FX_RATES_URL,
+// when set, is fetched (and cached for an hour) with FX_RATES as the fallback.
+func NewExchangeRates(cfg *config.Config) ExchangeRates {
+	static := ParseStaticRates("")
+	var url string
+	if cfg != nil {
+		static = ParseStaticRates(cfg.FXRates)
+		url = cfg.FXRatesURL
+	}
+	if url == "" {
+		return static
+	}
+	return &httpRates{url: url, fallback: static, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// StaticRates is a fixed rupee rate per currency
+type StaticRates map[string]float64
+
+// ParseStaticRates reads "USD=83.10,EUR=90.25" into a rate table
+func ParseStaticRates(spec string) StaticRates {
+	rates := StaticRates{}
+	for _, pair := range strings.Split(spec, ",") {
+		code, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		if rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && rate > 0 {
+			rates[strings.ToUpper(strings.TrimSpace(code))] = rate
+		}
+	}
+	return rates
+}
+
+func (r StaticRates) Rate(ctx context.Context, currency string) (float64, error) {
+	if currency == CurrencyINR {
+		return 1, nil
+	}
+	if rate, ok := r[currency]; ok {
+		return rate, nil
+	}
+	return 0, fmt.Errorf("no exchange rate configured for %s", currency)
+}
+
+// httpRates reads an INR-based rate feed of the form {"rates": {"USD": 0.012}}
+// (open.er-api.com and exchangerate.host both answer this way) and inverts it
+type httpRates struct {
+	url      string
+	fallback StaticRates
+	client   *http.Client
+
+	mu          sync.Mutex
+	rates       map[string]float64
+	attemptedAt time.Time // last fetch, successful or not
+	fetching    bool
+}
+
+const httpRatesTTL = time.Hour
+
+// Rate refreshes the feed at most once per TTL, failed attempts included, so
+// a feed that is down costs one request its timeout instead of every
+// donation. The fetch runs outside the lock; meanwhile other requests use the
+// rates already held, or the fallback.
+func (r *httpRates) Rate(ctx context.Context, currency string) (float64, error) {
+	if currency == CurrencyINR {
+		return 1, nil
+	}
+
+	r.mu.Lock()
+	refresh := !r.fetching && time.Since(r.attemptedAt) > httpRatesTTL
+	if refresh {
+		r.fetching, r.attemptedAt = true, time.Now()
+	}
+	r.mu.Unlock()
+
+	if refresh {
+		rates, err := r.fetch(ctx)
+		if err != nil {
+			log.Printf("⚠️ Exchange rate feed unavailable, retrying in %s: %v", httpRatesTTL, err)
+		}
+		r.mu.Lock()
+		if err == nil {
+			r.rates = rates
+		}
+		r.fetching = false
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	perRupee, ok := r.rates[currency]
+	r.mu.Unlock()
+	if ok && perRupee > 0 {
+		return 1 / perRupee, nil
+	}
+	return r.fallback.Rate(ctx, currency)
+}
+
+func (r *httpRates) fetch(ctx context.Context) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange rate feed returned %s", resp.Status)
+	}
+	var out struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	if len(out.Rates) == 0 {
+		return nil, errors.New("exchange rate feed returned no rates")
+	}
+	return out.Rates, nil
+}
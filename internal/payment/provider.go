@@ -0,0 +1,258 @@
+package payment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// Supported providers
+const (
+	ProviderRazorpay = "razorpay"
+	ProviderCashfree = "cashfree"
+	ProviderFake     = "fake" // fully local, never touches the network
+)
+
+// Normalised webhook event types, whatever the provider calls them
+const (
+	EventPaymentCaptured = "payment.captured"
+	EventPaymentFailed   = "payment.failed"
+	EventRefundProcessed = "refund.processed"
+	EventRefundFailed    = "refund.failed"
+)
+
+// Normalised refund states
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusProcessed = "processed"
+	RefundStatusFailed    = "failed"
+)
+
+// Normalised order states, used to reconcile orders whose webhook never arrived
+const (
+	OrderStatusPaid    = "paid"
+	OrderStatusFailed  = "failed"
+	OrderStatusPending = "pending"
+)
+
+var (
+	ErrNotConfigured      = errors.New("temple has not configured a payment gateway yet. Please use UPI Direct or contact the temple admin")
+	ErrInvalidSignature   = errors.New("invalid payment signature")
+	ErrNoWebhookSecret    = errors.New("webhook secret not configured")
+	ErrUnknownProvider    = errors.New("unknown payment provider")
+	ErrPaymentNotCaptured = errors.New("payment has not been captured")
+)
+
+// Provider is implemented by every payment gateway the temples can use.
+// Amounts are in the order's currency (rupees unless stated); implementations
+// convert to the gateway's unit.
+type Provider interface {
+	Name() string
+
+	// CreateOrder registers a checkout order with the gateway
+	CreateOrder(ctx context.Context, req OrderRequest) (*Order, error)
+
+	// VerifyPayment checks the checkout callback. Razorpay signs
+	// "<order_id>|<payment_id>" with the key secret; Cashfree has no client-side
+	// signature, so the payment is confirmed with the gateway instead.
+	VerifyPayment(ctx context.Context, orderID, paymentID, signature string) (*Payment, error)
+
+	// FetchPayment returns the gateway's view of a payment
+	FetchPayment(ctx context.Context, orderID, paymentID string) (*Payment, error)
+
+	// FetchOrderStatus asks the gateway whether an order has been paid.
+	// Payment is set to the successful payment when Status is OrderStatusPaid.
+	FetchOrderStatus(ctx context.Context, orderID string) (*OrderStatus, error)
+
+	// Refund raises a full or partial refund against a captured payment
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+
+	// ParseWebhook decodes a webhook body into a normalised event.
+	// It does not check the signature — call VerifyWebhook for that.
+	ParseWebhook(body []byte) (*WebhookEvent, error)
+
+	// VerifyWebhook checks the webhook signature headers against the body
+	VerifyWebhook(body []byte, headers http.Header) error
+}
+
+// OrderRequest describes the order to create
+type OrderRequest struct {
+	Amount        float64
+	Currency      string
+	Receipt       string
+	CustomerID    string
+	CustomerEmail string
+	CustomerPhone string
+	Notes         map[string]interface{}
+}
+
+// Order is what the frontend needs to open the gateway checkout
+type Order struct {
+	ID        string  `json:"order_id"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+	Provider  string  `json:"provider"`
+	KeyID     string  `json:"key_id,omitempty"`             // Razorpay public key for checkout.js
+	SessionID string  `json:"payment_session_id,omitempty"` // Cashfree checkout session
+}
+
+// Payment is a captured (or attempted) payment
+type Payment struct {
+	ID      string
+	OrderID string
+	Amount  float64
+	Method  string
+	Status  string
+}
+
+// OrderStatus is the gateway's view of a checkout order
+type OrderStatus struct {
+	Status  string // one of the OrderStatus* constants
+	Payment *Payment
+}
+
+// RefundRequest describes a refund. ReferenceID is our own refund id.
+type RefundRequest struct {
+	OrderID     string
+	PaymentID   string
+	Amount      float64
+	ReferenceID string
+	Reason      string
+	Notes       map[string]interface{}
+}
+
+// RefundResult is the gateway's response to a refund request
+type RefundResult struct {
+	ID     string
+	Status string // one of the RefundStatus* constants
+}
+
+// WebhookEvent is a provider-neutral webhook notification
+type WebhookEvent struct {
+	Type        string  `json:"type"`
+	OrderID     string  `json:"order_id"`
+	PaymentID   string  `json:"payment_id"`
+	Method      string  `json:"method,omitempty"`
+	Amount      float64 `json:"amount"`
+	RefundID    string  `json:"refund_id,omitempty"`    // gateway refund id
+	ReferenceID string  `json:"reference_id,omitempty"` // our refund id, when the gateway echoes it
+	Reason      string  `json:"reason,omitempty"`
+}
+
+// WebhookEventID identifies a webhook delivery so retries of the same event can
+// be recognised. Razorpay sends an event id header; for gateways that do not,
+// the payload is hashed, since retries resend the same body.
+func WebhookEventID(provider string, body []byte, headers http.Header) string {
+	var header string
+	switch provider {
+	case ProviderRazorpay:
+		header = "X-Razorpay-Event-Id"
+	case ProviderFake:
+		header = "X-Fake-Event-Id"
+	}
+	if header != "" {
+		if id := strings.TrimSpace(headers.Get(header)); id != "" {
+			return id
+		}
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ==============================
+// Tenant Selection
+// ==============================
+
+// TenantSettings are the payment columns stored in tenant_bank_account_details
+type TenantSettings struct {
+	Provider       string
+	RazorpayKeyID  string
+	RazorpaySecret string
+	CashfreeAppID  string
+	CashfreeSecret string
+}
+
+// Resolver builds the provider a tenant has chosen
+type Resolver struct {
+	cfg *config.Config
+}
+
+func NewResolver(cfg *config.Config) *Resolver {
+	return &Resolver{cfg: cfg}
+}
+
+// ForTenant returns the tenant's provider. PAYMENT_PROVIDER=fake forces the
+// local fake provider for every tenant (development and tests).
+func (r *Resolver) ForTenant(t TenantSettings) (Provider, error) {
+	if r.forceFake() {
+		return NewFakeProvider(), nil
+	}
+	switch providerName(t.Provider) {
+	case ProviderRazorpay:
+		if t.RazorpayKeyID == "" || t.RazorpaySecret == "" {
+			return nil, ErrNotConfigured
+		}
+		return NewRazorpayProvider(t.RazorpayKeyID, t.RazorpaySecret, r.razorpayWebhookSecret()), nil
+	case ProviderCashfree:
+		if t.CashfreeAppID == "" || t.CashfreeSecret == "" {
+			return nil, ErrNotConfigured
+		}
+		return NewCashfreeProvider(t.CashfreeAppID, t.CashfreeSecret, r.cashfreeSandbox()), nil
+	}
+	// The fake provider is only ever chosen by the server config above; a
+	// tenant row naming it is treated like any other unknown provider
+	return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, t.Provider)
+}
+
+// ByName returns a provider without tenant credentials — enough to decode a
+// webhook and, for Razorpay, to verify it with the platform webhook secret.
+// "fake" resolves only while PAYMENT_PROVIDER=fake, so its public signing key
+// cannot be used against a real deployment.
+func (r *Resolver) ByName(name string) (Provider, error) {
+	if r.forceFake() {
+		return NewFakeProvider(), nil
+	}
+	switch providerName(name) {
+	case ProviderRazorpay:
+		return NewRazorpayProvider("", "", r.razorpayWebhookSecret()), nil
+	case ProviderCashfree:
+		return NewCashfreeProvider("", "", r.cashfreeSandbox()), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+}
+
+func (r *Resolver) forceFake() bool {
+	return r.cfg != nil && strings.EqualFold(r.cfg.PaymentProvider, ProviderFake)
+}
+
+func (r *Resolver) razorpayWebhookSecret() string {
+	if r.cfg == nil {
+		return ""
+	}
+	return r.cfg.RazorpayWebhookSecret
+}
+
+func (r *Resolver) cashfreeSandbox() bool {
+	return r.cfg == nil || !strings.EqualFold(r.cfg.CashfreeEnv, "production")
+}
+
+// providerName defaults tenants that never chose a provider to Razorpay
+func providerName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ProviderRazorpay
+	}
+	return name
+}
+
+// toPaise converts rupees to the smallest currency unit
+func toPaise(amount float64) int {
+	return int(utils.Paise(amount))
+}
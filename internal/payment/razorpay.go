@@ -0,0 +1,236 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	razorpay "github.com/razorpay/razorpay-go"
+)
+
+// ==============================
+// Razorpay
+// ==============================
+
+type razorpayProvider struct {
+	keyID         string
+	secret        string
+	webhookSecret string // platform-wide RAZORPAY_WEBHOOK_SECRET
+}
+
+func NewRazorpayProvider(keyID, secret, webhookSecret string) Provider {
+	return &razorpayProvider{keyID: keyID, secret: secret, webhookSecret: webhookSecret}
+}
+
+func (p *razorpayProvider) Name() string { return ProviderRazorpay }
+
+func (p *razorpayProvider) client() *razorpay.Client {
+	return razorpay.NewClient(p.keyID, p.secret)
+}
+
+func (p *razorpayProvider) CreateOrder(ctx context.Context, req OrderRequest) (*Order, error) {
+	currency := req.Currency
+	if currency == "" {
+		currency = "INR"
+	}
+	data := map[string]interface{}{
+		"amount":          toPaise(req.Amount),
+		"currency":        currency,
+		"payment_capture": 1,
+		"notes":           req.Notes,
+	}
+	if req.Receipt != "" {
+		data["receipt"] = req.Receipt
+	}
+
+	body, err := p.client().Order.Create(data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("razorpay order creation failed: %w", err)
+	}
+	orderID, ok := body["id"].(string)
+	if !ok || orderID == "" {
+		return nil, errors.New("unable to extract order_id from Razorpay response")
+	}
+	return &Order{ID: orderID, Amount: req.Amount, Currency: currency, Provider: ProviderRazorpay, KeyID: p.keyID}, nil
+}
+
+// VerifyPayment checks the checkout signature: HMAC-SHA256("<order_id>|<payment_id>", key secret)
+func (p *razorpayProvider) VerifyPayment(ctx context.Context, orderID, paymentID, signature string) (*Payment, error) {
+	if p.secret == "" {
+		return nil, ErrNotConfigured
+	}
+	if orderID == "" || paymentID == "" || signature == "" {
+		return nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(hmacHex(p.secret, orderID+"|"+paymentID)), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+	return &Payment{ID: paymentID, OrderID: orderID, Status: "captured"}, nil
+}
+
+func (p *razorpayProvider) FetchPayment(ctx context.Context, orderID, paymentID string) (*Payment, error) {
+	body, err := p.client().Payment.Fetch(paymentID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	pay := &Payment{ID: paymentID}
+	pay.OrderID, _ = body["order_id"].(string)
+	pay.Method, _ = body["method"].(string)
+	pay.Status, _ = body["status"].(string)
+	if amount, ok := body["amount"].(float64); ok {
+		pay.Amount = amount / 100
+	}
+	return pay, nil
+}
+
+// FetchOrderStatus looks at every payment attempt made against the order.
+// An order with only failed attempts is failed; one with no attempts, or an
+// authorised but not yet captured payment, is still pending.
+func (p *razorpayProvider) FetchOrderStatus(ctx context.Context, orderID string) (*OrderStatus, error) {
+	body, err := p.client().Order.Payments(orderID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := body["items"].([]interface{})
+	failed := 0
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pay := &Payment{OrderID: orderID}
+		pay.ID, _ = m["id"].(string)
+		pay.Method, _ = m["method"].(string)
+		pay.Status, _ = m["status"].(string)
+		if amount, ok := m["amount"].(float64); ok {
+			pay.Amount = amount / 100
+		}
+		switch pay.Status {
+		case "captured", "refunded":
+			return &OrderStatus{Status: OrderStatusPaid, Payment: pay}, nil
+		case "failed":
+			failed++
+		}
+	}
+	if len(items) > 0 && failed == len(items) {
+		return &OrderStatus{Status: OrderStatusFailed}, nil
+	}
+	return &OrderStatus{Status: OrderStatusPending}, nil
+}
+
+func (p *razorpayProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	notes := map[string]interface{}{"refund_id": req.ReferenceID, "reason": req.Reason}
+	for k, v := range req.Notes {
+		notes[k] = v
+	}
+	body, err := p.client().Payment.Refund(req.PaymentID, toPaise(req.Amount), map[string]interface{}{"notes": notes}, nil)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := body["id"].(string)
+	if id == "" {
+		return nil, errors.New("unable to extract refund id from Razorpay response")
+	}
+	status := RefundStatusPending
+	switch body["status"] {
+	case "processed":
+		status = RefundStatusProcessed
+	case "failed":
+		status = RefundStatusFailed
+	}
+	return &RefundResult{ID: id, Status: status}, nil
+}
+
+// razorpayWebhook is the part of a Razorpay webhook body we use
+type razorpayWebhook struct {
+	Event   string `json:"event"`
+	Payload struct {
+		Payment struct {
+			Entity struct {
+				ID               string  `json:"id"`
+				OrderID          string  `json:"order_id"`
+				Method           string  `json:"method"`
+				Amount           float64 `json:"amount"`
+				ErrorDescription string  `json:"error_description"`
+			} `json:"entity"`
+		} `json:"payment"`
+		Refund struct {
+			Entity struct {
+				ID               string                 `json:"id"`
+				PaymentID        string                 `json:"payment_id"`
+				Amount           float64                `json:"amount"`
+				Notes            map[string]interface{} `json:"notes"`
+				ErrorDescription string                 `json:"error_description"`
+			} `json:"entity"`
+		} `json:"refund"`
+	} `json:"payload"`
+}
+
+func (p *razorpayProvider) ParseWebhook(body []byte) (*WebhookEvent, error) {
+	var wh razorpayWebhook
+	if err := json.Unmarshal(body, &wh); err != nil {
+		return nil, errors.New("invalid JSON")
+	}
+	if wh.Event == "" {
+		return nil, errors.New("missing event type")
+	}
+
+	ev := &WebhookEvent{Type: wh.Event}
+	switch wh.Event {
+	case EventPaymentCaptured, EventPaymentFailed:
+		pay := wh.Payload.Payment.Entity
+		ev.OrderID, ev.PaymentID, ev.Method, ev.Amount = pay.OrderID, pay.ID, pay.Method, pay.Amount/100
+		ev.Reason = pay.ErrorDescription
+		if ev.OrderID == "" {
+			return nil, errors.New("missing order_id")
+		}
+	case EventRefundProcessed, EventRefundFailed:
+		ref := wh.Payload.Refund.Entity
+		ev.RefundID, ev.PaymentID, ev.Amount = ref.ID, ref.PaymentID, ref.Amount/100
+		if ev.RefundID == "" {
+			return nil, errors.New("missing refund id")
+		}
+		// Our own refund id travels in notes so early webhooks can still be matched
+		switch v := ref.Notes["refund_id"].(type) {
+		case float64:
+			ev.ReferenceID = strconv.FormatFloat(v, 'f', 0, 64)
+		case string:
+			ev.ReferenceID = v
+		}
+		if wh.Event == EventRefundFailed {
+			ev.Reason = ref.ErrorDescription
+			if ev.Reason == "" {
+				ev.Reason = "refund failed at gateway"
+			}
+		}
+	}
+	return ev, nil
+}
+
+// VerifyWebhook checks X-Razorpay-Signature: HMAC-SHA256(body, webhook secret)
+func (p *razorpayProvider) VerifyWebhook(body []byte, headers http.Header) error {
+	if p.webhookSecret == "" {
+		return ErrNoWebhookSecret
+	}
+	signature := headers.Get("X-Razorpay-Signature")
+	if signature == "" {
+		return errors.New("missing signature")
+	}
+	if !hmac.Equal([]byte(hmacHex(p.webhookSecret, string(body))), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// hmacHex returns the hex HMAC-SHA256 of message
+func hmacHex(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
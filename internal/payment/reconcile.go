@@ -0,0 +1,48 @@
+package payment
+
+import (
+	"context"
+	"time"
+)
+
+// ==============================
+// Reconciliation
+// ==============================
+
+// Reconciliation sources
+const (
+	SourceDonation    = "donation"
+	SourceSevaBooking = "seva_booking"
+)
+
+// Reconciliation outcomes besides the OrderStatus* constants
+const (
+	ReconcileOutcomeError   = "error"   // the order could not be settled
+	ReconcileOutcomeSettled = "settled" // a callback or webhook settled it first
+)
+
+// ReconcileResult records what a reconciliation run did with one stale order
+type ReconcileResult struct {
+	Source    string `json:"source"` // SourceDonation or SourceSevaBooking
+	RecordID  uint   `json:"record_id"`
+	EntityID  uint   `json:"entity_id"`
+	UserID    uint   `json:"user_id"`
+	OrderID   string `json:"order_id"`
+	PaymentID string `json:"payment_id,omitempty"`
+	Outcome   string `json:"outcome"`             // an OrderStatus* or ReconcileOutcome* constant
+	Abandoned bool   `json:"abandoned,omitempty"` // failed because it stayed unpaid too long
+	Error     string `json:"error,omitempty"`
+}
+
+// ResolveStaleOrder asks the gateway for the order's status. An order that is
+// still pending but was created before abandonBefore is reported as failed.
+func ResolveStaleOrder(ctx context.Context, p Provider, orderID string, createdAt, abandonBefore time.Time) (*OrderStatus, bool, error) {
+	status, err := p.FetchOrderStatus(ctx, orderID)
+	if err != nil {
+		return nil, false, err
+	}
+	if status.Status == OrderStatusPending && createdAt.Before(abandonBefore) {
+		return &OrderStatus{Status: OrderStatusFailed}, true, nil
+	}
+	return status, false, nil
+}
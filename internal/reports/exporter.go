@@ -5,6 +5,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jung-kurt/gofpdf"
@@ -39,6 +40,12 @@ func (e *reportExporter) Export(reportType, format string, data ReportData) ([]b
 	case ReportTypeDonations:
 		return e.exportDonationsByFormat(format, timestamp, data.Donations)
 
+	case ReportTypeInKindDonations:
+		return e.exportInKindDonationsByFormat(format, timestamp, data.InKindDonations)
+
+	case ReportTypePledges:
+		return e.exportPledgesByFormat(format, timestamp, data.Pledges)
+
 	case ReportTypeTempleRegistered:
 		return e.exportTemplesRegistered(data.TemplesRegistered)
 	case ReportTypeTempleRegisteredPDF:
@@ -432,7 +439,7 @@ func (e *reportExporter) exportDonationsExcel(donations []DonationReportRow) ([]
 	f.SetSheetName("Sheet1", sheetName)
 
 	// UPDATED with Temple Name
-	headers := []string{"ID", "Donor Name", "Temple Name", "Donor Email", "Amount", "Donation Type", "Payment Method", "Status", "Donation Date", "Order ID", "Payment ID", "Created At", "Updated At"}
+	headers := []string{"ID", "Donor Name", "Temple Name", "Donor Email", "Amount", "Donation Type", "Campaign", "Payment Method", "Status", "Donation Date", "Order ID", "Payment ID", "Created At", "Updated At", "Currency", "Original Amount", "Exchange Rate", "Contribution", "Donor Country", "Donor Nationality"}
 	for i, header := range headers {
 		cell := fmt.Sprintf("%c1", 'A'+i)
 		f.SetCellValue(sheetName, cell, header)
@@ -451,14 +458,29 @@ func (e *reportExporter) exportDonationsExcel(donations []DonationReportRow) ([]
 		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), donation.DonorEmail)
 		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), donation.Amount)
 		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), donation.DonationType)
-		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), donation.PaymentMethod)
-		f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), donation.Status)
-		f.SetCellValue(sheetName, fmt.Sprintf("I%d", row), donation.DonationDate.Format("2006-01-02 15:04:05"))
-		f.SetCellValue(sheetName, fmt.Sprintf("J%d", row), donation.OrderID)
-		f.SetCellValue(sheetName, fmt.Sprintf("K%d", row), paymentID)
-		f.SetCellValue(sheetName, fmt.Sprintf("L%d", row), donation.CreatedAt.Format("2006-01-02 15:04:05"))
-		f.SetCellValue(sheetName, fmt.Sprintf("M%d", row), donation.UpdatedAt.Format("2006-01-02 15:04:05"))
-	}
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), donation.CampaignTitle)
+		f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), donation.PaymentMethod)
+		f.SetCellValue(sheetName, fmt.Sprintf("I%d", row), donation.Status)
+		f.SetCellValue(sheetName, fmt.Sprintf("J%d", row), donation.DonationDate.Format("2006-01-02 15:04:05"))
+		f.SetCellValue(sheetName, fmt.Sprintf("K%d", row), donation.OrderID)
+		f.SetCellValue(sheetName, fmt.Sprintf("L%d", row), paymentID)
+		f.SetCellValue(sheetName, fmt.Sprintf("M%d", row), donation.CreatedAt.Format("2006-01-02 15:04:05"))
+		f.SetCellValue(sheetName, fmt.Sprintf("N%d", row), donation.UpdatedAt.Format("2006-01-02 15:04:05"))
+		f.SetCellValue(sheetName, fmt.Sprintf("O%d", row), donation.Currency)
+		f.SetCellValue(sheetName, fmt.Sprintf("P%d", row), donation.OriginalAmount)
+		f.SetCellValue(sheetName, fmt.Sprintf("Q%d", row), donation.ExchangeRate)
+		f.SetCellValue(sheetName, fmt.Sprintf("R%d", row), donation.ContributionType)
+		f.SetCellValue(sheetName, fmt.Sprintf("S%d", row), donation.DonorCountry)
+		f.SetCellValue(sheetName, fmt.Sprintf("T%d", row), donation.DonorNationality)
+	}
+
+	// FCRA reporting needs domestic and foreign contributions shown separately
+	domestic, foreign := contributionTotals(donations)
+	summary := len(donations) + 3
+	f.SetCellValue(sheetName, fmt.Sprintf("D%d", summary), "Domestic Contributions")
+	f.SetCellValue(sheetName, fmt.Sprintf("E%d", summary), domestic)
+	f.SetCellValue(sheetName, fmt.Sprintf("D%d", summary+1), "Foreign Contributions (FCRA)")
+	f.SetCellValue(sheetName, fmt.Sprintf("E%d", summary+1), foreign)
 
 	buf, err := f.WriteToBuffer()
 	if err != nil {
@@ -472,7 +494,7 @@ func (e *reportExporter) exportDonationsCSV(donations []DonationReportRow) ([]by
 	writer := csv.NewWriter(&buf)
 
 	// UPDATED with Temple Name
-	headers := []string{"ID", "Donor Name", "Temple Name", "Donor Email", "Amount", "Donation Type", "Payment Method", "Status", "Donation Date", "Order ID", "Payment ID", "Created At", "Updated At"}
+	headers := []string{"ID", "Donor Name", "Temple Name", "Donor Email", "Amount", "Donation Type", "Campaign", "Payment Method", "Status", "Donation Date", "Order ID", "Payment ID", "Created At", "Updated At", "Currency", "Original Amount", "Exchange Rate", "Contribution", "Donor Country", "Donor Nationality"}
 	if err := writer.Write(headers); err != nil {
 		return nil, err
 	}
@@ -490,6 +512,7 @@ func (e *reportExporter) exportDonationsCSV(donations []DonationReportRow) ([]by
 			donation.DonorEmail,
 			fmt.Sprintf("%.2f", donation.Amount),
 			donation.DonationType,
+			donation.CampaignTitle,
 			donation.PaymentMethod,
 			donation.Status,
 			donation.DonationDate.Format("2006-01-02 15:04:05"),
@@ -497,6 +520,12 @@ func (e *reportExporter) exportDonationsCSV(donations []DonationReportRow) ([]by
 			paymentID,
 			donation.CreatedAt.Format("2006-01-02 15:04:05"),
 			donation.UpdatedAt.Format("2006-01-02 15:04:05"),
+			donation.Currency,
+			fmt.Sprintf("%.2f", donation.OriginalAmount),
+			fmt.Sprintf("%.4f", donation.ExchangeRate),
+			donation.ContributionType,
+			donation.DonorCountry,
+			donation.DonorNationality,
 		}
 		if err := writer.Write(record); err != nil {
 			return nil, err
@@ -512,6 +541,21 @@ func (e *reportExporter) exportDonationsCSV(donations []DonationReportRow) ([]by
 	return buf.Bytes(), nil
 }
 
+// contributionTotals sums successful donations into domestic and foreign (FCRA) rupee totals
+func contributionTotals(donations []DonationReportRow) (domestic, foreign float64) {
+	for _, donation := range donations {
+		if !strings.EqualFold(donation.Status, "success") {
+			continue
+		}
+		if donation.ContributionType == "Domestic" {
+			domestic += donation.Amount
+		} else {
+			foreign += donation.Amount
+		}
+	}
+	return domestic, foreign
+}
+
 func (e *reportExporter) exportDonationsPDF(donations []DonationReportRow) ([]byte, error) {
 	pdf := gofpdf.New("L", "mm", "A4", "")
 	pdf.AddPage()
@@ -521,8 +565,8 @@ func (e *reportExporter) exportDonationsPDF(donations []DonationReportRow) ([]by
 
 	pdf.SetFont("Arial", "B", 10)
 	// Define column widths - UPDATED with Temple Name
-	widths := []float64{35, 30, 35, 20, 25, 25, 20, 25, 35}
-	headers := []string{"Donor Name", "Temple Name", "Donor Email", "Amount", "Type", "Method", "Status", "Donation Date", "Order ID"}
+	widths := []float64{35, 30, 35, 20, 25, 25, 20, 25, 35, 25}
+	headers := []string{"Donor Name", "Temple Name", "Donor Email", "Amount", "Type", "Method", "Status", "Donation Date", "Order ID", "Contribution"}
 
 	// Print headers with borders
 	for i, header := range headers {
@@ -542,9 +586,351 @@ func (e *reportExporter) exportDonationsPDF(donations []DonationReportRow) ([]by
 		pdf.CellFormat(widths[6], 6, donation.Status, "1", 0, "C", false, 0, "")
 		pdf.CellFormat(widths[7], 6, donation.DonationDate.Format("2006-01-02"), "1", 0, "C", false, 0, "")
 		pdf.CellFormat(widths[8], 6, donation.OrderID, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[9], 6, donation.ContributionType, "1", 0, "C", false, 0, "")
+		pdf.Ln(-1)
+	}
+
+	domestic, foreign := contributionTotals(donations)
+	pdf.Ln(4)
+	pdf.SetFont("Arial", "B", 10)
+	pdf.Cell(0, 6, fmt.Sprintf("Domestic Contributions: Rs. %.2f", domestic))
+	pdf.Ln(-1)
+	pdf.Cell(0, 6, fmt.Sprintf("Foreign Contributions (FCRA): Rs. %.2f", foreign))
+	pdf.Ln(-1)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportInKindDonationsByFormat(format, timestamp string, donations []InKindDonationReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportInKindDonationsExcel(donations)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("in_kind_donations_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportInKindDonationsCSV(donations)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("in_kind_donations_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportInKindDonationsPDF(donations)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("in_kind_donations_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for in-kind donations: %s", format)
+	}
+}
+
+var inKindDonationHeaders = []string{"ID", "Acknowledgement No", "Donor Name", "Temple Name", "Donor Email", "Donor Phone", "Item", "Category", "Quantity", "Unit", "Condition", "Estimated Value", "Received Date"}
+
+func inKindDonationRecord(donation InKindDonationReportRow) []string {
+	return []string{
+		strconv.FormatUint(uint64(donation.ID), 10),
+		donation.AcknowledgementNumber,
+		donation.DonorName,
+		donation.TempleName,
+		donation.DonorEmail,
+		donation.DonorPhone,
+		donation.ItemName,
+		donation.Category,
+		strconv.FormatFloat(donation.Quantity, 'f', -1, 64),
+		donation.Unit,
+		donation.Condition,
+		fmt.Sprintf("%.2f", donation.EstimatedValue),
+		donation.ReceivedAt.Format("2006-01-02"),
+	}
+}
+
+func (e *reportExporter) exportInKindDonationsExcel(donations []InKindDonationReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "In-Kind Donations"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range inKindDonationHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+
+	total := 0.0
+	for i, donation := range donations {
+		row := i + 2
+		for col, value := range inKindDonationRecord(donation) {
+			cell := fmt.Sprintf("%c%d", 'A'+col, row)
+			switch col {
+			case 8:
+				f.SetCellValue(sheetName, cell, donation.Quantity)
+			case 11:
+				f.SetCellValue(sheetName, cell, donation.EstimatedValue)
+			default:
+				f.SetCellValue(sheetName, cell, value)
+			}
+		}
+		total += donation.EstimatedValue
+	}
+
+	totalRow := len(donations) + 2
+	f.SetCellValue(sheetName, fmt.Sprintf("K%d", totalRow), "Total Estimated Value")
+	f.SetCellValue(sheetName, fmt.Sprintf("L%d", totalRow), total)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportInKindDonationsCSV(donations []InKindDonationReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(inKindDonationHeaders); err != nil {
+		return nil, err
+	}
+	for _, donation := range donations {
+		if err := writer.Write(inKindDonationRecord(donation)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportInKindDonationsPDF(donations []InKindDonationReportRow) ([]byte, error) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "In-Kind Donations Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 10)
+	widths := []float64{40, 35, 35, 45, 25, 20, 20, 25, 25}
+	headers := []string{"Ack No", "Donor Name", "Temple Name", "Item", "Category", "Quantity", "Condition", "Est. Value", "Received"}
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 8)
+	total := 0.0
+	for _, donation := range donations {
+		pdf.CellFormat(widths[0], 6, donation.AcknowledgementNumber, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, donation.DonorName, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, donation.TempleName, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, donation.ItemName, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[4], 6, donation.Category, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[5], 6, strconv.FormatFloat(donation.Quantity, 'f', -1, 64)+" "+donation.Unit, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[6], 6, donation.Condition, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[7], 6, fmt.Sprintf("%.2f", donation.EstimatedValue), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[8], 6, donation.ReceivedAt.Format("2006-01-02"), "1", 0, "C", false, 0, "")
 		pdf.Ln(-1)
+		total += donation.EstimatedValue
 	}
 
+	pdf.SetFont("Arial", "B", 9)
+	pdf.CellFormat(widths[0]+widths[1]+widths[2]+widths[3]+widths[4]+widths[5]+widths[6], 7, "Total Estimated Value", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[7], 7, fmt.Sprintf("%.2f", total), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[8], 7, "", "1", 0, "C", false, 0, "")
+	pdf.Ln(-1)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportPledgesByFormat(format, timestamp string, pledges []PledgeReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportPledgesExcel(pledges)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("pledges_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportPledgesCSV(pledges)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("pledges_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportPledgesPDF(pledges)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("pledges_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for pledges: %s", format)
+	}
+}
+
+var pledgeHeaders = []string{"ID", "Donor Name", "Temple Name", "Donor Email", "Donor Phone", "Donation Type", "Campaign", "Pledged", "Paid", "Outstanding", "Overdue", "Instalments Paid", "Next Due", "Status", "Pledged On"}
+
+func pledgeNextDue(pledge PledgeReportRow) string {
+	if pledge.NextDueDate == nil {
+		return ""
+	}
+	return pledge.NextDueDate.Format("2006-01-02")
+}
+
+func pledgeRecord(pledge PledgeReportRow) []string {
+	return []string{
+		strconv.FormatUint(uint64(pledge.ID), 10),
+		pledge.DonorName,
+		pledge.TempleName,
+		pledge.DonorEmail,
+		pledge.DonorPhone,
+		pledge.DonationType,
+		pledge.CampaignTitle,
+		fmt.Sprintf("%.2f", pledge.TotalAmount),
+		fmt.Sprintf("%.2f", pledge.PaidAmount),
+		fmt.Sprintf("%.2f", pledge.Outstanding),
+		fmt.Sprintf("%.2f", pledge.OverdueAmount),
+		fmt.Sprintf("%d/%d", pledge.InstalmentsPaid, pledge.InstalmentCount),
+		pledgeNextDue(pledge),
+		pledge.Status,
+		pledge.CreatedAt.Format("2006-01-02"),
+	}
+}
+
+func (e *reportExporter) exportPledgesExcel(pledges []PledgeReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Pledges"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range pledgeHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+
+	var pledged, paid, outstanding, overdue float64
+	for i, pledge := range pledges {
+		row := i + 2
+		for col, value := range pledgeRecord(pledge) {
+			cell := fmt.Sprintf("%c%d", 'A'+col, row)
+			switch col {
+			case 7:
+				f.SetCellValue(sheetName, cell, pledge.TotalAmount)
+			case 8:
+				f.SetCellValue(sheetName, cell, pledge.PaidAmount)
+			case 9:
+				f.SetCellValue(sheetName, cell, pledge.Outstanding)
+			case 10:
+				f.SetCellValue(sheetName, cell, pledge.OverdueAmount)
+			default:
+				f.SetCellValue(sheetName, cell, value)
+			}
+		}
+		pledged += pledge.TotalAmount
+		paid += pledge.PaidAmount
+		outstanding += pledge.Outstanding
+		overdue += pledge.OverdueAmount
+	}
+
+	totalRow := len(pledges) + 2
+	f.SetCellValue(sheetName, fmt.Sprintf("G%d", totalRow), "Total")
+	f.SetCellValue(sheetName, fmt.Sprintf("H%d", totalRow), pledged)
+	f.SetCellValue(sheetName, fmt.Sprintf("I%d", totalRow), paid)
+	f.SetCellValue(sheetName, fmt.Sprintf("J%d", totalRow), outstanding)
+	f.SetCellValue(sheetName, fmt.Sprintf("K%d", totalRow), overdue)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportPledgesCSV(pledges []PledgeReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(pledgeHeaders); err != nil {
+		return nil, err
+	}
+	for _, pledge := range pledges {
+		if err := writer.Write(pledgeRecord(pledge)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportPledgesPDF(pledges []PledgeReportRow) ([]byte, error) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Pledges Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 10)
+	widths := []float64{40, 35, 25, 35, 22, 22, 22, 22, 18, 22, 14}
+	headers := []string{"Donor Name", "Temple Name", "Type", "Campaign", "Pledged", "Paid", "Outstanding", "Overdue", "Instal.", "Next Due", "Status"}
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 8)
+	var pledged, paid, outstanding, overdue float64
+	for _, pledge := range pledges {
+		pdf.CellFormat(widths[0], 6, pledge.DonorName, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, pledge.TempleName, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, pledge.DonationType, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, pledge.CampaignTitle, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[4], 6, fmt.Sprintf("%.2f", pledge.TotalAmount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[5], 6, fmt.Sprintf("%.2f", pledge.PaidAmount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[6], 6, fmt.Sprintf("%.2f", pledge.Outstanding), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[7], 6, fmt.Sprintf("%.2f", pledge.OverdueAmount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[8], 6, fmt.Sprintf("%d/%d", pledge.InstalmentsPaid, pledge.InstalmentCount), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[9], 6, pledgeNextDue(pledge), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[10], 6, pledge.Status, "1", 0, "C", false, 0, "")
+		pdf.Ln(-1)
+		pledged += pledge.TotalAmount
+		paid += pledge.PaidAmount
+		outstanding += pledge.Outstanding
+		overdue += pledge.OverdueAmount
+	}
+
+	pdf.SetFont("Arial", "B", 9)
+	pdf.CellFormat(widths[0]+widths[1]+widths[2]+widths[3], 7, "Total", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[4], 7, fmt.Sprintf("%.2f", pledged), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[5], 7, fmt.Sprintf("%.2f", paid), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[6], 7, fmt.Sprintf("%.2f", outstanding), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[7], 7, fmt.Sprintf("%.2f", overdue), "1", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[8]+widths[9]+widths[10], 7, "", "1", 0, "C", false, 0, "")
+	pdf.Ln(-1)
+
 	var buf bytes.Buffer
 	if err := pdf.Output(&buf); err != nil {
 		return nil, err
@@ -1503,7 +1889,7 @@ func (e *reportExporter) exportSevasExcel(sevas []SevaReportRow) ([]byte, error)
 	f.SetSheetName("Sheet1", sheetName)
 
 	// UPDATED with Temple Name
-	headers := []string{"Name", "Temple Name", "Seva Type", "Description", "Price", "Date", "Start Time", "End Time", "Duration", "Max Bookings", "Status", "Is Active", "Created At", "Updated At"}
+	headers := []string{"Name", "Temple Name", "Seva Type", "Description", "Price", "Date", "Start Time", "End Time", "Duration", "Max Bookings", "Status", "Is Active", "Created At", "Updated At", "Approved Bookings", "Attended"}
 	for i, header := range headers {
 		cell := fmt.Sprintf("%c1", 'A'+i)
 		f.SetCellValue(sheetName, cell, header)
@@ -1525,6 +1911,8 @@ func (e *reportExporter) exportSevasExcel(sevas []SevaReportRow) ([]byte, error)
 		f.SetCellValue(sheetName, fmt.Sprintf("L%d", row), seva.IsActive)
 		f.SetCellValue(sheetName, fmt.Sprintf("M%d", row), seva.CreatedAt.Format("2006-01-02 15:04:05"))
 		f.SetCellValue(sheetName, fmt.Sprintf("N%d", row), seva.UpdatedAt.Format("2006-01-02 15:04:05"))
+		f.SetCellValue(sheetName, fmt.Sprintf("O%d", row), seva.ApprovedBookings)
+		f.SetCellValue(sheetName, fmt.Sprintf("P%d", row), seva.Attended)
 	}
 
 	buf, err := f.WriteToBuffer()
@@ -1538,7 +1926,7 @@ func (e *reportExporter) exportSevasCSV(sevas []SevaReportRow) ([]byte, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
-	headers := []string{"Name", "Temple Name", "Seva Type", "Description", "Price", "Date", "Start Time", "End Time", "Duration", "Max Bookings", "Status", "Is Active", "Created At", "Updated At"}
+	headers := []string{"Name", "Temple Name", "Seva Type", "Description", "Price", "Date", "Start Time", "End Time", "Duration", "Max Bookings", "Status", "Is Active", "Created At", "Updated At", "Approved Bookings", "Attended"}
 	if err := writer.Write(headers); err != nil {
 		return nil, err
 	}
@@ -1559,6 +1947,8 @@ func (e *reportExporter) exportSevasCSV(sevas []SevaReportRow) ([]byte, error) {
 			strconv.FormatBool(seva.IsActive),
 			seva.CreatedAt.Format("2006-01-02 15:04:05"),
 			seva.UpdatedAt.Format("2006-01-02 15:04:05"),
+			strconv.FormatInt(seva.ApprovedBookings, 10),
+			strconv.FormatInt(seva.Attended, 10),
 		}
 		if err := writer.Write(record); err != nil {
 			return nil, err
@@ -1584,8 +1974,8 @@ func (e *reportExporter) exportSevasPDF(sevas []SevaReportRow) ([]byte, error) {
 
 	pdf.SetFont("Arial", "B", 10)
 	// Define column widths - UPDATED with Temple Name
-	widths := []float64{40, 40, 20, 20, 25, 25, 15, 20, 15}
-	headers := []string{"Name", "Temple Name", "Type", "Price", "Start Time", "End Time", "Duration", "Status", "Active"}
+	widths := []float64{40, 40, 20, 20, 25, 25, 15, 20, 15, 20, 20}
+	headers := []string{"Name", "Temple Name", "Type", "Price", "Start Time", "End Time", "Duration", "Status", "Active", "Approved", "Attended"}
 
 	// Print headers with borders
 	for i, header := range headers {
@@ -1605,6 +1995,8 @@ func (e *reportExporter) exportSevasPDF(sevas []SevaReportRow) ([]byte, error) {
 		pdf.CellFormat(widths[6], 6, strconv.Itoa(seva.Duration), "1", 0, "C", false, 0, "")
 		pdf.CellFormat(widths[7], 6, seva.Status, "1", 0, "C", false, 0, "")
 		pdf.CellFormat(widths[8], 6, strconv.FormatBool(seva.IsActive), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[9], 6, strconv.FormatInt(seva.ApprovedBookings, 10), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[10], 6, strconv.FormatInt(seva.Attended, 10), "1", 0, "C", false, 0, "")
 		pdf.Ln(-1)
 	}
 
@@ -1621,7 +2013,7 @@ func (e *reportExporter) exportBookingsExcel(bookings []SevaBookingReportRow) ([
 	f.SetSheetName("Sheet1", sheetName)
 
 	// UPDATED with Temple Name
-	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Devotee Phone", "Booking Time", "Status", "Created At", "Updated At"}
+	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Devotee Phone", "Booking Time", "Status", "Created At", "Updated At", "Amount", "Variant", "Add-ons"}
 	for i, header := range headers {
 		cell := fmt.Sprintf("%c1", 'A'+i)
 		f.SetCellValue(sheetName, cell, header)
@@ -1638,6 +2030,9 @@ func (e *reportExporter) exportBookingsExcel(bookings []SevaBookingReportRow) ([
 		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), booking.Status)
 		f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), booking.CreatedAt.Format("2006-01-02 15:04:05"))
 		f.SetCellValue(sheetName, fmt.Sprintf("I%d", row), booking.UpdatedAt.Format("2006-01-02 15:04:05"))
+		f.SetCellValue(sheetName, fmt.Sprintf("J%d", row), booking.Amount)
+		f.SetCellValue(sheetName, fmt.Sprintf("K%d", row), booking.Variant)
+		f.SetCellValue(sheetName, fmt.Sprintf("L%d", row), booking.AddOns)
 	}
 
 	buf, err := f.WriteToBuffer()
@@ -1652,7 +2047,7 @@ func (e *reportExporter) exportBookingsCSV(bookings []SevaBookingReportRow) ([]b
 	writer := csv.NewWriter(&buf)
 
 	// UPDATED with Temple Name
-	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Devotee Phone", "Booking Time", "Status", "Created At", "Updated At"}
+	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Devotee Phone", "Booking Time", "Status", "Created At", "Updated At", "Amount", "Variant", "Add-ons"}
 	if err := writer.Write(headers); err != nil {
 		return nil, err
 	}
@@ -1668,6 +2063,9 @@ func (e *reportExporter) exportBookingsCSV(bookings []SevaBookingReportRow) ([]b
 			booking.Status,
 			booking.CreatedAt.Format("2006-01-02 15:04:05"),
 			booking.UpdatedAt.Format("2006-01-02 15:04:05"),
+			fmt.Sprintf("%.2f", booking.Amount),
+			booking.Variant,
+			booking.AddOns,
 		}
 		if err := writer.Write(record); err != nil {
 			return nil, err
@@ -1692,8 +2090,8 @@ func (e *reportExporter) exportBookingsPDF(bookings []SevaBookingReportRow) ([]b
 
 	pdf.SetFont("Arial", "B", 10)
 	// Define column widths - UPDATED with Temple Name
-	widths := []float64{40, 40, 25, 35, 30, 35, 20}
-	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Phone", "Booking Time", "Status"}
+	widths := []float64{32, 32, 22, 30, 24, 27, 18, 20, 30, 42}
+	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Phone", "Booking Time", "Status", "Amount", "Variant", "Add-ons"}
 
 	// Print headers with borders
 	for i, header := range headers {
@@ -1711,6 +2109,9 @@ func (e *reportExporter) exportBookingsPDF(bookings []SevaBookingReportRow) ([]b
 		pdf.CellFormat(widths[4], 6, booking.DevoteePhone, "1", 0, "C", false, 0, "")
 		pdf.CellFormat(widths[5], 6, booking.BookingTime.Format("02-01-06 15:04"), "1", 0, "C", false, 0, "")
 		pdf.CellFormat(widths[6], 6, booking.Status, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[7], 6, fmt.Sprintf("%.2f", booking.Amount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[8], 6, booking.Variant, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[9], 6, booking.AddOns, "1", 0, "L", false, 0, "")
 		pdf.Ln(-1)
 	}
 
@@ -28,6 +28,15 @@ func NewHandler(svc ReportService, repo ReportRepository, auditSvc auditlog.Serv
 	}
 }
 
+// parseCampaignID reads the optional ?campaign_id= donations report filter
+func parseCampaignID(c *gin.Context) uint {
+	id, err := strconv.ParseUint(c.Query("campaign_id"), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
+
 // GetActivities handles requests for the activities report
 func (h *Handler) GetActivities(c *gin.Context) {
 	// Get access context from middleware
@@ -44,7 +53,7 @@ func (h *Handler) GetActivities(c *gin.Context) {
 	entityParam := c.Param("id") // either "all" or numeric id
 	reportType := c.Query("type")
 	if reportType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations|in-kind-donations|pledges"})
 		return
 	}
 	dateRange := c.Query("date_range")
@@ -147,6 +156,8 @@ func (h *Handler) GetActivities(c *gin.Context) {
 		EndDate:   end,
 		Format:    format,
 		EntityIDs: entityIDs, // Pass the resolved entity IDs
+		CampaignID: parseCampaignID(c),
+		Contribution: c.Query("contribution"),
 	}
 
 	// If no format -> return JSON preview
@@ -206,7 +217,7 @@ func (h *Handler) GetSuperAdminActivities(c *gin.Context) {
 	// Get request parameters
 	reportType := c.Query("type")
 	if reportType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations|in-kind-donations|pledges"})
 		return
 	}
 
@@ -295,6 +306,8 @@ func (h *Handler) GetSuperAdminActivities(c *gin.Context) {
 		EndDate:   end,
 		Format:    format,
 		EntityIDs: allEntityIDs,
+		CampaignID: parseCampaignID(c),
+		Contribution: c.Query("contribution"),
 	}
 
 	// If no format -> return JSON preview
@@ -359,7 +372,7 @@ func (h *Handler) GetSuperAdminTenantActivities(c *gin.Context) {
 
 	reportType := c.Query("type")
 	if reportType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations|in-kind-donations|pledges"})
 		return
 	}
 
@@ -419,6 +432,8 @@ func (h *Handler) GetSuperAdminTenantActivities(c *gin.Context) {
 		EndDate:   end,
 		Format:    format,
 		EntityIDs: entityIDStrs, // All entities belonging to this tenant
+		CampaignID: parseCampaignID(c),
+		Contribution: c.Query("contribution"),
 		// If your struct supports it, you might want to add:
 		// TenantID: uint(tenantIDUint),
 	}
@@ -14,6 +14,12 @@ const (
 	// New donation report type
 	ReportTypeDonations = "donations"
 
+	// In-kind (material) donations, valued separately from monetary donations
+	ReportTypeInKindDonations = "in-kind-donations"
+
+	// Donation pledges with their paid, outstanding and overdue amounts
+	ReportTypePledges = "pledges"
+
 	// Date range constants
 	DateRangeDaily   = "daily"
 	DateRangeWeekly  = "weekly"
@@ -81,6 +87,11 @@ type ActivitiesReportRequest struct {
 	StartDate time.Time `json:"start_date"`
 	EndDate   time.Time `json:"end_date"`
 	Format    string    `json:"format"`
+
+	// CampaignID narrows the donations report to one fundraising campaign
+	CampaignID uint `json:"campaign_id,omitempty"`
+	// Contribution narrows the donations report to domestic or foreign (FCRA) contributions
+	Contribution string `json:"contribution,omitempty"`
 }
 
 // ReportData struct with all report types
@@ -89,6 +100,8 @@ type ReportData struct {
 	Sevas               []SevaReportRow               `json:"sevas,omitempty"`
 	Bookings            []SevaBookingReportRow        `json:"bookings,omitempty"`
 	Donations           []DonationReportRow           `json:"donations,omitempty"`
+	InKindDonations     []InKindDonationReportRow     `json:"in_kind_donations,omitempty"`
+	Pledges             []PledgeReportRow             `json:"pledges,omitempty"`
 	TemplesRegistered   []TempleRegisteredReportRow   `json:"temples_registered,omitempty"`
 	DevoteeBirthdays    []DevoteeBirthdayReportRow    `json:"devotee_birthdays,omitempty"`
 	DevoteeList         []DevoteeListReportRow        `json:"devotee_list,omitempty"`
@@ -128,6 +141,8 @@ type SevaReportRow struct {
 	MaxBookingsPerDay int       `json:"max_bookings_per_day"`
 	Status            string    `json:"status"`
 	IsActive          bool      `json:"is_active"`
+	ApprovedBookings  int64     `json:"approved_bookings"`
+	Attended          int64     `json:"attended"` // approved bookings checked in at the counter
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
 }
@@ -141,6 +156,9 @@ type SevaBookingReportRow struct {
 	DevoteePhone string    `json:"devotee_phone"`
 	BookingTime  time.Time `json:"booking_time"`
 	Status       string    `json:"status"`
+	Amount       float64   `json:"amount"`
+	Variant      string    `json:"variant"`
+	AddOns       string    `json:"add_ons"` // e.g. "Flowers, Vastram x2"
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
@@ -153,6 +171,7 @@ type DonationReportRow struct {
 	DonorEmail    string    `json:"donor_email"`
 	Amount        float64   `json:"amount"`
 	DonationType  string    `json:"donation_type"`
+	CampaignTitle string    `json:"campaign_title"`
 	PaymentMethod string    `json:"payment_method"`
 	Status        string    `json:"status"`
 	DonationDate  time.Time `json:"donation_date"`
@@ -160,6 +179,53 @@ type DonationReportRow struct {
 	PaymentID     *string   `json:"payment_id"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Amount is in rupees; foreign-currency gifts also carry what was paid
+	Currency         string  `json:"currency"`
+	OriginalAmount   float64 `json:"original_amount"`
+	ExchangeRate     float64 `json:"exchange_rate"`
+	ContributionType string  `json:"contribution_type"` // Domestic | Foreign (FCRA)
+	DonorCountry     string  `json:"donor_country"`
+	DonorNationality string  `json:"donor_nationality"`
+}
+
+// InKindDonationReportRow represents a single row in the in-kind donations report
+type InKindDonationReportRow struct {
+	ID                    uint      `json:"id"`
+	AcknowledgementNumber string    `json:"acknowledgement_number"`
+	DonorName             string    `json:"donor_name"`
+	TempleName            string    `json:"temple_name"`
+	DonorEmail            string    `json:"donor_email"`
+	DonorPhone            string    `json:"donor_phone"`
+	ItemName              string    `json:"item_name"`
+	Category              string    `json:"category"`
+	Quantity              float64   `json:"quantity"`
+	Unit                  string    `json:"unit"`
+	Condition             string    `json:"condition"`
+	EstimatedValue        float64   `json:"estimated_value"`
+	ReceivedAt            time.Time `json:"received_at"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// PledgeReportRow represents a single row in the pledges report. Outstanding
+// and overdue amounts are as of the time the report is run.
+type PledgeReportRow struct {
+	ID              uint       `json:"id"`
+	DonorName       string     `json:"donor_name"`
+	TempleName      string     `json:"temple_name"`
+	DonorEmail      string     `json:"donor_email"`
+	DonorPhone      string     `json:"donor_phone"`
+	DonationType    string     `json:"donation_type"`
+	CampaignTitle   string     `json:"campaign_title"`
+	TotalAmount     float64    `json:"total_amount"`
+	PaidAmount      float64    `json:"paid_amount"`
+	Outstanding     float64    `json:"outstanding"`
+	OverdueAmount   float64    `json:"overdue_amount"`
+	InstalmentCount int        `json:"instalment_count"`
+	InstalmentsPaid int        `json:"instalments_paid"`
+	NextDueDate     *time.Time `json:"next_due_date"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at"`
 }
 
 // TempleRegisteredReportRequest represents request parameters for temple registered report
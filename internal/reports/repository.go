@@ -21,7 +21,9 @@ type ReportRepository interface {
 	GetSevaBookings(entityIDs []uint, start, end time.Time) ([]SevaBookingReportRow, error)
 	GetTemplesRegistered(entityIDs []uint, start, end time.Time, status string) ([]TempleRegisteredReportRow, error)
 	GetDevoteeBirthdays(entityIDs []uint, start, end time.Time) ([]DevoteeBirthdayReportRow, error)
-	GetDonations(entityIDs []uint, start, end time.Time) ([]DonationReportRow, error)
+	GetDonations(entityIDs []uint, start, end time.Time, campaignID uint, contribution string) ([]DonationReportRow, error)
+	GetInKindDonations(entityIDs []uint, start, end time.Time) ([]InKindDonationReportRow, error)
+	GetPledges(entityIDs []uint, start, end time.Time, campaignID uint) ([]PledgeReportRow, error)
 	GetDevoteeList(entityIDs []uint, start, end time.Time, status string) ([]DevoteeListReportRow, error)
 	GetDevoteeProfiles(entityIDs []uint, start, end time.Time, status string) ([]DevoteeProfileReportRow, error)
 	GetDevoteeProfiles_ext(entityIDs []uint, start, end time.Time, status string, all string) ([]DevoteeProfileReportRow_ext, error)
@@ -123,6 +125,8 @@ func (r *repository) GetSevas(entityIDs []uint, start, end time.Time) ([]SevaRep
 			s.duration,
 			s.status,
 			s.is_active,
+			(SELECT COUNT(*) FROM seva_bookings sb WHERE sb.seva_id = s.id AND sb.status = 'approved') as approved_bookings,
+			(SELECT COUNT(*) FROM seva_bookings sb WHERE sb.seva_id = s.id AND sb.status = 'approved' AND sb.attended_at IS NOT NULL) as attended,
 			s.created_at,
 			s.updated_at
 		`).
@@ -150,6 +154,11 @@ func (r *repository) GetSevaBookings(entityIDs []uint, start, end time.Time) ([]
 			u.phone as devotee_phone,
 			sb.booking_time,
 			sb.status,
+			COALESCE(sb.amount, 0) as amount,
+			COALESCE((SELECT string_agg(i.name, ', ' ORDER BY i.id) FROM seva_booking_items i
+				WHERE i.booking_id = sb.id AND i.kind = 'variant'), '') as variant,
+			COALESCE((SELECT string_agg(CASE WHEN i.quantity > 1 THEN i.name || ' x' || i.quantity ELSE i.name END, ', ' ORDER BY i.id)
+				FROM seva_booking_items i WHERE i.booking_id = sb.id AND i.kind = 'addon'), '') as add_ons,
 			sb.created_at,
 			sb.updated_at
 		`).
@@ -163,37 +172,134 @@ func (r *repository) GetSevaBookings(entityIDs []uint, start, end time.Time) ([]
 	return out, err
 }
 
-func (r *repository) GetDonations(entityIDs []uint, start, end time.Time) ([]DonationReportRow, error) {
+func (r *repository) GetDonations(entityIDs []uint, start, end time.Time, campaignID uint, contribution string) ([]DonationReportRow, error) {
 	var out []DonationReportRow
 	if len(entityIDs) == 0 {
 		return out, nil
 	}
 
-	err := r.db.Table("donations d").
+	query := r.db.Table("donations d").
 		Select(`
 			d.id,
-			COALESCE(NULLIF(u.full_name, ''), u.email, 'Anonymous') as donor_name,
+			CASE WHEN COALESCE(d.is_anonymous, false) THEN 'Anonymous'
+				ELSE COALESCE(NULLIF(d.on_behalf_of, ''), NULLIF(d.donor_name, ''), NULLIF(u.full_name, ''), u.email, 'Anonymous') END as donor_name,
 			ent.name as temple_name,
-			COALESCE(u.email, '') as donor_email,
+			CASE WHEN COALESCE(d.is_anonymous, false) OR NULLIF(d.on_behalf_of, '') IS NOT NULL THEN ''
+				ELSE COALESCE(u.email, '') END as donor_email,
 			d.amount,
 			d.donation_type,
+			COALESCE(dc.title, '') as campaign_title,
 			d.method as payment_method,
 			d.status,
 			COALESCE(d.donated_at, d.created_at) as donation_date,
 			d.order_id,
 			d.payment_id,
 			d.created_at,
-			d.updated_at
+			d.updated_at,
+			COALESCE(d.currency, 'INR') as currency,
+			COALESCE(d.original_amount, d.amount) as original_amount,
+			COALESCE(d.exchange_rate, 1) as exchange_rate,
+			CASE WHEN COALESCE(d.is_foreign_contribution, false) THEN 'Foreign (FCRA)' ELSE 'Domestic' END as contribution_type,
+			COALESCE(d.donor_country, '') as donor_country,
+			COALESCE(d.donor_nationality, '') as donor_nationality
 		`).
 		Joins("LEFT JOIN users u ON d.user_id = u.id").
 		Joins("LEFT JOIN entities ent ON d.entity_id = ent.id").
+		Joins("LEFT JOIN donation_campaigns dc ON d.campaign_id = dc.id").
 		Where("d.entity_id IN ?", entityIDs).
-		Where("d.created_at BETWEEN ? AND ?", start, end).
+		Where("d.created_at BETWEEN ? AND ?", start, end)
+	if campaignID != 0 {
+		query = query.Where("d.campaign_id = ?", campaignID)
+	}
+	switch contribution {
+	case "domestic":
+		query = query.Where("COALESCE(d.is_foreign_contribution, false) = false")
+	case "foreign":
+		query = query.Where("d.is_foreign_contribution = true")
+	}
+	err := query.
 		Order("d.created_at DESC").
 		Scan(&out).Error
 	return out, err
 }
 
+func (r *repository) GetInKindDonations(entityIDs []uint, start, end time.Time) ([]InKindDonationReportRow, error) {
+	var out []InKindDonationReportRow
+	if len(entityIDs) == 0 {
+		return out, nil
+	}
+
+	err := r.db.Table("in_kind_donations k").
+		Select(`
+			k.id,
+			COALESCE(k.acknowledgement_number, '') as acknowledgement_number,
+			COALESCE(NULLIF(k.donor_name, ''), NULLIF(u.full_name, ''), u.email, 'Anonymous') as donor_name,
+			ent.name as temple_name,
+			COALESCE(u.email, '') as donor_email,
+			COALESCE(k.donor_phone, '') as donor_phone,
+			k.item_name,
+			COALESCE(k.category, '') as category,
+			k.quantity,
+			k.unit,
+			COALESCE(k.condition, '') as condition,
+			k.estimated_value,
+			k.received_at,
+			k.created_at
+		`).
+		Joins("LEFT JOIN users u ON k.user_id = u.id").
+		Joins("LEFT JOIN entities ent ON k.entity_id = ent.id").
+		Where("k.entity_id IN ?", entityIDs).
+		Where("k.received_at BETWEEN ? AND ?", start, end).
+		Where("k.deleted_at IS NULL").
+		Order("k.received_at DESC").
+		Scan(&out).Error
+	return out, err
+}
+
+// GetPledges returns the pledges made in [start, end]. Cancelled pledges have
+// nothing outstanding; overdue amounts count instalments past due today.
+func (r *repository) GetPledges(entityIDs []uint, start, end time.Time, campaignID uint) ([]PledgeReportRow, error) {
+	var out []PledgeReportRow
+	if len(entityIDs) == 0 {
+		return out, nil
+	}
+
+	query := r.db.Table("donation_pledges p").
+		Select(`
+			p.id,
+			COALESCE(NULLIF(p.donor_name, ''), NULLIF(u.full_name, ''), u.email, 'Anonymous') as donor_name,
+			ent.name as temple_name,
+			COALESCE(u.email, '') as donor_email,
+			COALESCE(NULLIF(p.donor_phone, ''), u.phone, '') as donor_phone,
+			p.donation_type,
+			COALESCE(dc.title, '') as campaign_title,
+			p.total_amount,
+			p.paid_amount,
+			CASE WHEN p.status = 'active' THEN GREATEST(p.total_amount - p.paid_amount, 0) ELSE 0 END as outstanding,
+			CASE WHEN p.status = 'active' THEN COALESCE((SELECT SUM(i.amount - i.paid_amount) FROM donation_pledge_instalments i
+				WHERE i.pledge_id = p.id AND i.status <> 'paid' AND i.due_date < ?), 0) ELSE 0 END as overdue_amount,
+			p.instalment_count,
+			(SELECT COUNT(*) FROM donation_pledge_instalments i WHERE i.pledge_id = p.id AND i.status = 'paid') as instalments_paid,
+			CASE WHEN p.status = 'active' THEN (SELECT MIN(i.due_date) FROM donation_pledge_instalments i
+				WHERE i.pledge_id = p.id AND i.status <> 'paid') END as next_due_date,
+			p.status,
+			p.created_at
+		`, time.Now()).
+		Joins("LEFT JOIN users u ON p.user_id = u.id").
+		Joins("LEFT JOIN entities ent ON p.entity_id = ent.id").
+		Joins("LEFT JOIN donation_campaigns dc ON p.campaign_id = dc.id").
+		Where("p.entity_id IN ?", entityIDs).
+		Where("p.created_at BETWEEN ? AND ?", start, end).
+		Where("p.deleted_at IS NULL")
+	if campaignID != 0 {
+		query = query.Where("p.campaign_id = ?", campaignID)
+	}
+	err := query.
+		Order("p.created_at DESC").
+		Scan(&out).Error
+	return out, err
+}
+
 func (r *repository) GetTemplesRegistered(entityIDs []uint, start, end time.Time, status string) ([]TempleRegisteredReportRow, error) {
 	var rows []TempleRegisteredReportRow
 	if len(entityIDs) == 0 {
@@ -70,7 +70,8 @@ func convertUintSlice(strs []string) []uint {
 
 func (s *reportService) GetActivities(req ActivitiesReportRequest) (ReportData, error) {
 	if req.Type != ReportTypeEvents && req.Type != ReportTypeSevas &&
-		req.Type != ReportTypeBookings && req.Type != ReportTypeDonations {
+		req.Type != ReportTypeBookings && req.Type != ReportTypeDonations &&
+		req.Type != ReportTypeInKindDonations && req.Type != ReportTypePledges {
 		return ReportData{}, fmt.Errorf("invalid report type: %s", req.Type)
 	}
 	start := req.StartDate
@@ -86,7 +87,11 @@ func (s *reportService) GetActivities(req ActivitiesReportRequest) (ReportData,
 	case ReportTypeBookings:
 		data.Bookings, err = s.repo.GetSevaBookings(convertUintSlice(req.EntityIDs), start, end)
 	case ReportTypeDonations:
-		data.Donations, err = s.repo.GetDonations(convertUintSlice(req.EntityIDs), start, end)
+		data.Donations, err = s.repo.GetDonations(convertUintSlice(req.EntityIDs), start, end, req.CampaignID, req.Contribution)
+	case ReportTypeInKindDonations:
+		data.InKindDonations, err = s.repo.GetInKindDonations(convertUintSlice(req.EntityIDs), start, end)
+	case ReportTypePledges:
+		data.Pledges, err = s.repo.GetPledges(convertUintSlice(req.EntityIDs), start, end, req.CampaignID)
 	}
 	return data, err
 }
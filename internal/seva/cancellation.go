@@ -0,0 +1,337 @@
+package seva
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// BookingRefunder raises the refund of a paid seva booking through the payment
+// layer. Implemented by the donation service; injected with SetBookingRefunder.
+type BookingRefunder interface {
+	RefundSevaBooking(ctx context.Context, bookingID uint, amount float64, reason string, initiatedBy uint, ip string) (uint, string, error)
+}
+
+func (s *service) SetBookingRefunder(r BookingRefunder) {
+	s.refunder = r
+}
+
+// defaultCancellationPolicy applies to temples that have not set one: bookings
+// can be cancelled until the seva starts, with a full refund
+func defaultCancellationPolicy(entityID uint) CancellationPolicy {
+	return CancellationPolicy{
+		EntityID:    entityID,
+		RefundTiers: []RefundTier{{HoursBefore: 0, RefundPercent: 100}},
+	}
+}
+
+// ─────────────────────────────────────────────
+// Cancellation policies
+// ─────────────────────────────────────────────
+
+// GetCancellationPolicy returns the policy that applies to the seva: its own,
+// else the temple's, else the default one
+func (s *service) GetCancellationPolicy(ctx context.Context, entityID, sevaID uint) (*CancellationPolicy, error) {
+	scopes := []uint{0}
+	if sevaID != 0 {
+		scopes = []uint{sevaID, 0}
+	}
+	for _, scope := range scopes {
+		policy, err := s.repo.GetCancellationPolicy(ctx, entityID, scope)
+		if err != nil {
+			return nil, err
+		}
+		if policy != nil {
+			return policy, nil
+		}
+	}
+	policy := defaultCancellationPolicy(entityID)
+	return &policy, nil
+}
+
+func (s *service) ListCancellationPolicies(ctx context.Context, entityID uint) ([]CancellationPolicy, error) {
+	return s.repo.ListCancellationPolicies(ctx, entityID)
+}
+
+// SetCancellationPolicy sets the policy of a seva, or the temple-wide one when
+// sevaID is 0, replacing any policy already set there
+func (s *service) SetCancellationPolicy(ctx context.Context, entityID, sevaID uint, req CancellationPolicyRequest, accessContext middleware.AccessContext, ip string) (*CancellationPolicy, error) {
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+
+	details := map[string]interface{}{
+		"seva_id":        sevaID,
+		"cutoff_hours":   req.CutoffHours,
+		"non_refundable": req.NonRefundable,
+		"refund_tiers":   req.RefundTiers,
+	}
+
+	tiers, err := normaliseRefundTiers(req.RefundTiers)
+	if err != nil {
+		details["error"] = err.Error()
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "SEVA_CANCELLATION_POLICY_UPDATE_FAILED", details, ip, "failure")
+		return nil, err
+	}
+
+	policy := &CancellationPolicy{
+		EntityID:      entityID,
+		SevaID:        sevaID,
+		CutoffHours:   req.CutoffHours,
+		NonRefundable: req.NonRefundable,
+		RefundTiers:   tiers,
+		UpdatedBy:     accessContext.UserID,
+	}
+	if err := s.repo.SaveCancellationPolicy(ctx, policy); err != nil {
+		details["error"] = err.Error()
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "SEVA_CANCELLATION_POLICY_UPDATE_FAILED", details, ip, "failure")
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "SEVA_CANCELLATION_POLICY_UPDATED", details, ip, "success")
+	return s.repo.GetCancellationPolicy(ctx, entityID, sevaID)
+}
+
+// DeleteCancellationPolicy removes the policy of a seva, or the temple-wide one
+// when sevaID is 0, so the next broader policy applies again
+func (s *service) DeleteCancellationPolicy(ctx context.Context, entityID, sevaID uint, accessContext middleware.AccessContext, ip string) error {
+	if !accessContext.CanWrite() {
+		return errors.New("write access denied")
+	}
+
+	removed, err := s.repo.DeleteCancellationPolicy(ctx, entityID, sevaID)
+	if err == nil && removed == 0 {
+		err = errors.New("no cancellation policy is set here")
+	}
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "SEVA_CANCELLATION_POLICY_DELETE_FAILED", map[string]interface{}{
+			"seva_id": sevaID,
+			"error":   err.Error(),
+		}, ip, "failure")
+		return err
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &entityID, "SEVA_CANCELLATION_POLICY_DELETED", map[string]interface{}{
+		"seva_id": sevaID,
+	}, ip, "success")
+	return nil
+}
+
+// normaliseRefundTiers orders the tiers by descending HoursBefore and rejects
+// two tiers for the same hour
+func normaliseRefundTiers(tiers []RefundTier) ([]RefundTier, error) {
+	sorted := append([]RefundTier{}, tiers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].HoursBefore > sorted[j].HoursBefore })
+	for i := range sorted {
+		if sorted[i].HoursBefore < 0 || sorted[i].RefundPercent < 0 || sorted[i].RefundPercent > 100 {
+			return nil, errors.New("refund tiers need hours_before >= 0 and a refund_percent between 0 and 100")
+		}
+		if i > 0 && sorted[i].HoursBefore == sorted[i-1].HoursBefore {
+			return nil, fmt.Errorf("more than one refund tier for %d hours before", sorted[i].HoursBefore)
+		}
+	}
+	return sorted, nil
+}
+
+// refundPercent is the share of the paid amount refunded to a cancellation
+// made hoursBefore the seva starts
+func (p *CancellationPolicy) refundPercent(hoursBefore float64) float64 {
+	if p.NonRefundable {
+		return 0
+	}
+	for _, tier := range p.RefundTiers {
+		if hoursBefore >= float64(tier.HoursBefore) {
+			return tier.RefundPercent
+		}
+	}
+	return 0
+}
+
+// ─────────────────────────────────────────────
+// Devotee cancellations
+// A devotee cancels their own pending or approved booking. Its place goes back
+// to the seva or slot in the same transaction as the status change, and a paid
+// booking is refunded through the payment layer as the policy allows.
+// ─────────────────────────────────────────────
+
+// QuoteCancellation tells the devotee whether the booking can be cancelled now
+// and how much would be refunded
+func (s *service) QuoteCancellation(ctx context.Context, bookingID, userID uint) (*CancellationQuote, error) {
+	booking, err := s.repo.GetBookingByID(ctx, bookingID)
+	if err != nil || booking.UserID != userID {
+		return nil, errors.New("booking not found")
+	}
+	return s.quoteCancellation(ctx, booking, time.Now())
+}
+
+func (s *service) CancelBooking(ctx context.Context, bookingID, userID uint, reason, ip string) (*CancellationResult, error) {
+	reason = strings.TrimSpace(reason)
+
+	booking, err := s.repo.GetBookingByID(ctx, bookingID)
+	if err != nil || booking.UserID != userID {
+		s.auditSvc.LogAction(ctx, &userID, nil, "SEVA_BOOKING_CANCEL_FAILED", map[string]interface{}{
+			"booking_id": bookingID,
+			"reason":     "booking not found",
+		}, ip, "failure")
+		return nil, errors.New("booking not found")
+	}
+
+	now := time.Now()
+	quote, err := s.quoteCancellation(ctx, booking, now)
+	if err == nil && !quote.Cancellable {
+		err = errors.New(quote.Reason)
+	}
+	if err == nil {
+		_, err = s.repo.TransitionBooking(ctx, booking.ID, booking.Status, "cancelled", map[string]interface{}{
+			"cancelled_at":        now,
+			"cancellation_reason": reason,
+		})
+	}
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_CANCEL_FAILED", map[string]interface{}{
+			"booking_id":     booking.ID,
+			"seva_id":        booking.SevaID,
+			"booking_status": booking.Status,
+			"error":          err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+
+	oldStatus := booking.Status
+	booking.Status = "cancelled"
+	booking.CancelledAt = &now
+	booking.CancellationReason = reason
+	result := &CancellationResult{Booking: booking, Quote: *quote}
+
+	if quote.RefundAmount > 0 {
+		refundReason := "Seva booking cancelled by devotee"
+		if reason != "" {
+			refundReason += ": " + reason
+		}
+		if s.refunder == nil {
+			result.RefundError = "refunds are not available; the temple will refund this booking"
+		} else if refundID, status, err := s.refunder.RefundSevaBooking(ctx, booking.ID, quote.RefundAmount, refundReason, userID, ip); err != nil {
+			result.RefundError = err.Error()
+		} else {
+			result.RefundID = &refundID
+			result.RefundStatus = status
+		}
+	}
+
+	s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_CANCELLED", map[string]interface{}{
+		"booking_id":     booking.ID,
+		"seva_id":        booking.SevaID,
+		"slot_id":        booking.SlotID,
+		"old_status":     oldStatus,
+		"reason":         reason,
+		"paid_amount":    quote.PaidAmount,
+		"refund_percent": quote.RefundPercent,
+		"refund_amount":  quote.RefundAmount,
+		"refund_id":      result.RefundID,
+		"refund_error":   result.RefundError,
+	}, ip, "success")
+
+	s.promoteWaitlist(ctx, booking.SevaID, booking.SlotID, ip)
+
+	if s.notifSvc != nil {
+		message := "Your seva booking has been cancelled."
+		switch {
+		case result.RefundID != nil:
+			message = fmt.Sprintf("Your seva booking has been cancelled. A refund of ₹%.2f has been requested.", quote.RefundAmount)
+		case result.RefundError != "":
+			message = fmt.Sprintf("Your seva booking has been cancelled. The temple will refund ₹%.2f to you.", quote.RefundAmount)
+		}
+		_ = s.notifSvc.CreateInAppNotification(ctx, booking.UserID, booking.EntityID, "Seva Booking Cancelled", message, "seva")
+		_ = s.notifSvc.CreateInAppForEntityRoles(
+			ctx,
+			booking.EntityID,
+			[]string{"templeadmin", "standarduser"},
+			"Seva Booking Cancelled",
+			fmt.Sprintf("Booking #%d was cancelled by the devotee", booking.ID),
+			"seva",
+		)
+	}
+
+	return result, nil
+}
+
+// quoteCancellation applies the booking's cancellation policy at now
+func (s *service) quoteCancellation(ctx context.Context, booking *SevaBooking, now time.Time) (*CancellationQuote, error) {
+	policy, err := s.GetCancellationPolicy(ctx, booking.EntityID, booking.SevaID)
+	if err != nil {
+		return nil, err
+	}
+	startsAt, err := s.bookingStartsAt(ctx, booking)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &CancellationQuote{BookingID: booking.ID, StartsAt: startsAt, Policy: *policy}
+	if booking.PaymentVerifiedAt != nil && booking.RazorpayPaymentID != "" {
+		quote.PaidAmount = math.Max(booking.Amount-booking.RefundedAmount, 0)
+	}
+
+	hoursBefore := math.Inf(1)
+	if startsAt != nil {
+		hoursBefore = startsAt.Sub(now).Hours()
+	}
+	switch {
+	case booking.Status == "offered":
+		quote.Reason = "this place is offered from the waitlist; decline the offer instead"
+	case booking.AttendedAt != nil:
+		quote.Reason = "the booking has already been checked in at the temple"
+	case !holdsSlot(booking.Status):
+		quote.Reason = "booking is already " + booking.Status
+	case hoursBefore <= 0:
+		quote.Reason = "the seva has already started"
+	case hoursBefore < float64(policy.CutoffHours):
+		quote.Reason = fmt.Sprintf("bookings can only be cancelled up to %d hours before the seva", policy.CutoffHours)
+	default:
+		quote.Cancellable = true
+	}
+	if !quote.Cancellable || quote.PaidAmount <= 0 {
+		return quote, nil
+	}
+
+	quote.RefundPercent = policy.refundPercent(hoursBefore)
+	quote.RefundAmount = math.Round(quote.PaidAmount*quote.RefundPercent) / 100
+	return quote, nil
+}
+
+// bookingStartsAt is when the booked occurrence starts: its slot's date and
+// start time, else the seva's own date and start time. Nil for sevas without a
+// date, which can be cancelled at any time.
+func (s *service) bookingStartsAt(ctx context.Context, booking *SevaBooking) (*time.Time, error) {
+	var day time.Time
+	var startTime string
+	if booking.SlotID != nil {
+		slot, err := s.repo.GetSlotByID(ctx, *booking.SlotID)
+		if err != nil {
+			return nil, errSlotNotFound
+		}
+		day, startTime = calendarDay(slot.SlotDate), slot.StartTime
+	} else {
+		seva, err := s.repo.GetSevaByID(ctx, booking.SevaID)
+		if err != nil {
+			return nil, errors.New("seva not found")
+		}
+		parsed, err := time.Parse("02-01-2006", strings.TrimSpace(seva.Date))
+		if err != nil {
+			return nil, nil
+		}
+		day, startTime = parsed, seva.StartTime
+	}
+
+	startsAt := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, utils.IST)
+	if minutes, err := parseClock(startTime); err == nil {
+		startsAt = startsAt.Add(time.Duration(minutes) * time.Minute)
+	}
+	return &startsAt, nil
+}
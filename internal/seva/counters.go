@@ -0,0 +1,49 @@
+package seva
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// repairIP is recorded as the IP on audit entries written by the counter repair
+const repairIP = "slot_counter_repair"
+
+var (
+	errSevaFull       = errors.New("no slots available for this seva")
+	errBookingChanged = errors.New("booking status changed meanwhile; reload it and try again")
+)
+
+// ─────────────────────────────────────────────
+// Slot counters
+// booked_slots and remaining_slots of a seva count the places held by its
+// pending, approved and offered bookings made without a dated slot. Bookings
+// and status changes move them in the same transaction as the booking row (see
+// TransitionBooking); RepairSlotCounters rebuilds them from seva_bookings after
+// manual data fixes or counters written before that was the case.
+// ─────────────────────────────────────────────
+
+// RepairSlotCounters recomputes the slot counters of a temple's sevas, or of
+// every seva when entityID is 0, and audits each seva that had drifted
+func (s *service) RepairSlotCounters(ctx context.Context, entityID uint) ([]SlotCounterRepair, error) {
+	repairs, err := s.repo.RepairSlotCounters(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, repair := range repairs {
+		entity := repair.EntityID
+		s.auditSvc.LogAction(ctx, nil, &entity, "SEVA_SLOT_COUNTERS_REPAIRED", map[string]interface{}{
+			"seva_id":             repair.SevaID,
+			"seva_name":           repair.Name,
+			"available_slots":     repair.AvailableSlots,
+			"old_booked_slots":    repair.OldBookedSlots,
+			"old_remaining_slots": repair.OldRemainingSlots,
+			"booked_slots":        repair.BookedSlots,
+			"remaining_slots":     repair.RemainingSlots,
+		}, repairIP, "success")
+		log.Printf("🧮 Seva=%d slot counters repaired: booked %d→%d remaining %d→%d",
+			repair.SevaID, repair.OldBookedSlots, repair.BookedSlots, repair.OldRemainingSlots, repair.RemainingSlots)
+	}
+	return repairs, nil
+}
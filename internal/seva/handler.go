@@ -1,9 +1,7 @@
 package seva
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	//"os"
@@ -11,9 +9,9 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/razorpay/razorpay-go"
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
 	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/payment"
 	"github.com/sharath018/temple-management-backend/middleware"
 )
 
@@ -21,6 +19,7 @@ type Handler struct {
 	service  Service
 	auditSvc auditlog.Service
 	repo     Repository
+	payments *payment.Resolver
 }
 
 func NewHandler(service Service, auditSvc auditlog.Service, repo Repository) *Handler {
@@ -28,9 +27,24 @@ func NewHandler(service Service, auditSvc auditlog.Service, repo Repository) *Ha
 		service:  service,
 		auditSvc: auditSvc,
 		repo:     repo,
+		payments: payment.NewResolver(nil),
 	}
 }
 
+// SetPaymentResolver injects the resolver configured from the app config
+func (h *Handler) SetPaymentResolver(r *payment.Resolver) {
+	h.payments = r
+}
+
+// paymentProvider returns the payment provider chosen by the temple's tenant
+func (h *Handler) paymentProvider(entityID uint) (payment.Provider, error) {
+	settings, err := h.repo.GetPaymentSettingsByEntityID(entityID)
+	if err != nil {
+		return nil, err
+	}
+	return h.payments.ForTenant(settings)
+}
+
 // ===========================
 // 📌 Extract Access Context
 // Returns a pointer so pointer-receiver methods (CanWrite, CanRead, etc.) work correctly.
@@ -104,6 +118,18 @@ func resolveEntityID(c *gin.Context, accessContext *middleware.AccessContext) (u
 	return 0, false
 }
 
+// occurrencesParam reads how many upcoming occurrences to list per seva (?occurrences=)
+func occurrencesParam(c *gin.Context) int {
+	n, err := strconv.Atoi(c.DefaultQuery("occurrences", strconv.Itoa(defaultListedOccurrences)))
+	if err != nil || n < 0 {
+		return defaultListedOccurrences
+	}
+	if n > maxListedOccurrences {
+		return maxListedOccurrences
+	}
+	return n
+}
+
 // ========================= REQUEST STRUCTS =============================
 
 type CreateSevaRequest struct {
@@ -134,21 +160,170 @@ type UpdateSevaRequest struct {
 }
 
 type BookSevaRequest struct {
-	SevaID uint `json:"seva_id" binding:"required"`
+	SevaID        uint                 `json:"seva_id" binding:"required"`
+	SlotID        *uint                `json:"slot_id"`
+	VariantID     *uint                `json:"variant_id"`
+	AddOns        []AddOnSelection     `json:"add_ons" binding:"omitempty,dive"`
+	Beneficiaries []BeneficiaryRequest `json:"beneficiaries" binding:"omitempty,dive"`
 }
 
+// BookSevaWithPaymentRequest is charged the price of the selected variant and
+// add-ons, computed on the server. Amount, when sent, is the total the
+// devotee was shown and must match it.
 type BookSevaWithPaymentRequest struct {
-	SevaID   uint    `json:"seva_id" binding:"required"`
-	Amount   float64 `json:"amount" binding:"required"`
-	EntityID uint    `json:"entity_id"`
-	SevaName string  `json:"seva_name"`
-	SevaType string  `json:"seva_type"`
+	SevaID        uint                 `json:"seva_id" binding:"required"`
+	SlotID        *uint                `json:"slot_id"`
+	VariantID     *uint                `json:"variant_id"`
+	AddOns        []AddOnSelection     `json:"add_ons" binding:"omitempty,dive"`
+	Amount        float64              `json:"amount"`
+	EntityID      uint                 `json:"entity_id"`
+	SevaName      string               `json:"seva_name"`
+	SevaType      string               `json:"seva_type"`
+	Beneficiaries []BeneficiaryRequest `json:"beneficiaries" binding:"omitempty,dive"`
+}
+
+// AddOnSelection books an add-on with the seva; quantity defaults to 1
+type AddOnSelection struct {
+	AddOnID  uint `json:"add_on_id" binding:"required"`
+	Quantity int  `json:"quantity" binding:"omitempty,min=1"`
+}
+
+func addOnItems(selections []AddOnSelection) []SevaBookingItem {
+	items := make([]SevaBookingItem, 0, len(selections))
+	for _, selection := range selections {
+		items = append(items, SevaBookingItem{
+			Kind:     ItemKindAddOn,
+			ItemID:   selection.AddOnID,
+			Quantity: selection.Quantity,
+		})
+	}
+	return items
+}
+
+// PriceQuoteRequest prices a selection of a seva before booking it
+type PriceQuoteRequest struct {
+	VariantID *uint            `json:"variant_id"`
+	AddOns    []AddOnSelection `json:"add_ons" binding:"omitempty,dive"`
+}
+
+// SevaPricingRequest replaces a seva's variants and add-ons, listed in the
+// order devotees see them. Entries with an id update that variant or add-on;
+// ones left out are deactivated.
+type SevaPricingRequest struct {
+	Variants []SevaVariantRequest `json:"variants" binding:"omitempty,dive"`
+	AddOns   []SevaAddOnRequest   `json:"add_ons" binding:"omitempty,dive"`
+}
+
+type SevaVariantRequest struct {
+	ID          uint    `json:"id"`
+	Name        string  `json:"name" binding:"required"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price" binding:"gte=0"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+type SevaAddOnRequest struct {
+	ID          uint    `json:"id"`
+	Name        string  `json:"name" binding:"required"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price" binding:"gte=0"`
+	MaxQuantity int     `json:"max_quantity" binding:"gte=0"` // default 1
+	IsActive    *bool   `json:"is_active"`
+}
+
+// BeneficiaryRequest names someone the seva is performed for. Details left
+// empty are filled in from the devotee's profile; "child" picks a child of
+// the profile by child_id, "other" is entered as free text.
+type BeneficiaryRequest struct {
+	Relation  string `json:"relation" binding:"required,oneof=self spouse child other"`
+	ChildID   *uint  `json:"child_id"`
+	Name      string `json:"name"`
+	Gotra     string `json:"gotra"`
+	Nakshatra string `json:"nakshatra"`
+	Rashi     string `json:"rashi"`
+	Sankalpa  string `json:"sankalpa"`
+}
+
+func beneficiariesFromRequest(requests []BeneficiaryRequest) []SevaBeneficiary {
+	beneficiaries := make([]SevaBeneficiary, 0, len(requests))
+	for _, req := range requests {
+		beneficiaries = append(beneficiaries, SevaBeneficiary{
+			Relation:  req.Relation,
+			ChildID:   req.ChildID,
+			Name:      req.Name,
+			Gotra:     req.Gotra,
+			Nakshatra: req.Nakshatra,
+			Rashi:     req.Rashi,
+			Sankalpa:  req.Sankalpa,
+		})
+	}
+	return beneficiaries
 }
 
+// CreateScheduleRequest sets a recurrence rule on a seva; dates are YYYY-MM-DD
+// and times HH:mm. Frequency is daily (default), weekly on weekdays ("mon".."sun"),
+// monthly on month_day, on the listed dates, or on the days of the listed tithis
+// ("ekadashi", "krishna chaturthi", "purnima") or nakshatras ("rohini"). Without
+// end_date the schedule is open-ended; without slot_minutes each day gets one
+// slot from start_time to end_time.
+type CreateScheduleRequest struct {
+	Frequency    string   `json:"frequency" binding:"omitempty,oneof=daily weekly monthly dates tithi nakshatra"`
+	Weekdays     []string `json:"weekdays"`
+	MonthDay     int      `json:"month_day" binding:"omitempty,min=1,max=31"`
+	Dates        []string `json:"dates"`
+	Tithis       []string `json:"tithis"`
+	Nakshatras   []string `json:"nakshatras"`
+	ExcludeDates []string `json:"exclude_dates"`
+	StartDate    string   `json:"start_date"`
+	EndDate      string   `json:"end_date"`
+	StartTime    string   `json:"start_time" binding:"required"`
+	EndTime      string   `json:"end_time" binding:"required"`
+	SlotMinutes  int      `json:"slot_minutes"`
+	Capacity     int      `json:"capacity" binding:"required,min=1"`
+}
+
+// UpdateOccurrenceRequest edits one occurrence (scope "this") or it and all
+// later occurrences of its schedule (scope "future"). Cancel removes them.
+type UpdateOccurrenceRequest struct {
+	Scope     string  `json:"scope" binding:"required,oneof=this future"`
+	StartTime *string `json:"start_time"`
+	EndTime   *string `json:"end_time"`
+	Capacity  *int    `json:"capacity" binding:"omitempty,min=1"`
+	Cancel    bool    `json:"cancel"`
+}
+
+// CancellationPolicyRequest sets a temple's or a seva's cancellation policy
+type CancellationPolicyRequest struct {
+	CutoffHours   int          `json:"cutoff_hours" binding:"gte=0"`
+	NonRefundable bool         `json:"non_refundable"`
+	RefundTiers   []RefundTier `json:"refund_tiers" binding:"omitempty,dive"`
+}
+
+type CancelBookingRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CheckInTicketRequest carries the code scanned from a seva ticket's QR
+type CheckInTicketRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// JoinWaitlistRequest queues for the seva, or for one of its slots when the
+// seva is booked by date and time, with the variant, add-ons and
+// beneficiaries to book it with once a place is offered
+type JoinWaitlistRequest struct {
+	SlotID        *uint                `json:"slot_id"`
+	VariantID     *uint                `json:"variant_id"`
+	AddOns        []AddOnSelection     `json:"add_ons" binding:"omitempty,dive"`
+	Beneficiaries []BeneficiaryRequest `json:"beneficiaries" binding:"omitempty,dive"`
+}
+
+// VerifySevaPaymentRequest keeps the razorpay_* field names for existing clients;
+// Cashfree checkouts send only the order id.
 type VerifySevaPaymentRequest struct {
 	RazorpayOrderID   string `json:"razorpay_order_id" binding:"required"`
-	RazorpayPaymentID string `json:"razorpay_payment_id" binding:"required"`
-	RazorpaySignature string `json:"razorpay_signature" binding:"required"`
+	RazorpayPaymentID string `json:"razorpay_payment_id"`
+	RazorpaySignature string `json:"razorpay_signature"`
 	SevaID            uint   `json:"seva_id" binding:"required"`
 }
 
@@ -269,8 +444,14 @@ func (h *Handler) ListEntitySevas(c *gin.Context) {
 		return
 	}
 
+	listings, err := h.service.WithUpcomingOccurrences(c, sevas, occurrencesParam(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch seva occurrences: " + err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"sevas": sevas,
+		"sevas": listings,
 		"total": total,
 		"page":  page,
 		"limit": limit,
@@ -534,7 +715,13 @@ func (h *Handler) GetSevas(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"sevas": sevas})
+	listings, err := h.service.WithUpcomingOccurrences(c, sevas, occurrencesParam(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch seva occurrences: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sevas": listings})
 }
 
 // ========================= BOOKING HANDLERS =============================
@@ -562,11 +749,15 @@ func (h *Handler) BookSeva(c *gin.Context) {
 	ip := middleware.GetIPFromContext(c)
 
 	booking := SevaBooking{
-		SevaID:      input.SevaID,
-		UserID:      user.ID,
-		EntityID:    seva.EntityID,
-		BookingTime: time.Now(),
-		Status:      "pending",
+		SevaID:        input.SevaID,
+		SlotID:        input.SlotID,
+		UserID:        user.ID,
+		EntityID:      seva.EntityID,
+		BookingTime:   time.Now(),
+		Status:        "pending",
+		VariantID:     input.VariantID,
+		Items:         addOnItems(input.AddOns),
+		Beneficiaries: beneficiariesFromRequest(input.Beneficiaries),
 	}
 
 	if err := h.service.BookSeva(c, &booking, "devotee", user.ID, seva.EntityID, ip); err != nil {
@@ -580,7 +771,7 @@ func (h *Handler) BookSeva(c *gin.Context) {
 	})
 }
 
-// 💳 Book Seva with Razorpay Payment
+// 💳 Book Seva with Online Payment
 func (h *Handler) BookSevaWithPayment(c *gin.Context) {
 	var input BookSevaWithPaymentRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -600,49 +791,79 @@ func (h *Handler) BookSevaWithPayment(c *gin.Context) {
 		return
 	}
 
-	if seva.RemainingSlots <= 0 {
+	if input.SlotID != nil {
+		slot, err := h.service.GetSlotAvailability(c, *input.SlotID)
+		if err != nil || slot.SevaID != input.SevaID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Slot not found for this seva"})
+			return
+		}
+		if slot.Remaining <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No places left in this slot"})
+			return
+		}
+	} else if seva.AvailableSlots > 0 && seva.RemainingSlots <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No slots available for this seva"})
 		return
 	}
 
-	// Fetch Razorpay keys from DB (per-temple, stored in tenant_bank_account_details)
-	razorpayKey, razorpaySecret, err := h.repo.GetRazorpayKeysByEntityID(input.EntityID)
+	// The devotee pays for their selection at today's prices, whatever the client sent
+	quote, err := h.service.QuoteSevaPrice(c, input.SevaID, input.VariantID, input.AddOns)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Amount > 0 && !samePrice(input.Amount, quote.Amount) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  fmt.Sprintf("amount does not match the price of the selection (₹%.2f)", quote.Amount),
+			"amount": quote.Amount,
+			"items":  quote.Items,
+		})
+		return
+	}
+
+	// Resolve the temple's payment provider (per-temple, stored in tenant_bank_account_details)
+	provider, err := h.paymentProvider(input.EntityID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Payment gateway not configured for this temple: " + err.Error()})
 		return
 	}
 
-	client := razorpay.NewClient(razorpayKey, razorpaySecret)
-	data := map[string]interface{}{
-		"amount":   int(input.Amount * 100),
-		"currency": "INR",
-		"receipt":  fmt.Sprintf("seva_%d_%d", input.SevaID, time.Now().Unix()),
-		"notes": map[string]interface{}{
+	order, err := provider.CreateOrder(c, payment.OrderRequest{
+		Amount:        quote.Amount,
+		Currency:      "INR",
+		Receipt:       fmt.Sprintf("seva_%d_%d", input.SevaID, time.Now().Unix()),
+		CustomerID:    strconv.FormatUint(uint64(user.ID), 10),
+		CustomerEmail: user.Email,
+		CustomerPhone: user.Phone,
+		Notes: map[string]interface{}{
 			"seva_id":   input.SevaID,
 			"user_id":   user.ID,
 			"entity_id": input.EntityID,
-			"seva_name": input.SevaName,
-			"seva_type": input.SevaType,
+			"seva_name":  input.SevaName,
+			"seva_type":  input.SevaType,
+			"variant_id": input.VariantID,
 		},
-	}
-
-	body, err := client.Order.Create(data, nil)
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payment order: " + err.Error()})
 		return
 	}
 
-	orderID := body["id"].(string)
+	orderID := order.ID
 
 	ip := middleware.GetIPFromContext(c)
 	booking := SevaBooking{
 		SevaID:          input.SevaID,
+		SlotID:          input.SlotID,
 		UserID:          user.ID,
 		EntityID:        input.EntityID,
-		Amount:          input.Amount,
+		Amount:          quote.Amount,
 		BookingTime:     time.Now(),
 		Status:          "pending",
 		RazorpayOrderID: orderID,
+		VariantID:       quote.VariantID,
+		Items:           addOnItems(input.AddOns),
+		Beneficiaries:   beneficiariesFromRequest(input.Beneficiaries),
 	}
 
 	if err := h.service.CreateSevaBookingWithPayment(c, &booking, user.ID, input.EntityID, ip); err != nil {
@@ -651,12 +872,15 @@ func (h *Handler) BookSevaWithPayment(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
-		"success":      true,
-		"order_id":     orderID,
-		"razorpay_key": razorpayKey,
-		"amount":       input.Amount,
-		"booking_id":   booking.ID,
-		"message":      "Razorpay order created successfully",
+		"success":            true,
+		"order_id":           orderID,
+		"provider":           order.Provider,
+		"razorpay_key":       order.KeyID,
+		"payment_session_id": order.SessionID,
+		"amount":             booking.Amount,
+		"items":              booking.Items,
+		"booking_id":         booking.ID,
+		"message":            "Payment order created successfully",
 	})
 }
 
@@ -670,7 +894,7 @@ func (h *Handler) VerifySevaPayment(c *gin.Context) {
 
 	user := c.MustGet("user").(auth.User)
 
-	// Fetch booking first to get EntityID for per-temple Razorpay secret lookup
+	// Fetch booking first to get EntityID for per-temple provider lookup
 	booking, err := h.repo.GetBookingByOrderID(c, input.RazorpayOrderID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -680,8 +904,8 @@ func (h *Handler) VerifySevaPayment(c *gin.Context) {
 		return
 	}
 
-	// Fetch Razorpay secret from DB using the booking's entity
-	_, razorpaySecret, err := h.repo.GetRazorpayKeysByEntityID(booking.EntityID)
+	// Resolve the provider from the booking's entity
+	provider, err := h.paymentProvider(booking.EntityID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -690,12 +914,8 @@ func (h *Handler) VerifySevaPayment(c *gin.Context) {
 		return
 	}
 
-	message := input.RazorpayOrderID + "|" + input.RazorpayPaymentID
-	mac := hmac.New(sha256.New, []byte(razorpaySecret))
-	mac.Write([]byte(message))
-	expectedSignature := hex.EncodeToString(mac.Sum(nil))
-
-	if expectedSignature != input.RazorpaySignature {
+	pay, err := provider.VerifyPayment(c, input.RazorpayOrderID, input.RazorpayPaymentID, input.RazorpaySignature)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   "Invalid payment signature",
@@ -708,7 +928,7 @@ func (h *Handler) VerifySevaPayment(c *gin.Context) {
 	if err := h.service.VerifySevaPayment(
 		c,
 		input.RazorpayOrderID,
-		input.RazorpayPaymentID,
+		pay.ID,
 		input.RazorpaySignature,
 		input.SevaID,
 		user.ID,
@@ -869,4 +1089,663 @@ func (h *Handler) UpdateBookingStatus(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Booking status updated successfully"})
-}
\ No newline at end of file
+}
+
+// ========================= SCHEDULE & SLOT HANDLERS =============================
+
+// managedSeva loads the seva in the :id path param and checks the caller works
+// for its temple, with write access when requireWrite is set
+func (h *Handler) managedSeva(c *gin.Context, requireWrite bool) (*middleware.AccessContext, *Seva, bool) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	if requireWrite && !accessContext.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "write access denied"})
+		return nil, nil, false
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seva ID"})
+		return nil, nil, false
+	}
+
+	seva, err := h.service.GetSevaByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Seva not found"})
+		return nil, nil, false
+	}
+
+	if !isSuperAdmin(accessContext.RoleName) {
+		if !h.canAccessSeva(accessContext, seva.EntityID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized: cannot manage this seva"})
+			return nil, nil, false
+		}
+	}
+
+	return accessContext, seva, true
+}
+
+// 🗓️ Create a schedule that generates dated slots for a seva
+func (h *Handler) CreateSchedule(c *gin.Context) {
+	accessContext, seva, ok := h.managedSeva(c, true)
+	if !ok {
+		return
+	}
+
+	var input CreateScheduleRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	schedule, created, err := h.service.CreateSchedule(c, seva.ID, input, *accessContext, ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to create schedule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Schedule created successfully",
+		"schedule":      schedule,
+		"slots_created": created,
+	})
+}
+
+// 🗓️ List a seva's schedules
+func (h *Handler) ListSchedules(c *gin.Context) {
+	_, seva, ok := h.managedSeva(c, false)
+	if !ok {
+		return
+	}
+
+	schedules, err := h.service.ListSchedules(c, seva.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedules: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules})
+}
+
+// ❌ Delete a schedule and its unbooked upcoming slots
+func (h *Handler) DeleteSchedule(c *gin.Context) {
+	accessContext, seva, ok := h.managedSeva(c, true)
+	if !ok {
+		return
+	}
+
+	scheduleID, err := strconv.Atoi(c.Param("scheduleId"))
+	if err != nil || scheduleID < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	removed, err := h.service.DeleteSchedule(c, seva.ID, uint(scheduleID), *accessContext, ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to delete schedule: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted", "slots_removed": removed})
+}
+
+// 📅 Remaining places per slot of a seva between ?from= and ?to= (YYYY-MM-DD)
+func (h *Handler) GetSevaAvailability(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seva ID"})
+		return
+	}
+
+	seva, err := h.service.GetSevaByID(c, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Seva not found"})
+		return
+	}
+
+	slots, err := h.service.GetAvailability(c, seva.ID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"seva_id": seva.ID,
+		"slots":   slots,
+	})
+}
+
+// ========================= PRICING HANDLERS =============================
+
+// 🏷️ A seva's price, variants and add-ons; temple staff see inactive ones with ?all=true
+func (h *Handler) GetSevaPricing(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seva ID"})
+		return
+	}
+
+	user := c.MustGet("user").(auth.User)
+	activeOnly := c.Query("all") != "true" || user.Role.RoleName == "devotee"
+
+	pricing, err := h.service.GetSevaPricing(c, uint(id), activeOnly)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"pricing": pricing})
+}
+
+// 🏷️ Replace a seva's variants and add-ons
+func (h *Handler) SetSevaPricing(c *gin.Context) {
+	accessContext, seva, ok := h.managedSeva(c, true)
+	if !ok {
+		return
+	}
+
+	var input SevaPricingRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	pricing, err := h.service.SetSevaPricing(c, seva.ID, input, *accessContext, ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to save pricing: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pricing saved", "pricing": pricing})
+}
+
+// 🧾 What a selection of variant and add-ons of a seva costs
+func (h *Handler) QuoteSevaPrice(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seva ID"})
+		return
+	}
+
+	var input PriceQuoteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+	}
+
+	quote, err := h.service.QuoteSevaPrice(c, uint(id), input.VariantID, input.AddOns)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"quote": quote})
+}
+
+// ✏️ Edit one occurrence of a seva, or it and all later ones of its schedule
+func (h *Handler) UpdateOccurrence(c *gin.Context) {
+	accessContext, seva, ok := h.managedSeva(c, true)
+	if !ok {
+		return
+	}
+
+	slotID, err := strconv.Atoi(c.Param("slotId"))
+	if err != nil || slotID < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid occurrence ID"})
+		return
+	}
+
+	var input UpdateOccurrenceRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	update, err := h.service.UpdateOccurrence(c, seva.ID, uint(slotID), input, *accessContext, ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to update occurrence: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Occurrence updated successfully", "update": update})
+}
+
+// ========================= CANCELLATION HANDLERS =============================
+
+// policyScope resolves the temple and seva a cancellation policy request is
+// about: the seva in the :id path param, else the caller's temple (seva 0)
+func (h *Handler) policyScope(c *gin.Context, requireWrite bool) (*middleware.AccessContext, uint, uint, bool) {
+	if c.Param("id") != "" {
+		accessContext, seva, ok := h.managedSeva(c, requireWrite)
+		if !ok {
+			return nil, 0, 0, false
+		}
+		return accessContext, seva.EntityID, seva.ID, true
+	}
+
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return nil, 0, 0, false
+	}
+	if requireWrite && !accessContext.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "write access denied"})
+		return nil, 0, 0, false
+	}
+	entityID, ok := resolveEntityID(c, accessContext)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entity_id is required"})
+		return nil, 0, 0, false
+	}
+	if !isSuperAdmin(accessContext.RoleName) && !h.canAccessSeva(accessContext, entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "unauthorized: cannot manage this temple"})
+		return nil, 0, 0, false
+	}
+	return accessContext, entityID, 0, true
+}
+
+// 📜 Cancellation policy that applies to a seva, or the temple's own policies
+func (h *Handler) GetCancellationPolicy(c *gin.Context) {
+	_, entityID, sevaID, ok := h.policyScope(c, false)
+	if !ok {
+		return
+	}
+
+	policy, err := h.service.GetCancellationPolicy(c, entityID, sevaID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cancellation policy: " + err.Error()})
+		return
+	}
+
+	response := gin.H{"policy": policy}
+	if sevaID == 0 {
+		policies, err := h.service.ListCancellationPolicies(c, entityID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cancellation policies: " + err.Error()})
+			return
+		}
+		response["policies"] = policies
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// 📜 Set the cancellation policy of a seva or of the temple
+func (h *Handler) SetCancellationPolicy(c *gin.Context) {
+	accessContext, entityID, sevaID, ok := h.policyScope(c, true)
+	if !ok {
+		return
+	}
+
+	var input CancellationPolicyRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	policy, err := h.service.SetCancellationPolicy(c, entityID, sevaID, input, *accessContext, ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to save cancellation policy: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cancellation policy saved", "policy": policy})
+}
+
+// ❌ Remove the cancellation policy of a seva or of the temple
+func (h *Handler) DeleteCancellationPolicy(c *gin.Context) {
+	accessContext, entityID, sevaID, ok := h.policyScope(c, true)
+	if !ok {
+		return
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	if err := h.service.DeleteCancellationPolicy(c, entityID, sevaID, *accessContext, ip); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to delete cancellation policy: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Cancellation policy deleted"})
+}
+
+// 💸 What cancelling one of the devotee's bookings now would refund
+func (h *Handler) GetBookingCancellation(c *gin.Context) {
+	user := c.MustGet("user").(auth.User)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	quote, err := h.service.QuoteCancellation(c, uint(id), user.ID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cancellation": quote})
+}
+
+// 🚫 Devotee cancels one of their bookings
+func (h *Handler) CancelBooking(c *gin.Context) {
+	user := c.MustGet("user").(auth.User)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var input CancelBookingRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	result, err := h.service.CancelBooking(c, uint(id), user.ID, input.Reason, ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cancellation failed: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled", "cancellation": result})
+}
+
+// ========================= WAITLIST HANDLERS =============================
+
+// ⏳ Devotee joins the waitlist of a full seva or slot
+func (h *Handler) JoinWaitlist(c *gin.Context) {
+	user := c.MustGet("user").(auth.User)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid seva ID"})
+		return
+	}
+
+	var input JoinWaitlistRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	entry, err := h.service.JoinWaitlist(c, &SevaWaitlistEntry{
+		SevaID:        uint(id),
+		SlotID:        input.SlotID,
+		UserID:        user.ID,
+		VariantID:     input.VariantID,
+		AddOns:        addOnItems(input.AddOns),
+		Beneficiaries: beneficiariesFromRequest(input.Beneficiaries),
+	}, ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not join waitlist: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Added to the waitlist", "entry": entry})
+}
+
+// ⏳ Devotee's waitlist entries and open offers
+func (h *Handler) GetMyWaitlist(c *gin.Context) {
+	user := c.MustGet("user").(auth.User)
+
+	entries, err := h.service.ListMyWaitlist(c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not fetch waitlist"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"waitlist": entries})
+}
+
+// ✅ Devotee confirms a place offered from the waitlist; paid sevas get a
+// payment order, verified through /verify-payment like any booking
+func (h *Handler) ConfirmWaitlistOffer(c *gin.Context) {
+	user := c.MustGet("user").(auth.User)
+
+	id, err := strconv.Atoi(c.Param("entryId"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid waitlist entry ID"})
+		return
+	}
+
+	entry, booking, err := h.service.GetWaitlistOffer(c, uint(id), user.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var order *payment.Order
+	if booking.Amount > 0 {
+		provider, err := h.paymentProvider(booking.EntityID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Payment gateway not configured for this temple: " + err.Error()})
+			return
+		}
+		order, err = provider.CreateOrder(c, payment.OrderRequest{
+			Amount:        booking.Amount,
+			Currency:      "INR",
+			Receipt:       fmt.Sprintf("seva_%d_%d", booking.SevaID, time.Now().Unix()),
+			CustomerID:    strconv.FormatUint(uint64(user.ID), 10),
+			CustomerEmail: user.Email,
+			CustomerPhone: user.Phone,
+			Notes: map[string]interface{}{
+				"seva_id":           booking.SevaID,
+				"user_id":           user.ID,
+				"entity_id":         booking.EntityID,
+				"waitlist_entry_id": entry.ID,
+			},
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payment order: " + err.Error()})
+			return
+		}
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	orderID := ""
+	if order != nil {
+		orderID = order.ID
+	}
+	booking, err = h.service.ConfirmWaitlistOffer(c, entry.ID, user.ID, orderID, ip)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not confirm offer: " + err.Error()})
+		return
+	}
+
+	if order == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Booking confirmed from the waitlist", "booking": booking})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":            true,
+		"order_id":           order.ID,
+		"provider":           order.Provider,
+		"razorpay_key":       order.KeyID,
+		"payment_session_id": order.SessionID,
+		"amount":             booking.Amount,
+		"booking_id":         booking.ID,
+		"offer_expires_at":   entry.OfferExpiresAt,
+		"message":            "Payment order created successfully",
+	})
+}
+
+// 🚪 Devotee leaves the waitlist, declining any open offer
+func (h *Handler) LeaveWaitlist(c *gin.Context) {
+	user := c.MustGet("user").(auth.User)
+
+	id, err := strconv.Atoi(c.Param("entryId"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid waitlist entry ID"})
+		return
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	if err := h.service.LeaveWaitlist(c, uint(id), user.ID, ip); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Could not leave waitlist: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Removed from the waitlist"})
+}
+
+// ⏳ Temple staff view a seva's waitlist in queue order
+func (h *Handler) ListWaitlist(c *gin.Context) {
+	_, seva, ok := h.managedSeva(c, false)
+	if !ok {
+		return
+	}
+
+	entries, err := h.service.ListWaitlist(c, seva.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch waitlist: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"waitlist": entries})
+}
+
+// ========================= TICKET HANDLERS =============================
+
+// 🎫 Ticket of one of the devotee's approved bookings: ?format=json|pdf|png
+func (h *Handler) GetBookingTicket(c *gin.Context) {
+	user := c.MustGet("user").(auth.User)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format == "json" {
+		ticket, err := h.service.GetBookingTicket(c, uint(id), user.ID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"ticket": ticket})
+		return
+	}
+
+	content, filename, err := h.service.ExportBookingTicket(c, uint(id), user.ID, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	contentType := "application/pdf"
+	if format == "png" {
+		contentType = "image/png"
+	}
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, contentType, content)
+}
+
+// ✅ Temple staff check a devotee in at the counter by scanning their ticket
+func (h *Handler) CheckInTicket(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	if !accessContext.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "write access denied"})
+		return
+	}
+
+	var input CheckInTicketRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	ticket, err := h.service.CheckInTicket(c, input.Code, *accessContext, ip)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, errTicketUsed) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": "Check-in failed: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Devotee checked in", "ticket": ticket})
+}
+
+// ========================= SANKALPA HANDLERS =============================
+
+// 🙏 People on the devotee's profile a seva can be booked for, pre-filled
+func (h *Handler) GetMyBeneficiaries(c *gin.Context) {
+	user := c.MustGet("user").(auth.User)
+
+	var entityID uint
+	if user.EntityID != nil {
+		entityID = *user.EntityID
+	}
+	if id, err := strconv.ParseUint(c.Query("entity_id"), 10, 32); err == nil {
+		entityID = uint(id)
+	}
+
+	beneficiaries, err := h.service.SuggestBeneficiaries(c, user.ID, entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not load beneficiaries"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"beneficiaries": beneficiaries})
+}
+
+// 📋 Priest worksheet of a seva for a day: ?date=YYYY-MM-DD&format=pdf|html|json
+func (h *Handler) GetPriestWorksheet(c *gin.Context) {
+	_, seva, ok := h.managedSeva(c, false)
+	if !ok {
+		return
+	}
+
+	date := c.Query("date")
+	format := c.DefaultQuery("format", "pdf")
+	if format == "json" {
+		worksheet, err := h.service.GetPriestWorksheet(c, seva.ID, date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"worksheet": worksheet})
+		return
+	}
+
+	content, filename, err := h.service.ExportPriestWorksheet(c, seva.ID, date, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if format == "html" {
+		c.Header("Content-Disposition", "inline; filename="+filename)
+		c.Data(http.StatusOK, "text/html; charset=utf-8", content)
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/pdf", content)
+}
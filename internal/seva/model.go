@@ -23,8 +23,9 @@ type Seva struct {
 	Duration       int       `json:"duration"` // in minutes
 	
 	// ✅ UPDATED: Slot Management Fields
+	// Bookings made without a slot; scheduled sevas keep capacity per SevaSlot
 	AvailableSlots int       `json:"available_slots" gorm:"default:0"` // Total slots available
-	BookedSlots    int       `json:"booked_slots" gorm:"default:0"`    // Number of approved bookings
+	BookedSlots    int       `json:"booked_slots" gorm:"default:0"`    // Places held by pending, approved and offered bookings
 	RemainingSlots int       `json:"remaining_slots" gorm:"default:0"` // Calculated: AvailableSlots - BookedSlots
 
 	Status         string    `gorm:"type:varchar(20);default:'upcoming'" json:"status"` // upcoming/ongoing/completed
@@ -43,17 +44,387 @@ type SevaBooking struct {
 	UserID      uint      `gorm:"not null" json:"user_id"`        // Who is booking (devotee)
 	EntityID    uint      `gorm:"not null" json:"entity_id"`      // Temple where the seva is hosted
 	BookingTime time.Time `json:"booking_time"`                   // Auto-timestamp
-	Status      string    `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending / approved / rejected
+	Status      string    `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending / approved / rejected / cancelled / offered / expired
 	Amount              float64   `gorm:"type:decimal(10,2)" json:"amount"`
-	RazorpayOrderID     string    `gorm:"type:varchar(255)" json:"razorpay_order_id,omitempty"`
+	RazorpayOrderID     string    `gorm:"type:varchar(255)" json:"razorpay_order_id,omitempty"` // Gateway order ID (Razorpay, Cashfree, ...)
 	RazorpayPaymentID   string    `gorm:"type:varchar(255)" json:"razorpay_payment_id,omitempty"`
 	RazorpaySignature   string    `gorm:"type:varchar(512)" json:"razorpay_signature,omitempty"`
 	PaymentVerifiedAt   *time.Time `json:"payment_verified_at,omitempty"`
+
+	// Refunds are tracked in the refunds table; these mirror the processed total
+	RefundStatus   string  `gorm:"type:varchar(20)" json:"refund_status,omitempty"` // "" / partial / full
+	RefundedAmount float64 `gorm:"type:decimal(10,2);default:0" json:"refunded_amount"`
+
+	// Dated slot the booking holds a place in; nil for sevas without a schedule
+	SlotID *uint `gorm:"index" json:"slot_id,omitempty"`
+
+	// Set when the devotee cancelled the booking
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+	CancellationReason string     `gorm:"type:text" json:"cancellation_reason,omitempty"`
+
+	// People the seva is performed for, recited by the priest in the sankalpa
+	Beneficiaries []SevaBeneficiary `gorm:"foreignKey:BookingID" json:"beneficiaries,omitempty"`
+
+	// Variant of the seva booked, and the priced lines Amount is the total of
+	VariantID *uint             `json:"variant_id,omitempty"`
+	Items     []SevaBookingItem `gorm:"foreignKey:BookingID" json:"items,omitempty"`
+
+	// Set when temple staff checked the devotee's ticket in at the counter
+	AttendedAt *time.Time `json:"attended_at,omitempty"`
+	AttendedBy *uint      `json:"attended_by,omitempty"`
 	
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// Relations of a beneficiary to the devotee who booked the seva
+const (
+	BeneficiarySelf   = "self"
+	BeneficiarySpouse = "spouse"
+	BeneficiaryChild  = "child"
+	BeneficiaryOther  = "other" // anyone else, entered as free text
+)
+
+// SevaBeneficiary is a person a booking's seva is performed for, with the
+// sankalpa details the priest needs. Details the devotee leaves empty are
+// filled in from their devotee profile when the booking is made.
+type SevaBeneficiary struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	BookingID uint      `gorm:"not null;index" json:"booking_id"`
+	Relation  string    `gorm:"type:varchar(20);not null" json:"relation"`
+	ChildID   *uint     `json:"child_id,omitempty"` // profile child the details came from
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	Gotra     string    `gorm:"type:varchar(100)" json:"gotra,omitempty"`
+	Nakshatra string    `gorm:"type:varchar(100)" json:"nakshatra,omitempty"`
+	Rashi     string    `gorm:"type:varchar(100)" json:"rashi,omitempty"`
+	Sankalpa  string    `gorm:"type:text" json:"sankalpa,omitempty"` // the devotee's prayer or intention
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ======================
+// 🔹 Variants & Add-ons
+// ======================
+
+// SevaVariant is a priced way of performing a seva, e.g. single, couple or
+// family, or with a silver or gold kavacha. A seva with active variants is
+// booked as one of them, at the variant's price instead of Seva.Price.
+type SevaVariant struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	SevaID      uint      `gorm:"not null;index" json:"seva_id"`
+	Name        string    `gorm:"type:varchar(255);not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	Price       float64   `gorm:"type:decimal(10,2);not null" json:"price"`
+	SortOrder   int       `gorm:"default:0" json:"sort_order"`
+	IsActive    bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SevaAddOn is an optional extra booked with a seva, e.g. flowers or
+// vastram, up to MaxQuantity of it
+type SevaAddOn struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	SevaID      uint      `gorm:"not null;index" json:"seva_id"`
+	Name        string    `gorm:"type:varchar(255);not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	Price       float64   `gorm:"type:decimal(10,2);not null" json:"price"`
+	MaxQuantity int       `gorm:"default:1" json:"max_quantity"`
+	SortOrder   int       `gorm:"default:0" json:"sort_order"`
+	IsActive    bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Kinds of a booking's priced lines
+const (
+	ItemKindSeva    = "seva"    // the seva at its own price, for sevas without variants
+	ItemKindVariant = "variant" // the variant booked
+	ItemKindAddOn   = "addon"
+)
+
+// SevaBookingItem is a priced line of a booking. Name and price are copied
+// from the seva, variant or add-on when the booking is made, so later price
+// changes leave the booking as it was paid.
+type SevaBookingItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	BookingID uint      `gorm:"not null;index" json:"booking_id"`
+	Kind      string    `gorm:"type:varchar(20);not null" json:"kind"`
+	ItemID    uint      `json:"item_id"` // seva, variant or add-on ID, by Kind
+	Name      string    `gorm:"type:varchar(255);not null" json:"name"`
+	UnitPrice float64   `gorm:"type:decimal(10,2);not null" json:"unit_price"`
+	Quantity  int       `gorm:"not null;default:1" json:"quantity"`
+	Amount    float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SevaPricing is a seva's price list: its own price, its variants and the
+// add-ons that can be booked with it
+type SevaPricing struct {
+	SevaID   uint          `json:"seva_id"`
+	Price    float64       `json:"price"`
+	Variants []SevaVariant `json:"variants"`
+	AddOns   []SevaAddOn   `json:"add_ons"`
+}
+
+// PriceQuote is what a selection of a seva costs, line by line
+type PriceQuote struct {
+	SevaID    uint              `json:"seva_id"`
+	VariantID *uint             `json:"variant_id,omitempty"`
+	Items     []SevaBookingItem `json:"items"`
+	Amount    float64           `json:"amount"`
+}
+
+// ======================
+// 🔹 Schedules & Slots
+// ======================
+
+// Recurrence rules of a seva schedule
+const (
+	FrequencyDaily     = "daily"
+	FrequencyWeekly    = "weekly"    // on the listed Weekdays
+	FrequencyMonthly   = "monthly"   // on MonthDay; months without that day are skipped
+	FrequencyDates     = "dates"     // on the listed Dates
+	FrequencyTithi     = "tithi"     // on the days of the listed Tithis in the panchang
+	FrequencyNakshatra = "nakshatra" // on the days of the listed Nakshatras in the panchang
+)
+
+// SevaSchedule is a recurrence rule of a seva. Each day the rule falls on,
+// from StartDate to EndDate, gets slots between StartTime and EndTime, each
+// SlotMinutes long (one slot for the whole window when SlotMinutes is 0), with
+// Capacity places. Open-ended schedules are generated GeneratedUntil a rolling
+// horizon and extended by the schedule worker.
+type SevaSchedule struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	SevaID         uint       `gorm:"not null;index" json:"seva_id"`
+	EntityID       uint       `gorm:"not null;index" json:"entity_id"`
+	Frequency      string     `gorm:"type:varchar(20);not null;default:'daily'" json:"frequency"`
+	Weekdays       string     `gorm:"type:varchar(50)" json:"weekdays,omitempty"` // e.g. "mon,thu"
+	MonthDay       int        `gorm:"default:0" json:"month_day,omitempty"`
+	Dates          string     `gorm:"type:text" json:"dates,omitempty"`         // comma-separated YYYY-MM-DD
+	Tithis         string     `gorm:"type:text" json:"tithis,omitempty"`        // e.g. "shukla_ekadashi,krishna_ekadashi"
+	Nakshatras     string     `gorm:"type:text" json:"nakshatras,omitempty"`    // e.g. "rohini"
+	ExcludeDates   string     `gorm:"type:text" json:"exclude_dates,omitempty"` // comma-separated YYYY-MM-DD
+	StartDate      time.Time  `gorm:"type:date;not null" json:"start_date"`
+	EndDate        *time.Time `gorm:"type:date" json:"end_date,omitempty"` // nil: open-ended
+	GeneratedUntil time.Time  `gorm:"type:date" json:"generated_until"`
+	StartTime      string     `gorm:"type:varchar(10);not null" json:"start_time"` // Format: HH:mm
+	EndTime        string     `gorm:"type:varchar(10);not null" json:"end_time"`   // Format: HH:mm
+	SlotMinutes    int        `gorm:"default:0" json:"slot_minutes"`
+	Capacity       int        `gorm:"not null" json:"capacity"`
+	CreatedBy      uint       `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// SevaSlot is one bookable occurrence of a seva. Pending, approved and offered
+// bookings hold a place; Capacity is enforced when a booking is created.
+// Detached occurrences were edited on their own and are left alone by edits
+// to all future occurrences of their schedule.
+type SevaSlot struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	SevaID     uint      `gorm:"not null;uniqueIndex:idx_seva_slot_start" json:"seva_id"`
+	EntityID   uint      `gorm:"not null;index" json:"entity_id"`
+	ScheduleID *uint     `gorm:"index" json:"schedule_id,omitempty"`
+	SlotDate   time.Time `gorm:"type:date;not null;uniqueIndex:idx_seva_slot_start" json:"slot_date"`
+	StartTime  string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_seva_slot_start" json:"start_time"` // Format: HH:mm
+	EndTime    string    `gorm:"type:varchar(10)" json:"end_time"`                                            // Format: HH:mm
+	Capacity   int       `gorm:"not null" json:"capacity"`
+	IsActive   bool      `gorm:"default:true" json:"is_active"`
+	Detached   bool      `gorm:"default:false" json:"detached"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// SlotAvailability is a slot with the places still open in it
+type SlotAvailability struct {
+	SevaSlot
+	Booked    int64 `json:"booked"`
+	Remaining int64 `json:"remaining"`
+}
+
+// OccurrenceUpdate reports what an occurrence edit changed. Scope "this"
+// returns the edited Slot, scope "future" the Schedule now governing the
+// occurrences and how many of them were moved, removed or kept.
+type OccurrenceUpdate struct {
+	Scope        string        `json:"scope"`
+	Slot         *SevaSlot     `json:"slot,omitempty"`
+	Schedule     *SevaSchedule `json:"schedule,omitempty"`
+	SlotsMoved   int64         `json:"slots_moved"`
+	SlotsRemoved int64         `json:"slots_removed"`
+	BookedKept   int64         `json:"booked_kept"`
+}
+
+// SevaListing is a seva with its next bookable occurrences
+type SevaListing struct {
+	Seva
+	NextOccurrences []SlotAvailability `json:"next_occurrences"`
+}
+
+// ======================
+// 🔹 Cancellation Policies
+// ======================
+
+// CancellationPolicy decides until when devotees may cancel a booking and how
+// much of a paid booking is refunded. The policy with a SevaID applies to that
+// seva; the one with SevaID 0 to every other seva of the temple. Devotees
+// cannot cancel within CutoffHours of the seva's start. Before that they get
+// back the RefundPercent of the first tier, by descending HoursBefore, whose
+// HoursBefore the cancellation is made ahead of; nothing when no tier matches
+// or the seva is NonRefundable.
+type CancellationPolicy struct {
+	ID            uint         `gorm:"primaryKey" json:"id"`
+	EntityID      uint         `gorm:"not null;uniqueIndex:idx_cancellation_policy_scope" json:"entity_id"`
+	SevaID        uint         `gorm:"not null;default:0;uniqueIndex:idx_cancellation_policy_scope" json:"seva_id"` // 0: temple default
+	CutoffHours   int          `gorm:"default:0" json:"cutoff_hours"`
+	NonRefundable bool         `gorm:"default:false" json:"non_refundable"`
+	RefundTiers   []RefundTier `gorm:"type:jsonb;serializer:json" json:"refund_tiers"`
+	UpdatedBy     uint         `json:"updated_by"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// RefundTier refunds RefundPercent of the paid amount to cancellations made at
+// least HoursBefore hours before the seva starts
+type RefundTier struct {
+	HoursBefore   int     `json:"hours_before" binding:"gte=0"`
+	RefundPercent float64 `json:"refund_percent" binding:"gte=0,lte=100"`
+}
+
+// CancellationQuote is what cancelling a booking now would do under the
+// policy that applies to it
+type CancellationQuote struct {
+	BookingID     uint               `json:"booking_id"`
+	Cancellable   bool               `json:"cancellable"`
+	Reason        string             `json:"reason,omitempty"` // why it cannot be cancelled
+	StartsAt      *time.Time         `json:"starts_at,omitempty"`
+	PaidAmount    float64            `json:"paid_amount"`
+	RefundPercent float64            `json:"refund_percent"`
+	RefundAmount  float64            `json:"refund_amount"`
+	Policy        CancellationPolicy `json:"policy"`
+}
+
+// CancellationResult is a cancelled booking with the refund raised for it.
+// RefundError is set when the gateway refund could not be requested; the
+// temple then refunds the booking by hand.
+type CancellationResult struct {
+	Booking      *SevaBooking      `json:"booking"`
+	Quote        CancellationQuote `json:"quote"`
+	RefundID     *uint             `json:"refund_id,omitempty"`
+	RefundStatus string            `json:"refund_status,omitempty"`
+	RefundError  string            `json:"refund_error,omitempty"`
+}
+
+// ======================
+// 🔹 Waitlist
+// ======================
+
+// Statuses of a waitlist entry
+const (
+	WaitlistWaiting   = "waiting"
+	WaitlistOffered   = "offered"   // a place is held for the devotee until OfferExpiresAt
+	WaitlistConfirmed = "confirmed" // the devotee took the offered place
+	WaitlistExpired   = "expired"   // the offer ran out; the place went to the next devotee
+	WaitlistLeft      = "left"      // the devotee left the queue or declined the offer
+)
+
+// SevaWaitlistEntry queues a devotee for a full seva, or for a full slot of a
+// scheduled seva, with the variant, add-ons and beneficiaries they would book
+// it with. When a place frees up the first waiting devotee is offered it: the
+// place is held in a booking of that selection, priced when it is offered,
+// with status "offered" until the devotee confirms it, paying if it has a
+// price, or the offer expires.
+
+type SevaWaitlistEntry struct {
+	ID             uint              `gorm:"primaryKey" json:"id"`
+	SevaID         uint              `gorm:"not null;index:idx_seva_waitlist_queue" json:"seva_id"`
+	SlotID         *uint             `gorm:"index:idx_seva_waitlist_queue" json:"slot_id,omitempty"`
+	EntityID       uint              `gorm:"not null;index" json:"entity_id"`
+	UserID         uint              `gorm:"not null;index" json:"user_id"`
+	Status         string            `gorm:"type:varchar(20);not null;default:'waiting';index:idx_seva_waitlist_queue" json:"status"`
+	VariantID      *uint             `json:"variant_id,omitempty"`
+	AddOns         []SevaBookingItem `gorm:"type:jsonb;serializer:json" json:"add_ons,omitempty"`       // add-on lines' item IDs and quantities
+	Beneficiaries  []SevaBeneficiary `gorm:"type:jsonb;serializer:json" json:"beneficiaries,omitempty"` // as given, completed from the profile when offered
+	BookingID      *uint             `gorm:"index" json:"booking_id,omitempty"`                         // booking holding the offered place
+	OfferedAt      *time.Time        `json:"offered_at,omitempty"`
+	OfferExpiresAt *time.Time        `json:"offer_expires_at,omitempty"`
+	Position       int64             `gorm:"->;-:migration" json:"position,omitempty"` // place in the queue while waiting
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// WaitlistFilter narrows a waitlist listing; zero fields match everything
+type WaitlistFilter struct {
+	ID       uint
+	EntityID uint
+	SevaID   uint
+	UserID   uint
+	Statuses []string
+}
+
+// ======================
+// 🔹 Priest Worksheet
+// ======================
+
+// PriestWorksheet lists a seva's approved bookings on one day with the
+// sankalpa details of everyone it is performed for
+type PriestWorksheet struct {
+	EntityID    uint               `json:"entity_id"`
+	EntityName  string             `json:"entity_name"`
+	SevaID      uint               `json:"seva_id"`
+	SevaName    string             `json:"seva_name"`
+	SevaType    string             `json:"seva_type"`
+	Date        time.Time          `json:"date"`
+	Bookings    []WorksheetBooking `json:"bookings"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+// WorksheetBooking is one approved booking on a priest worksheet. Bookings
+// made without beneficiaries list the devotee themself.
+type WorksheetBooking struct {
+	BookingID     uint              `json:"booking_id"`
+	UserID        uint              `json:"user_id"`
+	DevoteeName   string            `json:"devotee_name"`
+	DevoteePhone  string            `json:"devotee_phone"`
+	StartTime     string            `json:"start_time,omitempty"`
+	EndTime       string            `json:"end_time,omitempty"`
+	Beneficiaries []SevaBeneficiary `json:"beneficiaries" gorm:"-"`
+}
+
+// ======================
+// 🔹 Seva Tickets
+// ======================
+
+// SevaTicket is the signed ticket of an approved booking, shown as a QR code
+// at the temple counter. Code is the QR content.
+type SevaTicket struct {
+	Code          string     `json:"code"`
+	BookingID     uint       `json:"booking_id"`
+	EntityID      uint       `json:"entity_id"`
+	EntityName    string     `json:"entity_name"`
+	SevaID        uint       `json:"seva_id"`
+	SevaName      string     `json:"seva_name"`
+	SlotID        *uint      `json:"slot_id,omitempty"`
+	Date          *time.Time `json:"date,omitempty"` // nil when the ticket is valid on any day
+	StartTime     string     `json:"start_time,omitempty"`
+	EndTime       string     `json:"end_time,omitempty"`
+	DevoteeName   string     `json:"devotee_name"`
+	Beneficiaries int        `json:"beneficiaries"`
+	AttendedAt    *time.Time `json:"attended_at,omitempty"`
+}
+
+// SlotCounterRepair is a seva whose slot counters had drifted from its
+// bookings, with the counters before and after the repair
+type SlotCounterRepair struct {
+	SevaID            uint   `json:"seva_id"`
+	EntityID          uint   `json:"entity_id"`
+	Name              string `json:"name"`
+	AvailableSlots    int    `json:"available_slots"`
+	OldBookedSlots    int    `json:"old_booked_slots"`
+	OldRemainingSlots int    `json:"old_remaining_slots"`
+	BookedSlots       int    `json:"booked_slots"`
+	RemainingSlots    int    `json:"remaining_slots"`
+}
+
 // ✅ For Filtered Search (Admin Dashboard)
 type BookingFilter struct {
 	EntityID   uint   `json:"entity_id"`
@@ -70,8 +441,9 @@ type BookingFilter struct {
 
 // ✅ Booking Status Counts (Dashboard Card)
 type BookingStatusCounts struct {
-	Total    int64 `json:"total"`
-	Approved int64 `json:"approved"`
-	Pending  int64 `json:"pending"`
-	Rejected int64 `json:"rejected"`
+	Total     int64 `json:"total"`
+	Approved  int64 `json:"approved"`
+	Pending   int64 `json:"pending"`
+	Rejected  int64 `json:"rejected"`
+	Cancelled int64 `json:"cancelled"`
 }
\ No newline at end of file
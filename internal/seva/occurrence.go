@@ -0,0 +1,268 @@
+package seva
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// Scopes of an occurrence edit
+const (
+	ScopeThis   = "this"
+	ScopeFuture = "future"
+)
+
+// Seva listings carry this many upcoming occurrences per seva by default
+const (
+	defaultListedOccurrences = 5
+	maxListedOccurrences     = 31
+)
+
+// ─────────────────────────────────────────────
+// Occurrence edits
+// An occurrence is one slot of a seva. Editing "this" occurrence detaches it
+// from its schedule; editing "future" occurrences continues the schedule from
+// the occurrence's date with the new times or capacity, or ends it there.
+// ─────────────────────────────────────────────
+func (s *service) UpdateOccurrence(ctx context.Context, sevaID, slotID uint, req UpdateOccurrenceRequest, accessContext middleware.AccessContext, ip string) (*OccurrenceUpdate, error) {
+	if !accessContext.CanWrite() {
+		return nil, errors.New("write access denied")
+	}
+
+	slot, err := s.repo.GetSlotByID(ctx, slotID)
+	if err != nil || slot.SevaID != sevaID {
+		return nil, errSlotNotFound
+	}
+	if calendarDay(slot.SlotDate).Before(today()) {
+		return nil, errors.New("past occurrences cannot be edited")
+	}
+
+	var update *OccurrenceUpdate
+	if req.Scope == ScopeFuture {
+		update, err = s.updateFutureOccurrences(ctx, slot, req, accessContext.UserID)
+	} else {
+		update, err = s.updateThisOccurrence(ctx, slot, req)
+	}
+
+	details := map[string]interface{}{
+		"seva_id":    sevaID,
+		"slot_id":    slotID,
+		"slot_date":  slot.SlotDate.Format("2006-01-02"),
+		"scope":      req.Scope,
+		"start_time": req.StartTime,
+		"end_time":   req.EndTime,
+		"capacity":   req.Capacity,
+		"cancel":     req.Cancel,
+	}
+	if err != nil {
+		details["error"] = err.Error()
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &slot.EntityID, "SEVA_OCCURRENCE_UPDATE_FAILED", details, ip, "failure")
+		return nil, err
+	}
+
+	details["slots_moved"] = update.SlotsMoved
+	details["slots_removed"] = update.SlotsRemoved
+	details["booked_kept"] = update.BookedKept
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &slot.EntityID, "SEVA_OCCURRENCE_UPDATED", details, ip, "success")
+
+	return update, nil
+}
+
+func (s *service) updateThisOccurrence(ctx context.Context, slot *SevaSlot, req UpdateOccurrenceRequest) (*OccurrenceUpdate, error) {
+	held, err := s.repo.GetSlotAvailability(ctx, slot.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Cancel {
+		if held.Booked > 0 {
+			return nil, fmt.Errorf("this occurrence has %d booking(s); reject them before cancelling it", held.Booked)
+		}
+		slot.IsActive = false
+	}
+
+	timesChanged := false
+	if req.StartTime != nil || req.EndTime != nil {
+		start, end, err := occurrenceTimes(slot.StartTime, slot.EndTime, req)
+		if err != nil {
+			return nil, err
+		}
+		timesChanged = start != slot.StartTime || end != slot.EndTime
+		slot.StartTime, slot.EndTime = start, end
+	}
+
+	if req.Capacity != nil {
+		if int64(*req.Capacity) < held.Booked {
+			return nil, fmt.Errorf("capacity cannot be below the %d place(s) already booked", held.Booked)
+		}
+		slot.Capacity = *req.Capacity
+	}
+
+	slot.Detached = true
+	if err := s.repo.UpdateSlot(ctx, slot); err != nil {
+		return nil, err
+	}
+
+	if timesChanged && held.Booked > 0 {
+		bookings, err := s.repo.ListHeldBookings(ctx, slot.ID, 0, time.Time{})
+		if err == nil {
+			s.notifyRescheduled(ctx, slot.SevaID, bookings,
+				fmt.Sprintf("on %s is now at %s–%s", slot.SlotDate.Format("02 Jan 2006"), slot.StartTime, slot.EndTime))
+		}
+	}
+
+	return &OccurrenceUpdate{Scope: ScopeThis, Slot: slot}, nil
+}
+
+func (s *service) updateFutureOccurrences(ctx context.Context, slot *SevaSlot, req UpdateOccurrenceRequest, userID uint) (*OccurrenceUpdate, error) {
+	if slot.ScheduleID == nil {
+		return nil, errors.New("this occurrence is not part of a schedule; edit it with scope \"this\"")
+	}
+	schedule, err := s.repo.GetScheduleByID(ctx, *slot.ScheduleID)
+	if err != nil {
+		return nil, errors.New("schedule not found")
+	}
+	from := calendarDay(slot.SlotDate)
+
+	if req.Cancel {
+		removed, kept, err := s.repo.EndSchedule(ctx, schedule.ID, from)
+		if err != nil {
+			return nil, err
+		}
+		return &OccurrenceUpdate{Scope: ScopeFuture, SlotsRemoved: removed, BookedKept: kept}, nil
+	}
+
+	next := *schedule
+	next.ID = 0
+	next.StartDate = from
+	next.CreatedBy = userID
+	next.CreatedAt, next.UpdatedAt = time.Time{}, time.Time{}
+
+	timesChanged := false
+	if req.StartTime != nil || req.EndTime != nil {
+		if !schedule.singleSlotPerDay() {
+			return nil, errors.New("times of all future occurrences can only change for schedules with one slot a day; edit the slots one by one or create a new schedule")
+		}
+		start, end, err := occurrenceTimes(schedule.StartTime, schedule.EndTime, req)
+		if err != nil {
+			return nil, err
+		}
+		timesChanged = start != schedule.StartTime || end != schedule.EndTime
+		next.StartTime, next.EndTime, next.SlotMinutes = start, end, 0
+	}
+	if req.Capacity != nil {
+		next.Capacity = *req.Capacity
+	}
+
+	// Devotees holding a moved occurrence are told its new time
+	var held []SevaBooking
+	if timesChanged {
+		held, _ = s.repo.ListHeldBookings(ctx, 0, schedule.ID, from)
+	}
+
+	moved, err := s.repo.SplitSchedule(ctx, schedule, from, &next)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(held) > 0 {
+		s.notifyRescheduled(ctx, slot.SevaID, held,
+			fmt.Sprintf("from %s onwards is now at %s–%s", from.Format("02 Jan 2006"), next.StartTime, next.EndTime))
+	}
+
+	return &OccurrenceUpdate{Scope: ScopeFuture, Schedule: &next, SlotsMoved: moved}, nil
+}
+
+// singleSlotPerDay reports whether the schedule has one slot for its whole window
+func (sc *SevaSchedule) singleSlotPerDay() bool {
+	start, _ := parseClock(sc.StartTime)
+	end, _ := parseClock(sc.EndTime)
+	return sc.SlotMinutes == 0 || sc.SlotMinutes == end-start
+}
+
+// occurrenceTimes applies the requested start and end times to the current ones
+func occurrenceTimes(currentStart, currentEnd string, req UpdateOccurrenceRequest) (string, string, error) {
+	startValue, endValue := currentStart, currentEnd
+	if req.StartTime != nil {
+		startValue = *req.StartTime
+	}
+	if req.EndTime != nil {
+		endValue = *req.EndTime
+	}
+	start, err := parseClock(startValue)
+	if err != nil {
+		return "", "", errors.New("start_time must be in HH:mm format")
+	}
+	end, err := parseClock(endValue)
+	if err != nil {
+		return "", "", errors.New("end_time must be in HH:mm format")
+	}
+	if end <= start {
+		return "", "", errors.New("end_time must be after start_time")
+	}
+	return formatClock(start), formatClock(end), nil
+}
+
+// notifyRescheduled tells each devotee with a booking once that the seva moved
+func (s *service) notifyRescheduled(ctx context.Context, sevaID uint, bookings []SevaBooking, change string) {
+	if s.notifSvc == nil || len(bookings) == 0 {
+		return
+	}
+	seva, err := s.repo.GetSevaByID(ctx, sevaID)
+	if err != nil {
+		return
+	}
+
+	notified := make(map[uint]bool)
+	for _, booking := range bookings {
+		if notified[booking.UserID] {
+			continue
+		}
+		notified[booking.UserID] = true
+		_ = s.notifSvc.CreateInAppNotification(
+			ctx,
+			booking.UserID,
+			booking.EntityID,
+			"Seva Rescheduled",
+			fmt.Sprintf("%s %s. Your booking has moved with it.", seva.Name, change),
+			"seva",
+		)
+	}
+}
+
+// ─────────────────────────────────────────────
+// Listings with upcoming occurrences
+// ─────────────────────────────────────────────
+
+// WithUpcomingOccurrences attaches up to perSeva upcoming occurrences, with
+// their remaining places, to each seva
+func (s *service) WithUpcomingOccurrences(ctx context.Context, sevas []Seva, perSeva int) ([]SevaListing, error) {
+	listings := make([]SevaListing, len(sevas))
+	ids := make([]uint, len(sevas))
+	for i := range sevas {
+		listings[i] = SevaListing{Seva: sevas[i], NextOccurrences: []SlotAvailability{}}
+		ids[i] = sevas[i].ID
+	}
+	if perSeva <= 0 {
+		return listings, nil
+	}
+
+	slots, err := s.repo.ListUpcomingSlots(ctx, ids, time.Now().In(utils.IST), perSeva)
+	if err != nil {
+		return nil, err
+	}
+	bySeva := make(map[uint][]SlotAvailability)
+	for _, slot := range slots {
+		bySeva[slot.SevaID] = append(bySeva[slot.SevaID], slot)
+	}
+	for i := range listings {
+		if upcoming, ok := bySeva[listings[i].ID]; ok {
+			listings[i].NextOccurrences = upcoming
+		}
+	}
+	return listings, nil
+}
@@ -0,0 +1,283 @@
+package seva
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// ─────────────────────────────────────────────
+// Panchang
+// Tithi and nakshatra schedules fall on the days the panchang names them. The
+// panchang is computed from the apparent longitudes of the sun and the moon
+// (Meeus, Astronomical Algorithms, ch. 25 and 47), which places tithi and
+// nakshatra boundaries to within a minute or two. Nakshatras use the Lahiri
+// ayanamsa. A day takes the tithi and nakshatra prevailing at its sunrise,
+// taken as 06:00 IST for every temple.
+// ─────────────────────────────────────────────
+
+const panchangSunriseHour = 6
+
+// Tithis 1-15 are the shukla paksha ending in purnima, 16-30 the krishna
+// paksha ending in amavasya
+var tithiNames = []string{
+	"pratipada", "dwitiya", "tritiya", "chaturthi", "panchami", "shashthi", "saptami",
+	"ashtami", "navami", "dashami", "ekadashi", "dwadashi", "trayodashi", "chaturdashi",
+}
+
+var tithiAliases = map[string]string{
+	"prathama": "pratipada", "padyami": "pratipada", "dvitiya": "dwitiya", "vidiya": "dwitiya",
+	"thritiya": "tritiya", "chavithi": "chaturthi", "chathurthi": "chaturthi", "shashti": "shashthi",
+	"sashti": "shashthi", "dasami": "dashami", "ekadasi": "ekadashi", "dvadashi": "dwadashi",
+	"dwadasi": "dwadashi", "trayodasi": "trayodashi", "chaturdasi": "chaturdashi",
+	"pournami": "purnima", "pournima": "purnima", "poornima": "purnima", "purnami": "purnima",
+	"amavasai": "amavasya", "amavasi": "amavasya",
+}
+
+var nakshatraNames = []string{
+	"ashwini", "bharani", "krittika", "rohini", "mrigashira", "ardra", "punarvasu",
+	"pushya", "ashlesha", "magha", "purva_phalguni", "uttara_phalguni", "hasta", "chitra",
+	"swati", "vishakha", "anuradha", "jyeshtha", "mula", "purva_ashadha", "uttara_ashadha",
+	"shravana", "dhanishta", "shatabhisha", "purva_bhadrapada", "uttara_bhadrapada", "revati",
+}
+
+var nakshatraAliases = map[string]string{
+	"aswini": "ashwini", "ashvini": "ashwini", "kritika": "krittika", "karthigai": "krittika",
+	"mrigasira": "mrigashira", "mrigashirsha": "mrigashira", "arudra": "ardra",
+	"thiruvathirai": "ardra", "punarpoosam": "punarvasu", "pushyami": "pushya", "poosam": "pushya",
+	"aslesha": "ashlesha", "ayilyam": "ashlesha", "makha": "magha", "pubba": "purva_phalguni",
+	"pooram": "purva_phalguni", "uthiram": "uttara_phalguni", "hastham": "hasta",
+	"chithirai": "chitra", "svati": "swati", "visakha": "vishakha", "vishaka": "vishakha",
+	"anusham": "anuradha", "jyeshta": "jyeshtha", "kettai": "jyeshtha", "moola": "mula",
+	"purvashada": "purva_ashadha", "pooradam": "purva_ashadha", "uttarashada": "uttara_ashadha",
+	"uthradam": "uttara_ashadha", "sravana": "shravana", "shravanam": "shravana",
+	"thiruvonam": "shravana", "dhanishtha": "dhanishta", "avittam": "dhanishta",
+	"satabhisha": "shatabhisha", "sadayam": "shatabhisha", "purvabhadra": "purva_bhadrapada",
+	"poorattathi": "purva_bhadrapada", "uttarabhadra": "uttara_bhadrapada",
+	"uthrattathi": "uttara_bhadrapada",
+}
+
+// tithiKey is the stored name of tithi n, e.g. "shukla_ekadashi" or "purnima"
+func tithiKey(n int) string {
+	switch {
+	case n == 15:
+		return "purnima"
+	case n == 30:
+		return "amavasya"
+	case n < 15:
+		return "shukla_" + tithiNames[n-1]
+	}
+	return "krishna_" + tithiNames[n-16]
+}
+
+func nakshatraKey(n int) string {
+	return nakshatraNames[n-1]
+}
+
+// panchangWord lower-cases a name and drops everything but its letters
+func panchangWord(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// parseTithi resolves a tithi name to the keys it stands for. A name without
+// a paksha, e.g. "ekadashi", means the tithi in both.
+func parseTithi(name string) ([]string, error) {
+	word := panchangWord(name)
+	paksha := ""
+	for _, p := range []string{"shukla", "sukla", "krishna", "krsna", "bahula"} {
+		if strings.HasPrefix(word, p) {
+			paksha, word = p, strings.TrimPrefix(word, p)
+			break
+		}
+	}
+	if alias, ok := tithiAliases[word]; ok {
+		word = alias
+	}
+	shukla := paksha == "shukla" || paksha == "sukla"
+	krishna := paksha != "" && !shukla
+
+	switch word {
+	case "purnima":
+		if krishna {
+			return nil, fmt.Errorf("purnima is in the shukla paksha, got %q", name)
+		}
+		return []string{tithiKey(15)}, nil
+	case "amavasya":
+		if shukla {
+			return nil, fmt.Errorf("amavasya is in the krishna paksha, got %q", name)
+		}
+		return []string{tithiKey(30)}, nil
+	}
+	for i, tithi := range tithiNames {
+		if tithi != word {
+			continue
+		}
+		var keys []string
+		if !krishna {
+			keys = append(keys, tithiKey(i+1))
+		}
+		if !shukla {
+			keys = append(keys, tithiKey(i+16))
+		}
+		return keys, nil
+	}
+	return nil, fmt.Errorf("unknown tithi %q", name)
+}
+
+func parseNakshatra(name string) (string, error) {
+	word := panchangWord(name)
+	if alias, ok := nakshatraAliases[word]; ok {
+		return alias, nil
+	}
+	for _, nakshatra := range nakshatraNames {
+		if strings.ReplaceAll(nakshatra, "_", "") == word {
+			return nakshatra, nil
+		}
+	}
+	return "", fmt.Errorf("unknown nakshatra %q", name)
+}
+
+// tithisOn lists the tithis observed on the day: the one prevailing at its
+// sunrise unless it already did at the sunrise before (a vriddhi tithi is
+// kept on its first day), and one that begins and ends before the next
+// sunrise (a kshaya tithi)
+func tithisOn(day time.Time) []string {
+	var keys []string
+	for _, n := range observedOn(day, 30, tithiAt) {
+		keys = append(keys, tithiKey(n))
+	}
+	return keys
+}
+
+// nakshatrasOn lists the nakshatras observed on the day, as tithisOn does
+func nakshatrasOn(day time.Time) []string {
+	var keys []string
+	for _, n := range observedOn(day, 27, nakshatraAt) {
+		keys = append(keys, nakshatraKey(n))
+	}
+	return keys
+}
+
+func observedOn(day time.Time, count int, at func(time.Time) int) []int {
+	prev, cur, next := at(sunrise(day.AddDate(0, 0, -1))), at(sunrise(day)), at(sunrise(day.AddDate(0, 0, 1)))
+	var observed []int
+	if cur != prev {
+		observed = append(observed, cur)
+	}
+	if skipped := cur%count + 1; next == skipped%count+1 {
+		observed = append(observed, skipped)
+	}
+	return observed
+}
+
+func sunrise(day time.Time) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, panchangSunriseHour, 0, 0, 0, utils.IST)
+}
+
+// tithiAt numbers the tithi at t from 1 (shukla pratipada) to 30 (amavasya).
+// Each tithi is 12° of the moon's elongation from the sun.
+func tithiAt(t time.Time) int {
+	T := julianCenturies(t)
+	return int(normDegrees(moonLongitude(T)-sunLongitude(T))/12) + 1
+}
+
+// nakshatraAt numbers the nakshatra at t from 1 (ashwini) to 27 (revati).
+// Each nakshatra is 13°20' of the moon's sidereal longitude.
+func nakshatraAt(t time.Time) int {
+	T := julianCenturies(t)
+	return int(normDegrees(moonLongitude(T)-lahiriAyanamsa(T))/(360.0/27)) + 1
+}
+
+// julianCenturies is the time from J2000.0 in Julian centuries of terrestrial
+// time, taking TT - UT as 69 seconds
+func julianCenturies(t time.Time) float64 {
+	jd := float64(t.Unix()+69)/86400 + 2440587.5
+	return (jd - 2451545) / 36525
+}
+
+func lahiriAyanamsa(T float64) float64 {
+	return 23.85306 + 1.39688*T
+}
+
+// sunLongitude is the sun's apparent longitude in degrees, less nutation,
+// which the moon's longitude leaves out as well
+func sunLongitude(T float64) float64 {
+	L0 := 280.46646 + 36000.76983*T + 0.0003032*T*T
+	M := radians(357.52911 + 35999.05029*T - 0.0001537*T*T)
+	C := (1.914602-0.004817*T-0.000014*T*T)*math.Sin(M) +
+		(0.019993-0.000101*T)*math.Sin(2*M) +
+		0.000289*math.Sin(3*M)
+	return normDegrees(L0 + C - 0.00569)
+}
+
+// moonLongitudeTerms are the periodic terms of the moon's longitude: the
+// multiples of D, M, M' and F and the coefficient in 1e-6 degrees
+var moonLongitudeTerms = [][5]float64{
+	{0, 0, 1, 0, 6288774}, {2, 0, -1, 0, 1274027}, {2, 0, 0, 0, 658314}, {0, 0, 2, 0, 213618},
+	{0, 1, 0, 0, -185116}, {0, 0, 0, 2, -114332}, {2, 0, -2, 0, 58793}, {2, -1, -1, 0, 57066},
+	{2, 0, 1, 0, 53322}, {2, -1, 0, 0, 45758}, {0, 1, -1, 0, -40923}, {1, 0, 0, 0, -34720},
+	{0, 1, 1, 0, -30383}, {2, 0, 0, -2, 15327}, {0, 0, 1, 2, -12528}, {0, 0, 1, -2, 10980},
+	{4, 0, -1, 0, 10675}, {0, 0, 3, 0, 10034}, {4, 0, -2, 0, 8548}, {2, 1, -1, 0, -7888},
+	{2, 1, 0, 0, -6766}, {1, 0, -1, 0, -5163}, {1, 1, 0, 0, 4987}, {2, -1, 1, 0, 4036},
+	{2, 0, 2, 0, 3994}, {4, 0, 0, 0, 3861}, {2, 0, -3, 0, 3665}, {0, 1, -2, 0, -2689},
+	{2, 0, -1, 2, -2602}, {2, -1, -2, 0, 2390}, {1, 0, 1, 0, -2348}, {2, -2, 0, 0, 2236},
+	{0, 1, 2, 0, -2120}, {0, 2, 0, 0, -2069}, {2, -2, -1, 0, 2048}, {2, 0, 1, -2, -1773},
+	{2, 0, 0, 2, -1595}, {4, -1, -1, 0, 1215}, {0, 0, 2, 2, -1110}, {3, 0, -1, 0, -892},
+	{2, 1, 1, 0, -810}, {4, -1, -2, 0, 759}, {0, 2, -1, 0, -713}, {2, 2, -1, 0, -700},
+	{2, 1, -2, 0, 691}, {2, -1, 0, -2, 596}, {4, 0, 1, 0, 549}, {0, 0, 4, 0, 537},
+	{4, -1, 0, 0, 520}, {1, 0, -2, 0, -487}, {2, 1, 0, -2, -399}, {0, 0, 2, -2, -381},
+	{1, 1, 1, 0, 351}, {3, 0, -2, 0, -340}, {4, 0, -3, 0, 330}, {2, -1, 2, 0, 327},
+	{0, 2, 1, 0, -323}, {1, 1, -1, 0, 299}, {2, 0, 3, 0, 294},
+}
+
+// moonLongitude is the moon's geocentric longitude in degrees, referred to
+// the mean equinox of the date
+func moonLongitude(T float64) float64 {
+	T2, T3, T4 := T*T, T*T*T, T*T*T*T
+	Lp := 218.3164477 + 481267.88123421*T - 0.0015786*T2 + T3/538841 - T4/65194000
+	D := 297.8501921 + 445267.1114034*T - 0.0018819*T2 + T3/545868 - T4/113065000
+	M := 357.5291092 + 35999.0502909*T - 0.0001536*T2 + T3/24490000
+	Mp := 134.9633964 + 477198.8675055*T + 0.0087414*T2 + T3/69699 - T4/14712000
+	F := 93.2720950 + 483202.0175233*T - 0.0036539*T2 - T3/3526000 + T4/863310000
+	E := 1 - 0.002516*T - 0.0000074*T2
+
+	var sum float64
+	for _, term := range moonLongitudeTerms {
+		arg := radians(term[0]*D + term[1]*M + term[2]*Mp + term[3]*F)
+		coeff := term[4]
+		switch math.Abs(term[1]) {
+		case 1:
+			coeff *= E
+		case 2:
+			coeff *= E * E
+		}
+		sum += coeff * math.Sin(arg)
+	}
+	A1 := 119.75 + 131.849*T
+	A2 := 53.09 + 479264.290*T
+	sum += 3958*math.Sin(radians(A1)) + 1962*math.Sin(radians(Lp-F)) + 318*math.Sin(radians(A2))
+
+	return normDegrees(Lp + sum/1e6)
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func normDegrees(degrees float64) float64 {
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees
+}
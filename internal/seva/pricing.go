@@ -0,0 +1,231 @@
+package seva
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+var errPriceChanged = errors.New("the price of this seva changed; please review the amount and book again")
+
+// ─────────────────────────────────────────────
+// Variants, add-ons and booking prices
+// A seva is booked at its own price, or, when it has active variants, as one
+// of them at the variant's price. Add-ons are priced per unit on top. The
+// booking amount is always computed here from the selection; what the client
+// shows is only checked against it.
+// ─────────────────────────────────────────────
+
+// GetSevaPricing lists the seva's price, variants and add-ons; devotees see
+// the active ones only
+func (s *service) GetSevaPricing(ctx context.Context, sevaID uint, activeOnly bool) (*SevaPricing, error) {
+	seva, err := s.repo.GetSevaByID(ctx, sevaID)
+	if err != nil {
+		return nil, errors.New("seva not found")
+	}
+	variants, err := s.repo.ListVariants(ctx, sevaID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	addOns, err := s.repo.ListAddOns(ctx, sevaID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	return &SevaPricing{SevaID: seva.ID, Price: seva.Price, Variants: variants, AddOns: addOns}, nil
+}
+
+// SetSevaPricing replaces the seva's variants and add-ons with the listed
+// ones. Those left out are deactivated, so bookings made with them keep
+// their names.
+func (s *service) SetSevaPricing(ctx context.Context, sevaID uint, req SevaPricingRequest, accessContext middleware.AccessContext, ip string) (*SevaPricing, error) {
+	seva, err := s.repo.GetSevaByID(ctx, sevaID)
+	if err != nil {
+		return nil, errors.New("seva not found")
+	}
+
+	details := map[string]interface{}{
+		"seva_id":   sevaID,
+		"seva_name": seva.Name,
+		"variants":  req.Variants,
+		"add_ons":   req.AddOns,
+	}
+	variants, addOns, err := pricingFromRequest(req)
+	if err == nil {
+		err = s.repo.SaveSevaPricing(ctx, sevaID, variants, addOns)
+	}
+	if err != nil {
+		details["error"] = err.Error()
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &seva.EntityID, "SEVA_PRICING_UPDATE_FAILED", details, ip, "failure")
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &seva.EntityID, "SEVA_PRICING_UPDATED", details, ip, "success")
+	return s.GetSevaPricing(ctx, sevaID, false)
+}
+
+func pricingFromRequest(req SevaPricingRequest) ([]SevaVariant, []SevaAddOn, error) {
+	names := make(map[string]bool)
+	variants := make([]SevaVariant, 0, len(req.Variants))
+	for i, v := range req.Variants {
+		name := strings.TrimSpace(v.Name)
+		active := v.IsActive == nil || *v.IsActive
+		if active && names[strings.ToLower(name)] {
+			return nil, nil, fmt.Errorf("variant %q is listed twice", name)
+		}
+		names[strings.ToLower(name)] = active
+		variants = append(variants, SevaVariant{
+			ID:          v.ID,
+			Name:        name,
+			Description: strings.TrimSpace(v.Description),
+			Price:       utils.RoundMoney(v.Price),
+			SortOrder:   i,
+			IsActive:    active,
+		})
+	}
+
+	names = make(map[string]bool)
+	addOns := make([]SevaAddOn, 0, len(req.AddOns))
+	for i, a := range req.AddOns {
+		name := strings.TrimSpace(a.Name)
+		active := a.IsActive == nil || *a.IsActive
+		if active && names[strings.ToLower(name)] {
+			return nil, nil, fmt.Errorf("add-on %q is listed twice", name)
+		}
+		names[strings.ToLower(name)] = active
+		maxQuantity := a.MaxQuantity
+		if maxQuantity == 0 {
+			maxQuantity = 1
+		}
+		addOns = append(addOns, SevaAddOn{
+			ID:          a.ID,
+			Name:        name,
+			Description: strings.TrimSpace(a.Description),
+			Price:       utils.RoundMoney(a.Price),
+			MaxQuantity: maxQuantity,
+			SortOrder:   i,
+			IsActive:    active,
+		})
+	}
+	return variants, addOns, nil
+}
+
+// QuoteSevaPrice prices a selection of the seva without booking it
+func (s *service) QuoteSevaPrice(ctx context.Context, sevaID uint, variantID *uint, addOns []AddOnSelection) (*PriceQuote, error) {
+	seva, err := s.repo.GetSevaByID(ctx, sevaID)
+	if err != nil {
+		return nil, errors.New("seva not found")
+	}
+	return s.quoteSelection(ctx, seva, variantID, addOnItems(addOns))
+}
+
+// priceBooking replaces the booking's lines with the priced selection it
+// carries, the variant and the add-on lines' item IDs and quantities, and
+// sets its amount to their total
+func (s *service) priceBooking(ctx context.Context, seva *Seva, booking *SevaBooking) error {
+	quote, err := s.quoteSelection(ctx, seva, booking.VariantID, booking.Items)
+	if err != nil {
+		return err
+	}
+	booking.Items = quote.Items
+	booking.Amount = quote.Amount
+	return nil
+}
+
+func (s *service) quoteSelection(ctx context.Context, seva *Seva, variantID *uint, selected []SevaBookingItem) (*PriceQuote, error) {
+	variants, err := s.repo.ListVariants(ctx, seva.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	addOns, err := s.repo.ListAddOns(ctx, seva.ID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &PriceQuote{SevaID: seva.ID}
+	switch {
+	case variantID != nil:
+		variant := findVariant(variants, *variantID)
+		if variant == nil {
+			return nil, errors.New("variant not found for this seva")
+		}
+		quote.VariantID = &variant.ID
+		quote.Items = append(quote.Items, priceLine(ItemKindVariant, variant.ID, variant.Name, variant.Price, 1))
+	case len(variants) > 0:
+		return nil, errors.New("choose a variant of this seva")
+	default:
+		quote.Items = append(quote.Items, priceLine(ItemKindSeva, seva.ID, seva.Name, seva.Price, 1))
+	}
+
+	// The same add-on selected twice is booked once, with both quantities
+	quantities := make(map[uint]int)
+	for _, item := range selected {
+		if item.Kind != ItemKindAddOn {
+			continue
+		}
+		quantity := item.Quantity
+		if quantity <= 0 {
+			quantity = 1
+		}
+		quantities[item.ItemID] += quantity
+	}
+	for id := range quantities {
+		if findAddOn(addOns, id) == nil {
+			return nil, fmt.Errorf("add-on %d not found for this seva", id)
+		}
+	}
+	for _, addOn := range addOns {
+		quantity, ok := quantities[addOn.ID]
+		if !ok {
+			continue
+		}
+		if addOn.MaxQuantity > 0 && quantity > addOn.MaxQuantity {
+			return nil, fmt.Errorf("at most %d of %s can be booked", addOn.MaxQuantity, addOn.Name)
+		}
+		quote.Items = append(quote.Items, priceLine(ItemKindAddOn, addOn.ID, addOn.Name, addOn.Price, quantity))
+	}
+
+	for _, item := range quote.Items {
+		quote.Amount += item.Amount
+	}
+	quote.Amount = utils.RoundMoney(quote.Amount)
+	return quote, nil
+}
+
+func priceLine(kind string, id uint, name string, unitPrice float64, quantity int) SevaBookingItem {
+	return SevaBookingItem{
+		Kind:      kind,
+		ItemID:    id,
+		Name:      name,
+		UnitPrice: unitPrice,
+		Quantity:  quantity,
+		Amount:    utils.RoundMoney(unitPrice * float64(quantity)),
+	}
+}
+
+func findVariant(variants []SevaVariant, id uint) *SevaVariant {
+	for i := range variants {
+		if variants[i].ID == id {
+			return &variants[i]
+		}
+	}
+	return nil
+}
+
+func findAddOn(addOns []SevaAddOn, id uint) *SevaAddOn {
+	for i := range addOns {
+		if addOns[i].ID == id {
+			return &addOns[i]
+		}
+	}
+	return nil
+}
+
+// samePrice compares rupee amounts to the paisa
+func samePrice(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
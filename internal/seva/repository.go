@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/payment"
+	"github.com/sharath018/temple-management-backend/internal/userprofile"
 )
 
 type Repository interface {
@@ -19,7 +23,8 @@ type Repository interface {
 	DeleteSeva(ctx context.Context, id uint) error
 	GetBookingByOrderID(ctx context.Context, orderID string) (*SevaBooking, error)
 	UpdateSevaBooking(ctx context.Context, booking *SevaBooking) error
-	GetRazorpayKeysByEntityID(entityID uint) (string, string, error)
+	ListStalePaymentBookings(ctx context.Context, createdBefore time.Time, limit int) ([]SevaBooking, error)
+	GetPaymentSettingsByEntityID(entityID uint) (payment.TenantSettings, error)
 
 	// Enhanced seva listing with filters
 	GetSevasWithFilters(ctx context.Context, entityID uint, sevaType, search, status string, limit, offset int) ([]Seva, int64, error)
@@ -29,17 +34,71 @@ type Repository interface {
 	ListBookingsByUserID(ctx context.Context, userID uint) ([]SevaBooking, error)
 	ListBookingsByEntityID(ctx context.Context, entityID uint) ([]SevaBooking, error)
 	UpdateBookingStatus(ctx context.Context, bookingID uint, newStatus string) error
+	TransitionBooking(ctx context.Context, bookingID uint, fromStatus, toStatus string, changes map[string]interface{}) (string, error)
 
 	// ✅ UPDATED: Slot management methods
 	UpdateSevaSlots(ctx context.Context, sevaID uint, bookedSlots, remainingSlots int) error
-	IncrementBookedSlots(ctx context.Context, sevaID uint) error
-	DecrementBookedSlots(ctx context.Context, sevaID uint) error
+	RepairSlotCounters(ctx context.Context, entityID uint) ([]SlotCounterRepair, error)
 
 	// Booking limits
 	CountBookingsForSlot(ctx context.Context, sevaID uint, date time.Time, slot string) (int64, error)
 	CountApprovedBookingsForSeva(ctx context.Context, sevaID uint) (int64, error)
 	GetApprovedBookingsCountPerSeva(ctx context.Context, entityID uint) (map[uint]int64, error)
 
+	// Schedules and dated slots
+	CreateSchedule(ctx context.Context, schedule *SevaSchedule, slots []SevaSlot) (int, error)
+	ListSchedules(ctx context.Context, sevaID uint) ([]SevaSchedule, error)
+	GetScheduleByID(ctx context.Context, id uint) (*SevaSchedule, error)
+	DeleteSchedule(ctx context.Context, id uint, from time.Time) (int64, error)
+	GetSlotByID(ctx context.Context, id uint) (*SevaSlot, error)
+	HasActiveSlots(ctx context.Context, sevaID uint, from time.Time) (bool, error)
+	ListSlotAvailability(ctx context.Context, sevaID uint, from, to time.Time) ([]SlotAvailability, error)
+	GetSlotAvailability(ctx context.Context, slotID uint) (*SlotAvailability, error)
+	BookSevaSlot(ctx context.Context, booking *SevaBooking) error
+
+	// Recurring schedules and occurrence edits
+	ListSchedulesToExtend(ctx context.Context, horizon time.Time, limit int) ([]SevaSchedule, error)
+	ExtendSchedule(ctx context.Context, scheduleID uint, slots []SevaSlot, generatedUntil time.Time) (int, error)
+	UpdateSlot(ctx context.Context, slot *SevaSlot) error
+	EndSchedule(ctx context.Context, scheduleID uint, from time.Time) (removed int64, kept int64, err error)
+	SplitSchedule(ctx context.Context, old *SevaSchedule, from time.Time, next *SevaSchedule) (int64, error)
+	ListHeldBookings(ctx context.Context, slotID uint, scheduleID uint, from time.Time) ([]SevaBooking, error)
+	ListUpcomingSlots(ctx context.Context, sevaIDs []uint, now time.Time, perSeva int) ([]SlotAvailability, error)
+
+	// Waitlist
+	CreateWaitlistEntry(ctx context.Context, entry *SevaWaitlistEntry) error
+	GetWaitlistEntry(ctx context.Context, id uint) (*SevaWaitlistEntry, error)
+	ListWaitlist(ctx context.Context, filter WaitlistFilter) ([]SevaWaitlistEntry, error)
+	NextWaitlistEntry(ctx context.Context, sevaID uint, slotID *uint) (*SevaWaitlistEntry, error)
+	OfferWaitlistEntry(ctx context.Context, entryID uint, booking *SevaBooking, expiresAt time.Time) (*SevaWaitlistEntry, error)
+	ConfirmWaitlistOffer(ctx context.Context, entryID uint, toStatus string, changes map[string]interface{}) error
+	CloseWaitlistOffer(ctx context.Context, bookingID uint) error
+	LeaveWaitlist(ctx context.Context, entryID uint) (bool, error)
+	ListExpiredWaitlistOffers(ctx context.Context, now time.Time, limit int) ([]SevaWaitlistEntry, error)
+	ExpireWaitlistOffer(ctx context.Context, entryID uint, now time.Time) (bool, error)
+	ListWaitlistQueues(ctx context.Context, limit int) ([]SevaWaitlistEntry, error)
+	CloseWaitlistQueue(ctx context.Context, sevaID uint, slotID *uint) (int64, error)
+
+	// Sankalpa beneficiaries and priest worksheets
+	GetSankalpaProfile(ctx context.Context, userID, entityID uint) (*userprofile.DevoteeProfile, error)
+	ListWorksheetBookings(ctx context.Context, sevaID uint, day time.Time, unslotted bool) ([]WorksheetBooking, error)
+	GetEntityName(ctx context.Context, entityID uint) (string, error)
+	GetDevoteeName(ctx context.Context, userID uint) (string, error)
+
+	// Variants and add-ons
+	ListVariants(ctx context.Context, sevaID uint, activeOnly bool) ([]SevaVariant, error)
+	ListAddOns(ctx context.Context, sevaID uint, activeOnly bool) ([]SevaAddOn, error)
+	SaveSevaPricing(ctx context.Context, sevaID uint, variants []SevaVariant, addOns []SevaAddOn) error
+
+	// Ticket check-in
+	MarkBookingAttended(ctx context.Context, bookingID, staffID uint, at time.Time) (bool, error)
+
+	// Cancellation policies
+	GetCancellationPolicy(ctx context.Context, entityID, sevaID uint) (*CancellationPolicy, error)
+	ListCancellationPolicies(ctx context.Context, entityID uint) ([]CancellationPolicy, error)
+	SaveCancellationPolicy(ctx context.Context, policy *CancellationPolicy) error
+	DeleteCancellationPolicy(ctx context.Context, entityID, sevaID uint) (int64, error)
+
 	// Composite list with Seva + User info
 	ListBookingsWithDetails(ctx context.Context, entityID uint) ([]DetailedBooking, error)
 
@@ -142,13 +201,50 @@ func (r *repository) GetSevasWithFilters(ctx context.Context, entityID uint, sev
 	return sevas, total, err
 }
 
+// UpdateSeva saves the seva's details. The slot counters are owned by bookings,
+// so they are not written back from the struct: remaining_slots is recomputed
+// from the stored booked_slots and both are read back into the seva.
 func (r *repository) UpdateSeva(ctx context.Context, seva *Seva) error {
-	return r.db.WithContext(ctx).Save(seva).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("booked_slots", "remaining_slots").Save(seva).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Seva{}).
+			Where("id = ?", seva.ID).
+			Update("remaining_slots", gorm.Expr("GREATEST(available_slots - booked_slots, 0)")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Seva{}).
+			Select("booked_slots", "remaining_slots").
+			Where("id = ?", seva.ID).
+			Scan(seva).Error
+	})
 }
 
-// Permanent delete - removes the record completely
+// Permanent delete - removes the record completely, with its schedules, slots
+// and cancellation policy
 func (r *repository) DeleteSeva(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Unscoped().Delete(&Seva{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("seva_id = ?", id).Delete(&SevaSlot{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("seva_id = ?", id).Delete(&SevaSchedule{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("seva_id = ?", id).Delete(&CancellationPolicy{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("seva_id = ?", id).Delete(&SevaWaitlistEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("seva_id = ?", id).Delete(&SevaVariant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("seva_id = ?", id).Delete(&SevaAddOn{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&Seva{}, id).Error
+	})
 }
 
 // -----------------------------------------
@@ -166,38 +262,134 @@ func (r *repository) UpdateSevaSlots(ctx context.Context, sevaID uint, bookedSlo
 		}).Error
 }
 
-// IncrementBookedSlots increments booked_slots by 1 and decrements remaining_slots by 1
-func (r *repository) IncrementBookedSlots(ctx context.Context, sevaID uint) error {
-	return r.db.WithContext(ctx).
-		Model(&Seva{}).
-		Where("id = ?", sevaID).
+// takeSevaPlace moves one place of the seva to booked, unless none is left. A
+// seva with no AvailableSlots takes any number of bookings. The conditional
+// update locks the seva row until the transaction ends.
+func takeSevaPlace(tx *gorm.DB, sevaID uint) error {
+	res := tx.Model(&Seva{}).
+		Where("id = ? AND (available_slots = 0 OR booked_slots < available_slots)", sevaID).
 		Updates(map[string]interface{}{
-			"booked_slots":    gorm.Expr("booked_slots + ?", 1),
-			"remaining_slots": gorm.Expr("remaining_slots - ?", 1),
-		}).Error
+			"booked_slots":    gorm.Expr("booked_slots + 1"),
+			"remaining_slots": gorm.Expr("GREATEST(available_slots - booked_slots - 1, 0)"),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errSevaFull
+	}
+	return nil
 }
 
-// DecrementBookedSlots decrements booked_slots by 1 and increments remaining_slots by 1
-func (r *repository) DecrementBookedSlots(ctx context.Context, sevaID uint) error {
-	return r.db.WithContext(ctx).
-		Model(&Seva{}).
+// releaseSevaPlace gives one booked place of the seva back
+func releaseSevaPlace(tx *gorm.DB, sevaID uint) error {
+	return tx.Model(&Seva{}).
 		Where("id = ?", sevaID).
 		Updates(map[string]interface{}{
-			"booked_slots":    gorm.Expr("booked_slots - ?", 1),
-			"remaining_slots": gorm.Expr("remaining_slots + ?", 1),
+			"booked_slots":    gorm.Expr("GREATEST(booked_slots - 1, 0)"),
+			"remaining_slots": gorm.Expr("GREATEST(available_slots - GREATEST(booked_slots - 1, 0), 0)"),
 		}).Error
 }
 
+// takeSlotPlace locks the dated slot and checks it still has a free place for
+// one more booking. The lock holds until the transaction ends.
+func takeSlotPlace(tx *gorm.DB, slotID uint) (*SevaSlot, error) {
+	var slot SevaSlot
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, slotID).Error; err != nil {
+		return nil, errSlotNotFound
+	}
+	if !slot.IsActive {
+		return nil, errSlotNotFound
+	}
+
+	var held int64
+	if err := tx.Model(&SevaBooking{}).
+		Where("slot_id = ? AND status IN (?)", slot.ID, slotHoldingStatuses).
+		Count(&held).Error; err != nil {
+		return nil, err
+	}
+	if held >= int64(slot.Capacity) {
+		return nil, errSlotFull
+	}
+	return &slot, nil
+}
+
+// RepairSlotCounters recomputes booked_slots and remaining_slots of the sevas
+// of a temple (every temple when entityID is 0) from the bookings holding a
+// place, and returns the sevas whose counters had drifted. The sevas stay
+// locked while they are recounted, so bookings made meanwhile wait for it.
+func (r *repository) RepairSlotCounters(ctx context.Context, entityID uint) ([]SlotCounterRepair, error) {
+	var repairs []SlotCounterRepair
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		locked := tx.Model(&Seva{}).Clauses(clause.Locking{Strength: "UPDATE"})
+		if entityID != 0 {
+			locked = locked.Where("entity_id = ?", entityID)
+		}
+		var ids []uint
+		if err := locked.Order("id").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		var counts []SlotCounterRepair
+		if err := tx.Raw(`
+			SELECT s.id AS seva_id, s.entity_id, s.name, s.available_slots,
+			       s.booked_slots AS old_booked_slots, s.remaining_slots AS old_remaining_slots,
+			       COUNT(b.id) AS booked_slots
+			FROM sevas s
+			LEFT JOIN seva_bookings b ON b.seva_id = s.id AND b.slot_id IS NULL AND b.status IN (?)
+			WHERE s.id IN (?)
+			GROUP BY s.id
+			ORDER BY s.id`, slotHoldingStatuses, ids).
+			Scan(&counts).Error; err != nil {
+			return err
+		}
+
+		for _, count := range counts {
+			count.RemainingSlots = int(remainingPlaces(count.AvailableSlots, int64(count.BookedSlots)))
+			if count.BookedSlots == count.OldBookedSlots && count.RemainingSlots == count.OldRemainingSlots {
+				continue
+			}
+			if err := tx.Model(&Seva{}).
+				Where("id = ?", count.SevaID).
+				Updates(map[string]interface{}{
+					"booked_slots":    count.BookedSlots,
+					"remaining_slots": count.RemainingSlots,
+				}).Error; err != nil {
+				return err
+			}
+			repairs = append(repairs, count)
+		}
+		return nil
+	})
+	return repairs, err
+}
+
 // -----------------------------------------
 // Booking Core
 // -----------------------------------------
+// BookSeva creates a booking made without a dated slot. A booking that holds a
+// place takes it from the seva's counters in the same transaction, failing
+// instead of overbooking once none is left.
 func (r *repository) BookSeva(ctx context.Context, booking *SevaBooking) error {
-	return r.db.WithContext(ctx).Create(booking).Error
+	if booking.Status == "" {
+		booking.Status = "pending"
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if holdsSlot(booking.Status) {
+			if err := takeSevaPlace(tx, booking.SevaID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(booking).Error
+	})
 }
 
 func (r *repository) ListBookingsByUserID(ctx context.Context, userID uint) ([]SevaBooking, error) {
 	var bookings []SevaBooking
-	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&bookings).Error
+	err := r.db.WithContext(ctx).Preload("Beneficiaries").Preload("Items").Where("user_id = ?", userID).Find(&bookings).Error
 	return bookings, err
 }
 
@@ -214,14 +406,70 @@ func (r *repository) UpdateBookingStatus(ctx context.Context, bookingID uint, ne
 		Update("status", newStatus).Error
 }
 
+// TransitionBooking moves a booking to toStatus, along with any other column
+// changes, in one transaction. The booking row is locked first so concurrent
+// changes of the same booking run one after the other. Moving into a status
+// that holds a place takes one from the booking's slot or seva, refusing when
+// none is left; moving out of one gives the place back. When fromStatus is set
+// the booking must still be in it. Returns the status the booking moved from.
+func (r *repository) TransitionBooking(ctx context.Context, bookingID uint, fromStatus, toStatus string, changes map[string]interface{}) (string, error) {
+	var oldStatus string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		oldStatus, err = transitionBooking(tx, bookingID, fromStatus, toStatus, changes)
+		return err
+	})
+	return oldStatus, err
+}
+
+// transitionBooking is TransitionBooking within the caller's transaction
+func transitionBooking(tx *gorm.DB, bookingID uint, fromStatus, toStatus string, changes map[string]interface{}) (string, error) {
+	var booking SevaBooking
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&booking, bookingID).Error; err != nil {
+		return "", err
+	}
+	if fromStatus != "" && booking.Status != fromStatus {
+		return booking.Status, errBookingChanged
+	}
+
+	switch {
+	case !holdsSlot(booking.Status) && holdsSlot(toStatus):
+		if booking.SlotID != nil {
+			if _, err := takeSlotPlace(tx, *booking.SlotID); err != nil {
+				return booking.Status, err
+			}
+		} else if err := takeSevaPlace(tx, booking.SevaID); err != nil {
+			return booking.Status, err
+		}
+	case holdsSlot(booking.Status) && !holdsSlot(toStatus) && booking.SlotID == nil:
+		if err := releaseSevaPlace(tx, booking.SevaID); err != nil {
+			return booking.Status, err
+		}
+	}
+
+	updates := map[string]interface{}{"status": toStatus}
+	for column, value := range changes {
+		updates[column] = value
+	}
+	oldStatus := booking.Status
+	return oldStatus, tx.Model(&booking).Updates(updates).Error
+}
+
 // -----------------------------------------
 // Booking Limit Checker
 // -----------------------------------------
+
+// CountBookingsForSlot counts the bookings holding a place in the seva's slot
+// starting at the given time (HH:mm) on the date
 func (r *repository) CountBookingsForSlot(ctx context.Context, sevaID uint, date time.Time, slot string) (int64, error) {
 	var count int64
-	// This can be implemented based on your specific slot booking requirements
-	// For now, returning 0 as placeholder
-	return count, nil
+	err := r.db.WithContext(ctx).
+		Table("seva_bookings AS b").
+		Joins("JOIN seva_slots sl ON sl.id = b.slot_id").
+		Where("b.seva_id = ? AND sl.slot_date = ? AND sl.start_time = ? AND b.status IN (?)",
+			sevaID, date.Format("2006-01-02"), slot, slotHoldingStatuses).
+		Count(&count).Error
+	return count, err
 }
 
 // Count only approved bookings for a specific seva
@@ -262,6 +510,801 @@ func (r *repository) GetApprovedBookingsCountPerSeva(ctx context.Context, entity
 	return countMap, nil
 }
 
+// -----------------------------------------
+// Schedules & Dated Slots
+// -----------------------------------------
+
+// CreateSchedule saves the schedule and the slots it generates. Slots that
+// already exist at the same date and start time are left as they are; the
+// number of slots actually created is returned.
+func (r *repository) CreateSchedule(ctx context.Context, schedule *SevaSchedule, slots []SevaSlot) (int, error) {
+	created := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(schedule).Error; err != nil {
+			return err
+		}
+		for i := range slots {
+			slots[i].ScheduleID = &schedule.ID
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&slots[i])
+			if res.Error != nil {
+				return res.Error
+			}
+			created += int(res.RowsAffected)
+		}
+		return nil
+	})
+	return created, err
+}
+
+func (r *repository) ListSchedules(ctx context.Context, sevaID uint) ([]SevaSchedule, error) {
+	var schedules []SevaSchedule
+	err := r.db.WithContext(ctx).
+		Where("seva_id = ?", sevaID).
+		Order("start_date ASC, start_time ASC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *repository) GetScheduleByID(ctx context.Context, id uint) (*SevaSchedule, error) {
+	var schedule SevaSchedule
+	err := r.db.WithContext(ctx).First(&schedule, id).Error
+	return &schedule, err
+}
+
+// DeleteSchedule removes the schedule and its slots from the date on that no
+// booking references. Slots with bookings stay bookable on their own.
+func (r *repository) DeleteSchedule(ctx context.Context, id uint, from time.Time) (int64, error) {
+	var removed int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("schedule_id = ? AND slot_date >= ? AND NOT EXISTS (SELECT 1 FROM seva_bookings b WHERE b.slot_id = seva_slots.id)",
+			id, from.Format("2006-01-02")).
+			Delete(&SevaSlot{})
+		if res.Error != nil {
+			return res.Error
+		}
+		removed = res.RowsAffected
+		if err := tx.Model(&SevaSlot{}).Where("schedule_id = ?", id).Update("schedule_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&SevaSchedule{}, id).Error
+	})
+	return removed, err
+}
+
+func (r *repository) GetSlotByID(ctx context.Context, id uint) (*SevaSlot, error) {
+	var slot SevaSlot
+	err := r.db.WithContext(ctx).First(&slot, id).Error
+	return &slot, err
+}
+
+// HasActiveSlots reports whether the seva has bookable slots from the date on
+func (r *repository) HasActiveSlots(ctx context.Context, sevaID uint, from time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&SevaSlot{}).
+		Where("seva_id = ? AND is_active = ? AND slot_date >= ?", sevaID, true, from.Format("2006-01-02")).
+		Count(&count).Error
+	return count > 0, err
+}
+
+const slotHeldJoin = `
+	LEFT JOIN (
+		SELECT slot_id, COUNT(*) AS booked
+		FROM seva_bookings
+		WHERE slot_id IS NOT NULL AND status IN (?)
+		GROUP BY slot_id
+	) b ON b.slot_id = sl.id`
+
+const slotAvailabilitySelect = `
+	SELECT sl.*, COALESCE(b.booked, 0) AS booked
+	FROM seva_slots sl` + slotHeldJoin
+
+// ListSlotAvailability returns the seva's active slots between the dates
+// (inclusive) with the places already held in each
+func (r *repository) ListSlotAvailability(ctx context.Context, sevaID uint, from, to time.Time) ([]SlotAvailability, error) {
+	var slots []SlotAvailability
+	err := r.db.WithContext(ctx).Raw(slotAvailabilitySelect+`
+		WHERE sl.seva_id = ? AND sl.is_active = TRUE AND sl.slot_date BETWEEN ? AND ?
+		ORDER BY sl.slot_date ASC, sl.start_time ASC
+	`, slotHoldingStatuses, sevaID, from.Format("2006-01-02"), to.Format("2006-01-02")).Scan(&slots).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range slots {
+		slots[i].Remaining = remainingPlaces(slots[i].Capacity, slots[i].Booked)
+	}
+	return slots, nil
+}
+
+func (r *repository) GetSlotAvailability(ctx context.Context, slotID uint) (*SlotAvailability, error) {
+	var slots []SlotAvailability
+	err := r.db.WithContext(ctx).Raw(slotAvailabilitySelect+`
+		WHERE sl.id = ?
+	`, slotHoldingStatuses, slotID).Scan(&slots).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	slots[0].Remaining = remainingPlaces(slots[0].Capacity, slots[0].Booked)
+	return &slots[0], nil
+}
+
+// BookSevaSlot creates the booking if its slot still has a free place. The
+// slot row stays locked until the booking is inserted, so concurrent bookings
+// of the same slot cannot overfill it.
+func (r *repository) BookSevaSlot(ctx context.Context, booking *SevaBooking) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		slot, err := takeSlotPlace(tx, *booking.SlotID)
+		if err != nil {
+			return err
+		}
+		if slot.SevaID != booking.SevaID {
+			return errSlotNotFound
+		}
+		return tx.Create(booking).Error
+	})
+}
+
+// ListSchedulesToExtend returns the schedules whose slots are not yet
+// generated up to the horizon and that have not reached their end date
+func (r *repository) ListSchedulesToExtend(ctx context.Context, horizon time.Time, limit int) ([]SevaSchedule, error) {
+	var schedules []SevaSchedule
+	err := r.db.WithContext(ctx).
+		Where("generated_until < ? AND (end_date IS NULL OR generated_until < end_date)", horizon.Format("2006-01-02")).
+		Order("generated_until ASC").
+		Limit(limit).
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// ExtendSchedule adds newly generated slots and moves generated_until on
+func (r *repository) ExtendSchedule(ctx context.Context, scheduleID uint, slots []SevaSlot, generatedUntil time.Time) (int, error) {
+	created := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range slots {
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&slots[i])
+			if res.Error != nil {
+				return res.Error
+			}
+			created += int(res.RowsAffected)
+		}
+		return tx.Model(&SevaSchedule{}).
+			Where("id = ?", scheduleID).
+			Update("generated_until", generatedUntil.Format("2006-01-02")).Error
+	})
+	return created, err
+}
+
+func (r *repository) UpdateSlot(ctx context.Context, slot *SevaSlot) error {
+	return r.db.WithContext(ctx).Save(slot).Error
+}
+
+// futureSlotsWithoutBookings matches a schedule's slots from a date on that
+// were not edited on their own and that no booking references
+const futureSlotsWithoutBookings = "schedule_id = ? AND slot_date >= ? AND detached = FALSE AND NOT EXISTS (SELECT 1 FROM seva_bookings b WHERE b.slot_id = seva_slots.id)"
+
+// EndSchedule ends the schedule the day before from. Its later slots without
+// bookings are removed; the number of booked slots left in place is returned.
+func (r *repository) EndSchedule(ctx context.Context, scheduleID uint, from time.Time) (int64, int64, error) {
+	var removed, kept int64
+	lastDay := from.AddDate(0, 0, -1).Format("2006-01-02")
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&SevaSchedule{}).
+			Where("id = ?", scheduleID).
+			Updates(map[string]interface{}{
+				"end_date":        lastDay,
+				"generated_until": gorm.Expr("LEAST(generated_until, ?::date)", lastDay),
+			}).Error; err != nil {
+			return err
+		}
+		res := tx.Where(futureSlotsWithoutBookings, scheduleID, from.Format("2006-01-02")).Delete(&SevaSlot{})
+		if res.Error != nil {
+			return res.Error
+		}
+		removed = res.RowsAffected
+		return tx.Model(&SevaSlot{}).
+			Where("schedule_id = ? AND slot_date >= ? AND detached = FALSE AND is_active = TRUE", scheduleID, from.Format("2006-01-02")).
+			Count(&kept).Error
+	})
+	return removed, kept, err
+}
+
+// SplitSchedule ends old the day before from and continues it as next: the
+// old schedule's slots from then on, except those edited on their own, move to
+// next with its times and capacity, never below the places already held. A
+// schedule split on its first day is replaced entirely.
+func (r *repository) SplitSchedule(ctx context.Context, old *SevaSchedule, from time.Time, next *SevaSchedule) (int64, error) {
+	var moved int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+
+		res := tx.Model(&SevaSlot{}).
+			Where("schedule_id = ? AND slot_date >= ? AND detached = FALSE", old.ID, from.Format("2006-01-02")).
+			Updates(map[string]interface{}{
+				"schedule_id": next.ID,
+				"start_time":  next.StartTime,
+				"end_time":    next.EndTime,
+				"capacity": gorm.Expr("GREATEST(?, (SELECT COUNT(*) FROM seva_bookings b WHERE b.slot_id = seva_slots.id AND b.status IN (?)))",
+					next.Capacity, slotHoldingStatuses),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		moved = res.RowsAffected
+
+		if !from.After(old.StartDate) {
+			if err := tx.Model(&SevaSlot{}).Where("schedule_id = ?", old.ID).Update("schedule_id", next.ID).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&SevaSchedule{}, old.ID).Error
+		}
+		lastDay := from.AddDate(0, 0, -1).Format("2006-01-02")
+		return tx.Model(&SevaSchedule{}).
+			Where("id = ?", old.ID).
+			Updates(map[string]interface{}{
+				"end_date":        lastDay,
+				"generated_until": gorm.Expr("LEAST(generated_until, ?::date)", lastDay),
+			}).Error
+	})
+	return moved, err
+}
+
+// ListHeldBookings returns the bookings holding a place in a slot, or in
+// a schedule's slots from a date on that were not edited on their own
+func (r *repository) ListHeldBookings(ctx context.Context, slotID uint, scheduleID uint, from time.Time) ([]SevaBooking, error) {
+	var bookings []SevaBooking
+	query := r.db.WithContext(ctx).
+		Table("seva_bookings AS b").
+		Select("b.*").
+		Where("b.status IN (?)", slotHoldingStatuses)
+	if slotID != 0 {
+		query = query.Where("b.slot_id = ?", slotID)
+	} else {
+		query = query.
+			Joins("JOIN seva_slots sl ON sl.id = b.slot_id").
+			Where("sl.schedule_id = ? AND sl.slot_date >= ? AND sl.detached = FALSE", scheduleID, from.Format("2006-01-02"))
+	}
+	err := query.Scan(&bookings).Error
+	return bookings, err
+}
+
+// ListUpcomingSlots returns up to perSeva active slots of each seva that have
+// not started yet, earliest first
+func (r *repository) ListUpcomingSlots(ctx context.Context, sevaIDs []uint, now time.Time, perSeva int) ([]SlotAvailability, error) {
+	var slots []SlotAvailability
+	if len(sevaIDs) == 0 {
+		return slots, nil
+	}
+	day, clock := now.Format("2006-01-02"), now.Format("15:04")
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT * FROM (
+			SELECT sl.*, COALESCE(b.booked, 0) AS booked,
+				ROW_NUMBER() OVER (PARTITION BY sl.seva_id ORDER BY sl.slot_date, sl.start_time) AS occurrence_rank
+			FROM seva_slots sl`+slotHeldJoin+`
+			WHERE sl.seva_id IN (?) AND sl.is_active = TRUE
+				AND (sl.slot_date > ? OR (sl.slot_date = ? AND sl.start_time >= ?))
+		) o
+		WHERE o.occurrence_rank <= ?
+		ORDER BY o.seva_id, o.slot_date, o.start_time
+	`, slotHoldingStatuses, sevaIDs, day, day, clock, perSeva).Scan(&slots).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range slots {
+		slots[i].Remaining = remainingPlaces(slots[i].Capacity, slots[i].Booked)
+	}
+	return slots, nil
+}
+
+// -----------------------------------------
+// Waitlist
+// -----------------------------------------
+
+// waitlistQueue narrows a query to the waitlist of a seva, or of one of its slots
+func waitlistQueue(db *gorm.DB, sevaID uint, slotID *uint) *gorm.DB {
+	if slotID == nil {
+		return db.Where("seva_id = ? AND slot_id IS NULL", sevaID)
+	}
+	return db.Where("seva_id = ? AND slot_id = ?", sevaID, *slotID)
+}
+
+// CreateWaitlistEntry adds the devotee at the end of the queue, unless they
+// are already waiting in it or holding an offer from it
+func (r *repository) CreateWaitlistEntry(ctx context.Context, entry *SevaWaitlistEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var active int64
+		if err := waitlistQueue(tx.Model(&SevaWaitlistEntry{}), entry.SevaID, entry.SlotID).
+			Where("user_id = ? AND status IN (?)", entry.UserID, []string{WaitlistWaiting, WaitlistOffered}).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return errAlreadyWaitlisted
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *repository) GetWaitlistEntry(ctx context.Context, id uint) (*SevaWaitlistEntry, error) {
+	entries, err := r.ListWaitlist(ctx, WaitlistFilter{ID: id})
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &entries[0], nil
+}
+
+// ListWaitlist returns the matching entries in queue order, with the position
+// of the ones still waiting
+func (r *repository) ListWaitlist(ctx context.Context, filter WaitlistFilter) ([]SevaWaitlistEntry, error) {
+	query := r.db.WithContext(ctx).
+		Table("seva_waitlist_entries AS w").
+		Select(`w.*, CASE WHEN w.status = ? THEN (
+			SELECT COUNT(*) FROM seva_waitlist_entries q
+			WHERE q.seva_id = w.seva_id AND q.slot_id IS NOT DISTINCT FROM w.slot_id
+				AND q.status = ? AND q.id <= w.id
+		) ELSE 0 END AS position`, WaitlistWaiting, WaitlistWaiting)
+
+	if filter.ID != 0 {
+		query = query.Where("w.id = ?", filter.ID)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("w.entity_id = ?", filter.EntityID)
+	}
+	if filter.SevaID != 0 {
+		query = query.Where("w.seva_id = ?", filter.SevaID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("w.user_id = ?", filter.UserID)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("w.status IN (?)", filter.Statuses)
+	}
+
+	var entries []SevaWaitlistEntry
+	err := query.Order("w.id ASC").Scan(&entries).Error
+	return entries, err
+}
+
+// NextWaitlistEntry returns the first devotee waiting for the seva, or for
+// its slot; nil when nobody is
+func (r *repository) NextWaitlistEntry(ctx context.Context, sevaID uint, slotID *uint) (*SevaWaitlistEntry, error) {
+	var entries []SevaWaitlistEntry
+	if err := waitlistQueue(r.db.WithContext(ctx), sevaID, slotID).
+		Where("status = ?", WaitlistWaiting).
+		Order("id ASC").
+		Limit(1).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
+// OfferWaitlistEntry holds a free place of the entry's seva, or of its slot,
+// in booking, created with status "offered" until expiresAt. Returns nil when
+// no place is free, and errOfferClosed when the entry is no longer waiting.
+func (r *repository) OfferWaitlistEntry(ctx context.Context, entryID uint, booking *SevaBooking, expiresAt time.Time) (*SevaWaitlistEntry, error) {
+	var offered *SevaWaitlistEntry
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entry SevaWaitlistEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, entryID).Error; err != nil {
+			return err
+		}
+		if entry.Status != WaitlistWaiting {
+			return errOfferClosed
+		}
+
+		var err error
+		if entry.SlotID != nil {
+			_, err = takeSlotPlace(tx, *entry.SlotID)
+		} else {
+			err = takeSevaPlace(tx, entry.SevaID)
+		}
+		if errors.Is(err, errSlotFull) || errors.Is(err, errSevaFull) || errors.Is(err, errSlotNotFound) {
+			return errNoPlaceToOffer
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		booking.SevaID, booking.SlotID = entry.SevaID, entry.SlotID
+		booking.UserID, booking.EntityID = entry.UserID, entry.EntityID
+		booking.BookingTime, booking.Status = now, "offered"
+		if err := tx.Create(booking).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&entry).Updates(map[string]interface{}{
+			"status":           WaitlistOffered,
+			"booking_id":       booking.ID,
+			"offered_at":       now,
+			"offer_expires_at": expiresAt,
+		}).Error; err != nil {
+			return err
+		}
+		entry.Status, entry.BookingID, entry.OfferedAt, entry.OfferExpiresAt = WaitlistOffered, &booking.ID, &now, &expiresAt
+		offered = &entry
+		return nil
+	})
+	if errors.Is(err, errNoPlaceToOffer) {
+		return nil, nil
+	}
+	return offered, err
+}
+
+// ConfirmWaitlistOffer moves the booking held by an open offer from "offered"
+// to bookingStatus, with any other column changes. The entry is confirmed
+// unless the booking stays offered, as it does until a paid offer is paid.
+func (r *repository) ConfirmWaitlistOffer(ctx context.Context, entryID uint, bookingStatus string, changes map[string]interface{}) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entry SevaWaitlistEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, entryID).Error; err != nil {
+			return err
+		}
+		if entry.Status != WaitlistOffered || entry.BookingID == nil {
+			return errOfferClosed
+		}
+		if entry.OfferExpiresAt != nil && !time.Now().Before(*entry.OfferExpiresAt) {
+			return errOfferClosed
+		}
+
+		if _, err := transitionBooking(tx, *entry.BookingID, "offered", bookingStatus, changes); err != nil {
+			return err
+		}
+		if bookingStatus == "offered" {
+			return nil
+		}
+		return tx.Model(&entry).Update("status", WaitlistConfirmed).Error
+	})
+}
+
+// CloseWaitlistOffer confirms the offer that held the booking, once the
+// booking has been paid
+func (r *repository) CloseWaitlistOffer(ctx context.Context, bookingID uint) error {
+	return r.db.WithContext(ctx).
+		Model(&SevaWaitlistEntry{}).
+		Where("booking_id = ? AND status = ?", bookingID, WaitlistOffered).
+		Update("status", WaitlistConfirmed).Error
+}
+
+// LeaveWaitlist takes the devotee off the queue. Declining an open offer gives
+// its place back, reported by the returned flag.
+func (r *repository) LeaveWaitlist(ctx context.Context, entryID uint) (bool, error) {
+	released := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entry SevaWaitlistEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, entryID).Error; err != nil {
+			return err
+		}
+
+		switch entry.Status {
+		case WaitlistWaiting:
+		case WaitlistOffered:
+			if _, err := transitionBooking(tx, *entry.BookingID, "offered", "cancelled", map[string]interface{}{
+				"cancelled_at":        time.Now(),
+				"cancellation_reason": "Waitlist offer declined",
+			}); err != nil {
+				if errors.Is(err, errBookingChanged) {
+					return errOfferClosed
+				}
+				return err
+			}
+			released = true
+		default:
+			return errOfferClosed
+		}
+		return tx.Model(&entry).Update("status", WaitlistLeft).Error
+	})
+	return released, err
+}
+
+func (r *repository) ListExpiredWaitlistOffers(ctx context.Context, now time.Time, limit int) ([]SevaWaitlistEntry, error) {
+	var entries []SevaWaitlistEntry
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND offer_expires_at <= ?", WaitlistOffered, now).
+		Order("offer_expires_at ASC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+// ExpireWaitlistOffer closes an offer that ran out. A booking still only
+// offered gives its place back, reported by the returned flag; one the devotee
+// confirmed or paid for meanwhile confirms the entry instead.
+func (r *repository) ExpireWaitlistOffer(ctx context.Context, entryID uint, now time.Time) (bool, error) {
+	released := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entry SevaWaitlistEntry
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, entryID).Error; err != nil {
+			return err
+		}
+		if entry.Status != WaitlistOffered || entry.OfferExpiresAt == nil || entry.OfferExpiresAt.After(now) {
+			return nil
+		}
+
+		status := WaitlistExpired
+		oldStatus, err := transitionBooking(tx, *entry.BookingID, "offered", "expired", nil)
+		switch {
+		case err == nil:
+			released = true
+		case errors.Is(err, errBookingChanged):
+			if holdsSlot(oldStatus) {
+				status = WaitlistConfirmed
+			}
+		default:
+			return err
+		}
+		return tx.Model(&entry).Update("status", status).Error
+	})
+	return released, err
+}
+
+// ListWaitlistQueues returns the first waiting entry of every queue
+func (r *repository) ListWaitlistQueues(ctx context.Context, limit int) ([]SevaWaitlistEntry, error) {
+	var entries []SevaWaitlistEntry
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (seva_id, slot_id) *
+		FROM seva_waitlist_entries
+		WHERE status = ?
+		ORDER BY seva_id, slot_id, id
+		LIMIT ?
+	`, WaitlistWaiting, limit).Scan(&entries).Error
+	return entries, err
+}
+
+// CloseWaitlistQueue expires the entries still waiting for an occurrence that
+// can no longer be booked
+func (r *repository) CloseWaitlistQueue(ctx context.Context, sevaID uint, slotID *uint) (int64, error) {
+	result := waitlistQueue(r.db.WithContext(ctx).Model(&SevaWaitlistEntry{}), sevaID, slotID).
+		Where("status = ?", WaitlistWaiting).
+		Update("status", WaitlistExpired)
+	return result.RowsAffected, result.Error
+}
+
+// -----------------------------------------
+// Sankalpa & Priest Worksheets
+// -----------------------------------------
+
+// GetSankalpaProfile returns the devotee's profile with their children,
+// preferring the one kept for the temple. A devotee without a profile gets an
+// empty one carrying their account name.
+func (r *repository) GetSankalpaProfile(ctx context.Context, userID, entityID uint) (*userprofile.DevoteeProfile, error) {
+	var profiles []userprofile.DevoteeProfile
+	if err := r.db.WithContext(ctx).
+		Preload("Children").
+		Where("user_id = ?", userID).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "entity_id = ? DESC, id DESC", Vars: []interface{}{entityID}}}).
+		Limit(1).
+		Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+
+	profile := &userprofile.DevoteeProfile{UserID: userID, EntityID: entityID}
+	if len(profiles) > 0 {
+		profile = &profiles[0]
+	}
+	if profile.FullName == nil || strings.TrimSpace(*profile.FullName) == "" {
+		var name string
+		if err := r.db.WithContext(ctx).Table("users").Select("full_name").Where("id = ?", userID).Scan(&name).Error; err != nil {
+			return nil, err
+		}
+		profile.FullName = &name
+	}
+	return profile, nil
+}
+
+// ListWorksheetBookings returns the seva's approved bookings in its slots on
+// the day, and its bookings without a slot when unslotted is set, with their
+// beneficiaries, in order of start time
+func (r *repository) ListWorksheetBookings(ctx context.Context, sevaID uint, day time.Time, unslotted bool) ([]WorksheetBooking, error) {
+	var rows []WorksheetBooking
+	err := r.db.WithContext(ctx).
+		Table("seva_bookings AS b").
+		Select("b.id AS booking_id, b.user_id, u.full_name AS devotee_name, u.phone AS devotee_phone, sl.start_time, sl.end_time").
+		Joins("JOIN users u ON u.id = b.user_id").
+		Joins("LEFT JOIN seva_slots sl ON sl.id = b.slot_id").
+		Where("b.seva_id = ? AND b.status = ?", sevaID, "approved").
+		Where("(sl.slot_date = ? OR (b.slot_id IS NULL AND ?))", day.Format("2006-01-02"), unslotted).
+		Order("sl.start_time ASC NULLS FIRST, b.id ASC").
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return rows, err
+	}
+
+	ids := make([]uint, len(rows))
+	for i := range rows {
+		ids[i] = rows[i].BookingID
+	}
+	var beneficiaries []SevaBeneficiary
+	if err := r.db.WithContext(ctx).
+		Where("booking_id IN (?)", ids).
+		Order("id ASC").
+		Find(&beneficiaries).Error; err != nil {
+		return nil, err
+	}
+
+	byBooking := make(map[uint][]SevaBeneficiary, len(rows))
+	for _, beneficiary := range beneficiaries {
+		byBooking[beneficiary.BookingID] = append(byBooking[beneficiary.BookingID], beneficiary)
+	}
+	for i := range rows {
+		rows[i].Beneficiaries = byBooking[rows[i].BookingID]
+	}
+	return rows, nil
+}
+
+func (r *repository) GetEntityName(ctx context.Context, entityID uint) (string, error) {
+	var name string
+	err := r.db.WithContext(ctx).
+		Table("entities").
+		Select("name").
+		Where("id = ?", entityID).
+		Scan(&name).Error
+	return name, err
+}
+
+func (r *repository) GetDevoteeName(ctx context.Context, userID uint) (string, error) {
+	var name string
+	err := r.db.WithContext(ctx).
+		Table("users").
+		Select("full_name").
+		Where("id = ?", userID).
+		Scan(&name).Error
+	return name, err
+}
+
+// -----------------------------------------
+// Variants & Add-ons
+// -----------------------------------------
+
+func (r *repository) ListVariants(ctx context.Context, sevaID uint, activeOnly bool) ([]SevaVariant, error) {
+	var variants []SevaVariant
+	query := r.db.WithContext(ctx).Where("seva_id = ?", sevaID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("sort_order ASC, id ASC").Find(&variants).Error
+	return variants, err
+}
+
+func (r *repository) ListAddOns(ctx context.Context, sevaID uint, activeOnly bool) ([]SevaAddOn, error) {
+	var addOns []SevaAddOn
+	query := r.db.WithContext(ctx).Where("seva_id = ?", sevaID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("sort_order ASC, id ASC").Find(&addOns).Error
+	return addOns, err
+}
+
+// SaveSevaPricing replaces the seva's variants and add-ons in one
+// transaction: listed ones with an ID are updated, new ones created, and the
+// rest deactivated rather than deleted, as bookings may name them
+func (r *repository) SaveSevaPricing(ctx context.Context, sevaID uint, variants []SevaVariant, addOns []SevaAddOn) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		kept := make([]uint, 0, len(variants))
+		for i := range variants {
+			v := &variants[i]
+			v.SevaID = sevaID
+			if err := savePricingRow(tx, v, v.ID, sevaID, "variant", "name", "description", "price", "sort_order", "is_active", "updated_at"); err != nil {
+				return err
+			}
+			kept = append(kept, v.ID)
+		}
+		if err := deactivateOthers(tx, &SevaVariant{}, sevaID, kept); err != nil {
+			return err
+		}
+
+		kept = make([]uint, 0, len(addOns))
+		for i := range addOns {
+			a := &addOns[i]
+			a.SevaID = sevaID
+			if err := savePricingRow(tx, a, a.ID, sevaID, "add-on", "name", "description", "price", "max_quantity", "sort_order", "is_active", "updated_at"); err != nil {
+				return err
+			}
+			kept = append(kept, a.ID)
+		}
+		return deactivateOthers(tx, &SevaAddOn{}, sevaID, kept)
+	})
+}
+
+// savePricingRow creates a new variant or add-on, or updates the columns of
+// an existing one of the same seva
+func savePricingRow(tx *gorm.DB, row interface{}, id, sevaID uint, what string, columns ...string) error {
+	if id == 0 {
+		return tx.Create(row).Error
+	}
+	res := tx.Model(row).Where("seva_id = ?", sevaID).Select(columns).Updates(row)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%s %d not found for this seva", what, id)
+	}
+	return nil
+}
+
+func deactivateOthers(tx *gorm.DB, model interface{}, sevaID uint, kept []uint) error {
+	query := tx.Model(model).Where("seva_id = ?", sevaID)
+	if len(kept) > 0 {
+		query = query.Where("id NOT IN (?)", kept)
+	}
+	return query.Update("is_active", false).Error
+}
+
+// -----------------------------------------
+// Ticket Check-in
+// -----------------------------------------
+
+// MarkBookingAttended records the check-in of an approved booking. Reports
+// false when the booking was already checked in or is no longer approved, so
+// a ticket shown twice at once is let in only once.
+func (r *repository) MarkBookingAttended(ctx context.Context, bookingID, staffID uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&SevaBooking{}).
+		Where("id = ? AND status = ? AND attended_at IS NULL", bookingID, "approved").
+		Updates(map[string]interface{}{
+			"attended_at": at,
+			"attended_by": staffID,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// -----------------------------------------
+// Cancellation Policies
+// -----------------------------------------
+
+// GetCancellationPolicy returns the temple's policy for the seva, or its
+// temple-wide policy when sevaID is 0; nil when none is set
+func (r *repository) GetCancellationPolicy(ctx context.Context, entityID, sevaID uint) (*CancellationPolicy, error) {
+	var policies []CancellationPolicy
+	err := r.db.WithContext(ctx).
+		Where("entity_id = ? AND seva_id = ?", entityID, sevaID).
+		Limit(1).
+		Find(&policies).Error
+	if err != nil || len(policies) == 0 {
+		return nil, err
+	}
+	return &policies[0], nil
+}
+
+func (r *repository) ListCancellationPolicies(ctx context.Context, entityID uint) ([]CancellationPolicy, error) {
+	var policies []CancellationPolicy
+	err := r.db.WithContext(ctx).
+		Where("entity_id = ?", entityID).
+		Order("seva_id ASC").
+		Find(&policies).Error
+	return policies, err
+}
+
+// SaveCancellationPolicy creates the policy or replaces the one already set
+// for the same temple and seva
+func (r *repository) SaveCancellationPolicy(ctx context.Context, policy *CancellationPolicy) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "entity_id"}, {Name: "seva_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"cutoff_hours", "non_refundable", "refund_tiers", "updated_by", "updated_at"}),
+		}).
+		Create(policy).Error
+}
+
+func (r *repository) DeleteCancellationPolicy(ctx context.Context, entityID, sevaID uint) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("entity_id = ? AND seva_id = ?", entityID, sevaID).
+		Delete(&CancellationPolicy{})
+	return res.RowsAffected, res.Error
+}
+
 // -----------------------------------------
 // Detailed Booking Listing
 // -----------------------------------------
@@ -288,14 +1331,46 @@ func (r *repository) ListBookingsWithDetails(ctx context.Context, entityID uint)
 		WHERE b.entity_id = ?
 		ORDER BY b.booking_time DESC
 	`, entityID).Scan(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return results, r.attachBookingItems(ctx, results)
+}
 
-	return results, err
+// attachBookingItems loads the priced lines of the listed bookings
+func (r *repository) attachBookingItems(ctx context.Context, bookings []DetailedBooking) error {
+	if len(bookings) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(bookings))
+	for i := range bookings {
+		ids[i] = bookings[i].ID
+	}
+	var items []SevaBookingItem
+	if err := r.db.WithContext(ctx).
+		Where("booking_id IN (?)", ids).
+		Order("id ASC").
+		Find(&items).Error; err != nil {
+		return err
+	}
+
+	byBooking := make(map[uint][]SevaBookingItem, len(bookings))
+	for _, item := range items {
+		byBooking[item.BookingID] = append(byBooking[item.BookingID], item)
+	}
+	for i := range bookings {
+		bookings[i].Items = byBooking[bookings[i].ID]
+	}
+	return nil
 }
 
 // View Booking by ID (for view modal)
 func (r *repository) GetBookingByID(ctx context.Context, bookingID uint) (*SevaBooking, error) {
 	var booking SevaBooking
 	err := r.db.WithContext(ctx).
+		Preload("Beneficiaries").
+		Preload("Items").
 		Where("id = ?", bookingID).
 		First(&booking).Error
 	return &booking, err
@@ -349,8 +1424,10 @@ func (r *repository) SearchBookingsWithFilters(ctx context.Context, filter Booki
 		query = query.Limit(filter.Limit).Offset(filter.Offset)
 	}
 
-	err := query.Scan(&results).Error
-	return results, total, err
+	if err := query.Scan(&results).Error; err != nil {
+		return nil, 0, err
+	}
+	return results, total, r.attachBookingItems(ctx, results)
 }
 
 // Get Counts by Status
@@ -362,6 +1439,7 @@ func (r *repository) CountBookingsByStatus(ctx context.Context, entityID uint) (
 	counts.Approved = 0
 	counts.Pending = 0
 	counts.Rejected = 0
+	counts.Cancelled = 0
 
 	// Use raw SQL for better performance
 	rows, err := r.db.WithContext(ctx).Raw(`
@@ -394,11 +1472,12 @@ func (r *repository) CountBookingsByStatus(ctx context.Context, entityID uint) (
 	counts.Approved = statusCounts["approved"]
 	counts.Pending = statusCounts["pending"]
 	counts.Rejected = statusCounts["rejected"]
+	counts.Cancelled = statusCounts["cancelled"]
 
 	return counts, nil
 }
 
-// GetBookingByOrderID retrieves a booking by Razorpay order ID
+// GetBookingByOrderID retrieves a booking by gateway order ID
 func (r *repository) GetBookingByOrderID(ctx context.Context, orderID string) (*SevaBooking, error) {
 	var booking SevaBooking
 	err := r.db.WithContext(ctx).
@@ -412,6 +1491,19 @@ func (r *repository) UpdateSevaBooking(ctx context.Context, booking *SevaBooking
 	return r.db.WithContext(ctx).Save(booking).Error
 }
 
+// ListStalePaymentBookings returns pending bookings, and waitlist offers being
+// paid for, with a gateway order that were created before the cutoff and never
+// had their payment verified
+func (r *repository) ListStalePaymentBookings(ctx context.Context, createdBefore time.Time, limit int) ([]SevaBooking, error) {
+	var bookings []SevaBooking
+	err := r.db.WithContext(ctx).
+		Where("status IN (?) AND razorpay_order_id <> '' AND payment_verified_at IS NULL AND created_at < ?", []string{"pending", "offered"}, createdBefore).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&bookings).Error
+	return bookings, err
+}
+
 // GetTenantIDByEntityID returns the tenant (created_by) for a given entity.
 func (r *repository) GetTenantIDByEntityID(entityID uint) (uint, error) {
 	var createdBy uint
@@ -424,29 +1516,32 @@ func (r *repository) GetTenantIDByEntityID(entityID uint) (uint, error) {
 	}
 	return createdBy, nil
 }
-func (r *repository) GetRazorpayKeysByEntityID(entityID uint) (string, string, error) {
+// GetPaymentSettingsByEntityID returns the temple's payment provider and credentials
+func (r *repository) GetPaymentSettingsByEntityID(entityID uint) (payment.TenantSettings, error) {
 	// Step 1: Get tenant (created_by) for this entity
 	var createdBy uint
 	if err := r.db.Table("entities").
 		Select("created_by").
 		Where("id = ?", entityID).
 		Scan(&createdBy).Error; err != nil {
-		return "", "", fmt.Errorf("entity not found: %v", err)
+		return payment.TenantSettings{}, fmt.Errorf("entity not found: %v", err)
 	}
 	if createdBy == 0 {
-		return "", "", errors.New("entity has no associated tenant")
+		return payment.TenantSettings{}, errors.New("entity has no associated tenant")
 	}
 
-	// Step 2: Fetch Razorpay keys from tenant's bank account details
+	// Step 2: Fetch gateway settings from tenant's bank account details
 	var bankDetails auth.Tenant_BankAccountDetails
 	if err := r.db.Where("user_id = ?", createdBy).
 		First(&bankDetails).Error; err != nil {
-		return "", "", fmt.Errorf("bank details not found for this temple: %v", err)
-	}
-
-	if bankDetails.RazorpayKeyID == "" || bankDetails.RazorpaySecret == "" {
-		return "", "", errors.New("razorpay keys not configured for this temple")
+		return payment.TenantSettings{}, fmt.Errorf("bank details not found for this temple: %v", err)
 	}
 
-	return bankDetails.RazorpayKeyID, bankDetails.RazorpaySecret, nil
+	return payment.TenantSettings{
+		Provider:       bankDetails.PaymentProvider,
+		RazorpayKeyID:  bankDetails.RazorpayKeyID,
+		RazorpaySecret: bankDetails.RazorpaySecret,
+		CashfreeAppID:  bankDetails.CashfreeAppID,
+		CashfreeSecret: bankDetails.CashfreeSecret,
+	}, nil
 }
@@ -0,0 +1,203 @@
+package seva
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/userprofile"
+)
+
+// maxBeneficiaries bounds how many people one booking is performed for
+const maxBeneficiaries = 10
+
+// ─────────────────────────────────────────────
+// Sankalpa beneficiaries
+// A booking is performed for one or more beneficiaries: the devotee, their
+// spouse, a child, or anyone else named in free text. The gotra, nakshatra
+// and rashi the priest recites are taken from the devotee's profile unless
+// given with the booking.
+// ─────────────────────────────────────────────
+
+// fillBeneficiaries completes the booking's beneficiaries from the devotee's
+// profile; a booking made without any is performed for the devotee themself
+func (s *service) fillBeneficiaries(ctx context.Context, booking *SevaBooking) error {
+	if len(booking.Beneficiaries) > maxBeneficiaries {
+		return fmt.Errorf("a booking can be made for at most %d beneficiaries", maxBeneficiaries)
+	}
+
+	profile, err := s.repo.GetSankalpaProfile(ctx, booking.UserID, booking.EntityID)
+	if err != nil {
+		return err
+	}
+
+	if len(booking.Beneficiaries) == 0 {
+		booking.Beneficiaries = []SevaBeneficiary{{Relation: BeneficiarySelf}}
+	}
+	for i := range booking.Beneficiaries {
+		if err := fillBeneficiary(&booking.Beneficiaries[i], profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillBeneficiary fills the details left empty from the profile: the
+// devotee's own for "self", their spouse's for "spouse", and the child's name
+// with the family gotra for "child"
+func fillBeneficiary(b *SevaBeneficiary, profile *userprofile.DevoteeProfile) error {
+	var name, gotra, nakshatra, rashi *string
+	switch b.Relation {
+	case BeneficiarySelf:
+		name, gotra, nakshatra, rashi = profile.FullName, profile.Gotra, profile.Nakshatra, profile.Rashi
+	case BeneficiarySpouse:
+		name, gotra, nakshatra = profile.SpouseName, profile.SpouseGotra, profile.SpouseNakshatra
+	case BeneficiaryChild:
+		if b.ChildID != nil {
+			child := findChild(profile, *b.ChildID)
+			if child == nil {
+				return errors.New("child not found in your devotee profile")
+			}
+			name = child.ChildName
+		}
+		gotra = profile.Gotra
+	case BeneficiaryOther:
+	default:
+		return fmt.Errorf("unknown beneficiary relation %q", b.Relation)
+	}
+	if b.Relation != BeneficiaryChild {
+		b.ChildID = nil
+	}
+
+	b.Name = orProfile(b.Name, name)
+	b.Gotra = orProfile(b.Gotra, gotra)
+	b.Nakshatra = orProfile(b.Nakshatra, nakshatra)
+	b.Rashi = orProfile(b.Rashi, rashi)
+	b.Sankalpa = strings.TrimSpace(b.Sankalpa)
+	if b.Name == "" {
+		return fmt.Errorf("a name is required for the %s beneficiary", b.Relation)
+	}
+	return nil
+}
+
+func findChild(profile *userprofile.DevoteeProfile, childID uint) *userprofile.Child {
+	for _, child := range profile.Children {
+		if child != nil && child.ID == childID {
+			return child
+		}
+	}
+	return nil
+}
+
+// orProfile keeps the given value, else falls back to the profile's
+func orProfile(value string, fromProfile *string) string {
+	if value = strings.TrimSpace(value); value != "" || fromProfile == nil {
+		return value
+	}
+	return strings.TrimSpace(*fromProfile)
+}
+
+// SuggestBeneficiaries lists the people on the devotee's profile a booking
+// can be made for, with their details filled in
+func (s *service) SuggestBeneficiaries(ctx context.Context, userID, entityID uint) ([]SevaBeneficiary, error) {
+	profile, err := s.repo.GetSankalpaProfile(ctx, userID, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []SevaBeneficiary{{Relation: BeneficiarySelf}}
+	if profile.SpouseName != nil && strings.TrimSpace(*profile.SpouseName) != "" {
+		candidates = append(candidates, SevaBeneficiary{Relation: BeneficiarySpouse})
+	}
+	for _, child := range profile.Children {
+		if child != nil && child.ChildName != nil && strings.TrimSpace(*child.ChildName) != "" {
+			id := child.ID
+			candidates = append(candidates, SevaBeneficiary{Relation: BeneficiaryChild, ChildID: &id})
+		}
+	}
+
+	suggestions := make([]SevaBeneficiary, 0, len(candidates))
+	for _, candidate := range candidates {
+		if err := fillBeneficiary(&candidate, profile); err == nil {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions, nil
+}
+
+// ─────────────────────────────────────────────
+// Priest worksheet
+// ─────────────────────────────────────────────
+
+// GetPriestWorksheet lists the seva's approved bookings on the day
+// (YYYY-MM-DD, default today) for the priest performing it: bookings in the
+// day's slots, and bookings without a slot when the seva itself is on that day
+func (s *service) GetPriestWorksheet(ctx context.Context, sevaID uint, date string) (*PriestWorksheet, error) {
+	day := today()
+	if date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, errors.New("date must be in YYYY-MM-DD format")
+		}
+		day = parsed
+	}
+
+	seva, err := s.repo.GetSevaByID(ctx, sevaID)
+	if err != nil {
+		return nil, errors.New("seva not found")
+	}
+	sevaDay, err := time.Parse("02-01-2006", strings.TrimSpace(seva.Date))
+	unslotted := err == nil && sevaDay.Equal(day)
+
+	bookings, err := s.repo.ListWorksheetBookings(ctx, sevaID, day, unslotted)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bookings {
+		booking := &bookings[i]
+		if booking.StartTime == "" {
+			booking.StartTime, booking.EndTime = seva.StartTime, seva.EndTime
+		}
+		// Bookings made before beneficiaries were recorded are for the devotee
+		if len(booking.Beneficiaries) == 0 {
+			self := SevaBeneficiary{BookingID: booking.BookingID, Relation: BeneficiarySelf, Name: booking.DevoteeName}
+			if profile, err := s.repo.GetSankalpaProfile(ctx, booking.UserID, seva.EntityID); err == nil {
+				_ = fillBeneficiary(&self, profile)
+			}
+			booking.Beneficiaries = []SevaBeneficiary{self}
+		}
+	}
+
+	entityName, _ := s.repo.GetEntityName(ctx, seva.EntityID)
+	return &PriestWorksheet{
+		EntityID:    seva.EntityID,
+		EntityName:  entityName,
+		SevaID:      seva.ID,
+		SevaName:    seva.Name,
+		SevaType:    seva.SevaType,
+		Date:        day,
+		Bookings:    bookings,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+// ExportPriestWorksheet renders the day's worksheet as a PDF, or as an HTML
+// page to print from the browser. Returns the file and its name.
+func (s *service) ExportPriestWorksheet(ctx context.Context, sevaID uint, date, format string) ([]byte, string, error) {
+	worksheet, err := s.GetPriestWorksheet(ctx, sevaID, date)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case "html":
+		content, err := renderWorksheetHTML(worksheet)
+		return content, worksheetFileName(worksheet, "html"), err
+	case "", "pdf":
+		content, err := renderWorksheetPDF(worksheet)
+		return content, worksheetFileName(worksheet, "pdf"), err
+	}
+	return nil, "", errors.New("format must be pdf or html")
+}
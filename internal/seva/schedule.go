@@ -0,0 +1,565 @@
+package seva
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// Bookings in these statuses hold a place in their slot; "offered" ones hold
+// it for a devotee promoted from the waitlist until they confirm
+var slotHoldingStatuses = []string{"pending", "approved", "offered"}
+
+const (
+	maxScheduleDays     = 366
+	maxSlotsPerSchedule = 5000
+	maxAvailabilityDays = 92
+
+	// Open-ended schedules always have slots generated this far ahead
+	scheduleHorizonDays = 90
+)
+
+var (
+	errSlotNotFound = errors.New("slot not found for this seva")
+	errSlotFull     = errors.New("no places left in this slot")
+	errSlotRequired = errors.New("slot_id is required: this seva is booked by date and time")
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ─────────────────────────────────────────────
+// Schedules
+// A schedule generates the dated slots a seva is booked in. Slots already
+// present at a date and start time are kept, so overlapping schedules never
+// duplicate a slot.
+// ─────────────────────────────────────────────
+func (s *service) CreateSchedule(ctx context.Context, sevaID uint, req CreateScheduleRequest, accessContext middleware.AccessContext, ip string) (*SevaSchedule, int, error) {
+	if !accessContext.CanWrite() {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, accessContext.GetAccessibleEntityID(), "SEVA_SCHEDULE_CREATE_FAILED", map[string]interface{}{
+			"reason":  "write access denied",
+			"seva_id": sevaID,
+		}, ip, "failure")
+		return nil, 0, errors.New("write access denied")
+	}
+
+	seva, err := s.repo.GetSevaByID(ctx, sevaID)
+	if err != nil {
+		return nil, 0, errors.New("seva not found")
+	}
+
+	schedule, err := buildSchedule(seva, req)
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &seva.EntityID, "SEVA_SCHEDULE_CREATE_FAILED", map[string]interface{}{
+			"seva_id": sevaID,
+			"reason":  err.Error(),
+		}, ip, "failure")
+		return nil, 0, err
+	}
+	schedule.CreatedBy = accessContext.UserID
+
+	// Bounded schedules are generated in full, open-ended ones up to the horizon
+	until := today().AddDate(0, 0, scheduleHorizonDays)
+	if schedule.StartDate.After(today()) {
+		until = schedule.StartDate.AddDate(0, 0, scheduleHorizonDays)
+	}
+	if schedule.EndDate != nil {
+		until = *schedule.EndDate
+	}
+	schedule.GeneratedUntil = until
+
+	slots := generateSlots(schedule, schedule.StartDate, until)
+	if len(slots) == 0 {
+		return nil, 0, errors.New("schedule does not produce any slot")
+	}
+	if len(slots) > maxSlotsPerSchedule {
+		return nil, 0, fmt.Errorf("schedule would produce %d slots, at most %d are allowed", len(slots), maxSlotsPerSchedule)
+	}
+
+	created, err := s.repo.CreateSchedule(ctx, schedule, slots)
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &seva.EntityID, "SEVA_SCHEDULE_CREATE_FAILED", map[string]interface{}{
+			"seva_id": sevaID,
+			"error":   err.Error(),
+		}, ip, "failure")
+		return nil, 0, err
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &seva.EntityID, "SEVA_SCHEDULE_CREATED", map[string]interface{}{
+		"seva_id":       sevaID,
+		"seva_name":     seva.Name,
+		"schedule_id":   schedule.ID,
+		"frequency":     schedule.Frequency,
+		"weekdays":      schedule.Weekdays,
+		"month_day":     schedule.MonthDay,
+		"dates":         schedule.Dates,
+		"tithis":        schedule.Tithis,
+		"nakshatras":    schedule.Nakshatras,
+		"exclude_dates": schedule.ExcludeDates,
+		"start_date":    schedule.StartDate.Format("2006-01-02"),
+		"end_date":      schedule.EndDate,
+		"start_time":    schedule.StartTime,
+		"end_time":      schedule.EndTime,
+		"slot_minutes":  schedule.SlotMinutes,
+		"capacity":      schedule.Capacity,
+		"slots_created": created,
+	}, ip, "success")
+
+	return schedule, created, nil
+}
+
+// buildSchedule validates the request against the seva
+func buildSchedule(seva *Seva, req CreateScheduleRequest) (*SevaSchedule, error) {
+	schedule := &SevaSchedule{
+		SevaID:      seva.ID,
+		EntityID:    seva.EntityID,
+		Frequency:   req.Frequency,
+		MonthDay:    req.MonthDay,
+		SlotMinutes: req.SlotMinutes,
+		Capacity:    req.Capacity,
+	}
+	if schedule.Frequency == "" {
+		schedule.Frequency = FrequencyDaily
+	}
+
+	// ── Recurrence rule ────────────────────────────────────────────────────
+	switch schedule.Frequency {
+	case FrequencyDaily:
+	case FrequencyWeekly:
+		days := make([]string, 0, len(req.Weekdays))
+		for _, day := range req.Weekdays {
+			name := strings.ToLower(strings.TrimSpace(day))
+			if len(name) > 3 {
+				name = name[:3]
+			}
+			if _, ok := weekdayNames[name]; !ok {
+				return nil, fmt.Errorf("unknown weekday %q", day)
+			}
+			days = append(days, name)
+		}
+		if len(days) == 0 {
+			return nil, errors.New("weekdays are required for a weekly schedule")
+		}
+		schedule.Weekdays = strings.Join(days, ",")
+	case FrequencyMonthly:
+		if req.MonthDay < 1 || req.MonthDay > 31 {
+			return nil, errors.New("month_day between 1 and 31 is required for a monthly schedule")
+		}
+	case FrequencyDates:
+		dates, err := normaliseDates(req.Dates, "dates")
+		if err != nil {
+			return nil, err
+		}
+		if len(dates) == 0 {
+			return nil, errors.New("dates are required for a dates schedule")
+		}
+		schedule.Dates = strings.Join(dates, ",")
+		// The listed dates bound the schedule unless given explicitly
+		if req.StartDate == "" {
+			req.StartDate = dates[0]
+		}
+		if req.EndDate == "" {
+			req.EndDate = dates[len(dates)-1]
+		}
+	case FrequencyTithi:
+		seen := make(map[string]bool)
+		var tithis []string
+		for _, name := range req.Tithis {
+			keys, err := parseTithi(name)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range keys {
+				if !seen[key] {
+					seen[key] = true
+					tithis = append(tithis, key)
+				}
+			}
+		}
+		if len(tithis) == 0 {
+			return nil, errors.New("tithis are required for a tithi schedule")
+		}
+		schedule.Tithis = strings.Join(tithis, ",")
+	case FrequencyNakshatra:
+		seen := make(map[string]bool)
+		var nakshatras []string
+		for _, name := range req.Nakshatras {
+			key, err := parseNakshatra(name)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[key] {
+				seen[key] = true
+				nakshatras = append(nakshatras, key)
+			}
+		}
+		if len(nakshatras) == 0 {
+			return nil, errors.New("nakshatras are required for a nakshatra schedule")
+		}
+		schedule.Nakshatras = strings.Join(nakshatras, ",")
+	default:
+		return nil, errors.New("frequency must be daily, weekly, monthly, dates, tithi or nakshatra")
+	}
+
+	excluded, err := normaliseDates(req.ExcludeDates, "exclude_dates")
+	if err != nil {
+		return nil, err
+	}
+	schedule.ExcludeDates = strings.Join(excluded, ",")
+
+	// ── Date range ─────────────────────────────────────────────────────────
+	schedule.StartDate = today()
+	if req.StartDate != "" {
+		startDate, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			return nil, errors.New("start_date must be in YYYY-MM-DD format")
+		}
+		schedule.StartDate = startDate
+	}
+	if req.EndDate != "" {
+		endDate, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return nil, errors.New("end_date must be in YYYY-MM-DD format")
+		}
+		if endDate.Before(schedule.StartDate) {
+			return nil, errors.New("end_date cannot be before start_date")
+		}
+		if endDate.Sub(schedule.StartDate) >= maxScheduleDays*24*time.Hour {
+			return nil, fmt.Errorf("a schedule can span at most %d days; leave end_date empty for an open-ended schedule", maxScheduleDays)
+		}
+		schedule.EndDate = &endDate
+	}
+
+	// ── Daily time window ──────────────────────────────────────────────────
+	start, err := parseClock(req.StartTime)
+	if err != nil {
+		return nil, errors.New("start_time must be in HH:mm format")
+	}
+	end, err := parseClock(req.EndTime)
+	if err != nil {
+		return nil, errors.New("end_time must be in HH:mm format")
+	}
+	if end <= start {
+		return nil, errors.New("end_time must be after start_time")
+	}
+	if req.SlotMinutes < 0 || req.SlotMinutes > end-start {
+		return nil, errors.New("slot_minutes must fit between start_time and end_time")
+	}
+	if req.Capacity <= 0 {
+		return nil, errors.New("capacity must be at least 1")
+	}
+	schedule.StartTime, schedule.EndTime = formatClock(start), formatClock(end)
+
+	return schedule, nil
+}
+
+// normaliseDates validates YYYY-MM-DD dates and returns them sorted and unique
+func normaliseDates(values []string, field string) ([]string, error) {
+	seen := make(map[string]bool, len(values))
+	dates := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return nil, fmt.Errorf("%s must be in YYYY-MM-DD format, got %q", field, value)
+		}
+		if !seen[value] {
+			seen[value] = true
+			dates = append(dates, value)
+		}
+	}
+	sort.Strings(dates)
+	return dates, nil
+}
+
+// fallsOn reports whether the schedule's rule has an occurrence on the day
+func (sc *SevaSchedule) fallsOn(day time.Time) bool {
+	key := day.Format("2006-01-02")
+	if sc.ExcludeDates != "" && containsItem(sc.ExcludeDates, key) {
+		return false
+	}
+	switch sc.Frequency {
+	case FrequencyWeekly:
+		for _, name := range strings.Split(sc.Weekdays, ",") {
+			if weekday, ok := weekdayNames[name]; ok && weekday == day.Weekday() {
+				return true
+			}
+		}
+		return false
+	case FrequencyMonthly:
+		return day.Day() == sc.MonthDay
+	case FrequencyDates:
+		return containsItem(sc.Dates, key)
+	case FrequencyTithi:
+		for _, tithi := range tithisOn(day) {
+			if containsItem(sc.Tithis, tithi) {
+				return true
+			}
+		}
+		return false
+	case FrequencyNakshatra:
+		for _, nakshatra := range nakshatrasOn(day) {
+			if containsItem(sc.Nakshatras, nakshatra) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// generateSlots lays the schedule's time window out on each day of its rule
+// between from and until (inclusive, clipped to the schedule's own range)
+func generateSlots(schedule *SevaSchedule, from, until time.Time) []SevaSlot {
+	start, _ := parseClock(schedule.StartTime)
+	end, _ := parseClock(schedule.EndTime)
+	step := schedule.SlotMinutes
+	if step == 0 {
+		step = end - start
+	}
+
+	from, until = calendarDay(from), calendarDay(until)
+	if first := calendarDay(schedule.StartDate); from.Before(first) {
+		from = first
+	}
+	if schedule.EndDate != nil && until.After(calendarDay(*schedule.EndDate)) {
+		until = calendarDay(*schedule.EndDate)
+	}
+
+	var slots []SevaSlot
+	for day := from; !day.After(until); day = day.AddDate(0, 0, 1) {
+		if !schedule.fallsOn(day) {
+			continue
+		}
+		for at := start; at+step <= end; at += step {
+			slots = append(slots, SevaSlot{
+				SevaID:     schedule.SevaID,
+				EntityID:   schedule.EntityID,
+				ScheduleID: &schedule.ID,
+				SlotDate:   day,
+				StartTime:  formatClock(at),
+				EndTime:    formatClock(at + step),
+				Capacity:   schedule.Capacity,
+				IsActive:   true,
+			})
+		}
+	}
+	return slots
+}
+
+func (s *service) ListSchedules(ctx context.Context, sevaID uint) ([]SevaSchedule, error) {
+	return s.repo.ListSchedules(ctx, sevaID)
+}
+
+// DeleteSchedule stops a schedule: its upcoming slots without bookings are
+// removed, booked ones are kept so their bookings stay valid
+func (s *service) DeleteSchedule(ctx context.Context, sevaID, scheduleID uint, accessContext middleware.AccessContext, ip string) (int64, error) {
+	if !accessContext.CanWrite() {
+		return 0, errors.New("write access denied")
+	}
+
+	schedule, err := s.repo.GetScheduleByID(ctx, scheduleID)
+	if err != nil || schedule.SevaID != sevaID {
+		return 0, errors.New("schedule not found")
+	}
+
+	removed, err := s.repo.DeleteSchedule(ctx, scheduleID, today())
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &accessContext.UserID, &schedule.EntityID, "SEVA_SCHEDULE_DELETE_FAILED", map[string]interface{}{
+			"seva_id":     sevaID,
+			"schedule_id": scheduleID,
+			"error":       err.Error(),
+		}, ip, "failure")
+		return 0, err
+	}
+
+	s.auditSvc.LogAction(ctx, &accessContext.UserID, &schedule.EntityID, "SEVA_SCHEDULE_DELETED", map[string]interface{}{
+		"seva_id":       sevaID,
+		"schedule_id":   scheduleID,
+		"slots_removed": removed,
+	}, ip, "success")
+
+	return removed, nil
+}
+
+// ─────────────────────────────────────────────
+// Schedule worker
+// Open-ended schedules are generated a rolling scheduleHorizonDays ahead
+// ─────────────────────────────────────────────
+
+// ExtendSchedules generates the slots that have come within the horizon.
+// Returns how many slots were created.
+func (s *service) ExtendSchedules(ctx context.Context) (int, error) {
+	horizon := today().AddDate(0, 0, scheduleHorizonDays)
+	schedules, err := s.repo.ListSchedulesToExtend(ctx, horizon, 500)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for i := range schedules {
+		schedule := &schedules[i]
+		until := horizon
+		if schedule.EndDate != nil && schedule.EndDate.Before(until) {
+			until = calendarDay(*schedule.EndDate)
+		}
+		slots := generateSlots(schedule, schedule.GeneratedUntil.AddDate(0, 0, 1), until)
+		created, err := s.repo.ExtendSchedule(ctx, schedule.ID, slots, until)
+		if err != nil {
+			log.Printf("❌ Extending seva schedule=%d: %v", schedule.ID, err)
+			continue
+		}
+		total += created
+	}
+	return total, nil
+}
+
+// StartScheduleWorker launches the background worker that keeps open-ended
+// seva schedules generated ahead
+func StartScheduleWorker(svc Service, interval time.Duration) {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	go func() {
+		log.Printf("🗓️ Seva schedule worker started (every %s)", interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			created, err := svc.ExtendSchedules(context.Background())
+			if err != nil {
+				log.Printf("❌ Seva schedule worker: %v", err)
+			} else if created > 0 {
+				log.Printf("🗓️ Seva schedule worker generated %d slot(s)", created)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ─────────────────────────────────────────────
+// Availability
+// ─────────────────────────────────────────────
+
+// GetAvailability returns the seva's slots between from and to (YYYY-MM-DD,
+// inclusive) with their remaining places. Defaults to the coming week.
+func (s *service) GetAvailability(ctx context.Context, sevaID uint, from, to string) ([]SlotAvailability, error) {
+	fromDate := today()
+	if from != "" {
+		parsed, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return nil, errors.New("from must be in YYYY-MM-DD format")
+		}
+		fromDate = parsed
+	}
+	toDate := fromDate.AddDate(0, 0, 6)
+	if to != "" {
+		parsed, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return nil, errors.New("to must be in YYYY-MM-DD format")
+		}
+		toDate = parsed
+	}
+	if toDate.Before(fromDate) {
+		return nil, errors.New("to cannot be before from")
+	}
+	if toDate.Sub(fromDate) >= maxAvailabilityDays*24*time.Hour {
+		return nil, fmt.Errorf("availability can be fetched for at most %d days at a time", maxAvailabilityDays)
+	}
+
+	return s.repo.ListSlotAvailability(ctx, sevaID, fromDate, toDate)
+}
+
+func (s *service) GetSlotAvailability(ctx context.Context, slotID uint) (*SlotAvailability, error) {
+	return s.repo.GetSlotAvailability(ctx, slotID)
+}
+
+// ─────────────────────────────────────────────
+// Slot bookings
+// ─────────────────────────────────────────────
+
+// createBooking inserts the booking. A booking for a slot takes one of the
+// slot's places; a seva with upcoming slots can only be booked through one.
+func (s *service) createBooking(ctx context.Context, booking *SevaBooking) error {
+	if booking.SlotID == nil {
+		scheduled, err := s.repo.HasActiveSlots(ctx, booking.SevaID, today())
+		if err != nil {
+			return err
+		}
+		if scheduled {
+			return errSlotRequired
+		}
+		return s.repo.BookSeva(ctx, booking)
+	}
+
+	slot, err := s.repo.GetSlotByID(ctx, *booking.SlotID)
+	if err != nil || slot.SevaID != booking.SevaID || !slot.IsActive {
+		return errSlotNotFound
+	}
+	if calendarDay(slot.SlotDate).Before(today()) {
+		return errors.New("this slot has already passed")
+	}
+	return s.repo.BookSevaSlot(ctx, booking)
+}
+
+// holdsSlot reports whether a booking in the status keeps its slot place
+func holdsSlot(status string) bool {
+	for _, held := range slotHoldingStatuses {
+		if status == held {
+			return true
+		}
+	}
+	return false
+}
+
+func remainingPlaces(capacity int, booked int64) int64 {
+	if remaining := int64(capacity) - booked; remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// ─────────────────────────────────────────────
+// Dates and times
+// Slot dates are calendar days, kept as midnight UTC; "today" is the IST day.
+// ─────────────────────────────────────────────
+
+func calendarDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func today() time.Time {
+	return calendarDay(time.Now().In(utils.IST))
+}
+
+// parseClock turns HH:mm into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// containsItem reports whether the comma-separated list has the item
+func containsItem(list, item string) bool {
+	for _, v := range strings.Split(list, ",") {
+		if v == item {
+			return true
+		}
+	}
+	return false
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
 	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/payment"
 	"github.com/sharath018/temple-management-backend/middleware"
 )
 
@@ -48,19 +50,81 @@ type Service interface {
 	// Get approved booking counts per seva
 	GetApprovedBookingCountsPerSeva(ctx context.Context, entityID uint) (map[uint]int64, error)
 
+	// Schedules and dated slots
+	CreateSchedule(ctx context.Context, sevaID uint, req CreateScheduleRequest, accessContext middleware.AccessContext, ip string) (*SevaSchedule, int, error)
+	ListSchedules(ctx context.Context, sevaID uint) ([]SevaSchedule, error)
+	DeleteSchedule(ctx context.Context, sevaID, scheduleID uint, accessContext middleware.AccessContext, ip string) (int64, error)
+	GetAvailability(ctx context.Context, sevaID uint, from, to string) ([]SlotAvailability, error)
+	GetSlotAvailability(ctx context.Context, slotID uint) (*SlotAvailability, error)
+
+	// Recurring schedules and occurrences
+	UpdateOccurrence(ctx context.Context, sevaID, slotID uint, req UpdateOccurrenceRequest, accessContext middleware.AccessContext, ip string) (*OccurrenceUpdate, error)
+	WithUpcomingOccurrences(ctx context.Context, sevas []Seva, perSeva int) ([]SevaListing, error)
+	ExtendSchedules(ctx context.Context) (int, error)
+
+	// Recompute booked/remaining slot counters from the bookings
+	RepairSlotCounters(ctx context.Context, entityID uint) ([]SlotCounterRepair, error)
+
+	// Cancellation policies and devotee cancellations
+	GetCancellationPolicy(ctx context.Context, entityID, sevaID uint) (*CancellationPolicy, error)
+	ListCancellationPolicies(ctx context.Context, entityID uint) ([]CancellationPolicy, error)
+	SetCancellationPolicy(ctx context.Context, entityID, sevaID uint, req CancellationPolicyRequest, accessContext middleware.AccessContext, ip string) (*CancellationPolicy, error)
+	DeleteCancellationPolicy(ctx context.Context, entityID, sevaID uint, accessContext middleware.AccessContext, ip string) error
+	QuoteCancellation(ctx context.Context, bookingID, userID uint) (*CancellationQuote, error)
+	CancelBooking(ctx context.Context, bookingID, userID uint, reason, ip string) (*CancellationResult, error)
+
+	// Sankalpa beneficiaries and the priest worksheet
+	SuggestBeneficiaries(ctx context.Context, userID, entityID uint) ([]SevaBeneficiary, error)
+	GetPriestWorksheet(ctx context.Context, sevaID uint, date string) (*PriestWorksheet, error)
+	ExportPriestWorksheet(ctx context.Context, sevaID uint, date, format string) ([]byte, string, error)
+
+	// Variants, add-ons and booking prices
+	GetSevaPricing(ctx context.Context, sevaID uint, activeOnly bool) (*SevaPricing, error)
+	SetSevaPricing(ctx context.Context, sevaID uint, req SevaPricingRequest, accessContext middleware.AccessContext, ip string) (*SevaPricing, error)
+	QuoteSevaPrice(ctx context.Context, sevaID uint, variantID *uint, addOns []AddOnSelection) (*PriceQuote, error)
+
+	// Seva tickets and counter check-in
+	GetBookingTicket(ctx context.Context, bookingID, userID uint) (*SevaTicket, error)
+	ExportBookingTicket(ctx context.Context, bookingID, userID uint, format string) ([]byte, string, error)
+	CheckInTicket(ctx context.Context, code string, accessContext middleware.AccessContext, ip string) (*SevaTicket, error)
+
+	// Waitlist for full sevas and slots
+	JoinWaitlist(ctx context.Context, entry *SevaWaitlistEntry, ip string) (*SevaWaitlistEntry, error)
+	ListMyWaitlist(ctx context.Context, userID uint) ([]SevaWaitlistEntry, error)
+	ListWaitlist(ctx context.Context, sevaID uint) ([]SevaWaitlistEntry, error)
+	GetWaitlistOffer(ctx context.Context, entryID, userID uint) (*SevaWaitlistEntry, *SevaBooking, error)
+	ConfirmWaitlistOffer(ctx context.Context, entryID, userID uint, orderID, ip string) (*SevaBooking, error)
+	LeaveWaitlist(ctx context.Context, entryID, userID uint, ip string) error
+	ExpireWaitlistOffers(ctx context.Context) (int, error)
+
+	// Settle bookings whose payment callback never arrived
+	ReconcileStalePayments(ctx context.Context, createdBefore, abandonBefore time.Time, limit int) ([]payment.ReconcileResult, error)
+
 	SetNotifService(n notification.Service)
+	SetPaymentResolver(r *payment.Resolver)
+	SetBookingRefunder(r BookingRefunder)
+	SetWaitlistOfferWindow(d time.Duration)
+	SetTicketSecret(secret string)
 }
 
 type service struct {
 	repo     Repository
 	auditSvc auditlog.Service
 	notifSvc notification.Service
+	payments *payment.Resolver // picks each temple's payment provider
+	refunder BookingRefunder   // refunds paid bookings devotees cancel
+
+	offerWindow  time.Duration // how long a waitlist offer holds its place
+	ticketSecret []byte        // signs the QR tickets of approved bookings
 }
 
 func NewService(repo Repository, auditSvc auditlog.Service) Service {
 	return &service{
 		repo:     repo,
 		auditSvc: auditSvc,
+		payments: payment.NewResolver(nil),
+
+		offerWindow: defaultOfferWindow,
 	}
 }
 
@@ -68,6 +132,11 @@ func (s *service) SetNotifService(n notification.Service) {
 	s.notifSvc = n
 }
 
+// SetPaymentResolver injects the resolver configured from the app config
+func (s *service) SetPaymentResolver(r *payment.Resolver) {
+	s.payments = r
+}
+
 // isSuperAdmin returns true for roles that can manage any entity's sevas.
 func isSuperAdmin(roleName string) bool {
 	switch roleName {
@@ -428,7 +497,7 @@ func (s *service) BookSeva(ctx context.Context, booking *SevaBooking, userRole s
 		return errors.New("seva is not available for booking")
 	}
 
-	if seva.AvailableSlots > 0 && seva.RemainingSlots <= 0 {
+	if booking.SlotID == nil && seva.AvailableSlots > 0 && seva.RemainingSlots <= 0 {
 		s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
 			"seva_id":         booking.SevaID,
 			"seva_name":       seva.Name,
@@ -445,10 +514,30 @@ func (s *service) BookSeva(ctx context.Context, booking *SevaBooking, userRole s
 	booking.BookingTime = time.Now()
 	booking.Status = "pending"
 
-	err = s.repo.BookSeva(ctx, booking)
+	if err := s.priceBooking(ctx, seva, booking); err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
+			"seva_id":    booking.SevaID,
+			"variant_id": booking.VariantID,
+			"reason":     "invalid selection",
+			"error":      err.Error(),
+		}, ip, "failure")
+		return err
+	}
+
+	if err := s.fillBeneficiaries(ctx, booking); err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
+			"seva_id": booking.SevaID,
+			"reason":  "invalid beneficiaries",
+			"error":   err.Error(),
+		}, ip, "failure")
+		return err
+	}
+
+	err = s.createBooking(ctx, booking)
 	if err != nil {
 		s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
 			"seva_id":   booking.SevaID,
+			"slot_id":   booking.SlotID,
 			"seva_name": seva.Name,
 			"error":     err.Error(),
 		}, ip, "failure")
@@ -458,6 +547,7 @@ func (s *service) BookSeva(ctx context.Context, booking *SevaBooking, userRole s
 	s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKED", map[string]interface{}{
 		"booking_id":      booking.ID,
 		"seva_id":         booking.SevaID,
+		"slot_id":         booking.SlotID,
 		"seva_name":       seva.Name,
 		"seva_type":       seva.SevaType,
 		"seva_status":     seva.Status,
@@ -521,54 +611,27 @@ func (s *service) UpdateBookingStatus(ctx context.Context, bookingID uint, newSt
 		return err
 	}
 
-	oldStatus := booking.Status
-
-	// Approving a booking (pending/rejected -> approved)
-	if newStatus == "approved" && oldStatus != "approved" {
-		if seva.RemainingSlots <= 0 {
-			s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_STATUS_UPDATE_FAILED", map[string]interface{}{
-				"booking_id":      bookingID,
-				"new_status":      newStatus,
-				"reason":          "no slots available",
-				"available_slots": seva.AvailableSlots,
-				"booked_slots":    seva.BookedSlots,
-				"remaining_slots": seva.RemainingSlots,
-			}, ip, "failure")
-			return errors.New("no slots available for this seva")
-		}
-
-		if err := s.repo.IncrementBookedSlots(ctx, booking.SevaID); err != nil {
-			s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_STATUS_UPDATE_FAILED", map[string]interface{}{
-				"booking_id": bookingID,
-				"new_status": newStatus,
-				"reason":     "failed to update slots",
-				"error":      err.Error(),
-			}, ip, "failure")
-			return fmt.Errorf("failed to update slots: %v", err)
-		}
-	}
-
-	// Rejecting/Canceling an approved booking (approved -> rejected/pending)
-	if oldStatus == "approved" && newStatus != "approved" {
-		if err := s.repo.DecrementBookedSlots(ctx, booking.SevaID); err != nil {
-			s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_STATUS_UPDATE_FAILED", map[string]interface{}{
-				"booking_id": bookingID,
-				"new_status": newStatus,
-				"reason":     "failed to update slots",
-				"error":      err.Error(),
-			}, ip, "failure")
-			return fmt.Errorf("failed to update slots: %v", err)
-		}
-	}
-
-	err = s.repo.UpdateBookingStatus(ctx, bookingID, newStatus)
+	// The status change takes or releases the booking's place in the same
+	// transaction: pending, approved and offered bookings hold one, others do not
+	oldStatus, err := s.repo.TransitionBooking(ctx, bookingID, "", newStatus, nil)
 	if err != nil {
-		s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_STATUS_UPDATE_FAILED", map[string]interface{}{
+		details := map[string]interface{}{
 			"booking_id": bookingID,
 			"seva_id":    booking.SevaID,
+			"slot_id":    booking.SlotID,
 			"new_status": newStatus,
 			"error":      err.Error(),
-		}, ip, "failure")
+		}
+		switch {
+		case errors.Is(err, errSlotFull):
+			details["reason"] = "no places left in slot"
+		case errors.Is(err, errSevaFull):
+			details["reason"] = "no slots available"
+			details["available_slots"] = seva.AvailableSlots
+			details["booked_slots"] = seva.BookedSlots
+			details["remaining_slots"] = seva.RemainingSlots
+		}
+		s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_STATUS_UPDATE_FAILED", details, ip, "failure")
 		return err
 	}
 
@@ -603,7 +666,15 @@ func (s *service) UpdateBookingStatus(ctx context.Context, bookingID uint, newSt
 
 	s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, action, auditDetails, ip, "success")
 
-	if s.notifSvc != nil {
+	// A place given up goes to the first devotee on the waitlist
+	if holdsSlot(oldStatus) && !holdsSlot(newStatus) {
+		s.promoteWaitlist(ctx, booking.SevaID, booking.SlotID, ip)
+	}
+
+	if newStatus == "approved" {
+		booking.Status = newStatus
+		s.notifyBookingApproved(ctx, booking, "Seva Booking approved", "Your booking status is now approved.", ip)
+	} else if s.notifSvc != nil {
 		_ = s.notifSvc.CreateInAppNotification(
 			ctx,
 			booking.UserID,
@@ -681,7 +752,7 @@ func (s *service) CreateSevaBookingWithPayment(ctx context.Context, booking *Sev
 		return errors.New("seva is not available for booking")
 	}
 
-	if seva.RemainingSlots <= 0 {
+	if booking.SlotID == nil && seva.AvailableSlots > 0 && seva.RemainingSlots <= 0 {
 		s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
 			"seva_id":         booking.SevaID,
 			"remaining_slots": seva.RemainingSlots,
@@ -690,10 +761,39 @@ func (s *service) CreateSevaBookingWithPayment(ctx context.Context, booking *Sev
 		return errors.New("no slots available for this seva")
 	}
 
-	err = s.repo.BookSeva(ctx, booking)
+	// The order was created for the amount quoted to the devotee; a price
+	// changed since then fails the booking rather than charging otherwise
+	quoted := booking.Amount
+	err = s.priceBooking(ctx, seva, booking)
+	if err == nil && !samePrice(booking.Amount, quoted) {
+		err = errPriceChanged
+	}
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
+			"seva_id":       booking.SevaID,
+			"variant_id":    booking.VariantID,
+			"quoted_amount": quoted,
+			"amount":        booking.Amount,
+			"reason":        "invalid selection",
+			"error":         err.Error(),
+		}, ip, "failure")
+		return err
+	}
+
+	if err := s.fillBeneficiaries(ctx, booking); err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
+			"seva_id": booking.SevaID,
+			"reason":  "invalid beneficiaries",
+			"error":   err.Error(),
+		}, ip, "failure")
+		return err
+	}
+
+	err = s.createBooking(ctx, booking)
 	if err != nil {
 		s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
 			"seva_id": booking.SevaID,
+			"slot_id": booking.SlotID,
 			"error":   err.Error(),
 		}, ip, "failure")
 		return err
@@ -702,6 +802,9 @@ func (s *service) CreateSevaBookingWithPayment(ctx context.Context, booking *Sev
 	s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_PAYMENT_INITIATED", map[string]interface{}{
 		"booking_id":        booking.ID,
 		"seva_id":           booking.SevaID,
+		"slot_id":           booking.SlotID,
+		"variant_id":        booking.VariantID,
+		"items":             booking.Items,
 		"razorpay_order_id": booking.RazorpayOrderID,
 		"amount":            booking.Amount,
 		"remaining_slots":   seva.RemainingSlots,
@@ -730,41 +833,51 @@ func (s *service) VerifySevaPayment(ctx context.Context, razorpayOrderID, razorp
 		return errors.New("unauthorized access to booking")
 	}
 
-	seva, err := s.repo.GetSevaByID(ctx, booking.SevaID)
-	if err != nil {
-		return err
-	}
+	return s.approveBookingPayment(ctx, booking, razorpayPaymentID, razorpaySignature, ip)
+}
+
+// approveBookingPayment records a confirmed payment against a booking. A pending
+// booking has held its place since checkout began; one already rejected, e.g.
+// as abandoned before a late payment arrived, takes a place again if one is
+// left. Shared by the checkout callback and the payment reconciler.
+func (s *service) approveBookingPayment(ctx context.Context, booking *SevaBooking, razorpayPaymentID, razorpaySignature, ip string) error {
+	userID := booking.UserID
 
-	if seva.RemainingSlots <= 0 {
+	// A repeated callback for a payment already recorded changes nothing
+	if booking.Status == "approved" && booking.RazorpayPaymentID == razorpayPaymentID {
+		return nil
+	}
+	// The devotee gave the place up; the temple refunds a payment made after that
+	if booking.Status == "cancelled" {
 		s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_PAYMENT_VERIFICATION_FAILED", map[string]interface{}{
-			"booking_id":      booking.ID,
-			"remaining_slots": seva.RemainingSlots,
-			"reason":          "no slots available",
+			"booking_id":          booking.ID,
+			"razorpay_payment_id": razorpayPaymentID,
+			"reason":              "booking was cancelled before the payment arrived",
 		}, ip, "failure")
-		return errors.New("no slots available for this seva")
+		return errors.New("booking was cancelled before the payment arrived")
 	}
 
 	now := time.Now()
-	booking.RazorpayPaymentID = razorpayPaymentID
-	booking.RazorpaySignature = razorpaySignature
-	booking.PaymentVerifiedAt = &now
-	booking.Status = "approved"
-
-	if err := s.repo.UpdateSevaBooking(ctx, booking); err != nil {
+	if _, err := s.repo.TransitionBooking(ctx, booking.ID, booking.Status, "approved", map[string]interface{}{
+		"razorpay_payment_id": razorpayPaymentID,
+		"razorpay_signature":  razorpaySignature,
+		"payment_verified_at": now,
+	}); err != nil {
 		s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_PAYMENT_VERIFICATION_FAILED", map[string]interface{}{
-			"booking_id": booking.ID,
-			"error":      err.Error(),
+			"booking_id":     booking.ID,
+			"booking_status": booking.Status,
+			"error":          err.Error(),
 		}, ip, "failure")
 		return err
 	}
+	booking.RazorpayPaymentID = razorpayPaymentID
+	booking.RazorpaySignature = razorpaySignature
+	booking.PaymentVerifiedAt = &now
+	booking.Status = "approved"
 
-	if err := s.repo.IncrementBookedSlots(ctx, booking.SevaID); err != nil {
-		s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_SLOT_UPDATE_FAILED", map[string]interface{}{
-			"booking_id": booking.ID,
-			"seva_id":    booking.SevaID,
-			"error":      err.Error(),
-		}, ip, "failure")
-		return fmt.Errorf("failed to update slots: %v", err)
+	// A place offered from the waitlist is now taken
+	if err := s.repo.CloseWaitlistOffer(ctx, booking.ID); err != nil {
+		log.Printf("❌ Closing waitlist offer of booking=%d: %v", booking.ID, err)
 	}
 
 	s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_PAYMENT_VERIFIED", map[string]interface{}{
@@ -775,16 +888,123 @@ func (s *service) VerifySevaPayment(ctx context.Context, razorpayOrderID, razorp
 		"status":              "approved",
 	}, ip, "success")
 
+	s.notifyBookingApproved(ctx, booking, "Seva Booking Confirmed",
+		fmt.Sprintf("Your seva booking has been confirmed. Payment ID: %s.", razorpayPaymentID), ip)
+
+	return nil
+}
+
+// reconcilerIP is recorded as the IP on audit entries written by the payment reconciler
+const reconcilerIP = "payment_reconciler"
+
+// ─────────────────────────────────────────────
+// ReconcileStalePayments
+// Bookings whose checkout callback never reached us are settled from the
+// gateway's view of the order: paid orders are approved exactly as
+// VerifySevaPayment would, failed or abandoned orders are rejected.
+// ─────────────────────────────────────────────
+func (s *service) ReconcileStalePayments(ctx context.Context, createdBefore, abandonBefore time.Time, limit int) ([]payment.ReconcileResult, error) {
+	bookings, err := s.repo.ListStalePaymentBookings(ctx, createdBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]payment.ReconcileResult, 0, len(bookings))
+	for i := range bookings {
+		booking := &bookings[i]
+		result := payment.ReconcileResult{
+			Source:   payment.SourceSevaBooking,
+			RecordID: booking.ID,
+			EntityID: booking.EntityID,
+			UserID:   booking.UserID,
+			OrderID:  booking.RazorpayOrderID,
+		}
+		if err := s.reconcileBooking(ctx, booking, abandonBefore, &result); err != nil {
+			result.Outcome = payment.ReconcileOutcomeError
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *service) reconcileBooking(ctx context.Context, booking *SevaBooking, abandonBefore time.Time, result *payment.ReconcileResult) error {
+	settings, err := s.repo.GetPaymentSettingsByEntityID(booking.EntityID)
+	if err != nil {
+		return err
+	}
+	provider, err := s.payments.ForTenant(settings)
+	if err != nil {
+		return err
+	}
+
+	status, abandoned, err := payment.ResolveStaleOrder(ctx, provider, booking.RazorpayOrderID, booking.CreatedAt, abandonBefore)
+	if err != nil {
+		return err
+	}
+	result.Outcome, result.Abandoned = status.Status, abandoned
+	if status.Status == payment.OrderStatusPending {
+		return nil
+	}
+
+	// The checkout callback may have settled the booking while we asked the gateway
+	current, err := s.repo.GetBookingByOrderID(ctx, booking.RazorpayOrderID)
+	if err != nil {
+		return err
+	}
+	if current.Status != booking.Status {
+		result.Outcome = payment.ReconcileOutcomeSettled
+		return nil
+	}
+
+	if status.Status == payment.OrderStatusPaid {
+		result.PaymentID = status.Payment.ID
+		return s.approveBookingPayment(ctx, current, status.Payment.ID, "", reconcilerIP)
+	}
+	// An unpaid waitlist offer keeps its place until the offer expires
+	if current.Status == "offered" {
+		return nil
+	}
+	return s.rejectUnpaidBooking(ctx, current, abandoned)
+}
+
+// rejectUnpaidBooking releases a booking whose payment failed or was abandoned
+func (s *service) rejectUnpaidBooking(ctx context.Context, booking *SevaBooking, abandoned bool) error {
+	reason := "payment failed"
+	if abandoned {
+		reason = "payment not completed"
+	}
+
+	if _, err := s.repo.TransitionBooking(ctx, booking.ID, "pending", "rejected", nil); err != nil {
+		s.auditSvc.LogAction(ctx, &booking.UserID, &booking.EntityID, "SEVA_PAYMENT_FAILED", map[string]interface{}{
+			"booking_id": booking.ID,
+			"error":      err.Error(),
+		}, reconcilerIP, "failure")
+		return err
+	}
+	booking.Status = "rejected"
+
+	s.auditSvc.LogAction(ctx, &booking.UserID, &booking.EntityID, "SEVA_PAYMENT_FAILED", map[string]interface{}{
+		"booking_id":        booking.ID,
+		"seva_id":           booking.SevaID,
+		"razorpay_order_id": booking.RazorpayOrderID,
+		"amount":            booking.Amount,
+		"reason":            reason,
+		"status":            "rejected",
+	}, reconcilerIP, "success")
+
+	s.promoteWaitlist(ctx, booking.SevaID, booking.SlotID, reconcilerIP)
+
 	if s.notifSvc != nil {
 		_ = s.notifSvc.CreateInAppNotification(
 			ctx,
-			userID,
+			booking.UserID,
 			booking.EntityID,
-			"Seva Booking Confirmed",
-			fmt.Sprintf("Your seva booking has been confirmed. Payment ID: %s", razorpayPaymentID),
+			"Seva Booking Cancelled",
+			fmt.Sprintf("Your seva booking was cancelled because the %s. Please book again.", reason),
 			"seva",
 		)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,357 @@
+package seva
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// ticketPrefix starts every ticket code and versions its layout
+const ticketPrefix = "SVT1"
+
+// ticketQRSize is the side of the ticket QR code PNG in pixels
+const ticketQRSize = 512
+
+var (
+	errTicketsDisabled = errors.New("seva tickets are not configured")
+	errTicketInvalid   = errors.New("invalid ticket")
+	errTicketUsed      = errors.New("ticket has already been used")
+	errTicketWrongDay  = errors.New("ticket is not valid today")
+	errTicketNotIssued = errors.New("tickets are issued for approved bookings only")
+)
+
+// SetTicketSecret sets the key seva tickets are signed with
+func (s *service) SetTicketSecret(secret string) {
+	s.ticketSecret = []byte(secret)
+}
+
+// ─────────────────────────────────────────────
+// Seva tickets
+// An approved booking gets a ticket the devotee shows at the temple counter as
+// a QR code. The code carries the booking, temple, seva, slot, beneficiary
+// count and day, signed with HMAC-SHA256 so it cannot be forged or altered:
+//
+//	SVT1.<booking>.<entity>.<seva>.<slot>.<beneficiaries>.<yyyymmdd>.<signature>
+//
+// Slot is 0 for bookings without a slot and day 0 for sevas without a date.
+// Staff scan it at check-in, which lets each booking in once, on its day.
+// ─────────────────────────────────────────────
+
+// ticketClaims are the booking details a ticket code carries
+type ticketClaims struct {
+	BookingID     uint
+	EntityID      uint
+	SevaID        uint
+	SlotID        uint
+	Beneficiaries int
+	Day           string // YYYYMMDD, "0" when valid on any day
+}
+
+func (t ticketClaims) payload() string {
+	return fmt.Sprintf("%s.%d.%d.%d.%d.%d.%s", ticketPrefix, t.BookingID, t.EntityID, t.SevaID, t.SlotID, t.Beneficiaries, t.Day)
+}
+
+// ticketSignature is the first 16 bytes of the payload's HMAC, URL-safe
+func (s *service) ticketSignature(payload string) string {
+	mac := hmac.New(sha256.New, s.ticketSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func (s *service) signTicket(t ticketClaims) string {
+	payload := t.payload()
+	return payload + "." + s.ticketSignature(payload)
+}
+
+// parseTicket checks the code's signature and returns the claims it carries
+func (s *service) parseTicket(code string) (*ticketClaims, error) {
+	code = strings.TrimSpace(code)
+	cut := strings.LastIndex(code, ".")
+	if cut < 0 {
+		return nil, errTicketInvalid
+	}
+	payload, signature := code[:cut], code[cut+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.ticketSignature(payload))) {
+		return nil, errTicketInvalid
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 7 || parts[0] != ticketPrefix {
+		return nil, errTicketInvalid
+	}
+	ids := make([]uint64, 5)
+	for i := range ids {
+		n, err := strconv.ParseUint(parts[i+1], 10, 32)
+		if err != nil {
+			return nil, errTicketInvalid
+		}
+		ids[i] = n
+	}
+	return &ticketClaims{
+		BookingID:     uint(ids[0]),
+		EntityID:      uint(ids[1]),
+		SevaID:        uint(ids[2]),
+		SlotID:        uint(ids[3]),
+		Beneficiaries: int(ids[4]),
+		Day:           parts[6],
+	}, nil
+}
+
+// bookingTicket builds the signed ticket of an approved booking
+func (s *service) bookingTicket(ctx context.Context, booking *SevaBooking) (*SevaTicket, error) {
+	if len(s.ticketSecret) == 0 {
+		return nil, errTicketsDisabled
+	}
+	if booking.Status != "approved" {
+		return nil, errTicketNotIssued
+	}
+
+	seva, err := s.repo.GetSevaByID(ctx, booking.SevaID)
+	if err != nil {
+		return nil, errors.New("seva not found")
+	}
+	ticket := &SevaTicket{
+		BookingID:     booking.ID,
+		EntityID:      booking.EntityID,
+		SevaID:        seva.ID,
+		SevaName:      seva.Name,
+		SlotID:        booking.SlotID,
+		StartTime:     seva.StartTime,
+		EndTime:       seva.EndTime,
+		Beneficiaries: len(booking.Beneficiaries),
+		AttendedAt:    booking.AttendedAt,
+	}
+	// Bookings made before beneficiaries were recorded are for the devotee
+	if ticket.Beneficiaries == 0 {
+		ticket.Beneficiaries = 1
+	}
+
+	if booking.SlotID != nil {
+		slot, err := s.repo.GetSlotByID(ctx, *booking.SlotID)
+		if err != nil {
+			return nil, errSlotNotFound
+		}
+		day := calendarDay(slot.SlotDate)
+		ticket.Date, ticket.StartTime, ticket.EndTime = &day, slot.StartTime, slot.EndTime
+	} else if day, err := time.Parse("02-01-2006", strings.TrimSpace(seva.Date)); err == nil {
+		ticket.Date = &day
+	}
+
+	ticket.EntityName, _ = s.repo.GetEntityName(ctx, booking.EntityID)
+	ticket.DevoteeName, _ = s.repo.GetDevoteeName(ctx, booking.UserID)
+	ticket.Code = s.signTicket(ticketClaimsOf(ticket))
+	return ticket, nil
+}
+
+func ticketClaimsOf(t *SevaTicket) ticketClaims {
+	claims := ticketClaims{
+		BookingID:     t.BookingID,
+		EntityID:      t.EntityID,
+		SevaID:        t.SevaID,
+		Beneficiaries: t.Beneficiaries,
+		Day:           "0",
+	}
+	if t.SlotID != nil {
+		claims.SlotID = *t.SlotID
+	}
+	if t.Date != nil {
+		claims.Day = t.Date.Format("20060102")
+	}
+	return claims
+}
+
+// GetBookingTicket returns the ticket of one of the devotee's approved bookings
+func (s *service) GetBookingTicket(ctx context.Context, bookingID, userID uint) (*SevaTicket, error) {
+	booking, err := s.repo.GetBookingByID(ctx, bookingID)
+	if err != nil || booking.UserID != userID {
+		return nil, errors.New("booking not found")
+	}
+	return s.bookingTicket(ctx, booking)
+}
+
+// ExportBookingTicket renders the devotee's ticket as a printable PDF, or its
+// QR code alone as a PNG. Returns the file and its name.
+func (s *service) ExportBookingTicket(ctx context.Context, bookingID, userID uint, format string) ([]byte, string, error) {
+	ticket, err := s.GetBookingTicket(ctx, bookingID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	name := fmt.Sprintf("seva_ticket_%d", ticket.BookingID)
+	switch format {
+	case "png":
+		png, err := qrcode.Encode(ticket.Code, qrcode.Medium, ticketQRSize)
+		return png, name + ".png", err
+	case "", "pdf":
+		content, err := renderTicketPDF(ticket)
+		return content, name + ".pdf", err
+	}
+	return nil, "", errors.New("format must be pdf or png")
+}
+
+// CheckInTicket admits the devotee holding the ticket at the counter of the
+// staff member's temple: the signature must hold, the booking must still be
+// approved, and the ticket must be for today and not used before
+func (s *service) CheckInTicket(ctx context.Context, code string, accessContext middleware.AccessContext, ip string) (*SevaTicket, error) {
+	staffID := accessContext.UserID
+	fail := func(bookingID uint, entityID *uint, err error) (*SevaTicket, error) {
+		s.auditSvc.LogAction(ctx, &staffID, entityID, "SEVA_TICKET_CHECKIN_FAILED", map[string]interface{}{
+			"booking_id": bookingID,
+			"reason":     err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+
+	if len(s.ticketSecret) == 0 {
+		return nil, errTicketsDisabled
+	}
+	claims, err := s.parseTicket(code)
+	if err != nil {
+		return fail(0, accessContext.GetAccessibleEntityID(), err)
+	}
+	if entityID := accessContext.GetAccessibleEntityID(); entityID != nil && *entityID != claims.EntityID {
+		return fail(claims.BookingID, entityID, errors.New("ticket belongs to another temple"))
+	}
+
+	booking, err := s.repo.GetBookingByID(ctx, claims.BookingID)
+	if err != nil || booking.EntityID != claims.EntityID || booking.SevaID != claims.SevaID {
+		return fail(claims.BookingID, &claims.EntityID, errTicketInvalid)
+	}
+	if booking.AttendedAt != nil {
+		return fail(booking.ID, &booking.EntityID, fmt.Errorf("%w at %s", errTicketUsed, booking.AttendedAt.In(utils.IST).Format("02 Jan 2006 03:04 PM")))
+	}
+	if booking.Status != "approved" {
+		return fail(booking.ID, &booking.EntityID, fmt.Errorf("booking is %s", booking.Status))
+	}
+
+	// The day is taken from the booking as it is now, so a ticket for an
+	// occurrence that was moved is valid on the new day
+	ticket, err := s.bookingTicket(ctx, booking)
+	if err != nil {
+		return fail(booking.ID, &booking.EntityID, err)
+	}
+	if ticketClaimsOf(ticket).SlotID != claims.SlotID {
+		return fail(booking.ID, &booking.EntityID, errTicketInvalid)
+	}
+	if ticket.Date != nil && !ticket.Date.Equal(today()) {
+		return fail(booking.ID, &booking.EntityID, fmt.Errorf("%w: it is for %s", errTicketWrongDay, ticket.Date.Format("02 Jan 2006")))
+	}
+
+	now := time.Now()
+	marked, err := s.repo.MarkBookingAttended(ctx, booking.ID, staffID, now)
+	if err != nil {
+		return fail(booking.ID, &booking.EntityID, err)
+	}
+	if !marked {
+		// Checked in at another counter meanwhile, or cancelled
+		return fail(booking.ID, &booking.EntityID, errTicketUsed)
+	}
+	ticket.AttendedAt = &now
+
+	s.auditSvc.LogAction(ctx, &staffID, &booking.EntityID, "SEVA_TICKET_CHECKED_IN", map[string]interface{}{
+		"booking_id":    booking.ID,
+		"seva_id":       booking.SevaID,
+		"slot_id":       booking.SlotID,
+		"devotee_id":    booking.UserID,
+		"beneficiaries": ticket.Beneficiaries,
+	}, ip, "success")
+	return ticket, nil
+}
+
+// notifyBookingApproved tells the devotee their booking is confirmed, in the
+// app and on their devices, with where to get the ticket for the counter
+func (s *service) notifyBookingApproved(ctx context.Context, booking *SevaBooking, title, message, ip string) {
+	if s.notifSvc == nil {
+		return
+	}
+
+	if ticket, err := s.bookingTicket(ctx, booking); err == nil {
+		message += fmt.Sprintf(" Your ticket no. %d", ticket.BookingID)
+		if ticket.Date != nil {
+			message += " is for " + ticket.Date.Format("02 Jan 2006")
+			if ticket.StartTime != "" {
+				message += " at " + ticket.StartTime
+			}
+		}
+		message += fmt.Sprintf("; show its QR code at the temple counter. Download: /api/v1/sevas/bookings/%d/ticket", ticket.BookingID)
+	}
+
+	_ = s.notifSvc.CreateInAppNotification(ctx, booking.UserID, booking.EntityID, title, message, "seva")
+	go func() {
+		_ = s.notifSvc.SendPushNotification(context.Background(), 0, booking.EntityID, title, message, []uint{booking.UserID}, ip)
+	}()
+}
+
+// ==============================
+// Ticket Rendering
+// ==============================
+
+// renderTicketPDF draws the ticket on an A6 page: temple, seva, day and
+// devotee, with the QR code the counter scans
+func renderTicketPDF(t *SevaTicket) ([]byte, error) {
+	png, err := qrcode.Encode(t.Code, qrcode.Medium, ticketQRSize)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := gofpdf.New("P", "mm", "A6", "")
+	pdf.SetTitle(fmt.Sprintf("Seva Ticket %d", t.BookingID), true)
+	pdf.SetAutoPageBreak(false, 8)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetFont("Arial", "B", 13)
+	pdf.MultiCell(85, 6, tr(t.EntityName), "", "C", false)
+	pdf.SetFont("Arial", "", 9)
+	pdf.CellFormat(85, 5, "SEVA TICKET", "", 1, "C", false, 0, "")
+	pdf.Ln(2)
+
+	pdf.SetFont("Arial", "B", 12)
+	pdf.MultiCell(85, 6, tr(t.SevaName), "", "C", false)
+	pdf.SetFont("Arial", "", 10)
+	when := "Valid on any day"
+	if t.Date != nil {
+		when = t.Date.Format("Monday, 02 Jan 2006")
+	}
+	if t.StartTime != "" {
+		at := t.StartTime
+		if t.EndTime != "" {
+			at += "–" + t.EndTime
+		}
+		when += " · " + at
+	}
+	pdf.CellFormat(85, 6, tr(when), "", 1, "C", false, 0, "")
+
+	opts := gofpdf.ImageOptions{ImageType: "PNG"}
+	imageName := fmt.Sprintf("ticket_%d", t.BookingID)
+	pdf.RegisterImageOptionsReader(imageName, opts, bytes.NewReader(png))
+	pdf.ImageOptions(imageName, 21.5, pdf.GetY()+3, 62, 62, false, opts, 0, "")
+	pdf.SetY(pdf.GetY() + 68)
+
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(85, 6, tr("Devotee: "+t.DevoteeName), "", 1, "C", false, 0, "")
+	pdf.CellFormat(85, 6, tr(fmt.Sprintf("Booking no. %d · %d beneficiary(ies)", t.BookingID, t.Beneficiaries)), "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "I", 8)
+	pdf.MultiCell(85, 4, "Show this QR code at the temple counter. It admits once, on the day of the seva.", "", "C", false)
+
+	if !pdf.Ok() {
+		return nil, pdf.Error()
+	}
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
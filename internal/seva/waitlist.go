@@ -0,0 +1,478 @@
+package seva
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// waitlistIP is recorded as the IP on audit entries written by the waitlist worker
+const waitlistIP = "seva_waitlist_worker"
+
+// defaultOfferWindow is how long an offer holds its place unless configured
+const defaultOfferWindow = 2 * time.Hour
+
+var (
+	errAlreadyWaitlisted = errors.New("you are already on the waitlist for this seva")
+	errOfferClosed       = errors.New("this waitlist offer is no longer open")
+	errNoPlaceToOffer    = errors.New("no place to offer")
+)
+
+// SetWaitlistOfferWindow sets how long a devotee promoted from the waitlist
+// has to confirm the offered place
+func (s *service) SetWaitlistOfferWindow(d time.Duration) {
+	if d > 0 {
+		s.offerWindow = d
+	}
+}
+
+// ─────────────────────────────────────────────
+// Waitlist
+// Devotees queue for a full seva, or a full slot of a scheduled seva, first
+// come first served. Whenever a place frees up (a booking is rejected,
+// cancelled or declined, or an offer expires) it is offered to the first
+// devotee waiting: the place is held for them in an "offered" booking, and
+// passes to the next devotee if they do not confirm it in time.
+// ─────────────────────────────────────────────
+
+// JoinWaitlist queues the devotee for the entry's seva, or for its slot, with
+// the selection they would book it with. The selection is checked now, and
+// priced again when a place is offered.
+func (s *service) JoinWaitlist(ctx context.Context, entry *SevaWaitlistEntry, ip string) (*SevaWaitlistEntry, error) {
+	sevaID, slotID, userID := entry.SevaID, entry.SlotID, entry.UserID
+	seva, err := s.repo.GetSevaByID(ctx, sevaID)
+	if err != nil {
+		return nil, errors.New("seva not found")
+	}
+
+	entry.EntityID = seva.EntityID
+	entry.Status = WaitlistWaiting
+	err = s.checkWaitlistable(ctx, seva, slotID)
+	if err == nil {
+		_, err = s.waitlistBooking(ctx, seva, entry)
+	}
+	if err == nil {
+		if err = s.repo.CreateWaitlistEntry(ctx, entry); err == nil {
+			s.auditSvc.LogAction(ctx, &userID, &seva.EntityID, "SEVA_WAITLIST_JOINED", map[string]interface{}{
+				"entry_id":   entry.ID,
+				"seva_id":    sevaID,
+				"seva_name":  seva.Name,
+				"slot_id":    slotID,
+				"variant_id": entry.VariantID,
+			}, ip, "success")
+
+			// A place may have freed up since the seva was found full
+			s.promoteWaitlist(ctx, sevaID, slotID, ip)
+			return s.repo.GetWaitlistEntry(ctx, entry.ID)
+		}
+	}
+
+	s.auditSvc.LogAction(ctx, &userID, &seva.EntityID, "SEVA_WAITLIST_JOIN_FAILED", map[string]interface{}{
+		"seva_id": sevaID,
+		"slot_id": slotID,
+		"error":   err.Error(),
+	}, ip, "failure")
+	return nil, err
+}
+
+// checkWaitlistable only lets devotees queue for an occurrence that is full
+func (s *service) checkWaitlistable(ctx context.Context, seva *Seva, slotID *uint) error {
+	if seva.Status != "upcoming" && seva.Status != "ongoing" {
+		return errors.New("seva is not available for booking")
+	}
+
+	if slotID != nil {
+		slot, err := s.repo.GetSlotAvailability(ctx, *slotID)
+		if err != nil || slot.SevaID != seva.ID || !slot.IsActive {
+			return errSlotNotFound
+		}
+		if calendarDay(slot.SlotDate).Before(today()) {
+			return errors.New("this slot has already passed")
+		}
+		if slot.Remaining > 0 {
+			return errors.New("this slot still has places; book it instead")
+		}
+		return nil
+	}
+
+	scheduled, err := s.repo.HasActiveSlots(ctx, seva.ID, today())
+	if err != nil {
+		return err
+	}
+	if scheduled {
+		return errSlotRequired
+	}
+	if seva.AvailableSlots == 0 || seva.RemainingSlots > 0 {
+		return errors.New("this seva still has places; book it instead")
+	}
+	return nil
+}
+
+// waitlistBooking builds the booking that holds a place for the entry: its
+// selection priced and its beneficiaries completed, as BookSeva does
+func (s *service) waitlistBooking(ctx context.Context, seva *Seva, entry *SevaWaitlistEntry) (*SevaBooking, error) {
+	booking := &SevaBooking{
+		SevaID:        entry.SevaID,
+		SlotID:        entry.SlotID,
+		UserID:        entry.UserID,
+		EntityID:      entry.EntityID,
+		VariantID:     entry.VariantID,
+		Items:         append([]SevaBookingItem(nil), entry.AddOns...),
+		Beneficiaries: append([]SevaBeneficiary(nil), entry.Beneficiaries...),
+	}
+	if err := s.priceBooking(ctx, seva, booking); err != nil {
+		return nil, err
+	}
+	if err := s.fillBeneficiaries(ctx, booking); err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// ListMyWaitlist returns the devotee's entries, newest first
+func (s *service) ListMyWaitlist(ctx context.Context, userID uint) ([]SevaWaitlistEntry, error) {
+	entries, err := s.repo.ListWaitlist(ctx, WaitlistFilter{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// ListWaitlist returns the seva's open entries in queue order
+func (s *service) ListWaitlist(ctx context.Context, sevaID uint) ([]SevaWaitlistEntry, error) {
+	return s.repo.ListWaitlist(ctx, WaitlistFilter{
+		SevaID:   sevaID,
+		Statuses: []string{WaitlistWaiting, WaitlistOffered},
+	})
+}
+
+// GetWaitlistOffer returns the devotee's open offer with the booking holding
+// its place
+func (s *service) GetWaitlistOffer(ctx context.Context, entryID, userID uint) (*SevaWaitlistEntry, *SevaBooking, error) {
+	entry, err := s.repo.GetWaitlistEntry(ctx, entryID)
+	if err != nil || entry.UserID != userID {
+		return nil, nil, errors.New("waitlist entry not found")
+	}
+	if entry.Status != WaitlistOffered || entry.BookingID == nil {
+		return nil, nil, errOfferClosed
+	}
+	if entry.OfferExpiresAt != nil && !time.Now().Before(*entry.OfferExpiresAt) {
+		return nil, nil, errOfferClosed
+	}
+
+	booking, err := s.repo.GetBookingByID(ctx, *entry.BookingID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, booking, nil
+}
+
+// ConfirmWaitlistOffer takes the place offered to the devotee. On a free seva
+// the booking becomes pending like any other booking; on a paid one it stays
+// offered while the devotee pays orderID, and the payment approves it.
+func (s *service) ConfirmWaitlistOffer(ctx context.Context, entryID, userID uint, orderID, ip string) (*SevaBooking, error) {
+	entry, booking, err := s.GetWaitlistOffer(ctx, entryID, userID)
+	if err == nil && booking.Amount > 0 && orderID == "" {
+		err = errors.New("a payment order is required to confirm this offer")
+	}
+
+	status, changes := "pending", map[string]interface{}(nil)
+	if err == nil && booking.Amount > 0 {
+		status, changes = "offered", map[string]interface{}{"razorpay_order_id": orderID}
+	}
+	if err == nil {
+		err = s.repo.ConfirmWaitlistOffer(ctx, entry.ID, status, changes)
+	}
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &userID, nil, "SEVA_WAITLIST_CONFIRM_FAILED", map[string]interface{}{
+			"entry_id": entryID,
+			"error":    err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+
+	booking.Status = status
+	if orderID != "" {
+		booking.RazorpayOrderID = orderID
+	}
+
+	s.auditSvc.LogAction(ctx, &userID, &entry.EntityID, "SEVA_WAITLIST_CONFIRMED", map[string]interface{}{
+		"entry_id":          entry.ID,
+		"booking_id":        booking.ID,
+		"seva_id":           entry.SevaID,
+		"slot_id":           entry.SlotID,
+		"amount":            booking.Amount,
+		"razorpay_order_id": booking.RazorpayOrderID,
+		"booking_status":    status,
+	}, ip, "success")
+
+	if s.notifSvc != nil && status == "pending" {
+		_ = s.notifSvc.CreateInAppForEntityRoles(
+			ctx,
+			booking.EntityID,
+			[]string{"templeadmin", "standarduser"},
+			"New Seva Booking",
+			fmt.Sprintf("Booking #%d was confirmed from the waitlist", booking.ID),
+			"seva",
+		)
+	}
+
+	return booking, nil
+}
+
+// LeaveWaitlist takes the devotee off the queue; an open offer is declined
+// and its place offered to the next devotee
+func (s *service) LeaveWaitlist(ctx context.Context, entryID, userID uint, ip string) error {
+	entry, err := s.repo.GetWaitlistEntry(ctx, entryID)
+	if err != nil || entry.UserID != userID {
+		return errors.New("waitlist entry not found")
+	}
+
+	released, err := s.repo.LeaveWaitlist(ctx, entryID)
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &entry.EntityID, "SEVA_WAITLIST_LEAVE_FAILED", map[string]interface{}{
+			"entry_id": entryID,
+			"status":   entry.Status,
+			"error":    err.Error(),
+		}, ip, "failure")
+		return err
+	}
+
+	s.auditSvc.LogAction(ctx, &userID, &entry.EntityID, "SEVA_WAITLIST_LEFT", map[string]interface{}{
+		"entry_id":       entryID,
+		"seva_id":        entry.SevaID,
+		"slot_id":        entry.SlotID,
+		"offer_declined": released,
+	}, ip, "success")
+
+	if released {
+		s.promoteWaitlist(ctx, entry.SevaID, entry.SlotID, ip)
+	}
+	return nil
+}
+
+// ─────────────────────────────────────────────
+// Promotion
+// ─────────────────────────────────────────────
+
+// promoteWaitlist offers every free place of the seva, or of its slot, to the
+// devotees waiting for it, in order. Returns how many were offered a place.
+func (s *service) promoteWaitlist(ctx context.Context, sevaID uint, slotID *uint, ip string) int {
+	seva, expiresAt, open := s.waitlistOffer(ctx, sevaID, slotID)
+	if !open {
+		return 0
+	}
+
+	offered := 0
+	for {
+		next, err := s.repo.NextWaitlistEntry(ctx, sevaID, slotID)
+		if err != nil {
+			log.Printf("❌ Promoting seva=%d waitlist: %v", sevaID, err)
+			return offered
+		}
+		if next == nil {
+			return offered
+		}
+
+		booking, err := s.waitlistBooking(ctx, seva, next)
+		if err != nil {
+			if !s.dropWaitlistEntry(ctx, seva, next, err, ip) {
+				return offered
+			}
+			continue
+		}
+
+		entry, err := s.repo.OfferWaitlistEntry(ctx, next.ID, booking, expiresAt)
+		if errors.Is(err, errOfferClosed) {
+			continue // offered or left meanwhile
+		}
+		if err != nil {
+			log.Printf("❌ Promoting seva=%d waitlist: %v", sevaID, err)
+			return offered
+		}
+		if entry == nil {
+			return offered
+		}
+		offered++
+
+		s.auditSvc.LogAction(ctx, nil, &entry.EntityID, "SEVA_WAITLIST_OFFERED", map[string]interface{}{
+			"entry_id":         entry.ID,
+			"booking_id":       entry.BookingID,
+			"seva_id":          sevaID,
+			"slot_id":          slotID,
+			"devotee_id":       entry.UserID,
+			"variant_id":       booking.VariantID,
+			"amount":           booking.Amount,
+			"offer_expires_at": expiresAt,
+		}, ip, "success")
+		s.notifyWaitlistOffer(ctx, seva, entry, booking.Amount)
+	}
+}
+
+// dropWaitlistEntry takes a devotee whose selection can no longer be booked,
+// e.g. because its variant was withdrawn, off the queue and tells them.
+// Reports whether the entry left the queue.
+func (s *service) dropWaitlistEntry(ctx context.Context, seva *Seva, entry *SevaWaitlistEntry, cause error, ip string) bool {
+	if _, err := s.repo.LeaveWaitlist(ctx, entry.ID); err != nil && !errors.Is(err, errOfferClosed) {
+		log.Printf("❌ Dropping waitlist entry=%d: %v", entry.ID, err)
+		return false
+	}
+
+	s.auditSvc.LogAction(ctx, nil, &entry.EntityID, "SEVA_WAITLIST_OFFER_FAILED", map[string]interface{}{
+		"entry_id":   entry.ID,
+		"seva_id":    entry.SevaID,
+		"slot_id":    entry.SlotID,
+		"devotee_id": entry.UserID,
+		"variant_id": entry.VariantID,
+		"error":      cause.Error(),
+	}, ip, "failure")
+	if s.notifSvc != nil {
+		_ = s.notifSvc.CreateInAppNotification(ctx, entry.UserID, entry.EntityID,
+			"Seva Waitlist Closed",
+			fmt.Sprintf("A place opened up for %s, but it can no longer be booked as you selected (%v). Please book it again or rejoin the waitlist.", seva.Name, cause),
+			"seva",
+		)
+	}
+	return true
+}
+
+// waitlistOffer decides whether the occurrence can still be offered and until
+// when an offer made now holds its place: the offer window, cut short by the
+// occurrence's start
+func (s *service) waitlistOffer(ctx context.Context, sevaID uint, slotID *uint) (*Seva, time.Time, bool) {
+	seva, err := s.repo.GetSevaByID(ctx, sevaID)
+	if err != nil || (seva.Status != "upcoming" && seva.Status != "ongoing") {
+		return nil, time.Time{}, false
+	}
+	if slotID != nil {
+		slot, err := s.repo.GetSlotByID(ctx, *slotID)
+		if err != nil || slot.SevaID != sevaID || !slot.IsActive {
+			return nil, time.Time{}, false
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.offerWindow)
+	startsAt, err := s.bookingStartsAt(ctx, &SevaBooking{SevaID: sevaID, SlotID: slotID})
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	if startsAt != nil {
+		if !startsAt.After(now) {
+			return nil, time.Time{}, false
+		}
+		if startsAt.Before(expiresAt) {
+			expiresAt = *startsAt
+		}
+	}
+	return seva, expiresAt, true
+}
+
+// notifyWaitlistOffer tells the devotee in the app and on their devices, with
+// the amount the offered booking costs
+func (s *service) notifyWaitlistOffer(ctx context.Context, seva *Seva, entry *SevaWaitlistEntry, amount float64) {
+	if s.notifSvc == nil {
+		return
+	}
+
+	title := "Seva Place Available"
+	deadline := entry.OfferExpiresAt.In(utils.IST).Format("02 Jan 2006 03:04 PM")
+	message := fmt.Sprintf("A place opened up for %s. Confirm it before %s or it goes to the next devotee.", seva.Name, deadline)
+	if amount > 0 {
+		message = fmt.Sprintf("A place opened up for %s. Confirm and pay ₹%.2f before %s or it goes to the next devotee.", seva.Name, amount, deadline)
+	}
+
+	_ = s.notifSvc.CreateInAppNotification(ctx, entry.UserID, entry.EntityID, title, message, "seva")
+	go func() {
+		_ = s.notifSvc.SendPushNotification(context.Background(), 0, entry.EntityID, title, message, []uint{entry.UserID}, waitlistIP)
+	}()
+}
+
+// ─────────────────────────────────────────────
+// Waitlist worker
+// ─────────────────────────────────────────────
+
+// ExpireWaitlistOffers passes the offers that ran out to the next devotee in
+// line, then offers any place that freed up otherwise, e.g. by a seva's
+// capacity being raised. Returns how many offers expired.
+func (s *service) ExpireWaitlistOffers(ctx context.Context) (int, error) {
+	now := time.Now()
+	entries, err := s.repo.ListExpiredWaitlistOffers(ctx, now, 500)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, entry := range entries {
+		released, err := s.repo.ExpireWaitlistOffer(ctx, entry.ID, now)
+		if err != nil {
+			log.Printf("❌ Expiring waitlist offer=%d: %v", entry.ID, err)
+			continue
+		}
+		if !released {
+			continue
+		}
+		expired++
+
+		s.auditSvc.LogAction(ctx, nil, &entry.EntityID, "SEVA_WAITLIST_OFFER_EXPIRED", map[string]interface{}{
+			"entry_id":   entry.ID,
+			"booking_id": entry.BookingID,
+			"seva_id":    entry.SevaID,
+			"slot_id":    entry.SlotID,
+			"devotee_id": entry.UserID,
+		}, waitlistIP, "success")
+		if s.notifSvc != nil {
+			_ = s.notifSvc.CreateInAppNotification(ctx, entry.UserID, entry.EntityID,
+				"Seva Offer Expired",
+				"The place offered to you from the waitlist was not confirmed in time and has gone to the next devotee.",
+				"seva",
+			)
+		}
+		s.promoteWaitlist(ctx, entry.SevaID, entry.SlotID, waitlistIP)
+	}
+
+	queues, err := s.repo.ListWaitlistQueues(ctx, 500)
+	if err != nil {
+		return expired, err
+	}
+	for _, queue := range queues {
+		if _, _, open := s.waitlistOffer(ctx, queue.SevaID, queue.SlotID); !open {
+			if closed, err := s.repo.CloseWaitlistQueue(ctx, queue.SevaID, queue.SlotID); err == nil && closed > 0 {
+				log.Printf("⏳ Seva=%d waitlist closed: %d devotee(s) were still waiting", queue.SevaID, closed)
+			}
+			continue
+		}
+		s.promoteWaitlist(ctx, queue.SevaID, queue.SlotID, waitlistIP)
+	}
+	return expired, nil
+}
+
+// StartWaitlistWorker launches the background worker that passes expired
+// waitlist offers on to the next devotee
+func StartWaitlistWorker(svc Service, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	go func() {
+		log.Printf("⏳ Seva waitlist worker started (every %s)", interval)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			expired, err := svc.ExpireWaitlistOffers(context.Background())
+			if err != nil {
+				log.Printf("❌ Seva waitlist worker: %v", err)
+			} else if expired > 0 {
+				log.Printf("⏳ Seva waitlist worker expired %d offer(s)", expired)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,237 @@
+package seva
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// ==============================
+// Priest Worksheet Rendering
+// ==============================
+
+// worksheetLine is one beneficiary row of the worksheet; the booking's own
+// columns are printed on its first row only
+type worksheetLine struct {
+	First       bool
+	Rows        int // beneficiaries of the booking, on the first row
+	BookingID   uint
+	Time        string
+	DevoteeName string
+	Phone       string
+	Beneficiary SevaBeneficiary
+}
+
+func worksheetLines(w *PriestWorksheet) []worksheetLine {
+	var lines []worksheetLine
+	for _, booking := range w.Bookings {
+		at := booking.StartTime
+		if booking.EndTime != "" {
+			at += "–" + booking.EndTime
+		}
+		for i, beneficiary := range booking.Beneficiaries {
+			lines = append(lines, worksheetLine{
+				First:       i == 0,
+				Rows:        len(booking.Beneficiaries),
+				BookingID:   booking.BookingID,
+				Time:        at,
+				DevoteeName: booking.DevoteeName,
+				Phone:       booking.DevoteePhone,
+				Beneficiary: beneficiary,
+			})
+		}
+	}
+	return lines
+}
+
+func worksheetTitle(w *PriestWorksheet) string {
+	return fmt.Sprintf("%s – %s", w.SevaName, w.Date.Format("Monday, 02 Jan 2006"))
+}
+
+func worksheetFileName(w *PriestWorksheet, ext string) string {
+	return fmt.Sprintf("worksheet_seva_%d_%s.%s", w.SevaID, w.Date.Format("2006-01-02"), ext)
+}
+
+var (
+	worksheetWidths  = []float64{12, 22, 42, 42, 18, 32, 28, 24, 45, 12}
+	worksheetHeaders = []string{"#", "Time", "Devotee", "Beneficiary", "Relation", "Gotra", "Nakshatra", "Rashi", "Sankalpa", "Done"}
+)
+
+// renderWorksheetPDF draws the worksheet as a landscape table, one row per
+// beneficiary, with a column the priest ticks as each sankalpa is done
+func renderWorksheetPDF(w *PriestWorksheet) ([]byte, error) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.SetCreationDate(w.GeneratedAt)
+	pdf.SetModificationDate(w.GeneratedAt)
+	pdf.SetTitle("Priest Worksheet "+w.SevaName, true)
+	pdf.SetAutoPageBreak(false, 10)
+	pdf.AddPage()
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(277, 8, tr(w.EntityName), "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(277, 7, tr(worksheetTitle(w)), "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	pdf.CellFormat(277, 6, tr(fmt.Sprintf("%s · %d booking(s) · generated %s", w.SevaType, len(w.Bookings),
+		w.GeneratedAt.In(utils.IST).Format("02-01-2006 15:04"))), "", 1, "C", false, 0, "")
+	pdf.Ln(3)
+
+	drawWorksheetHeader(pdf)
+	lines := worksheetLines(w)
+	if len(lines) == 0 {
+		pdf.SetFont("Arial", "I", 10)
+		pdf.CellFormat(277, 10, "No approved bookings for this day.", "1", 1, "C", false, 0, "")
+	}
+	_, pageHeight := pdf.GetPageSize()
+	for _, line := range lines {
+		cells := make([]string, len(worksheetHeaders))
+		if line.First {
+			cells[0] = fmt.Sprintf("%d", line.BookingID)
+			cells[1] = line.Time
+			cells[2] = line.DevoteeName
+		}
+		b := line.Beneficiary
+		cells[3], cells[4], cells[5], cells[6], cells[7], cells[8] = b.Name, b.Relation, b.Gotra, b.Nakshatra, b.Rashi, b.Sankalpa
+
+		height := worksheetRowHeight(pdf, tr, cells)
+		if pdf.GetY()+height > pageHeight-10 {
+			pdf.AddPage()
+			drawWorksheetHeader(pdf)
+		}
+		drawWorksheetRow(pdf, tr, cells, height)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawWorksheetHeader(pdf *gofpdf.Fpdf) {
+	pdf.SetFont("Arial", "B", 9)
+	for i, header := range worksheetHeaders {
+		pdf.CellFormat(worksheetWidths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Arial", "", 9)
+}
+
+// worksheetRowHeight fits the tallest wrapped cell of the row
+func worksheetRowHeight(pdf *gofpdf.Fpdf, tr func(string) string, cells []string) float64 {
+	lines := 1
+	for i, cell := range cells {
+		if n := len(splitCell(pdf, tr, cell, worksheetWidths[i]-2)); n > lines {
+			lines = n
+		}
+	}
+	return float64(lines)*5 + 1
+}
+
+func drawWorksheetRow(pdf *gofpdf.Fpdf, tr func(string) string, cells []string, height float64) {
+	x, y := pdf.GetX(), pdf.GetY()
+	for i, cell := range cells {
+		pdf.Rect(x, y, worksheetWidths[i], height, "D")
+		for j, text := range splitCell(pdf, tr, cell, worksheetWidths[i]-2) {
+			pdf.SetXY(x+1, y+0.5+float64(j)*5)
+			pdf.CellFormat(worksheetWidths[i]-2, 5, text, "", 0, "L", false, 0, "")
+		}
+		x += worksheetWidths[i]
+	}
+	pdf.SetXY(10, y+height)
+}
+
+// splitCell wraps the text, translated to the PDF's code page, into lines that
+// fit the width. SplitText reads its input as runes, so the translated bytes
+// are passed through as runes below 256 and turned back into bytes after.
+func splitCell(pdf *gofpdf.Fpdf, tr func(string) string, text string, width float64) []string {
+	encoded := tr(text)
+	runes := make([]rune, len(encoded))
+	for i := 0; i < len(encoded); i++ {
+		runes[i] = rune(encoded[i])
+	}
+
+	lines := pdf.SplitText(string(runes), width)
+	for i, line := range lines {
+		raw := make([]byte, 0, len(line))
+		for _, r := range line {
+			raw = append(raw, byte(r))
+		}
+		lines[i] = string(raw)
+	}
+	return lines
+}
+
+// renderWorksheetHTML renders the worksheet as a page meant to be printed
+// from the browser
+func renderWorksheetHTML(w *PriestWorksheet) ([]byte, error) {
+	var buf bytes.Buffer
+	err := worksheetTemplate.Execute(&buf, map[string]interface{}{
+		"Worksheet": w,
+		"Title":     worksheetTitle(w),
+		"Lines":     worksheetLines(w),
+		"Generated": w.GeneratedAt.In(utils.IST).Format("02-01-2006 15:04"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var worksheetTemplate = template.Must(template.New("worksheet").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<title>Priest Worksheet – {{.Title}}</title>
+<style>
+	@page { size: A4 landscape; margin: 10mm; }
+	body { font-family: Arial, sans-serif; font-size: 12px; color: #000; margin: 0; }
+	h1 { font-size: 18px; text-align: center; margin: 0 0 4px; }
+	h2 { font-size: 15px; text-align: center; margin: 0 0 4px; }
+	p.meta { text-align: center; margin: 0 0 10px; color: #444; }
+	table { width: 100%; border-collapse: collapse; }
+	th, td { border: 1px solid #000; padding: 4px 6px; text-align: left; vertical-align: top; }
+	th { background: #eee; }
+	thead { display: table-header-group; }
+	tr { page-break-inside: avoid; }
+	td.done { width: 32px; }
+	@media print { button { display: none; } }
+</style>
+</head>
+<body>
+<button onclick="window.print()">Print</button>
+<h1>{{.Worksheet.EntityName}}</h1>
+<h2>{{.Title}}</h2>
+<p class="meta">{{.Worksheet.SevaType}} · {{len .Worksheet.Bookings}} booking(s) · generated {{.Generated}}</p>
+<table>
+<thead>
+<tr><th>#</th><th>Time</th><th>Devotee</th><th>Beneficiary</th><th>Relation</th><th>Gotra</th><th>Nakshatra</th><th>Rashi</th><th>Sankalpa</th><th>Done</th></tr>
+</thead>
+<tbody>
+{{- range .Lines}}
+<tr>
+	{{- if .First}}
+	<td rowspan="{{.Rows}}">{{.BookingID}}</td>
+	<td rowspan="{{.Rows}}">{{.Time}}</td>
+	<td rowspan="{{.Rows}}">{{.DevoteeName}}{{if .Phone}}<br>{{.Phone}}{{end}}</td>
+	{{- end}}
+	<td>{{.Beneficiary.Name}}</td>
+	<td>{{.Beneficiary.Relation}}</td>
+	<td>{{.Beneficiary.Gotra}}</td>
+	<td>{{.Beneficiary.Nakshatra}}</td>
+	<td>{{.Beneficiary.Rashi}}</td>
+	<td>{{.Beneficiary.Sankalpa}}</td>
+	<td class="done"></td>
+</tr>
+{{- else}}
+<tr><td colspan="10" style="text-align:center"><em>No approved bookings for this day.</em></td></tr>
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
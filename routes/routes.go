@@ -12,13 +12,16 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sharath018/temple-management-backend/config"
 	"github.com/sharath018/temple-management-backend/database"
+	"github.com/sharath018/temple-management-backend/internal/accounting"
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
 	"github.com/sharath018/temple-management-backend/internal/auth"
 	"github.com/sharath018/temple-management-backend/internal/donation"
 	"github.com/sharath018/temple-management-backend/internal/entity"
 	"github.com/sharath018/temple-management-backend/internal/event"
 	"github.com/sharath018/temple-management-backend/internal/eventrsvp"
+	"github.com/sharath018/temple-management-backend/internal/hundi"
 	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/payment"
 	"github.com/sharath018/temple-management-backend/internal/reports"
 	"github.com/sharath018/temple-management-backend/internal/seva"
 	"github.com/sharath018/temple-management-backend/internal/superadmin"
@@ -340,11 +343,16 @@ func Setup(r *gin.Engine, cfg *config.Config, captchaService *utils.CaptchaServi
 	sevaRepo := seva.NewRepository(database.DB)
 	sevaService := seva.NewService(sevaRepo, auditSvc)
 	sevaHandler := seva.NewHandler(sevaService, auditSvc,sevaRepo)
+	sevaHandler.SetPaymentResolver(payment.NewResolver(cfg))
+	sevaService.SetPaymentResolver(payment.NewResolver(cfg))
+	sevaService.SetTicketSecret(cfg.SevaTicketSecret)
 
 	// All Seva routes under: /api/v1/sevas
 	sevaRoutes := protected.Group("/sevas")
 
 	sevaRoutes.GET("/booking-counts", sevaHandler.GetBookingCounts)
+	sevaRoutes.GET("/:id/availability", sevaHandler.GetSevaAvailability)
+	sevaRoutes.GET("/:id/pricing", sevaHandler.GetSevaPricing)
 
 	templeSevaRoutes := sevaRoutes.Group("")
 	templeSevaRoutes.Use(middleware.RequireTempleAccess()) // access check
@@ -362,12 +370,34 @@ func Setup(r *gin.Engine, cfg *config.Config, captchaService *utils.CaptchaServi
 
 			// Booking status update
 			writeRoutes.PATCH("/bookings/:id/status", sevaHandler.UpdateBookingStatus)
+
+			// Schedules generating dated slots
+			writeRoutes.POST("/:id/schedules", sevaHandler.CreateSchedule)
+			writeRoutes.DELETE("/:id/schedules/:scheduleId", sevaHandler.DeleteSchedule)
+			writeRoutes.PATCH("/:id/occurrences/:slotId", sevaHandler.UpdateOccurrence)
+
+			// Variants and add-ons a seva is booked with
+			writeRoutes.PUT("/:id/pricing", sevaHandler.SetSevaPricing)
+
+			// Cancellation policies of the temple and of single sevas
+			writeRoutes.PUT("/cancellation-policy", sevaHandler.SetCancellationPolicy)
+			writeRoutes.DELETE("/cancellation-policy", sevaHandler.DeleteCancellationPolicy)
+			writeRoutes.PUT("/:id/cancellation-policy", sevaHandler.SetCancellationPolicy)
+			writeRoutes.DELETE("/:id/cancellation-policy", sevaHandler.DeleteCancellationPolicy)
+
+			// Counter check-in of devotees' QR tickets
+			writeRoutes.POST("/tickets/check-in", sevaHandler.CheckInTicket)
 		}
 
 		templeSevaRoutes.GET("/entity-sevas", sevaHandler.ListEntitySevas)
 		templeSevaRoutes.GET("/:id", sevaHandler.GetSevaByID)
 		templeSevaRoutes.GET("/entity-bookings", sevaHandler.GetEntityBookings)
 		templeSevaRoutes.GET("/bookings/:id", sevaHandler.GetBookingByID)
+		templeSevaRoutes.GET("/:id/schedules", sevaHandler.ListSchedules)
+		templeSevaRoutes.GET("/cancellation-policy", sevaHandler.GetCancellationPolicy)
+		templeSevaRoutes.GET("/:id/cancellation-policy", sevaHandler.GetCancellationPolicy)
+		templeSevaRoutes.GET("/:id/waitlist", sevaHandler.ListWaitlist)
+		templeSevaRoutes.GET("/:id/worksheet", sevaHandler.GetPriestWorksheet)
 	}
 
 	devoteeSevaRoutes := sevaRoutes.Group("")
@@ -378,7 +408,16 @@ func Setup(r *gin.Engine, cfg *config.Config, captchaService *utils.CaptchaServi
 		devoteeSevaRoutes.GET("/my-bookings", sevaHandler.GetMyBookings)
 		devoteeSevaRoutes.GET("/", sevaHandler.GetSevas)
 		devoteeSevaRoutes.POST("/book-with-payment", sevaHandler.BookSevaWithPayment)
+		devoteeSevaRoutes.POST("/:id/quote", sevaHandler.QuoteSevaPrice)
 		devoteeSevaRoutes.POST("/verify-payment", sevaHandler.VerifySevaPayment)
+		devoteeSevaRoutes.GET("/bookings/:id/cancellation", sevaHandler.GetBookingCancellation)
+		devoteeSevaRoutes.POST("/bookings/:id/cancel", sevaHandler.CancelBooking)
+		devoteeSevaRoutes.GET("/bookings/:id/ticket", sevaHandler.GetBookingTicket)
+		devoteeSevaRoutes.POST("/:id/waitlist", sevaHandler.JoinWaitlist)
+		devoteeSevaRoutes.GET("/my-waitlist", sevaHandler.GetMyWaitlist)
+		devoteeSevaRoutes.GET("/my-beneficiaries", sevaHandler.GetMyBeneficiaries)
+		devoteeSevaRoutes.POST("/waitlist/:entryId/confirm", sevaHandler.ConfirmWaitlistOffer)
+		devoteeSevaRoutes.DELETE("/waitlist/:entryId", sevaHandler.LeaveWaitlist)
 	}
 
 	// ========== Entity ==========
@@ -565,12 +604,19 @@ func Setup(r *gin.Engine, cfg *config.Config, captchaService *utils.CaptchaServi
 	}
 
 	// ========== Donations with New Permission System ==========
+	donationRepo    := donation.NewRepository(database.DB)
+	entityBankRepo  := donation.NewEntityBankRepo(database.DB)
+	donationService := donation.NewServiceWithEntityRepo(donationRepo, entityBankRepo, cfg, auditSvc)
 	{
-		donationRepo    := donation.NewRepository(database.DB)
-		entityBankRepo  := donation.NewEntityBankRepo(database.DB)
-		donationService := donation.NewServiceWithEntityRepo(donationRepo, entityBankRepo, cfg, auditSvc)
 		donationHandler := donation.NewHandler(donationService)
 		api.POST("/donations/webhook", donationHandler.HandleWebhook)
+		api.POST("/donations/webhook/:provider", donationHandler.HandleProviderWebhook)
+		api.GET("/donations/campaigns/:id/progress", donationHandler.GetCampaignProgress)
+
+		// Guest donation flow opened by UPI payment links — no login required
+		api.GET("/donations/pay/:code", donationHandler.GetGuestPaymentLink)
+		api.GET("/donations/pay/:code/qr", donationHandler.GetPaymentLinkQR)
+		api.POST("/donations/pay/:code", donationHandler.StartGuestDonation)
 
 		donationRoutes := protected.Group("/donations")
 		{
@@ -582,6 +628,14 @@ func Setup(r *gin.Engine, cfg *config.Config, captchaService *utils.CaptchaServi
 				devoteeRoutes.POST("/verify", donationHandler.VerifyDonation)
 				devoteeRoutes.GET("/my", donationHandler.GetMyDonations)
 				devoteeRoutes.GET("/history", donationHandler.GetDonationsByEntity)
+
+				// Recurring donations
+				devoteeRoutes.POST("/recurring", donationHandler.CreateRecurringDonation)
+				devoteeRoutes.GET("/recurring", donationHandler.GetMyRecurringDonations)
+				devoteeRoutes.PATCH("/recurring/:id/status", donationHandler.UpdateRecurringDonationStatus)
+
+				// Pledges
+				devoteeRoutes.GET("/pledges/my", donationHandler.GetMyPledges)
 			}
 
 			// ========== TEMPLE ADMIN ROUTES (UPDATED PERMISSIONS) ==========
@@ -593,12 +647,29 @@ func Setup(r *gin.Engine, cfg *config.Config, captchaService *utils.CaptchaServi
 				templeRoutes.GET("/dashboard", donationHandler.GetDashboard)
 				templeRoutes.GET("/top-donors", donationHandler.GetTopDonors)
 				templeRoutes.GET("/analytics", donationHandler.GetAnalytics)
+				templeRoutes.GET("/refunds", donationHandler.ListRefunds)
+				templeRoutes.GET("/in-kind", donationHandler.ListInKindDonations)
+				templeRoutes.GET("/pledges", donationHandler.ListPledges)
+				templeRoutes.GET("/payment-links", donationHandler.ListPaymentLinks)
 
 				// Write operations - only templeadmin and standarduser can access
 				writeRoutes := templeRoutes.Group("")
 				writeRoutes.Use(middleware.RequireWriteAccess())
 				{
 					writeRoutes.GET("/export", donationHandler.ExportDonations)
+					writeRoutes.POST("/:id/refund", donationHandler.RefundDonation)
+					writeRoutes.POST("/offline", donationHandler.RecordOfflineDonation)
+					writeRoutes.PATCH("/:id/clearance", donationHandler.UpdateOfflineClearance)
+					writeRoutes.POST("/campaigns", donationHandler.CreateCampaign)
+					writeRoutes.PUT("/campaigns/:id", donationHandler.UpdateCampaign)
+					writeRoutes.POST("/campaigns/:id/close", donationHandler.CloseCampaign)
+					writeRoutes.POST("/in-kind", donationHandler.RecordInKindDonation)
+					writeRoutes.POST("/pledges", donationHandler.CreatePledge)
+					writeRoutes.POST("/pledges/:id/link", donationHandler.LinkPledgeDonation)
+					writeRoutes.POST("/pledges/:id/cancel", donationHandler.CancelPledge)
+					writeRoutes.POST("/payment-links", donationHandler.CreatePaymentLink)
+					writeRoutes.POST("/payment-links/:id/deactivate", donationHandler.DeactivatePaymentLink)
+					writeRoutes.POST("/upi-payments/match", donationHandler.MatchUPIPayment)
 				}
 			}
 
@@ -607,13 +678,105 @@ func Setup(r *gin.Engine, cfg *config.Config, captchaService *utils.CaptchaServi
 			donationRoutes.GET("/:id/receipt",
 				middleware.RBACMiddleware("devotee", "templeadmin", "standarduser", "monitoringuser"),
 				donationHandler.GenerateReceipt)
+			donationRoutes.GET("/:id/form-10be",
+				middleware.RBACMiddleware("devotee", "templeadmin", "standarduser", "monitoringuser"),
+				donationHandler.GetForm10BE)
+			donationRoutes.GET("/in-kind/:id/acknowledgement",
+				middleware.RBACMiddleware("devotee", "templeadmin", "standarduser", "monitoringuser"),
+				donationHandler.GetInKindAcknowledgement)
+			donationRoutes.GET("/pledges/:id",
+				middleware.RBACMiddleware("devotee", "templeadmin", "standarduser", "monitoringuser"),
+				donationHandler.GetPledge)
+
+			// Seva booking refunds share the donation refund workflow
+			sevaRefundRoutes := sevaRoutes.Group("")
+			sevaRefundRoutes.Use(middleware.RequireTempleAccess(), middleware.RequireWriteAccess())
+			sevaRefundRoutes.POST("/bookings/:id/refund", donationHandler.RefundSevaBooking)
+
+			// Campaigns - devotees pick one when donating, temple admins manage them
+			donationRoutes.GET("/campaigns",
+				middleware.RBACMiddleware("devotee", "templeadmin", "standarduser", "monitoringuser"),
+				donationHandler.ListCampaigns)
 
 			// Recent donations - both devotees and temple admins can access
 			donationRoutes.GET("/recent",
 				middleware.RBACMiddleware("devotee", "templeadmin", "standarduser", "monitoringuser"),
 				donationHandler.GetRecentDonations)
+
+			// Run the stale payment reconciler on demand (it also runs in the background)
+			donationRoutes.POST("/reconcile",
+				middleware.RBACMiddleware("superadmin"),
+				donationHandler.ReconcilePayments)
+
+			// Stored payment webhooks - superadmins inspect and replay them
+			donationRoutes.GET("/webhook-events",
+				middleware.RBACMiddleware("superadmin"),
+				donationHandler.ListWebhookEvents)
+			donationRoutes.POST("/webhook-events/:id/replay",
+				middleware.RBACMiddleware("superadmin"),
+				donationHandler.ReplayWebhookEvent)
+
+			// Annual donor statements - temple admins run, preview, regenerate and resend them
+			statementRoutes := donationRoutes.Group("/statements")
+			statementRoutes.Use(middleware.RBACMiddleware("templeadmin"))
+			{
+				statementRoutes.GET("", donationHandler.ListDonorStatements)
+				statementRoutes.POST("/run", donationHandler.GenerateDonorStatements)
+				statementRoutes.GET("/:user_id/preview", donationHandler.PreviewDonorStatement)
+				statementRoutes.POST("/:user_id/regenerate", donationHandler.RegenerateDonorStatement)
+				statementRoutes.POST("/:user_id/resend", donationHandler.ResendDonorStatement)
+			}
+		}
+	}
+	// ========== Hundi Counting ==========
+	{
+		hundiRepo := hundi.NewRepository(database.DB)
+		hundiService := hundi.NewService(hundiRepo, auditSvc)
+		hundiHandler := hundi.NewHandler(hundiService)
+
+		hundiRoutes := protected.Group("/hundis")
+		hundiRoutes.Use(middleware.RequireTempleAccess()) // Allow templeadmin, standarduser, monitoringuser
+		{
+			hundiRoutes.GET("", hundiHandler.ListHundis)
+			hundiRoutes.GET("/sessions", hundiHandler.ListSessions)
+			hundiRoutes.GET("/sessions/:id", hundiHandler.GetSession)
+
+			// Counters (templeadmin + standarduser) record hundis, sessions and tallies;
+			// approve / reject is further restricted to the templeadmin in the service
+			hundiWriteRoutes := hundiRoutes.Group("")
+			hundiWriteRoutes.Use(middleware.RequireWriteAccess())
+			{
+				hundiWriteRoutes.POST("", hundiHandler.CreateHundi)
+				hundiWriteRoutes.PUT("/:id", hundiHandler.UpdateHundi)
+				hundiWriteRoutes.POST("/:id/sessions", hundiHandler.OpenSession)
+				hundiWriteRoutes.POST("/sessions/:id/tally", hundiHandler.SubmitTally)
+				hundiWriteRoutes.POST("/sessions/:id/approve", hundiHandler.ApproveSession)
+				hundiWriteRoutes.POST("/sessions/:id/reject", hundiHandler.RejectSession)
+			}
+		}
+	}
+	// ========== Accounting Exports ==========
+	{
+		accountingRepo := accounting.NewRepository(database.DB)
+		accountingService := accounting.NewService(accountingRepo, auditSvc)
+		accountingHandler := accounting.NewHandler(accountingService)
+
+		accountingRoutes := protected.Group("/accounting")
+		accountingRoutes.Use(middleware.RequireTempleAccess()) // Allow templeadmin, standarduser, monitoringuser
+		{
+			accountingRoutes.GET("/ledger-heads", accountingHandler.ListLedgerHeads)
+			accountingRoutes.GET("/exports", accountingHandler.ListExports)
+
+			// Exporting marks transactions as exported, so it needs write access
+			accountingWriteRoutes := accountingRoutes.Group("")
+			accountingWriteRoutes.Use(middleware.RequireWriteAccess())
+			{
+				accountingWriteRoutes.PUT("/ledger-heads", accountingHandler.UpdateLedgerHeads)
+				accountingWriteRoutes.POST("/exports", accountingHandler.Export)
+			}
 		}
 	}
+
 	// ========== Notifications (UPDATED WITH FCM) ==========
 	{
 		notificationRepo := notification.NewRepository(database.DB)
@@ -672,6 +835,34 @@ func Setup(r *gin.Engine, cfg *config.Config, captchaService *utils.CaptchaServi
 	// Now inject notifSvc into eventService
 	eventService.NotifSvc = notifSvc
 	sevaService.SetNotifService(notifSvc)
+	donationService.SetNotifService(notifSvc)
+
+	// Background worker that raises due recurring donations
+	donation.StartRecurringScheduler(donationService, time.Duration(cfg.RecurringSchedulerMinutes)*time.Minute)
+
+	// Paid seva bookings that devotees cancel are refunded like donations
+	sevaService.SetBookingRefunder(donationService)
+
+	// Background worker that settles payments whose webhook never arrived
+	donationService.SetSevaReconciler(sevaService)
+	donation.StartPaymentReconciler(donationService, time.Duration(cfg.ReconcileIntervalMinutes)*time.Minute)
+
+	// Background worker that retries failed payment webhooks with backoff
+	donation.StartWebhookRetryWorker(donationService, time.Duration(cfg.WebhookRetryMinutes)*time.Minute)
+
+	// Background worker that emails annual donor statements for the last financial year
+	donationService.SetStatementMailer(notification.NewEmailSender(cfg))
+	donation.StartAnnualStatementWorker(donationService, time.Duration(cfg.DonorStatementHours)*time.Hour)
+
+	// Background worker that reminds devotees of overdue pledge instalments
+	donation.StartPledgeReminderWorker(donationService, time.Duration(cfg.PledgeReminderHours)*time.Hour)
+
+	// Background worker that keeps open-ended seva schedules generated ahead
+	seva.StartScheduleWorker(sevaService, time.Duration(cfg.SevaScheduleHours)*time.Hour)
+
+	// Background worker that passes expired waitlist offers to the next devotee
+	sevaService.SetWaitlistOfferWindow(time.Duration(cfg.SevaWaitlistOfferMinutes) * time.Minute)
+	seva.StartWaitlistWorker(sevaService, time.Duration(cfg.SevaWaitlistMinutes)*time.Minute)
 
 	/// ========== Tenant User Management (Complete Section) ==========
 	// Add this section in routes.go around line 580-650
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"math"
+	"time"
+)
+
+// IST is the zone used to decide which day, and which financial year, a
+// payment or a booking falls on
+var IST = time.FixedZone("IST", 5*60*60+30*60)
+
+// RoundMoney rounds a rupee amount to paise, halves away from zero
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Paise converts a rupee amount to whole paise, rounded as RoundMoney rounds
+func Paise(amount float64) int64 {
+	return int64(math.Round(RoundMoney(amount) * 100))
+}